@@ -0,0 +1,37 @@
+// Package api holds the OpenAPI 3 description of the HTTP API. The typed Go
+// client in pkg/client is generated from the same document.
+package api
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+//go:embed openapi.yaml
+var specYAML []byte
+
+// Load parses and validates the embedded specification.
+func Load() (*openapi3.T, error) {
+	loader := openapi3.NewLoader()
+	spec, err := loader.LoadFromData(specYAML)
+	if err != nil {
+		return nil, err
+	}
+	if err := spec.Validate(context.Background()); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// JSON returns the validated specification encoded as JSON, the form served
+// at /openapi.json.
+func JSON() ([]byte, error) {
+	spec, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(spec)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"backend/internal/config"
+	"backend/internal/logging"
+	"backend/internal/services"
+	"backend/internal/userdata"
+	"backend/pkg/database"
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// backend is the part of the application sirenctl needs: the database and
+// the services on top of it.
+type backend struct {
+	db       *database.DB
+	users    services.UserService
+	auth     services.AuthService
+	userData *userdata.Service
+	chats    services.ConversationImportService
+}
+
+func openBackend() (*backend, error) {
+	cfg := config.Load()
+	if cfg.DatabaseURL == "" {
+		return nil, errors.New("DATABASE_URL is not set")
+	}
+
+	db := database.Connect(cfg.DatabaseURL)
+	db.Logger = logging.NewGormLogger(logging.New(os.Stderr, cfg.LogLevel))
+	userService := services.NewUserService(db)
+	agentService := services.NewAgentService(db, time.Now)
+	clientService := services.NewClientService(db, agentService, time.Now)
+	customFieldService := services.NewCustomFieldService(db)
+
+	return &backend{
+		db:    db,
+		users: userService,
+		auth:  services.NewAuthService(userService, services.NewAuditService(db, time.Now), &cfg, time.Now),
+		userData: userdata.NewService(
+			agentService,
+			clientService,
+			services.NewClientProfileService(db, clientService, customFieldService),
+			customFieldService,
+			services.NewMessageService(db, agentService, clientService, time.Now),
+			services.NewTransactionService(db, agentService, clientService, time.Now),
+			time.Now,
+		),
+		chats: services.NewConversationImportService(db, agentService, clientService, time.Now),
+	}, nil
+}
+
+// readPassword returns the flag value, or reads one line from stdin so the
+// password does not have to appear in the shell history.
+func readPassword(flagValue string) (string, error) {
+	if flagValue != "" {
+		return flagValue, nil
+	}
+
+	fmt.Fprint(os.Stderr, "Password: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("read password: %w", err)
+	}
+
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return "", errors.New("password is required")
+	}
+	return password, nil
+}
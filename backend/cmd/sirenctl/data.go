@@ -0,0 +1,204 @@
+package main
+
+import (
+	"backend/internal/demodata"
+	"backend/internal/services"
+	"backend/internal/userdata"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+func exportUser(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	username := flags.String("username", "", "user to export")
+	out := flags.String("out", "", "file to write; stdout when empty")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *username == "" {
+		return errors.New("-username is required")
+	}
+
+	b, err := openBackend()
+	if err != nil {
+		return err
+	}
+
+	user, err := b.users.GetUserByUsername(ctx, *username)
+	if err != nil {
+		return err
+	}
+
+	archive, err := b.userData.Export(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	w := io.Writer(os.Stdout)
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(archive)
+}
+
+func importUser(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	username := flags.String("username", "", "existing user that receives the data")
+	in := flags.String("in", "", "export to read; stdin when empty")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *username == "" {
+		return errors.New("-username is required")
+	}
+
+	r := io.Reader(os.Stdin)
+	if *in != "" {
+		f, err := os.Open(*in)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var archive userdata.Archive
+	if err := json.NewDecoder(r).Decode(&archive); err != nil {
+		return fmt.Errorf("read archive: %w", err)
+	}
+
+	b, err := openBackend()
+	if err != nil {
+		return err
+	}
+
+	user, err := b.users.GetUserByUsername(ctx, *username)
+	if err != nil {
+		return err
+	}
+
+	summary, err := b.userData.Import(ctx, user.ID, &archive)
+	printSummary(summary)
+	return err
+}
+
+func importChat(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("import-chat", flag.ContinueOnError)
+	username := flags.String("username", "", "user who owns the client")
+	in := flags.String("in", "", "chat export to read")
+	format := flags.String("format", "", "whatsapp, telegram or csv; guessed from the file extension when empty")
+	clientID := flags.Uint("client-id", 0, "client the conversation is with")
+	agentID := flags.Uint("agent-id", 0, "agent the conversation is with; the client's agent when zero")
+	agentSender := flags.String("agent-sender", "", "participant who is the agent")
+	clientSender := flags.String("client-sender", "", "participant who is the client; anyone but the agent when empty")
+	timezone := flags.String("timezone", "", "IANA time zone for timestamps without one; UTC when empty")
+	dryRun := flags.Bool("dry-run", false, "report what would be imported, including the participants, without storing anything")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *username == "" {
+		return errors.New("-username is required")
+	}
+	if *in == "" {
+		return errors.New("-in is required")
+	}
+	if *clientID == 0 {
+		return errors.New("-client-id is required")
+	}
+
+	f, err := os.Open(*in)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	b, err := openBackend()
+	if err != nil {
+		return err
+	}
+
+	user, err := b.users.GetUserByUsername(ctx, *username)
+	if err != nil {
+		return err
+	}
+
+	report, err := b.chats.ImportConversation(ctx, services.ConversationImport{
+		File:         f,
+		Filename:     *in,
+		Format:       *format,
+		ClientID:     *clientID,
+		AgentID:      *agentID,
+		AgentSender:  *agentSender,
+		ClientSender: *clientSender,
+		Timezone:     *timezone,
+		DryRun:       *dryRun,
+	}, user.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, skipped := range report.Skipped {
+		fmt.Fprintf(os.Stderr, "line %d: %s: %s\n", skipped.Line, skipped.Reason, skipped.Text)
+	}
+	verb := "imported"
+	if report.DryRun {
+		verb = "would import"
+	}
+	fmt.Printf("participants: %s\n", strings.Join(report.Participants, ", "))
+	fmt.Printf("%s %d of %d %s messages, %d duplicates, %d skipped\n",
+		verb, report.Imported, report.Parsed, report.Format, report.Duplicates, len(report.Skipped))
+	return nil
+}
+
+func seed(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("seed", flag.ContinueOnError)
+	seedValue := flags.Uint64("seed", 1, "random seed; the same seed and days produce the same data")
+	users := flags.Int("users", 1, "number of demo users (demo, demo2, ...), created when missing")
+	agents := flags.Int("agents", 3, "agents per user")
+	clients := flags.Int("clients", 8, "clients per agent")
+	days := flags.Int("days", 90, "days of history, ending today")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	generated := demodata.Generate(demodata.Options{
+		Seed:            *seedValue,
+		Users:           *users,
+		AgentsPerUser:   *agents,
+		ClientsPerAgent: *clients,
+		Days:            *days,
+		End:             time.Now().UTC().Truncate(24 * time.Hour),
+	})
+
+	b, err := openBackend()
+	if err != nil {
+		return err
+	}
+
+	summary, err := demodata.Load(ctx, b.users, b.auth, b.userData, generated)
+	printSummary(summary)
+	if err == nil {
+		fmt.Printf("log in as %s with password %s\n", generated[0].Username, demodata.DefaultPassword)
+	}
+	return err
+}
+
+func printSummary(s userdata.Summary) {
+	fmt.Printf("imported %d custom fields, %d agents, %d clients, %d contacts, %d notes, %d messages, %d transactions, %d refunds\n",
+		s.CustomFields, s.Agents, s.Clients, s.Contacts, s.Notes, s.Messages, s.Transactions, s.Refunds)
+}
@@ -0,0 +1,64 @@
+// Command sirenctl administers a Siren-Net database from the command line.
+// It reads the same environment as the web server and goes through the
+// services layer, so every change it makes is validated and audited.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/joho/godotenv"
+)
+
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, args []string) error
+}
+
+var commands = []command{
+	{"create-admin", "create an admin user", createAdmin},
+	{"reset-password", "set a user's password and clear any lockout", resetPassword},
+	{"migrate", "apply database migrations", migrate},
+	{"seed", "load demo data into a demo user", seed},
+	{"export", "write one user's agents, clients with their profiles, messages and transactions as JSON", exportUser},
+	{"import", "load an export into an existing user", importUser},
+	{"import-chat", "import a WhatsApp, Telegram or CSV chat export into a client", importChat},
+}
+
+func main() {
+	// A missing .env is fine; the variables may come from the environment.
+	_ = godotenv.Load()
+
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != os.Args[1] {
+			continue
+		}
+		if err := cmd.run(context.Background(), os.Args[2:]); err != nil {
+			if !errors.Is(err, flag.ErrHelp) {
+				fmt.Fprintf(os.Stderr, "sirenctl %s: %v\n", cmd.name, err)
+			}
+			os.Exit(1)
+		}
+		return
+	}
+
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: sirenctl <command> [flags]\n\ncommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-15s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(os.Stderr, "\nRun sirenctl <command> -h for the flags of a command.")
+}
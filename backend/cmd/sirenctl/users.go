@@ -0,0 +1,101 @@
+package main
+
+import (
+	"backend/internal/utils"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+)
+
+func createAdmin(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("create-admin", flag.ContinueOnError)
+	username := flags.String("username", "", "username of the new admin")
+	email := flags.String("email", "", "email of the new admin")
+	password := flags.String("password", "", "password; read from stdin when empty")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *username == "" || *email == "" {
+		return errors.New("-username and -email are required")
+	}
+
+	pw, err := readPassword(*password)
+	if err != nil {
+		return err
+	}
+
+	b, err := openBackend()
+	if err != nil {
+		return err
+	}
+
+	user, err := b.auth.Register(ctx, *username, *email, pw)
+	if err != nil {
+		return err
+	}
+	user.Admin = true
+	if err := b.users.UpdateUser(ctx, user); err != nil {
+		return err
+	}
+
+	fmt.Printf("created admin %s (id %d)\n", user.Username, user.ID)
+	return nil
+}
+
+func resetPassword(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("reset-password", flag.ContinueOnError)
+	username := flags.String("username", "", "user whose password is reset")
+	password := flags.String("password", "", "new password; read from stdin when empty")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *username == "" {
+		return errors.New("-username is required")
+	}
+
+	pw, err := readPassword(*password)
+	if err != nil {
+		return err
+	}
+
+	b, err := openBackend()
+	if err != nil {
+		return err
+	}
+
+	user, err := b.users.GetUserByUsername(ctx, *username)
+	if err != nil {
+		return err
+	}
+
+	hashed, err := utils.HashPassword(pw)
+	if err != nil {
+		return err
+	}
+	user.Password = hashed
+	user.FailedLogins = 0
+	user.LockedUntil = nil
+	if err := b.users.UpdateUser(ctx, user); err != nil {
+		return err
+	}
+
+	fmt.Printf("reset password of %s\n", user.Username)
+	return nil
+}
+
+// migrate only opens the database; connecting applies every pending
+// migration.
+func migrate(_ context.Context, args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if _, err := openBackend(); err != nil {
+		return err
+	}
+
+	fmt.Println("database is up to date")
+	return nil
+}
@@ -0,0 +1,28 @@
+// Package ai hides the generative backends behind small interfaces so that
+// handlers do not depend on Ollama or Stable Diffusion directly and tests or
+// other deployments can swap them.
+package ai
+
+import (
+	"context"
+)
+
+// Completion is a language model's answer to a prompt.
+type Completion struct {
+	Model            string
+	Response         string
+	TotalDuration    int64 // nanoseconds
+	PromptTokens     int64
+	CompletionTokens int64
+}
+
+// LLMProvider answers prompts with a language model.
+type LLMProvider interface {
+	Complete(ctx context.Context, prompt string) (*Completion, error)
+}
+
+// ImageProvider turns a prompt into images. The result is the backend's
+// response body, which the API passes through unchanged.
+type ImageProvider interface {
+	TextToImage(ctx context.Context, prompt string) (map[string]interface{}, error)
+}
@@ -0,0 +1,90 @@
+package ai
+
+import (
+	"backend/internal/metrics"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const ollamaModel = "deepseek"
+
+// Ollama is an LLMProvider backed by an Ollama server.
+type Ollama struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+func NewOllama(baseURL string, httpClient *http.Client) *Ollama {
+	return &Ollama{baseURL: baseURL, httpClient: httpClient}
+}
+
+type ollamaResponse struct {
+	Model           string  `json:"model"`
+	Response        *string `json:"response"`
+	TotalDuration   int64   `json:"total_duration"`
+	PromptEvalCount int64   `json:"prompt_eval_count"`
+	EvalCount       int64   `json:"eval_count"`
+}
+
+func (o *Ollama) Complete(ctx context.Context, prompt string) (*Completion, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"prompt":     prompt,
+		"model":      ollamaModel,
+		"stream":     false,
+		"keep_alive": -1,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.baseURL+"/api/generate", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	start := time.Now()
+	completion, err := o.do(req)
+	metrics.OllamaRequestDuration.Observe(time.Since(start).Seconds())
+	if err != nil {
+		metrics.OllamaRequestErrors.Inc()
+		return nil, err
+	}
+
+	metrics.OllamaTokens.WithLabelValues("prompt").Add(float64(completion.PromptTokens))
+	metrics.OllamaTokens.WithLabelValues("completion").Add(float64(completion.CompletionTokens))
+	return completion, nil
+}
+
+func (o *Ollama) do(req *http.Request) (*Completion, error) {
+	resp, err := o.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ollama responded with %s", resp.Status)
+	}
+
+	var decoded ollamaResponse
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return nil, err
+	}
+	if decoded.Response == nil {
+		return nil, errors.New("ollama response has no answer")
+	}
+
+	return &Completion{
+		Model:            decoded.Model,
+		Response:         *decoded.Response,
+		TotalDuration:    decoded.TotalDuration,
+		PromptTokens:     decoded.PromptEvalCount,
+		CompletionTokens: decoded.EvalCount,
+	}, nil
+}
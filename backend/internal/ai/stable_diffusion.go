@@ -0,0 +1,75 @@
+package ai
+
+import (
+	"backend/internal/metrics"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// StableDiffusion is an ImageProvider backed by the AUTOMATIC1111 web UI API.
+type StableDiffusion struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+func NewStableDiffusion(baseURL string, httpClient *http.Client) *StableDiffusion {
+	return &StableDiffusion{baseURL: baseURL, httpClient: httpClient}
+}
+
+func (s *StableDiffusion) TextToImage(ctx context.Context, prompt string) (map[string]interface{}, error) {
+	return s.call(ctx, "sdapi/v1/txt2img", map[string]interface{}{
+		"prompt":          prompt,
+		"negative_prompt": "sdxl_cyberrealistic_simpleneg-neg",
+		"width":           512,
+		"height":          512,
+		"steps":           30,
+		"cfg_scale":       3,
+		"sampler_name":    "DPM++ 2S a Karras",
+		"sampler_index":   "DPM++ 2S a Karras",
+		"batch_size":      1,
+		"n_iter":          1,
+	})
+}
+
+func (s *StableDiffusion) call(ctx context.Context, endpoint string, payload interface{}) (sdResponse map[string]interface{}, err error) {
+	metrics.SDRequestsInFlight.Inc()
+	start := time.Now()
+	defer func() {
+		metrics.SDRequestsInFlight.Dec()
+		outcome := "success"
+		if err != nil {
+			outcome = "error"
+		}
+		metrics.SDRequestDuration.WithLabelValues(endpoint, outcome).Observe(time.Since(start).Seconds())
+	}()
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+"/"+endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("stable diffusion responded with %s", resp.Status)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&sdResponse); err != nil {
+		return nil, err
+	}
+	return sdResponse, nil
+}
@@ -1,58 +1,224 @@
 package app
 
 import (
+	"backend/api"
+	"backend/internal/ai"
 	"backend/internal/config"
 	"backend/internal/handlers"
+	"backend/internal/logging"
+	"backend/internal/metrics"
 	"backend/internal/middleware"
 	"backend/internal/routes"
 	"backend/internal/services"
+	"backend/internal/tracing"
 	"backend/pkg/database"
+	"context"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 type Application struct {
-	Router *gin.Engine
-	DB     *database.DB
+	Router            *gin.Engine
+	DB                *database.DB
+	WebhookDispatcher *services.WebhookDispatcher
+	RetentionJob      *services.RetentionJob
+	BulkJobRunner     *services.BulkJobRunner
+	ExportJobRunner   *services.ExportJobRunner
+
+	shutdownTracing func(context.Context) error
 }
 
-func New() *Application {
+func New(opts ...Option) *Application {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	cfg := config.Load()
-	db := database.Connect(cfg.DatabaseURL)
+	if o.cfg != nil {
+		cfg = *o.cfg
+	}
+	if o.now == nil {
+		o.now = time.Now
+	}
+	if o.httpClient == nil {
+		// Shared by the AI integrations so their calls show up as client
+		// spans in request traces.
+		o.httpClient = tracing.NewHTTPClient(0)
+	}
+	if o.llm == nil {
+		o.llm = ai.NewOllama(cfg.OllamaURL, o.httpClient)
+	}
+	if o.images == nil {
+		o.images = ai.NewStableDiffusion(cfg.SDUrl, o.httpClient)
+	}
+	logger := o.logger
+	if logger == nil {
+		logger = logging.New(os.Stdout, cfg.LogLevel)
+	}
+	slog.SetDefault(logger)
+
+	db := o.db
+	if db == nil {
+		db = database.Connect(cfg.DatabaseURL)
+	}
+	db.Logger = logging.NewGormLogger(logger)
+	if err := metrics.InstrumentDB(db.DB); err != nil {
+		panic("Failed to instrument database")
+	}
+	if err := tracing.InstrumentDB(db.DB); err != nil {
+		panic("Failed to instrument database")
+	}
+	db = db.WithClock(o.now)
+	shutdownTracing, err := tracing.Setup(context.Background(), &cfg)
+	if err != nil {
+		panic("Failed to set up tracing: " + err.Error())
+	}
+	openAPISpec, err := api.JSON()
+	if err != nil {
+		panic("Failed to load OpenAPI specification: " + err.Error())
+	}
 	userService := services.NewUserService(db)
+	auditService := services.NewAuditService(db, o.now)
 	authMiddleware := middleware.NewAuthMiddleware(&cfg, userService)
 
 	authService := services.NewAuthService(
 		userService,
+		auditService,
 		&cfg,
+		o.now,
 	)
-	agentService := services.NewAgentService(db)
-	clientService := services.NewClientService(db, agentService)
-	transactionService := services.NewTransactionService(db, agentService, clientService)
-	messageService := services.NewMessageService(db, agentService, clientService)
+	agentService := services.NewAgentService(db, o.now)
+	clientService := services.NewClientService(db, agentService, o.now)
+	customFieldService := services.NewCustomFieldService(db)
+	clientProfileService := services.NewClientProfileService(db, clientService, customFieldService)
+	timelineService := services.NewTimelineService(db, clientService)
+	segmentService := services.NewSegmentService(db, o.now)
+	bulkService := services.NewBulkService(db, &cfg, agentService, clientService, o.now)
+	bulkJobRunner := services.NewBulkJobRunner(db, &cfg, o.now)
+	exportService := services.NewExportService(db, &cfg, agentService, clientService, o.now)
+	exportJobRunner := services.NewExportJobRunner(db, &cfg, o.now)
+	erasureService := services.NewErasureService(db, o.now)
+	transactionService := services.NewTransactionService(db, agentService, clientService, o.now)
+	messageService := services.NewMessageService(db, agentService, clientService, o.now)
+	reconciliationService := services.NewReconciliationService(db)
+	conversationImportService := services.NewConversationImportService(db, agentService, clientService, o.now)
+	analyticsService := services.NewAnalyticsService(db, agentService, clientService)
+	webhookService := services.NewWebhookService(db, &cfg, o.now)
+	webhookDispatcher := services.NewWebhookDispatcher(db, &cfg, o.now)
+	retentionJob := services.NewRetentionJob(db, &cfg, o.now)
+	quotaService := services.NewQuotaService(db, &cfg, o.now)
 
 	authHandler := handlers.NewAuthHandler(authService)
 	agentHandler := handlers.NewAgentHandler(agentService)
 	clientHandler := handlers.NewClientHandler(clientService)
-	transactionHandler := handlers.NewTransactionHandler(transactionService)
-	messageHandler := handlers.NewMessageHandler(messageService)
+	clientProfileHandler := handlers.NewClientProfileHandler(clientProfileService)
+	timelineHandler := handlers.NewTimelineHandler(timelineService)
+	customFieldHandler := handlers.NewCustomFieldHandler(customFieldService)
+	segmentHandler := handlers.NewSegmentHandler(segmentService, clientService)
+	bulkHandler := handlers.NewBulkHandler(bulkService)
+	exportHandler := handlers.NewExportHandler(exportService)
+	erasureHandler := handlers.NewErasureHandler(erasureService, exportService)
+	transactionHandler := handlers.NewTransactionHandler(transactionService, reconciliationService)
+	messageHandler := handlers.NewMessageHandler(messageService, conversationImportService, int64(cfg.ImportMaxBytes))
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+	auditHandler := handlers.NewAuditHandler(auditService)
+	analyticsHandler := handlers.NewAnalyticsHandler(analyticsService)
+	llmHandler := handlers.NewLLMHandler(o.llm, quotaService)
+	sdHandler := handlers.NewSDHandler(o.images, quotaService)
+
+	router := gin.New()
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		panic("Invalid TRUSTED_PROXIES: " + err.Error())
+	}
+	router.Use(
+		otelgin.Middleware(cfg.TracingServiceName),
+		middleware.RequestMetadata(),
+		middleware.RequestLogger(logger),
+		middleware.Recovery(logger),
+		middleware.Metrics(),
+		middleware.ErrorHandler(),
+	)
+
+	apiHandlers := &routes.APIHandlers{
+		Auth:        authHandler,
+		Agent:       agentHandler,
+		Client:      clientHandler,
+		Profile:     clientProfileHandler,
+		Timeline:    timelineHandler,
+		CustomField: customFieldHandler,
+		Segment:     segmentHandler,
+		Bulk:        bulkHandler,
+		Export:      exportHandler,
+		Erasure:     erasureHandler,
+		Transaction: transactionHandler,
+		Message:     messageHandler,
+		Webhook:     webhookHandler,
+		Audit:       auditHandler,
+		Analytics:   analyticsHandler,
+		LLM:         llmHandler,
+		SD:          sdHandler,
+	}
+	limits := middleware.NewRateLimits(&cfg, o.now)
+
+	v1 := router.Group("/api/v1", limits.PerIP())
+	routes.RegisterAPIV1(v1, apiHandlers, authMiddleware, limits)
+	routes.RegisterOpenAPIRoutes(v1, openAPISpec)
 
-	router := gin.Default()
+	// The unversioned paths predate /api/v1 and stay available until the
+	// sunset date so existing clients keep working while they migrate.
+	legacy := router.Group("", limits.PerIP(), middleware.Deprecated(cfg.LegacyAPIDeprecatedAt, cfg.LegacyAPISunset, "/api/v1"))
+	routes.RegisterLegacyAPI(legacy, apiHandlers, authMiddleware, limits)
 
-	routes.RegisterAuthRoutes(router, authHandler, authMiddleware)
-	routes.RegisterAgentRoutes(router, agentHandler, authMiddleware)
-	routes.RegisterClientRoutes(router, clientHandler, authMiddleware)
-	routes.RegisterTransactionRoutes(router, transactionHandler, authMiddleware)
-	routes.RegisterMessageRoutes(router, messageHandler, authMiddleware)
-	routes.RegisterLLMRoutes(router)
-	routes.RegisterSDRoutes(router, &cfg)
+	routes.RegisterOpenAPIRoutes(router, openAPISpec)
+	routes.RegisterMetricsRoutes(router, metrics.NewRegistry(db), &cfg)
 
 	return &Application{
-		Router: router,
-		DB:     db,
+		Router:            router,
+		DB:                db,
+		WebhookDispatcher: webhookDispatcher,
+		RetentionJob:      retentionJob,
+		BulkJobRunner:     bulkJobRunner,
+		ExportJobRunner:   exportJobRunner,
+		shutdownTracing:   shutdownTracing,
 	}
 }
 
 func (a *Application) Run() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer a.shutdownTracing(context.Background())
+
+	go a.RunWorkers(ctx)
+
 	return a.Router.Run(":8080")
 }
+
+// RunWorkers runs the background jobs until ctx is cancelled. A worker-only
+// deployment calls it instead of Run and never serves HTTP.
+func (a *Application) RunWorkers(ctx context.Context) {
+	var wg sync.WaitGroup
+	wg.Add(4)
+	go func() {
+		defer wg.Done()
+		a.WebhookDispatcher.Run(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		a.RetentionJob.Run(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		a.BulkJobRunner.Run(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		a.ExportJobRunner.Run(ctx)
+	}()
+	wg.Wait()
+}
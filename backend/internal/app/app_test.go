@@ -0,0 +1,189 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"backend/internal/ai"
+	"backend/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeLLM struct {
+	prompts []string
+}
+
+func (f *fakeLLM) Complete(_ context.Context, prompt string) (*ai.Completion, error) {
+	f.prompts = append(f.prompts, prompt)
+	return &ai.Completion{Model: "fake", Response: "ok", PromptTokens: 40, CompletionTokens: 20}, nil
+}
+
+func TestNewWithInjectedDependencies(t *testing.T) {
+	now := time.Date(2026, time.March, 2, 23, 0, 0, 0, time.UTC)
+	llm := &fakeLLM{}
+	cfg := config.Load()
+	cfg.DatabaseURL = filepath.Join(t.TempDir(), "app.db")
+	cfg.JWTSecret = "test-secret"
+	cfg.LLMDailyTokenQuota = 100
+	// The clock stands still, so token buckets would never refill.
+	cfg.RateLimitAIPerMinute = 0
+	application := newTestApplication(t,
+		WithConfig(cfg),
+		WithClock(func() time.Time { return now }),
+		WithLLMProvider(llm),
+	)
+
+	token := registerAndLogin(t, application, "alice")
+	ask := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/llm/ask", bytes.NewBufferString(`{"prompt":"hi"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp := httptest.NewRecorder()
+		application.Router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	require.Equal(t, http.StatusOK, ask().Code)
+	require.Equal(t, http.StatusOK, ask().Code)
+
+	exceeded := ask()
+	assert.Equal(t, http.StatusTooManyRequests, exceeded.Code)
+	assert.Equal(t, "3600", exceeded.Header().Get("Retry-After"), "the quota resets at midnight on the injected clock")
+	assert.Equal(t, []string{"hi", "hi"}, llm.prompts)
+
+	now = now.Add(time.Hour)
+	assert.Equal(t, http.StatusOK, ask().Code)
+}
+
+func registerAndLogin(t *testing.T, application *Application, username string) string {
+	t.Helper()
+	post := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		application.Router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	registered := post("/api/v1/auth/register", `{"username":"`+username+`","email":"`+username+`@example.com","password":"password123","confirm_password":"password123"}`)
+	require.Equal(t, http.StatusCreated, registered.Code, registered.Body.String())
+
+	login := post("/api/v1/auth/login", `{"username":"`+username+`","password":"password123"}`)
+	require.Equal(t, http.StatusOK, login.Code, login.Body.String())
+
+	var body struct {
+		Token string `json:"token"`
+	}
+	require.NoError(t, json.Unmarshal(login.Body.Bytes(), &body))
+	return body.Token
+}
+
+func TestMetricsEndpointNeedsTokenOrOptIn(t *testing.T) {
+	scrape := func(application *Application, token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp := httptest.NewRecorder()
+		application.Router.ServeHTTP(resp, req)
+		return resp.Code
+	}
+	newApp := func(token string, public bool) *Application {
+		cfg := config.Load()
+		cfg.DatabaseURL = filepath.Join(t.TempDir(), "app.db")
+		cfg.JWTSecret = "test-secret"
+		cfg.MetricsToken = token
+		cfg.MetricsPublic = public
+		return newTestApplication(t, WithConfig(cfg))
+	}
+
+	assert.Equal(t, http.StatusNotFound, scrape(newApp("", false), ""), "not served without a token")
+
+	protected := newApp("scrape-token", false)
+	assert.Equal(t, http.StatusUnauthorized, scrape(protected, ""))
+	assert.Equal(t, http.StatusUnauthorized, scrape(protected, "wrong"))
+	assert.Equal(t, http.StatusOK, scrape(protected, "scrape-token"))
+
+	assert.Equal(t, http.StatusOK, scrape(newApp("", true), ""))
+}
+
+func TestForwardedForNeedsTrustedProxy(t *testing.T) {
+	newApp := func(trusted []string) *Application {
+		cfg := config.Load()
+		cfg.DatabaseURL = filepath.Join(t.TempDir(), "app.db")
+		cfg.JWTSecret = "test-secret"
+		cfg.RateLimitAuthPerMinute = 1
+		cfg.RateLimitAuthBurst = 1
+		cfg.TrustedProxies = trusted
+		return newTestApplication(t, WithConfig(cfg))
+	}
+	login := func(application *Application, forwardedFor string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", bytes.NewBufferString(`{"username":"nobody","password":"password123"}`))
+		req.RemoteAddr = "192.0.2.10:4000"
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		resp := httptest.NewRecorder()
+		application.Router.ServeHTTP(resp, req)
+		return resp.Code
+	}
+
+	// A forged header from an untrusted peer does not earn a new bucket.
+	direct := newApp(nil)
+	assert.Equal(t, http.StatusUnauthorized, login(direct, "198.51.100.1"))
+	assert.Equal(t, http.StatusTooManyRequests, login(direct, "198.51.100.2"))
+
+	proxied := newApp([]string{"192.0.2.0/24"})
+	assert.Equal(t, http.StatusUnauthorized, login(proxied, "198.51.100.1"))
+	assert.Equal(t, http.StatusUnauthorized, login(proxied, "198.51.100.2"))
+	assert.Equal(t, http.StatusTooManyRequests, login(proxied, "198.51.100.1"))
+}
+
+func TestInjectedClockDatesRecords(t *testing.T) {
+	now := time.Date(2026, time.March, 2, 9, 30, 0, 0, time.UTC)
+	cfg := config.Load()
+	cfg.DatabaseURL = filepath.Join(t.TempDir(), "app.db")
+	cfg.JWTSecret = "test-secret"
+	application := newTestApplication(t,
+		WithConfig(cfg),
+		WithClock(func() time.Time { return now }),
+	)
+
+	token := registerAndLogin(t, application, "alice")
+	call := func(method, path, body string, out any) {
+		t.Helper()
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp := httptest.NewRecorder()
+		application.Router.ServeHTTP(resp, req)
+		require.Less(t, resp.Code, 300, resp.Body.String())
+		if out != nil {
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), out))
+		}
+	}
+
+	var agent struct{ ID uint }
+	call(http.MethodPost, "/api/v1/agents", `{"name":"Nova","characteristics":"calm"}`, &agent)
+	var client struct{ ID uint }
+	call(http.MethodPost, "/api/v1/clients", `{"name":"Bob","agent_id":"`+strconv.FormatUint(uint64(agent.ID), 10)+`","start_date":"2026-01-01T00:00:00Z"}`, &client)
+
+	var message struct{ Date time.Time }
+	call(http.MethodPost, "/api/v1/messages", `{"content":"hi","type":"AGENT_TO_CLIENT","agent_id":"`+strconv.FormatUint(uint64(agent.ID), 10)+`","client_id":"`+strconv.FormatUint(uint64(client.ID), 10)+`"}`, &message)
+	assert.True(t, now.Equal(message.Date), "an undated message is dated by the injected clock")
+
+	now = now.Add(time.Hour)
+	call(http.MethodDelete, "/api/v1/clients/"+strconv.FormatUint(uint64(client.ID), 10), "", nil)
+	var trash []struct{ DeletedAt time.Time }
+	call(http.MethodGet, "/api/v1/clients/trash", "", &trash)
+	require.Len(t, trash, 1)
+	assert.True(t, now.Equal(trash[0].DeletedAt), "the deletion is dated by the injected clock")
+}
@@ -0,0 +1,139 @@
+package app
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"backend/api"
+	"backend/pkg/client"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestApplication(t *testing.T, opts ...Option) *Application {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("DATABASE_URL", filepath.Join(t.TempDir(), "app.db"))
+	t.Setenv("JWT_SECRET", "test-secret")
+	return New(append([]Option{WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))}, opts...)...)
+}
+
+var ginParam = regexp.MustCompile(`[:*](\w+)`)
+
+// unversionedRoutes are operational endpoints that live outside the API
+// versions and the specification.
+var unversionedRoutes = map[string]bool{"/metrics": true, "/openapi.json": true}
+
+func TestRoutesMatchOpenAPISpec(t *testing.T) {
+	spec, err := api.Load()
+	require.NoError(t, err)
+	application := newTestApplication(t)
+
+	registered := map[string]bool{}
+	var legacy []string
+	for _, route := range application.Router.Routes() {
+		path := ginParam.ReplaceAllString(route.Path, "{$1}")
+		if versioned, ok := strings.CutPrefix(path, "/api/v1"); ok {
+			registered[route.Method+" "+versioned] = true
+		} else if !unversionedRoutes[path] {
+			legacy = append(legacy, route.Method+" "+path)
+		}
+	}
+
+	documented := map[string]bool{}
+	for path, item := range spec.Paths.Map() {
+		for method := range item.Operations() {
+			documented[method+" "+path] = true
+		}
+	}
+
+	for route := range registered {
+		assert.True(t, documented[route], "%s is registered but missing from api/openapi.yaml", route)
+	}
+	for route := range documented {
+		assert.True(t, registered[route], "%s is documented but not registered", route)
+	}
+	for _, route := range legacy {
+		assert.True(t, registered[route], "%s has no /api/v1 counterpart", route)
+	}
+}
+
+func TestLegacyRoutesAreDeprecated(t *testing.T) {
+	application := newTestApplication(t)
+
+	for _, path := range []string{"/auth/logout", "/api/v1/auth/logout"} {
+		resp := httptest.NewRecorder()
+		application.Router.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, path, nil))
+		require.Equal(t, http.StatusOK, resp.Code, path)
+
+		if path == "/auth/logout" {
+			assert.Equal(t, "@1792368000", resp.Header().Get("Deprecation"))
+			assert.Equal(t, "Fri, 30 Apr 2027 00:00:00 GMT", resp.Header().Get("Sunset"))
+			assert.Equal(t, `</api/v1/auth/logout>; rel="successor-version"`, resp.Header().Get("Link"))
+		} else {
+			assert.Empty(t, resp.Header().Get("Deprecation"))
+		}
+	}
+}
+
+func TestNewRoutesAreOnlyVersioned(t *testing.T) {
+	application := newTestApplication(t)
+
+	for path, want := range map[string]int{"/webhooks": http.StatusNotFound, "/api/v1/webhooks": http.StatusUnauthorized} {
+		resp := httptest.NewRecorder()
+		application.Router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, want, resp.Code, path)
+	}
+}
+
+func TestGeneratedClient(t *testing.T) {
+	server := httptest.NewServer(newTestApplication(t).Router)
+	defer server.Close()
+	ctx := context.Background()
+	baseURL := server.URL + "/api/v1"
+
+	anonymous, err := client.NewClientWithResponses(baseURL)
+	require.NoError(t, err)
+
+	spec, err := anonymous.GetOpenAPISpecWithResponse(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, spec.StatusCode())
+
+	registered, err := anonymous.RegisterWithResponse(ctx, client.RegisterRequest{
+		Username:        "alice",
+		Email:           "alice@example.com",
+		Password:        "password123",
+		ConfirmPassword: "password123",
+	})
+	require.NoError(t, err)
+	require.NotNil(t, registered.JSON201, string(registered.Body))
+
+	login, err := anonymous.LoginWithResponse(ctx, client.LoginRequest{Username: "alice", Password: "password123"})
+	require.NoError(t, err)
+	require.NotNil(t, login.JSON200, string(login.Body))
+
+	authed, err := client.NewClientWithResponses(baseURL, client.WithBearerToken(login.JSON200.Token))
+	require.NoError(t, err)
+
+	created, err := authed.CreateAgentWithResponse(ctx, client.AgentInput{Name: "Nova", Characteristics: "warm"})
+	require.NoError(t, err)
+	require.NotNil(t, created.JSON201, string(created.Body))
+
+	fetched, err := authed.GetAgentByIDWithResponse(ctx, created.JSON201.ID)
+	require.NoError(t, err)
+	require.NotNil(t, fetched.JSON200, string(fetched.Body))
+	assert.Equal(t, "Nova", fetched.JSON200.Name)
+
+	missing, err := authed.GetAgentByIDWithResponse(ctx, created.JSON201.ID+1000)
+	require.NoError(t, err)
+	require.NotNil(t, missing.JSON404, string(missing.Body))
+	assert.Equal(t, "agent_not_found", missing.JSON404.Code)
+}
@@ -0,0 +1,79 @@
+package app
+
+import (
+	"backend/internal/ai"
+	"backend/internal/config"
+	"backend/pkg/database"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Option changes how New builds the application. Anything not set by an
+// option is built from the configuration, which itself defaults to the
+// environment.
+type Option func(*options)
+
+type options struct {
+	cfg        *config.Config
+	db         *database.DB
+	now        func() time.Time
+	httpClient *http.Client
+	llm        ai.LLMProvider
+	images     ai.ImageProvider
+	logger     *slog.Logger
+}
+
+// WithConfig replaces the configuration read from the environment.
+func WithConfig(cfg config.Config) Option {
+	return func(o *options) {
+		o.cfg = &cfg
+	}
+}
+
+// WithDB uses db instead of connecting to the configured DATABASE_URL. The
+// schema must already be migrated, which database.Connect does.
+func WithDB(db *database.DB) Option {
+	return func(o *options) {
+		o.db = db
+	}
+}
+
+// WithClock sets the time source of login lockouts, quotas, rate limits,
+// trash retention, webhook backoff, event and record timestamps and the
+// default dates of messages and transactions.
+func WithClock(now func() time.Time) Option {
+	return func(o *options) {
+		o.now = now
+	}
+}
+
+// WithHTTPClient sets the client the default Ollama and Stable Diffusion
+// providers use.
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = client
+	}
+}
+
+// WithLLMProvider replaces the Ollama provider.
+func WithLLMProvider(llm ai.LLMProvider) Option {
+	return func(o *options) {
+		o.llm = llm
+	}
+}
+
+// WithImageProvider replaces the Stable Diffusion provider.
+func WithImageProvider(images ai.ImageProvider) Option {
+	return func(o *options) {
+		o.images = images
+	}
+}
+
+// WithLogger replaces the JSON logger on stdout. It also becomes the slog
+// default.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
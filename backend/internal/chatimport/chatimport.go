@@ -0,0 +1,144 @@
+// Package chatimport reads conversations from the export files of chat apps:
+// WhatsApp .txt exports, Telegram JSON exports and a generic CSV. It only
+// parses; mapping the participants to an agent and a client and storing the
+// messages is up to the caller.
+package chatimport
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	FormatWhatsApp = "whatsapp"
+	FormatTelegram = "telegram"
+	FormatCSV      = "csv"
+)
+
+var Formats = []string{FormatWhatsApp, FormatTelegram, FormatCSV}
+
+var (
+	ErrUnknownFormat = errors.New("unknown export format")
+	errInvalidDate   = errors.New("invalid date")
+)
+
+// Message is one parsed chat message.
+type Message struct {
+	// Line is where the message starts in the file, or its position in
+	// the message list of a JSON export.
+	Line    int
+	Sender  string
+	Date    time.Time
+	Content string
+	// ExternalReference identifies the message within the conversation. It
+	// is the same every time the same export is parsed, so it can be used
+	// to skip messages imported before.
+	ExternalReference string
+}
+
+// Skipped is a line or entry that did not yield a message.
+type Skipped struct {
+	Line   int    `json:"line"`
+	Text   string `json:"text"`
+	Reason string `json:"reason"`
+}
+
+type Transcript struct {
+	Format   string
+	Messages []Message
+	Skipped  []Skipped
+}
+
+// Participants returns the distinct senders in order of their first message.
+func (t *Transcript) Participants() []string {
+	participants := []string{}
+	seen := map[string]bool{}
+	for _, message := range t.Messages {
+		if !seen[message.Sender] {
+			seen[message.Sender] = true
+			participants = append(participants, message.Sender)
+		}
+	}
+	return participants
+}
+
+func (t *Transcript) skip(line int, text, reason string) {
+	t.Skipped = append(t.Skipped, Skipped{Line: line, Text: snippet(text), Reason: reason})
+}
+
+// DetectFormat guesses the format from a file name.
+func DetectFormat(filename string) (string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".txt":
+		return FormatWhatsApp, nil
+	case ".json":
+		return FormatTelegram, nil
+	case ".csv":
+		return FormatCSV, nil
+	}
+	return "", ErrUnknownFormat
+}
+
+// Parse reads an export in the given format. Timestamps without a time zone
+// are read in loc. Lines that cannot be parsed are reported in the
+// transcript's Skipped list; an error means the file as a whole is unusable.
+func Parse(format string, r io.Reader, loc *time.Location) (*Transcript, error) {
+	var (
+		transcript *Transcript
+		err        error
+	)
+	switch format {
+	case FormatWhatsApp:
+		transcript, err = parseWhatsApp(r, loc)
+	case FormatTelegram:
+		transcript, err = parseTelegram(r, loc)
+	case FormatCSV:
+		transcript, err = parseCSV(r, loc)
+	default:
+		return nil, ErrUnknownFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	transcript.Format = format
+	transcript.assignReferences()
+	return transcript, nil
+}
+
+// assignReferences gives messages without an ID from the export a reference
+// derived from their content. Identical messages, such as two "ok" in the
+// same minute, are told apart by how often the same content came before.
+func (t *Transcript) assignReferences() {
+	occurrences := map[string]int{}
+	for i := range t.Messages {
+		message := &t.Messages[i]
+		if message.ExternalReference != "" {
+			message.ExternalReference = t.Format + ":" + message.ExternalReference
+			continue
+		}
+
+		content := fmt.Sprintf("%s\x00%s\x00%s", message.Sender, message.Date.UTC().Format(time.RFC3339Nano), message.Content)
+		occurrence := occurrences[content]
+		occurrences[content]++
+
+		sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%d", content, occurrence)))
+		message.ExternalReference = t.Format + ":" + hex.EncodeToString(sum[:16])
+	}
+}
+
+// snippet shortens a skipped line for the report.
+func snippet(text string) string {
+	const max = 120
+	text = strings.TrimSpace(text)
+	if len([]rune(text)) <= max {
+		return text
+	}
+	return string([]rune(text)[:max]) + "…"
+}
@@ -0,0 +1,103 @@
+package chatimport
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWhatsApp(t *testing.T) {
+	android := "12/01/2024, 14:32 - Messages and calls are end-to-end encrypted.\n" +
+		"12/01/2024, 14:32 - Daniel: hello\n" +
+		"12/01/2024, 14:33 - Nova: hi Daniel\n" +
+		"how can I help?\n" +
+		"12/01/2024, 14:33 - Nova: hi Daniel\n" +
+		"31/02/2024, 09:00 - Daniel: not a day\n"
+
+	transcript, err := Parse(FormatWhatsApp, strings.NewReader(android), time.UTC)
+	require.NoError(t, err)
+	require.Len(t, transcript.Messages, 3)
+	assert.Equal(t, []string{"Daniel", "Nova"}, transcript.Participants())
+	assert.Equal(t, time.Date(2024, time.January, 12, 14, 32, 0, 0, time.UTC), transcript.Messages[0].Date)
+	assert.Equal(t, "hi Daniel\nhow can I help?", transcript.Messages[1].Content)
+	assert.Equal(t, 3, transcript.Messages[1].Line)
+	// Identical messages still get references of their own.
+	assert.NotEqual(t, transcript.Messages[1].ExternalReference, transcript.Messages[2].ExternalReference)
+
+	require.Len(t, transcript.Skipped, 2)
+	assert.Equal(t, Skipped{Line: 1, Text: "12/01/2024, 14:32 - Messages and calls are end-to-end encrypted.", Reason: "system message"}, transcript.Skipped[0])
+	assert.Equal(t, "invalid date", transcript.Skipped[1].Reason)
+
+	again, err := Parse(FormatWhatsApp, strings.NewReader(android), time.UTC)
+	require.NoError(t, err)
+	assert.Equal(t, transcript.Messages[2].ExternalReference, again.Messages[2].ExternalReference)
+
+	ios := "\ufeff[1/13/24, 2:05:09\u202fPM] \u200eDaniel: hello\n" +
+		"[1/13/24, 12:01:00 AM] Nova: late\n"
+	transcript, err = Parse(FormatWhatsApp, strings.NewReader(ios), time.UTC)
+	require.NoError(t, err)
+	require.Len(t, transcript.Messages, 2)
+	assert.Equal(t, "Daniel", transcript.Messages[0].Sender)
+	assert.Equal(t, time.Date(2024, time.January, 13, 14, 5, 9, 0, time.UTC), transcript.Messages[0].Date)
+	assert.Equal(t, time.Date(2024, time.January, 13, 0, 1, 0, 0, time.UTC), transcript.Messages[1].Date)
+
+	_, err = Parse(FormatWhatsApp, strings.NewReader("just some text"), time.UTC)
+	assert.Error(t, err)
+}
+
+func TestParseTelegram(t *testing.T) {
+	export := `{
+		"name": "Daniel",
+		"id": 42,
+		"messages": [
+			{"id": 1, "type": "service", "date": "2024-01-12T14:30:00", "actor": "Daniel", "action": "create_group"},
+			{"id": 2, "type": "message", "date": "2024-01-12T14:32:00", "date_unixtime": "1705069920", "from": "Daniel", "text": "hello"},
+			{"id": 3, "type": "message", "date": "2024-01-12T14:33:00", "from": "Nova", "text": ["see ", {"type": "link", "text": "https://example.com"}]},
+			{"id": 4, "type": "message", "date": "2024-01-12T14:34:00", "from": "Nova", "text": "", "photo": "photos/1.jpg"}
+		]
+	}`
+
+	transcript, err := Parse(FormatTelegram, strings.NewReader(export), time.UTC)
+	require.NoError(t, err)
+	require.Len(t, transcript.Messages, 2)
+	assert.Equal(t, "telegram:42:2", transcript.Messages[0].ExternalReference)
+	assert.True(t, time.Unix(1705069920, 0).Equal(transcript.Messages[0].Date))
+	assert.Equal(t, "see https://example.com", transcript.Messages[1].Content)
+	assert.Equal(t, time.Date(2024, time.January, 12, 14, 33, 0, 0, time.UTC), transcript.Messages[1].Date)
+	require.Len(t, transcript.Skipped, 2)
+	assert.Equal(t, "system message", transcript.Skipped[0].Reason)
+	assert.Equal(t, "no text", transcript.Skipped[1].Reason)
+
+	_, err = Parse(FormatTelegram, strings.NewReader(`{"chats": {"list": []}}`), time.UTC)
+	assert.Error(t, err)
+}
+
+func TestParseCSV(t *testing.T) {
+	export := "Timestamp,From,Message,ID\n" +
+		"2024-01-12 14:32:00,Daniel,hello,a1\n" +
+		"yesterday,Daniel,hello,a2\n" +
+		"2024-01-12T14:33:00Z,Nova,\"hi, Daniel\",a3\n"
+
+	transcript, err := Parse(FormatCSV, strings.NewReader(export), time.UTC)
+	require.NoError(t, err)
+	require.Len(t, transcript.Messages, 2)
+	assert.Equal(t, "csv:a1", transcript.Messages[0].ExternalReference)
+	assert.Equal(t, "hi, Daniel", transcript.Messages[1].Content)
+	require.Len(t, transcript.Skipped, 1)
+	assert.Equal(t, Skipped{Line: 3, Text: "yesterday,Daniel,hello,a2", Reason: "invalid date"}, transcript.Skipped[0])
+
+	_, err = Parse(FormatCSV, strings.NewReader("when,who\n"), time.UTC)
+	assert.Error(t, err)
+}
+
+func TestDetectFormat(t *testing.T) {
+	format, err := DetectFormat("WhatsApp Chat with Daniel.txt")
+	require.NoError(t, err)
+	assert.Equal(t, FormatWhatsApp, format)
+
+	_, err = DetectFormat("chat.zip")
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+}
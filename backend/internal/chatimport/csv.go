@@ -0,0 +1,118 @@
+package chatimport
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+)
+
+// The generic CSV has a header row naming at least a date, a sender and a
+// content column, in any order. An id column, when present, is used to
+// recognise messages imported before.
+var csvColumns = map[string][]string{
+	"date":    {"date", "timestamp", "time", "sent_at"},
+	"sender":  {"sender", "from", "author", "name"},
+	"content": {"content", "text", "message", "body"},
+	"id":      {"id", "message_id"},
+}
+
+var csvDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+func parseCSVDate(value string, loc *time.Location) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range csvDateLayouts {
+		if date, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, errInvalidDate
+}
+
+func parseCSV(r io.Reader, loc *time.Location) (*Transcript, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("the CSV file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV header: %w", err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		for column, aliases := range csvColumns {
+			if _, ok := columns[column]; !ok && slices.Contains(aliases, name) {
+				columns[column] = i
+			}
+		}
+	}
+	for _, column := range []string{"date", "sender", "content"} {
+		if _, ok := columns[column]; !ok {
+			return nil, fmt.Errorf("the CSV header has no %s column", column)
+		}
+	}
+
+	transcript := &Transcript{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, err
+			}
+			transcript.skip(parseErr.StartLine, parseErr.Err.Error(), "malformed row")
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+
+		field := func(column string) string {
+			i, ok := columns[column]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+		text := strings.Join(record, ",")
+
+		date, err := parseCSVDate(field("date"), loc)
+		if err != nil {
+			transcript.skip(line, text, "invalid date")
+			continue
+		}
+		sender := field("sender")
+		if sender == "" {
+			transcript.skip(line, text, "no sender")
+			continue
+		}
+		content := field("content")
+		if content == "" {
+			transcript.skip(line, text, "no text")
+			continue
+		}
+
+		transcript.Messages = append(transcript.Messages, Message{
+			Line:              line,
+			Sender:            sender,
+			Date:              date,
+			Content:           content,
+			ExternalReference: field("id"),
+		})
+	}
+	return transcript, nil
+}
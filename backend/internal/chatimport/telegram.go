@@ -0,0 +1,126 @@
+package chatimport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// telegramExport is the result.json Telegram Desktop writes when exporting a
+// single chat. Exports of the whole account wrap the chats in another object
+// and are not supported.
+type telegramExport struct {
+	ID       int64             `json:"id"`
+	Messages []telegramMessage `json:"messages"`
+}
+
+type telegramMessage struct {
+	ID           int64           `json:"id"`
+	Type         string          `json:"type"`
+	Date         string          `json:"date"`
+	DateUnixtime string          `json:"date_unixtime"`
+	From         string          `json:"from"`
+	Text         json.RawMessage `json:"text"`
+}
+
+// text flattens the message text, which is a plain string or a list of plain
+// strings and formatted entities.
+func (m telegramMessage) text() (string, error) {
+	if len(m.Text) == 0 {
+		return "", nil
+	}
+	var plain string
+	if err := json.Unmarshal(m.Text, &plain); err == nil {
+		return plain, nil
+	}
+
+	var parts []json.RawMessage
+	if err := json.Unmarshal(m.Text, &parts); err != nil {
+		return "", err
+	}
+	var text strings.Builder
+	for _, part := range parts {
+		if err := json.Unmarshal(part, &plain); err == nil {
+			text.WriteString(plain)
+			continue
+		}
+		var entity struct {
+			Text string `json:"text"`
+		}
+		if err := json.Unmarshal(part, &entity); err != nil {
+			return "", err
+		}
+		text.WriteString(entity.Text)
+	}
+	return text.String(), nil
+}
+
+func (m telegramMessage) date(loc *time.Location) (time.Time, error) {
+	if m.DateUnixtime != "" {
+		seconds, err := strconv.ParseInt(m.DateUnixtime, 10, 64)
+		if err != nil {
+			return time.Time{}, errInvalidDate
+		}
+		return time.Unix(seconds, 0), nil
+	}
+	// Older exports only have the local time of the exporting device.
+	date, err := time.ParseInLocation("2006-01-02T15:04:05", m.Date, loc)
+	if err != nil {
+		return time.Time{}, errInvalidDate
+	}
+	return date, nil
+}
+
+func parseTelegram(r io.Reader, loc *time.Location) (*Transcript, error) {
+	var export telegramExport
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return nil, fmt.Errorf("invalid Telegram export: %w", err)
+	}
+	if export.Messages == nil {
+		return nil, errors.New("no Telegram messages found; export a single chat")
+	}
+
+	transcript := &Transcript{}
+	for i, message := range export.Messages {
+		position := i + 1
+		summary := fmt.Sprintf("message %d", message.ID)
+
+		if message.Type != "message" {
+			transcript.skip(position, summary, "system message")
+			continue
+		}
+		date, err := message.date(loc)
+		if err != nil {
+			transcript.skip(position, summary, "invalid date")
+			continue
+		}
+		text, err := message.text()
+		if err != nil {
+			transcript.skip(position, summary, "invalid text")
+			continue
+		}
+		text = strings.TrimSpace(text)
+		if text == "" {
+			transcript.skip(position, summary, "no text")
+			continue
+		}
+		sender := strings.TrimSpace(message.From)
+		if sender == "" {
+			transcript.skip(position, summary, "no sender")
+			continue
+		}
+
+		transcript.Messages = append(transcript.Messages, Message{
+			Line:              position,
+			Sender:            sender,
+			Date:              date,
+			Content:           text,
+			ExternalReference: fmt.Sprintf("%d:%d", export.ID, message.ID),
+		})
+	}
+	return transcript, nil
+}
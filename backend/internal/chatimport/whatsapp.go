@@ -0,0 +1,187 @@
+package chatimport
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WhatsApp exports are one message per line, continued on the following lines
+// when the message spans several. iOS and Android write the header
+// differently:
+//
+//	[12/01/2024, 14:32:05] Daniel: hello
+//	12/01/2024, 14:32 - Daniel: hello
+const (
+	whatsAppDate = `(\d{1,4})[./-](\d{1,2})[./-](\d{1,4})`
+	whatsAppTime = `(\d{1,2}):(\d{2})(?::(\d{2}))?(?:\s?([AaPp])\.?\s?[Mm]\.?)?`
+)
+
+var (
+	whatsAppHeader = regexp.MustCompile(`^(?:\[` + whatsAppDate + `,? ` + whatsAppTime + `\]|` + whatsAppDate + `,? ` + whatsAppTime + ` [-–])\s?(.*)$`)
+	// whatsAppSender splits "Name: text"; names never contain a colon.
+	whatsAppSender = regexp.MustCompile(`^([^:]{1,80}?): (.*)$`)
+)
+
+// whatsAppLine is a line that starts a message or a system notice.
+type whatsAppLine struct {
+	date                 [3]int
+	hour, minute, second int
+	meridiem             string
+	rest                 string
+}
+
+func matchWhatsAppHeader(line string) *whatsAppLine {
+	m := whatsAppHeader.FindStringSubmatch(line)
+	if m == nil {
+		return nil
+	}
+	// The iOS and Android alternatives capture into separate groups.
+	fields := m[1:8]
+	if m[1] == "" {
+		fields = m[8:15]
+	}
+	header := &whatsAppLine{meridiem: strings.ToUpper(fields[6]), rest: m[15]}
+	for i := 0; i < 3; i++ {
+		header.date[i], _ = strconv.Atoi(fields[i])
+	}
+	header.hour, _ = strconv.Atoi(fields[3])
+	header.minute, _ = strconv.Atoi(fields[4])
+	header.second, _ = strconv.Atoi(fields[5])
+	return header
+}
+
+// whatsAppDateOrder tells whether dates are day first. The export uses the
+// phone's locale, so the order is decided once for the whole file: a first
+// field above 12 means day first, a second field above 12 means month
+// first, and day first is assumed when nothing tells them apart.
+func whatsAppDateOrder(headers []*whatsAppLine) bool {
+	for _, header := range headers {
+		if header.date[0] > 31 {
+			continue
+		}
+		if header.date[0] > 12 {
+			return true
+		}
+		if header.date[1] > 12 {
+			return false
+		}
+	}
+	return true
+}
+
+func (h *whatsAppLine) time(dayFirst bool, loc *time.Location) (time.Time, error) {
+	year, month, day := h.date[2], h.date[1], h.date[0]
+	switch {
+	case h.date[0] > 31:
+		year, month, day = h.date[0], h.date[1], h.date[2]
+	case !dayFirst:
+		month, day = h.date[0], h.date[1]
+	}
+	if year < 100 {
+		year += 2000
+	}
+
+	hour := h.hour
+	switch h.meridiem {
+	case "A":
+		if hour == 12 {
+			hour = 0
+		}
+	case "P":
+		if hour < 12 {
+			hour += 12
+		}
+	}
+
+	if month < 1 || month > 12 || day < 1 || day > 31 || hour > 23 || h.minute > 59 || h.second > 59 {
+		return time.Time{}, errInvalidDate
+	}
+	date := time.Date(year, time.Month(month), day, hour, h.minute, h.second, 0, loc)
+	if date.Day() != day {
+		return time.Time{}, errInvalidDate
+	}
+	return date, nil
+}
+
+// whatsAppMarks removes the direction marks and unusual spaces WhatsApp puts
+// around names and times.
+var whatsAppMarks = strings.NewReplacer("\u200e", "", "\u200f", "", "\ufeff", "", "\u202f", " ", "\u00a0", " ")
+
+func parseWhatsApp(r io.Reader, loc *time.Location) (*Transcript, error) {
+	type line struct {
+		number int
+		text   string
+		header *whatsAppLine
+	}
+
+	var (
+		lines   []line
+		headers []*whatsAppLine
+	)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for number := 1; scanner.Scan(); number++ {
+		text := strings.TrimRight(whatsAppMarks.Replace(scanner.Text()), "\r")
+		header := matchWhatsAppHeader(text)
+		if header != nil {
+			headers = append(headers, header)
+		}
+		lines = append(lines, line{number: number, text: text, header: header})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(headers) == 0 {
+		return nil, errors.New("no WhatsApp messages found")
+	}
+	dayFirst := whatsAppDateOrder(headers)
+
+	transcript := &Transcript{}
+	// current is the message that continuation lines belong to; it is nil
+	// after a system notice or a line that could not be parsed.
+	var current *Message
+	flush := func() {
+		if current == nil {
+			return
+		}
+		current.Content = strings.TrimSpace(current.Content)
+		if current.Content == "" {
+			transcript.skip(current.Line, current.Sender, "no text")
+		} else {
+			transcript.Messages = append(transcript.Messages, *current)
+		}
+		current = nil
+	}
+
+	for _, l := range lines {
+		if l.header == nil {
+			if current != nil {
+				current.Content += "\n" + l.text
+			} else if strings.TrimSpace(l.text) != "" {
+				transcript.skip(l.number, l.text, "not a message")
+			}
+			continue
+		}
+
+		flush()
+		date, err := l.header.time(dayFirst, loc)
+		if err != nil {
+			transcript.skip(l.number, l.text, "invalid date")
+			continue
+		}
+		m := whatsAppSender.FindStringSubmatch(l.header.rest)
+		if m == nil {
+			transcript.skip(l.number, l.text, "system message")
+			continue
+		}
+		current = &Message{Line: l.number, Sender: strings.TrimSpace(m[1]), Date: date, Content: m[2]}
+	}
+	flush()
+
+	return transcript, nil
+}
@@ -2,6 +2,8 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -9,7 +11,79 @@ type Config struct {
 	DatabaseURL string
 	JWTSecret   string
 	TokenExpiry time.Duration
+	OllamaURL   string
 	SDUrl       string
+
+	WebhookPollInterval time.Duration
+	WebhookMaxAttempts  int
+	WebhookBaseBackoff  time.Duration
+	WebhookTimeout      time.Duration
+	// Webhooks may not target loopback, private or link-local addresses
+	// unless WebhookAllowPrivateNetworks is set, e.g. for local development.
+	WebhookAllowPrivateNetworks bool
+
+	TrashRetention     time.Duration
+	TrashPurgeInterval time.Duration
+
+	// /metrics requires MetricsToken as a bearer token. Without a token it
+	// is only served when MetricsPublic opts in, e.g. behind a private
+	// network; otherwise it is not registered at all.
+	MetricsToken  string
+	MetricsPublic bool
+
+	TracingExporter    string
+	TracingServiceName string
+
+	LogLevel string
+
+	// TrustedProxies lists the addresses or CIDR ranges of reverse proxies
+	// whose X-Forwarded-For header is believed. Requests from anywhere else
+	// are identified by their own address, so rate limits and the audit log
+	// cannot be fooled by a forged header. Empty trusts no proxy.
+	TrustedProxies []string
+
+	// Unversioned routes are served with deprecation headers until the
+	// sunset date, after which clients must use /api/v1.
+	LegacyAPIDeprecatedAt time.Time
+	LegacyAPISunset       time.Time
+
+	// Token buckets refill the given number of requests per minute; zero
+	// disables a limit.
+	RateLimitIPPerMinute   int
+	RateLimitIPBurst       int
+	RateLimitAuthPerMinute int
+	RateLimitAuthBurst     int
+	RateLimitUserPerMinute int
+	RateLimitUserBurst     int
+	RateLimitAIPerMinute   int
+	RateLimitAIBurst       int
+
+	// After LoginLockoutThreshold consecutive failures an account is locked
+	// for LoginLockoutBase, doubling with every further failure up to
+	// LoginLockoutMax.
+	LoginLockoutThreshold int
+	LoginLockoutBase      time.Duration
+	LoginLockoutMax       time.Duration
+
+	// Daily per-user quotas, reset at midnight UTC; zero means unlimited.
+	LLMDailyTokenQuota int
+	SDDailyImageQuota  int
+
+	// Bulk actions on up to BulkSyncLimit records run inside the request;
+	// larger ones become background jobs. BulkMaxItems caps a single job.
+	BulkSyncLimit    int
+	BulkMaxItems     int
+	BulkPollInterval time.Duration
+
+	// Exports of up to ExportSyncLimit records are rendered inside the
+	// request; larger ones become background jobs. Finished files are kept
+	// for ExportRetention.
+	ExportSyncLimit    int
+	ExportRetention    time.Duration
+	ExportPollInterval time.Duration
+
+	// ImportMaxBytes caps the request body of a conversation import.
+	ImportMaxBytes int
 }
 
 func Load() Config {
@@ -17,6 +91,132 @@ func Load() Config {
 		DatabaseURL: os.Getenv("DATABASE_URL"),
 		JWTSecret:   os.Getenv("JWT_SECRET"),
 		TokenExpiry: time.Second * 10,
-		SDUrl:       "https://dd2e43242112719bfa.gradio.live",
+		OllamaURL:   getEnv("OLLAMA_URL", "http://ollama:11434"),
+		SDUrl:       getEnv("SD_URL", "https://dd2e43242112719bfa.gradio.live"),
+
+		WebhookPollInterval: getEnvDuration("WEBHOOK_POLL_INTERVAL", 5*time.Second),
+		WebhookMaxAttempts:  getEnvInt("WEBHOOK_MAX_ATTEMPTS", 8),
+		WebhookBaseBackoff:  getEnvDuration("WEBHOOK_BASE_BACKOFF", 30*time.Second),
+		WebhookTimeout:      getEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+
+		WebhookAllowPrivateNetworks: getEnvBool("WEBHOOK_ALLOW_PRIVATE_NETWORKS", false),
+
+		TrashRetention:     getEnvDuration("TRASH_RETENTION", 30*24*time.Hour),
+		TrashPurgeInterval: getEnvDuration("TRASH_PURGE_INTERVAL", time.Hour),
+
+		MetricsToken:  os.Getenv("METRICS_TOKEN"),
+		MetricsPublic: getEnvBool("METRICS_PUBLIC", false),
+
+		TracingExporter:    getEnv("TRACING_EXPORTER", "none"),
+		TracingServiceName: getEnv("OTEL_SERVICE_NAME", "siren-net-backend"),
+
+		LogLevel: getEnv("LOG_LEVEL", "info"),
+
+		TrustedProxies: getEnvList("TRUSTED_PROXIES"),
+
+		LegacyAPIDeprecatedAt: getEnvDate("LEGACY_API_DEPRECATED_AT", time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)),
+		LegacyAPISunset:       getEnvDate("LEGACY_API_SUNSET", time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC)),
+
+		RateLimitIPPerMinute:   getEnvInt("RATE_LIMIT_IP_PER_MINUTE", 300),
+		RateLimitIPBurst:       getEnvInt("RATE_LIMIT_IP_BURST", 60),
+		RateLimitAuthPerMinute: getEnvInt("RATE_LIMIT_AUTH_PER_MINUTE", 10),
+		RateLimitAuthBurst:     getEnvInt("RATE_LIMIT_AUTH_BURST", 5),
+		RateLimitUserPerMinute: getEnvInt("RATE_LIMIT_USER_PER_MINUTE", 120),
+		RateLimitUserBurst:     getEnvInt("RATE_LIMIT_USER_BURST", 30),
+		RateLimitAIPerMinute:   getEnvInt("RATE_LIMIT_AI_PER_MINUTE", 6),
+		RateLimitAIBurst:       getEnvInt("RATE_LIMIT_AI_BURST", 2),
+
+		LoginLockoutThreshold: getEnvInt("LOGIN_LOCKOUT_THRESHOLD", 5),
+		LoginLockoutBase:      getEnvDuration("LOGIN_LOCKOUT_BASE", time.Minute),
+		LoginLockoutMax:       getEnvDuration("LOGIN_LOCKOUT_MAX", time.Hour),
+
+		LLMDailyTokenQuota: getEnvInt("LLM_DAILY_TOKEN_QUOTA", 100000),
+		SDDailyImageQuota:  getEnvInt("SD_DAILY_IMAGE_QUOTA", 50),
+
+		BulkSyncLimit:    getEnvInt("BULK_SYNC_LIMIT", 100),
+		BulkMaxItems:     getEnvInt("BULK_MAX_ITEMS", 10000),
+		BulkPollInterval: getEnvDuration("BULK_POLL_INTERVAL", 2*time.Second),
+
+		ExportSyncLimit:    getEnvInt("EXPORT_SYNC_LIMIT", 2000),
+		ExportRetention:    getEnvDuration("EXPORT_RETENTION", 7*24*time.Hour),
+		ExportPollInterval: getEnvDuration("EXPORT_POLL_INTERVAL", 2*time.Second),
+
+		ImportMaxBytes: getEnvInt("IMPORT_MAX_BYTES", 10<<20),
+	}
+}
+
+func getEnv(key string, fallback string) string {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
 	}
-}
\ No newline at end of file
+
+	return value
+}
+
+func getEnvInt(key string, fallback int) int {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return fallback
+	}
+
+	return parsed
+}
+
+// getEnvList splits a comma-separated variable, dropping empty items.
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return fallback
+	}
+
+	return parsed
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return fallback
+	}
+
+	return parsed
+}
+
+func getEnvDate(key string, fallback time.Time) time.Time {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+
+	parsed, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return fallback
+	}
+
+	return parsed
+}
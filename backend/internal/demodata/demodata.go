@@ -0,0 +1,248 @@
+// Package demodata generates realistic, reproducible demo accounts: agents,
+// clients with different activity patterns, message threads and
+// transactions. The same options always produce the same data, so tests can
+// assert on it and dashboards can be demoed on volumes nobody typed in.
+package demodata
+
+import (
+	"backend/internal/models"
+	"backend/internal/userdata"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+const DefaultPassword = "demo-password"
+
+// Options sizes the generated data. Zero fields take the defaults below.
+type Options struct {
+	Seed            uint64
+	Users           int       // default 1
+	AgentsPerUser   int       // default 3
+	ClientsPerAgent int       // default 8
+	Days            int       // length of the generated history, default 90
+	End             time.Time // last day of the history, default 2026-01-01
+}
+
+func (o Options) withDefaults() Options {
+	if o.Users <= 0 {
+		o.Users = 1
+	}
+	if o.AgentsPerUser <= 0 {
+		o.AgentsPerUser = 3
+	}
+	if o.ClientsPerAgent <= 0 {
+		o.ClientsPerAgent = 8
+	}
+	if o.Days <= 0 {
+		o.Days = 90
+	}
+	if o.End.IsZero() {
+		o.End = time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return o
+}
+
+// User is a generated account together with everything it owns, ready for
+// userdata.Service.Import.
+type User struct {
+	Username string
+	Email    string
+	Password string
+	Archive  *userdata.Archive
+}
+
+// profile describes how a client behaves over the generated history.
+type profile struct {
+	name string
+	// sessionEvery is the mean number of days between conversations.
+	sessionEvery float64
+	// activeShare is the part of the history, counted from the client's
+	// start date, in which the client is active.
+	activeShare float64
+	// purchaseChance is the probability that a conversation ends in a
+	// purchase; spend is the range of purchase amounts in cents.
+	purchaseChance float64
+	spendMin       int64
+	spendMax       int64
+}
+
+var profiles = []profile{
+	{name: "whale", sessionEvery: 1.5, activeShare: 1, purchaseChance: 0.5, spendMin: 2000, spendMax: 20000},
+	{name: "regular", sessionEvery: 4, activeShare: 1, purchaseChance: 0.25, spendMin: 500, spendMax: 5000},
+	{name: "casual", sessionEvery: 12, activeShare: 1, purchaseChance: 0.1, spendMin: 300, spendMax: 2000},
+	{name: "churned", sessionEvery: 3, activeShare: 0.35, purchaseChance: 0.2, spendMin: 500, spendMax: 4000},
+}
+
+// Generate builds the users described by opts.
+func Generate(opts Options) []User {
+	opts = opts.withDefaults()
+	g := &generator{
+		rng:   rand.New(rand.NewPCG(opts.Seed, opts.Seed^0x9e3779b97f4a7c15)),
+		opts:  opts,
+		start: opts.End.AddDate(0, 0, -opts.Days),
+	}
+
+	users := make([]User, 0, opts.Users)
+	for i := range opts.Users {
+		username := "demo"
+		if i > 0 {
+			username = fmt.Sprintf("demo%d", i+1)
+		}
+		users = append(users, User{
+			Username: username,
+			Email:    username + "@example.com",
+			Password: DefaultPassword,
+			Archive:  g.archive(username),
+		})
+	}
+	return users
+}
+
+type generator struct {
+	rng   *rand.Rand
+	opts  Options
+	start time.Time
+}
+
+func (g *generator) archive(username string) *userdata.Archive {
+	archive := &userdata.Archive{
+		Version:    userdata.ArchiveVersion,
+		ExportedAt: g.opts.End,
+		Username:   username,
+	}
+	for range g.opts.AgentsPerUser {
+		archive.Agents = append(archive.Agents, g.agent())
+	}
+	return archive
+}
+
+func (g *generator) agent() userdata.Agent {
+	agent := userdata.Agent{
+		Name:            pick(g.rng, agentNames),
+		Characteristics: pick(g.rng, personalities),
+	}
+	// Some agents answer within minutes, others take hours, which shows up
+	// in the reply-gap analytics.
+	replyDelay := time.Duration(5+g.rng.IntN(180)) * time.Minute
+
+	for range g.opts.ClientsPerAgent {
+		agent.Clients = append(agent.Clients, g.client(replyDelay))
+	}
+	return agent
+}
+
+func (g *generator) client(replyDelay time.Duration) userdata.Client {
+	p := profiles[g.rng.IntN(len(profiles))]
+
+	// Clients join throughout the first two thirds of the history.
+	startDay := g.rng.IntN(g.opts.Days*2/3 + 1)
+	startDate := g.start.AddDate(0, 0, startDay)
+	activeDays := float64(g.opts.Days-startDay) * p.activeShare
+
+	client := userdata.Client{
+		Name:         pick(g.rng, clientNames),
+		StartDate:    startDate,
+		Messages:     []userdata.Message{},
+		Transactions: []userdata.Transaction{},
+	}
+
+	var end time.Time
+	for day := g.rng.ExpFloat64() * p.sessionEvery; day < activeDays; day += g.rng.ExpFloat64() * p.sessionEvery {
+		at := startDate.Add(time.Duration(day * float64(24*time.Hour)))
+		at = g.duringWakingHours(at)
+		// Two conversations on the same day must not overlap.
+		if at.Before(end) {
+			at = end.Add(time.Duration(1+g.rng.IntN(4)) * time.Hour)
+		}
+		if !at.Before(g.opts.End) {
+			break
+		}
+
+		end = g.conversation(&client, at, replyDelay)
+		if g.rng.Float64() < p.purchaseChance && end.Before(g.opts.End) {
+			client.Transactions = append(client.Transactions, g.transaction(end, p))
+		}
+	}
+
+	return client
+}
+
+// duringWakingHours moves t to a plausible hour of the same day.
+func (g *generator) duringWakingHours(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return day.Add(time.Duration(9*60+g.rng.IntN(14*60)) * time.Minute)
+}
+
+// conversation appends a short alternating thread starting at at and
+// returns the time after its last message. Threads are cut off at the end
+// of the history.
+func (g *generator) conversation(client *userdata.Client, at time.Time, replyDelay time.Duration) time.Time {
+	fromClient := g.rng.Float64() < 0.7
+	for range 2 + g.rng.IntN(5) {
+		if !at.Before(g.opts.End) {
+			break
+		}
+		message := userdata.Message{Date: at}
+		if fromClient {
+			message.Type = models.MessageTypeClientToAgent
+			message.Content = pick(g.rng, clientLines)
+		} else {
+			message.Type = models.MessageTypeAgentToClient
+			message.Content = pick(g.rng, agentLines)
+		}
+		client.Messages = append(client.Messages, message)
+
+		fromClient = !fromClient
+		if fromClient {
+			at = at.Add(time.Duration(1+g.rng.IntN(30)) * time.Minute)
+		} else {
+			at = at.Add(replyDelay/2 + time.Duration(g.rng.Int64N(int64(replyDelay))))
+		}
+	}
+	return at
+}
+
+func (g *generator) transaction(at time.Time, p profile) userdata.Transaction {
+	amount := p.spendMin + g.rng.Int64N(p.spendMax-p.spendMin+1)
+	// Round to whole currency units ending in 99, like real price points.
+	amount = amount/100*100 + 99
+
+	currency := models.DefaultCurrency
+	if g.rng.Float64() < 0.2 {
+		currency = "EUR"
+	}
+
+	transaction := userdata.Transaction{
+		AmountMinor: amount,
+		Currency:    currency,
+		Date:        at,
+		Status:      models.TransactionStatusCompleted,
+		Refunds:     []userdata.Refund{},
+	}
+
+	switch r := g.rng.Float64(); {
+	case r < 0.05:
+		transaction.Status = models.TransactionStatusPending
+	case r < 0.08:
+		transaction.Status = models.TransactionStatusDisputed
+	case r < 0.12:
+		refundedAt := at.Add(time.Duration(1+g.rng.IntN(72)) * time.Hour)
+		if refundedAt.After(g.opts.End) {
+			refundedAt = g.opts.End
+		}
+		transaction.Status = models.TransactionStatusRefunded
+		transaction.Refunds = append(transaction.Refunds, userdata.Refund{
+			AmountMinor: amount,
+			Currency:    currency,
+			Reason:      pick(g.rng, refundReasons),
+			Date:        refundedAt,
+		})
+	}
+
+	return transaction
+}
+
+func pick(rng *rand.Rand, values []string) string {
+	return values[rng.IntN(len(values))]
+}
@@ -0,0 +1,72 @@
+package demodata
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"backend/internal/config"
+	"backend/internal/services"
+	"backend/internal/userdata"
+	"backend/pkg/database"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateIsDeterministic(t *testing.T) {
+	opts := Options{Seed: 42, Users: 2}
+
+	first := Generate(opts)
+	assert.Equal(t, first, Generate(opts))
+	assert.NotEqual(t, first, Generate(Options{Seed: 43, Users: 2}))
+
+	require.Len(t, first, 2)
+	assert.Equal(t, "demo", first[0].Username)
+	assert.Equal(t, "demo2", first[1].Username)
+
+	end := opts.withDefaults().End
+	for _, agent := range first[0].Archive.Agents {
+		for _, client := range agent.Clients {
+			for i, message := range client.Messages {
+				assert.True(t, message.Date.Before(end), "messages stay inside the history")
+				if i > 0 {
+					assert.False(t, message.Date.Before(client.Messages[i-1].Date), "threads are in order")
+				}
+			}
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	db := database.Connect(filepath.Join(t.TempDir(), "test.db"))
+	ctx := context.Background()
+	userService := services.NewUserService(db)
+	agentService := services.NewAgentService(db, time.Now)
+	clientService := services.NewClientService(db, agentService, time.Now)
+	authService := services.NewAuthService(userService, services.NewAuditService(db, time.Now), &config.Config{}, time.Now)
+	customFieldService := services.NewCustomFieldService(db)
+	importer := userdata.NewService(
+		agentService,
+		clientService,
+		services.NewClientProfileService(db, clientService, customFieldService),
+		customFieldService,
+		services.NewMessageService(db, agentService, clientService, time.Now),
+		services.NewTransactionService(db, agentService, clientService, time.Now),
+		time.Now,
+	)
+
+	generated := Generate(Options{Seed: 1, AgentsPerUser: 2, ClientsPerAgent: 3, Days: 30, End: time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)})
+	summary, err := Load(ctx, userService, authService, importer, generated)
+	require.NoError(t, err)
+	assert.Equal(t, 2, summary.Agents)
+	assert.Equal(t, 6, summary.Clients)
+	assert.NotZero(t, summary.Messages)
+
+	user, err := userService.GetUserByUsername(ctx, "demo")
+	require.NoError(t, err)
+	exported, err := importer.Export(ctx, user)
+	require.NoError(t, err)
+	assert.Len(t, exported.Agents, 2)
+}
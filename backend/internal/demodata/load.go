@@ -0,0 +1,38 @@
+package demodata
+
+import (
+	"backend/internal/services"
+	"backend/internal/userdata"
+	"context"
+	"errors"
+)
+
+// Load creates the generated users that do not exist yet and imports their
+// data through the services layer. Existing users keep what they have and
+// receive the generated data in addition.
+func Load(ctx context.Context, userService services.UserService, authService services.AuthService, importer *userdata.Service, users []User) (userdata.Summary, error) {
+	var total userdata.Summary
+	for _, user := range users {
+		account, err := userService.GetUserByUsername(ctx, user.Username)
+		if errors.Is(err, services.ErrUserNotFound) {
+			account, err = authService.Register(ctx, user.Username, user.Email, user.Password)
+		}
+		if err != nil {
+			return total, err
+		}
+
+		summary, err := importer.Import(ctx, account.ID, user.Archive)
+		total.CustomFields += summary.CustomFields
+		total.Agents += summary.Agents
+		total.Clients += summary.Clients
+		total.Contacts += summary.Contacts
+		total.Notes += summary.Notes
+		total.Messages += summary.Messages
+		total.Transactions += summary.Transactions
+		total.Refunds += summary.Refunds
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
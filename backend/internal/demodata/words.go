@@ -0,0 +1,58 @@
+package demodata
+
+var agentNames = []string{
+	"Nova", "Iris", "Luna", "Maya", "Aria", "Skye", "Jade", "Ruby", "Elena", "Clara",
+	"Sofia", "Mila", "Zoe", "Ivy", "Nina", "Leah",
+}
+
+var clientNames = []string{
+	"Daniel", "Marco", "Sam", "Oliver", "Lucas", "Ethan", "Noah", "Liam", "Jonas", "Felix",
+	"Mateo", "Adam", "Hugo", "Leon", "Theo", "Victor", "Owen", "Ryan", "Caleb", "Isaac",
+	"Jack", "Henry", "Eli", "Tom",
+}
+
+var personalities = []string{
+	"Warm and playful, remembers small details and asks follow-up questions.",
+	"Calm and thoughtful, prefers long messages and talks about books and travel.",
+	"Energetic and flirty, replies with short messages and lots of humour.",
+	"Mysterious and reserved, opens up slowly and rewards loyal fans.",
+	"Sporty and upbeat, shares workout routines and morning runs.",
+	"Artsy and dreamy, talks about music, films and late-night thoughts.",
+}
+
+var clientLines = []string{
+	"Hey, how is your day going?",
+	"Good morning! Did you sleep well?",
+	"Just got home from work, what a day.",
+	"I was thinking about what you said yesterday.",
+	"Do you have any plans for the weekend?",
+	"That photo you posted was amazing.",
+	"Sorry I went quiet, it has been a busy week.",
+	"What music are you listening to lately?",
+	"Can you send me something special tonight?",
+	"I finally tried that café you recommended.",
+	"Are you around tonight?",
+	"Tell me something nobody else knows about you.",
+}
+
+var agentLines = []string{
+	"Hi you! I was hoping you would write today.",
+	"It's going great now that you're here. How about yours?",
+	"Aw, that sounds exhausting. Put your feet up and tell me everything.",
+	"I might have something just for you later, stay tuned.",
+	"Haha, you always make me smile.",
+	"I'm heading out for a walk, I'll think of you on the way.",
+	"Really? I want to hear the whole story.",
+	"I missed our chats! What have you been up to?",
+	"Funny you ask, I just put on my favourite playlist.",
+	"Good night, talk tomorrow?",
+	"Check your inbox, I left you a surprise.",
+	"You have great taste, I knew you'd love it.",
+}
+
+var refundReasons = []string{
+	"Charged twice",
+	"Content did not load",
+	"Purchased by mistake",
+	"Goodwill refund",
+}
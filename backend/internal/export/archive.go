@@ -0,0 +1,66 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+)
+
+// PersonalData is everything stored about one client. Profile and History
+// are written as JSON exactly as given.
+type PersonalData struct {
+	Document *Document // holds the client's single conversation
+	Profile  interface{}
+	History  interface{}
+}
+
+// WriteArchive writes data as a ZIP archive holding client.json (the
+// profile), history.json (the change history), the client's records as
+// records.csv and records.jsonl, and transcript.pdf.
+func WriteArchive(w io.Writer, data *PersonalData) error {
+	archive := zip.NewWriter(w)
+	create := func(name string) (io.Writer, error) {
+		return archive.CreateHeader(&zip.FileHeader{
+			Name:     name,
+			Method:   zip.Deflate,
+			Modified: data.Document.GeneratedAt,
+		})
+	}
+
+	for _, file := range []struct {
+		name  string
+		value interface{}
+	}{
+		{"client.json", data.Profile},
+		{"history.json", data.History},
+	} {
+		out, err := create(file.name)
+		if err != nil {
+			return err
+		}
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.value); err != nil {
+			return err
+		}
+	}
+
+	for _, file := range []struct {
+		name   string
+		format string
+	}{
+		{"records.csv", FormatCSV},
+		{"records.jsonl", FormatJSONL},
+		{"transcript.pdf", FormatPDF},
+	} {
+		out, err := create(file.name)
+		if err != nil {
+			return err
+		}
+		if err := Write(out, file.format, data.Document); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
@@ -0,0 +1,54 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+)
+
+var csvHeader = []string{
+	"kind", "id", "date", "client_id", "client_name", "agent_id", "agent_name",
+	"type", "content", "amount", "currency", "status", "external_reference", "transaction_id", "reason",
+}
+
+func writeCSV(w io.Writer, doc *Document) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, r := range records(doc) {
+		row := []string{
+			r.Kind,
+			formatID(r.ID),
+			r.Date.Format(time.RFC3339),
+			formatID(r.ClientID),
+			r.ClientName,
+			formatID(r.AgentID),
+			r.AgentName,
+			r.Type,
+			r.Content,
+			r.Amount,
+			r.Currency,
+			r.Status,
+			r.ExternalReference,
+			formatID(r.TransactionID),
+			r.Reason,
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// formatID leaves zero IDs, which mean "not applicable", empty.
+func formatID(id uint) string {
+	if id == 0 {
+		return ""
+	}
+	return strconv.FormatUint(uint64(id), 10)
+}
@@ -0,0 +1,156 @@
+// Package export renders clients' conversations and ledgers as files: CSV
+// and JSON Lines with one record per message, transaction or refund, and a
+// PDF transcript for compliance archives, and a ZIP archive of everything
+// stored about one client for data-subject requests. Loading the data is up
+// to the caller.
+package export
+
+import (
+	"backend/internal/models"
+	"backend/internal/money"
+	"errors"
+	"io"
+	"sort"
+	"time"
+)
+
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+	FormatPDF   = "pdf"
+	// FormatZIP is the personal data archive written by WriteArchive; it
+	// cannot be chosen for other exports.
+	FormatZIP = "zip"
+)
+
+var Formats = []string{FormatCSV, FormatJSONL, FormatPDF}
+
+var ErrUnknownFormat = errors.New("unknown export format")
+
+// Conversation is one client's part of an export. Messages and transactions
+// need their Agent loaded, and transactions their Refunds.
+type Conversation struct {
+	Client       *models.Client
+	Messages     []*models.Message
+	Transactions []*models.Transaction
+}
+
+// Document is everything one export contains.
+type Document struct {
+	Title         string
+	GeneratedAt   time.Time
+	Conversations []*Conversation
+}
+
+// ContentType returns the MIME type of a format.
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv"
+	case FormatJSONL:
+		return "application/x-ndjson"
+	case FormatPDF:
+		return "application/pdf"
+	case FormatZIP:
+		return "application/zip"
+	}
+	return "application/octet-stream"
+}
+
+// Write renders doc in the given format.
+func Write(w io.Writer, format string, doc *Document) error {
+	switch format {
+	case FormatCSV:
+		return writeCSV(w, doc)
+	case FormatJSONL:
+		return writeJSONL(w, doc)
+	case FormatPDF:
+		return writePDF(w, doc)
+	}
+	return ErrUnknownFormat
+}
+
+// Record kinds.
+const (
+	KindMessage     = "message"
+	KindTransaction = "transaction"
+	KindRefund      = "refund"
+)
+
+// record is one row of a CSV export and one line of a JSON Lines export.
+// Fields that do not apply to the kind are left empty.
+type record struct {
+	Kind              string    `json:"kind"`
+	ID                uint      `json:"id"`
+	Date              time.Time `json:"date"`
+	ClientID          uint      `json:"client_id"`
+	ClientName        string    `json:"client_name"`
+	AgentID           uint      `json:"agent_id,omitempty"`
+	AgentName         string    `json:"agent_name,omitempty"`
+	Type              string    `json:"type,omitempty"`
+	Content           string    `json:"content,omitempty"`
+	Amount            string    `json:"amount,omitempty"`
+	Currency          string    `json:"currency,omitempty"`
+	Status            string    `json:"status,omitempty"`
+	ExternalReference string    `json:"external_reference,omitempty"`
+	TransactionID     uint      `json:"transaction_id,omitempty"`
+	Reason            string    `json:"reason,omitempty"`
+}
+
+// records flattens the document client by client, each client's records in
+// date order.
+func records(doc *Document) []record {
+	var all []record
+	for _, conversation := range doc.Conversations {
+		client := conversation.Client
+		var rows []record
+		for _, message := range conversation.Messages {
+			rows = append(rows, record{
+				Kind:              KindMessage,
+				ID:                message.ID,
+				Date:              message.Date.UTC(),
+				ClientID:          client.ID,
+				ClientName:        client.Name,
+				AgentID:           message.AgentID,
+				AgentName:         message.Agent.Name,
+				Type:              message.Type,
+				Content:           message.Content,
+				ExternalReference: message.ExternalReference,
+			})
+		}
+		for _, transaction := range conversation.Transactions {
+			rows = append(rows, record{
+				Kind:              KindTransaction,
+				ID:                transaction.ID,
+				Date:              transaction.Date.UTC(),
+				ClientID:          client.ID,
+				ClientName:        client.Name,
+				AgentID:           transaction.AgentID,
+				AgentName:         transaction.Agent.Name,
+				Amount:            money.Format(transaction.AmountMinor, transaction.Currency),
+				Currency:          transaction.Currency,
+				Status:            transaction.Status,
+				ExternalReference: transaction.ExternalReference,
+			})
+			for _, refund := range transaction.Refunds {
+				rows = append(rows, record{
+					Kind:              KindRefund,
+					ID:                refund.ID,
+					Date:              refund.Date.UTC(),
+					ClientID:          client.ID,
+					ClientName:        client.Name,
+					Amount:            money.Format(refund.AmountMinor, refund.Currency),
+					Currency:          refund.Currency,
+					ExternalReference: refund.ExternalReference,
+					TransactionID:     transaction.ID,
+					Reason:            refund.Reason,
+				})
+			}
+		}
+		sort.SliceStable(rows, func(i, j int) bool {
+			return rows[i].Date.Before(rows[j].Date)
+		})
+		all = append(all, rows...)
+	}
+	return all
+}
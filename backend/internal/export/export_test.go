@@ -0,0 +1,128 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testDocument() *Document {
+	agent := models.Agent{Name: "Nova"}
+	agent.ID = 2
+	client := &models.Client{Name: "Daniel", AgentID: agent.ID, Agent: agent, StartDate: time.Date(2026, time.January, 5, 0, 0, 0, 0, time.UTC)}
+	client.ID = 3
+
+	day := time.Date(2026, time.February, 1, 9, 0, 0, 0, time.UTC)
+	messages := []*models.Message{
+		{AgentID: agent.ID, Agent: agent, ClientID: client.ID, Date: day, Type: models.MessageTypeClientToAgent, Content: "hello (again)"},
+		{AgentID: agent.ID, Agent: agent, ClientID: client.ID, Date: day.Add(2 * time.Hour), Type: models.MessageTypeAgentToClient, Content: strings.Repeat("a long reply ", 400) + "€ ✓"},
+	}
+	messages[0].ID, messages[1].ID = 10, 11
+
+	transaction := &models.Transaction{AgentID: agent.ID, Agent: agent, ClientID: client.ID, AmountMinor: 1250, Currency: "USD", Date: day.Add(time.Hour), Status: models.TransactionStatusRefunded, ExternalReference: "ch_1"}
+	transaction.ID = 20
+	refund := models.Refund{TransactionID: transaction.ID, AmountMinor: 1250, Currency: "USD", Reason: "duplicate", Date: day.Add(3 * time.Hour)}
+	refund.ID = 30
+	transaction.Refunds = []models.Refund{refund}
+
+	return &Document{
+		Title:         "Client Daniel",
+		GeneratedAt:   day.AddDate(0, 1, 0),
+		Conversations: []*Conversation{{Client: client, Messages: messages, Transactions: []*models.Transaction{transaction}}},
+	}
+}
+
+func TestWriteCSVAndJSONL(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, Write(&out, FormatCSV, testDocument()))
+	rows, err := csv.NewReader(&out).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 5)
+	assert.Equal(t, csvHeader, rows[0])
+	// Records of a client are in date order whatever their kind.
+	var kinds []string
+	for _, row := range rows[1:] {
+		kinds = append(kinds, row[0])
+	}
+	assert.Equal(t, []string{KindMessage, KindTransaction, KindMessage, KindRefund}, kinds)
+	assert.Equal(t, []string{"transaction", "20", "2026-02-01T10:00:00Z", "3", "Daniel", "2", "Nova", "", "", "12.50", "USD", "REFUNDED", "ch_1", "", ""}, rows[2])
+	assert.Equal(t, "20", rows[4][13])
+
+	out.Reset()
+	require.NoError(t, Write(&out, FormatJSONL, testDocument()))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 4)
+	var first map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	assert.Equal(t, "message", first["kind"])
+	assert.Equal(t, "hello (again)", first["content"])
+	assert.NotContains(t, first, "amount")
+
+	assert.ErrorIs(t, Write(&out, "xlsx", testDocument()), ErrUnknownFormat)
+}
+
+func TestWritePDF(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, Write(&out, FormatPDF, testDocument()))
+	pdf := out.Bytes()
+
+	require.True(t, bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")))
+	require.True(t, bytes.HasSuffix(pdf, []byte("%%EOF\n")))
+	assert.Contains(t, string(pdf), `(hello \(again\))`)
+	assert.Contains(t, string(pdf), `\200 ?`, "the euro sign is encoded and the check mark replaced")
+
+	// The long reply spills onto a second page.
+	pages := regexp.MustCompile(`/Count (\d+)`).FindSubmatch(pdf)
+	require.NotNil(t, pages)
+	assert.Equal(t, "2", string(pages[1]))
+
+	// Every xref entry points at the object it names.
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(pdf)
+	require.NotNil(t, startxref)
+	xref, err := strconv.Atoi(string(startxref[1]))
+	require.NoError(t, err)
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(pdf[xref:], -1)
+	require.NotEmpty(t, entries)
+	for i, entry := range entries {
+		offset, err := strconv.Atoi(string(entry[1]))
+		require.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(pdf[offset:], []byte(fmt.Sprintf("%d 0 obj\n", i+1))), "object %d", i+1)
+	}
+}
+
+func TestWriteArchive(t *testing.T) {
+	var out bytes.Buffer
+	data := &PersonalData{
+		Document: testDocument(),
+		Profile:  map[string]string{"Name": "Daniel"},
+		History:  []string{},
+	}
+	require.NoError(t, WriteArchive(&out, data))
+
+	archive, err := zip.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	require.NoError(t, err)
+	var names []string
+	for _, file := range archive.File {
+		names = append(names, file.Name)
+	}
+	assert.Equal(t, []string{"client.json", "history.json", "records.csv", "records.jsonl", "transcript.pdf"}, names)
+
+	profile, err := archive.File[0].Open()
+	require.NoError(t, err)
+	defer profile.Close()
+	var decoded map[string]string
+	require.NoError(t, json.NewDecoder(profile).Decode(&decoded))
+	assert.Equal(t, "Daniel", decoded["Name"])
+}
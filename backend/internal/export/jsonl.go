@@ -0,0 +1,16 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+)
+
+func writeJSONL(w io.Writer, doc *Document) error {
+	encoder := json.NewEncoder(w)
+	for _, r := range records(doc) {
+		if err := encoder.Encode(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
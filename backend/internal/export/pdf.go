@@ -0,0 +1,293 @@
+package export
+
+import (
+	"backend/internal/models"
+	"backend/internal/money"
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// The transcript is a plain PDF 1.4 file using the standard Helvetica fonts,
+// which every reader provides, so nothing has to be embedded. Those fonts
+// only cover Latin-1 and a few punctuation marks; other characters print as
+// "?".
+
+const (
+	pageWidth    = 595.0 // A4 in points
+	pageHeight   = 842.0
+	pageMargin   = 50.0
+	footerHeight = 30.0
+)
+
+const (
+	fontRegular = "F1"
+	fontBold    = "F2"
+)
+
+// helveticaWidths are the advances of the printable ASCII characters in
+// Helvetica, in thousandths of the font size. Other characters are assumed
+// to be as wide as a digit.
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+func textWidth(text string, size float64) float64 {
+	width := 0
+	for _, r := range text {
+		if r >= 32 && r <= 126 {
+			width += helveticaWidths[r-32]
+		} else {
+			width += 556
+		}
+	}
+	// Bold is slightly wider; measuring everything a little generously
+	// keeps bold lines inside the margin too.
+	return float64(width) * size / 1000 * 1.05
+}
+
+// winAnsi maps the characters outside Latin-1 that WinAnsiEncoding has.
+var winAnsi = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87,
+	'ˆ': 0x88, '‰': 0x89, 'Š': 0x8a, '‹': 0x8b, 'Œ': 0x8c, 'Ž': 0x8e, '‘': 0x91,
+	'’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '˜': 0x98,
+	'™': 0x99, 'š': 0x9a, '›': 0x9b, 'œ': 0x9c, 'ž': 0x9e, 'Ÿ': 0x9f,
+}
+
+// pdfString encodes text as a PDF literal string in WinAnsiEncoding.
+func pdfString(text string) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for _, r := range text {
+		var c byte
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			c = byte(r)
+		case r >= 32 && r <= 126, r >= 0xa0 && r <= 0xff:
+			c = byte(r)
+		case winAnsi[r] != 0:
+			c = winAnsi[r]
+		case r == '\t':
+			c = ' '
+		default:
+			c = '?'
+		}
+		if c < 0x80 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "\\%03o", c)
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// wrap breaks text into lines no wider than width, at spaces where it can.
+func wrap(text string, size, width float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if textWidth(candidate, size) <= width {
+				line = candidate
+				continue
+			}
+			if line != "" {
+				lines = append(lines, line)
+			}
+			// A word longer than a line is cut where it overflows.
+			for textWidth(word, size) > width {
+				cut := nextRune(word, 0)
+				for cut < len(word) && textWidth(word[:nextRune(word, cut)], size) <= width {
+					cut = nextRune(word, cut)
+				}
+				lines = append(lines, word[:cut])
+				word = word[cut:]
+			}
+			line = word
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// nextRune returns the index of the rune after the one starting at i.
+func nextRune(s string, i int) int {
+	_, size := utf8.DecodeRuneInString(s[i:])
+	return i + size
+}
+
+// pdfLayout lays text out top to bottom, starting a new page when the
+// current one is full.
+type pdfLayout struct {
+	pages []*bytes.Buffer
+	y     float64
+}
+
+func (l *pdfLayout) newPage() {
+	l.pages = append(l.pages, &bytes.Buffer{})
+	l.y = pageHeight - pageMargin
+}
+
+func (l *pdfLayout) text(font string, size, indent float64, text string) {
+	leading := size * 1.35
+	for _, line := range wrap(text, size, pageWidth-2*pageMargin-indent) {
+		if len(l.pages) == 0 || l.y-leading < pageMargin+footerHeight {
+			l.newPage()
+		}
+		l.y -= leading
+		fmt.Fprintf(l.pages[len(l.pages)-1], "BT /%s %.1f Tf %.2f %.2f Td %s Tj ET\n", font, size, pageMargin+indent, l.y, pdfString(line))
+	}
+}
+
+func (l *pdfLayout) space(points float64) {
+	l.y -= points
+}
+
+func writePDF(w io.Writer, doc *Document) error {
+	layout := &pdfLayout{}
+	layout.text(fontBold, 16, 0, doc.Title)
+	layout.text(fontRegular, 9, 0, "Generated "+doc.GeneratedAt.UTC().Format("2006-01-02 15:04 MST"))
+
+	for i, conversation := range doc.Conversations {
+		if i > 0 {
+			layout.newPage()
+		} else {
+			layout.space(16)
+		}
+		writeConversation(layout, conversation)
+	}
+	if len(doc.Conversations) == 0 {
+		layout.space(16)
+		layout.text(fontRegular, 10, 0, "There is nothing to export.")
+	}
+
+	return writePDFFile(w, doc.Title, layout.pages)
+}
+
+func writeConversation(layout *pdfLayout, conversation *Conversation) {
+	client := conversation.Client
+	layout.text(fontBold, 13, 0, fmt.Sprintf("%s (client #%d)", client.Name, client.ID))
+	summary := "Client since " + client.StartDate.UTC().Format("2006-01-02")
+	if client.Agent.Name != "" {
+		summary += ", currently with " + client.Agent.Name
+	}
+	layout.text(fontRegular, 9, 0, summary)
+
+	layout.space(10)
+	layout.text(fontBold, 11, 0, "Messages")
+	if len(conversation.Messages) == 0 {
+		layout.text(fontRegular, 10, 0, "No messages.")
+	}
+	for _, message := range conversation.Messages {
+		from, to := message.Agent.Name, client.Name
+		if message.Type == models.MessageTypeClientToAgent {
+			from, to = to, from
+		}
+		layout.space(4)
+		layout.text(fontBold, 9, 0, fmt.Sprintf("%s - %s to %s", formatPDFTime(message.Date), from, to))
+		layout.text(fontRegular, 10, 12, message.Content)
+	}
+
+	layout.space(10)
+	layout.text(fontBold, 11, 0, "Transactions")
+	if len(conversation.Transactions) == 0 {
+		layout.text(fontRegular, 10, 0, "No transactions.")
+	}
+	for _, transaction := range conversation.Transactions {
+		line := fmt.Sprintf("%s - %s %s - %s - via %s", formatPDFTime(transaction.Date),
+			money.Format(transaction.AmountMinor, transaction.Currency), transaction.Currency, transaction.Status, transaction.Agent.Name)
+		if transaction.ExternalReference != "" {
+			line += " - ref " + transaction.ExternalReference
+		}
+		layout.text(fontRegular, 10, 0, line)
+		for _, refund := range transaction.Refunds {
+			line := fmt.Sprintf("Refund %s - %s %s", formatPDFTime(refund.Date), money.Format(refund.AmountMinor, refund.Currency), refund.Currency)
+			if refund.Reason != "" {
+				line += " - " + refund.Reason
+			}
+			layout.text(fontRegular, 9, 12, line)
+		}
+	}
+}
+
+func formatPDFTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04 MST")
+}
+
+// writePDFFile writes the pages as a PDF file, adding a footer with the
+// title and page number to each.
+func writePDFFile(w io.Writer, title string, pages []*bytes.Buffer) error {
+	out := &countingWriter{w: bufio.NewWriter(w)}
+	var offsets []int64
+	object := func(body string) {
+		offsets = append(offsets, out.n)
+		fmt.Fprintf(out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	io.WriteString(out, "%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1 to 4 are fixed; each page then takes a page object and
+	// its content stream.
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, page := range pages {
+		fmt.Fprintf(page, "BT /%s 8 Tf %.2f %.2f Td %s Tj ET\n", fontRegular, pageMargin, pageMargin-footerHeight/2,
+			pdfString(fmt.Sprintf("%s - page %d of %d", title, i+1, len(pages))))
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /%s 3 0 R /%s 4 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, fontRegular, fontBold, 6+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+	object(fmt.Sprintf("<< /Title %s /Producer (Siren-Net) >>", pdfString(title)))
+
+	xref := out.n
+	fmt.Fprintf(out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(out, "trailer\n<< /Size %d /Root 1 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, len(offsets), xref)
+
+	if out.err != nil {
+		return out.err
+	}
+	return out.w.Flush()
+}
+
+// countingWriter tracks the byte offsets the xref table needs and keeps the
+// first write error.
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
@@ -4,7 +4,6 @@ import (
 	"backend/internal/middleware"
 	"backend/internal/models"
 	"backend/internal/services"
-	"errors"
 	"net/http"
 	"strconv"
 
@@ -23,29 +22,25 @@ func NewAgentHandler(agentService services.AgentService) *AgentHandler {
 func (h *AgentHandler) GetAgentByID(c *gin.Context) {
 	idParam := c.Param("id")
 	if idParam == "" {
-		services.RespondError(c, http.StatusBadRequest, services.ErrAgentIDRequired)
+		_ = c.Error(services.ErrAgentIDRequired)
 		return
 	}
 
 	agentID, err := strconv.ParseUint(idParam, 10, 32)
 	if err != nil {
-		services.RespondError(c, http.StatusBadRequest, services.ErrInvalidAgentID)
+		_ = c.Error(services.ErrInvalidAgentID)
 		return
 	}
 
 	loggedInUserID, err := middleware.GetLoggedInUserID(c)
 	if err != nil {
-		services.RespondError(c, http.StatusUnauthorized, err)
+		_ = c.Error(err)
 		return
 	}
 
 	agent, err := h.agentService.GetAgentByID(c.Request.Context(), uint(agentID), loggedInUserID)
 	if err != nil {
-		if errors.Is(err, services.ErrAgentNotFound) {
-			services.RespondError(c, http.StatusNotFound, err)
-			return
-		}
-		services.RespondError(c, http.StatusInternalServerError, err)
+		_ = c.Error(err)
 		return
 	}
 
@@ -55,13 +50,13 @@ func (h *AgentHandler) GetAgentByID(c *gin.Context) {
 func (h *AgentHandler) GetAllAgents(c *gin.Context) {
 	loggedInUserID, err := middleware.GetLoggedInUserID(c)
 	if err != nil {
-		services.RespondError(c, http.StatusUnauthorized, err)
+		_ = c.Error(err)
 		return
 	}
 
 	agents, err := h.agentService.GetAllAgents(c.Request.Context(), loggedInUserID)
 	if err != nil {
-		services.RespondError(c, http.StatusInternalServerError, err)
+		_ = c.Error(err)
 		return
 	}
 
@@ -75,19 +70,13 @@ func (h *AgentHandler) CreateAgent(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		if input.Name == "" {
-			services.RespondError(c, http.StatusBadRequest, services.ErrAgentNameRequired)
-			return
-		}
-		if input.Characteristics == "" {
-			services.RespondError(c, http.StatusBadRequest, services.ErrAgentCharacteristicsRequired)
-			return
-		}
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
 	}
 
 	loggedInUserID, err := middleware.GetLoggedInUserID(c)
 	if err != nil {
-		services.RespondError(c, http.StatusUnauthorized, err)
+		_ = c.Error(err)
 		return
 	}
 
@@ -99,11 +88,7 @@ func (h *AgentHandler) CreateAgent(c *gin.Context) {
 
 	createdAgent, err := h.agentService.CreateAgent(c.Request.Context(), agent, loggedInUserID)
 	if err != nil {
-		if errors.Is(err, services.ErrAgentAlreadyExists) {
-			services.RespondError(c, http.StatusConflict, err)
-			return
-		}
-		services.RespondError(c, http.StatusInternalServerError, err)
+		_ = c.Error(err)
 		return
 	}
 	c.JSON(http.StatusCreated, createdAgent)
@@ -112,13 +97,13 @@ func (h *AgentHandler) CreateAgent(c *gin.Context) {
 func (h *AgentHandler) UpdateAgent(c *gin.Context) {
 	idParam := c.Param("id")
 	if idParam == "" {
-		services.RespondError(c, http.StatusBadRequest, services.ErrAgentIDRequired)
+		_ = c.Error(services.ErrAgentIDRequired)
 		return
 	}
 
 	agentID, err := strconv.ParseUint(idParam, 10, 32)
 	if err != nil {
-		services.RespondError(c, http.StatusBadRequest, services.ErrInvalidAgentID)
+		_ = c.Error(services.ErrInvalidAgentID)
 		return
 	}
 
@@ -128,21 +113,13 @@ func (h *AgentHandler) UpdateAgent(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		if input.Name == "" {
-			services.RespondError(c, http.StatusBadRequest, services.ErrAgentNameRequired)
-			return
-		}
-		if input.Characteristics == "" {
-			services.RespondError(c, http.StatusBadRequest, services.ErrAgentCharacteristicsRequired)
-			return
-		}
-		services.RespondError(c, http.StatusBadRequest, err)
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
 		return
 	}
 
 	loggedInUserID, err := middleware.GetLoggedInUserID(c)
 	if err != nil {
-		services.RespondError(c, http.StatusUnauthorized, err)
+		_ = c.Error(err)
 		return
 	}
 
@@ -157,15 +134,7 @@ func (h *AgentHandler) UpdateAgent(c *gin.Context) {
 
 	updatedAgent, err := h.agentService.UpdateAgent(c.Request.Context(), agent, loggedInUserID)
 	if err != nil {
-		if errors.Is(err, services.ErrAgentNotFound) {
-			services.RespondError(c, http.StatusNotFound, err)
-			return
-		}
-		if errors.Is(err, services.ErrUnauthorized) {
-			services.RespondError(c, http.StatusForbidden, err)
-			return
-		}
-		services.RespondError(c, http.StatusInternalServerError, err)
+		_ = c.Error(err)
 		return
 	}
 
@@ -175,33 +144,97 @@ func (h *AgentHandler) UpdateAgent(c *gin.Context) {
 func (h *AgentHandler) DeleteAgent(c *gin.Context) {
 	idParam := c.Param("id")
 	if idParam == "" {
-		services.RespondError(c, http.StatusBadRequest, services.ErrAgentIDRequired)
+		_ = c.Error(services.ErrAgentIDRequired)
 		return
 	}
 
 	agentID, err := strconv.ParseUint(idParam, 10, 32)
 	if err != nil {
-		services.RespondError(c, http.StatusBadRequest, services.ErrInvalidAgentID)
+		_ = c.Error(services.ErrInvalidAgentID)
 		return
 	}
 
 	loggedInUserID, err := middleware.GetLoggedInUserID(c)
 	if err != nil {
-		services.RespondError(c, http.StatusUnauthorized, err)
+		_ = c.Error(err)
 		return
 	}
 
 	err = h.agentService.DeleteAgent(c.Request.Context(), uint(agentID), loggedInUserID)
 	if err != nil {
-		if errors.Is(err, services.ErrAgentNotFound) {
-			services.RespondError(c, http.StatusNotFound, err)
-			return
-		}
-		if errors.Is(err, services.ErrUnauthorized) {
-			services.RespondError(c, http.StatusForbidden, err)
-			return
-		}
-		services.RespondError(c, http.StatusInternalServerError, err)
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+func (h *AgentHandler) GetDeletedAgents(c *gin.Context) {
+	loggedInUserID, err := middleware.GetLoggedInUserID(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	agents, err := h.agentService.GetDeletedAgents(c.Request.Context(), loggedInUserID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, agents)
+}
+
+func (h *AgentHandler) RestoreAgent(c *gin.Context) {
+	idParam := c.Param("id")
+	if idParam == "" {
+		_ = c.Error(services.ErrAgentIDRequired)
+		return
+	}
+
+	agentID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		_ = c.Error(services.ErrInvalidAgentID)
+		return
+	}
+
+	loggedInUserID, err := middleware.GetLoggedInUserID(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	restoredAgent, err := h.agentService.RestoreAgent(c.Request.Context(), uint(agentID), loggedInUserID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, restoredAgent)
+}
+
+func (h *AgentHandler) PurgeAgent(c *gin.Context) {
+	idParam := c.Param("id")
+	if idParam == "" {
+		_ = c.Error(services.ErrAgentIDRequired)
+		return
+	}
+
+	agentID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		_ = c.Error(services.ErrInvalidAgentID)
+		return
+	}
+
+	loggedInUserID, err := middleware.GetLoggedInUserID(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	err = h.agentService.PurgeAgent(c.Request.Context(), uint(agentID), loggedInUserID)
+	if err != nil {
+		_ = c.Error(err)
 		return
 	}
 
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"backend/internal/middleware"
+	"backend/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AnalyticsHandler struct {
+	analyticsService services.AnalyticsService
+}
+
+func NewAnalyticsHandler(analyticsService services.AnalyticsService) *AnalyticsHandler {
+	return &AnalyticsHandler{analyticsService: analyticsService}
+}
+
+func (h *AnalyticsHandler) GetMessageVolume(c *gin.Context) {
+	h.respond(c, func(filter services.AnalyticsFilter, userID uint) (interface{}, error) {
+		return h.analyticsService.GetMessageVolume(c.Request.Context(), filter, userID)
+	})
+}
+
+func (h *AnalyticsHandler) GetReplyGaps(c *gin.Context) {
+	h.respond(c, func(filter services.AnalyticsFilter, userID uint) (interface{}, error) {
+		return h.analyticsService.GetReplyGaps(c.Request.Context(), filter, userID)
+	})
+}
+
+func (h *AnalyticsHandler) GetRevenue(c *gin.Context) {
+	h.respond(c, func(filter services.AnalyticsFilter, userID uint) (interface{}, error) {
+		return h.analyticsService.GetRevenue(c.Request.Context(), filter, userID)
+	})
+}
+
+func (h *AnalyticsHandler) GetClientEngagement(c *gin.Context) {
+	h.respond(c, func(filter services.AnalyticsFilter, userID uint) (interface{}, error) {
+		return h.analyticsService.GetClientEngagement(c.Request.Context(), filter, userID)
+	})
+}
+
+func (h *AnalyticsHandler) GetAgentSummaries(c *gin.Context) {
+	h.respond(c, func(filter services.AnalyticsFilter, userID uint) (interface{}, error) {
+		return h.analyticsService.GetAgentSummaries(c.Request.Context(), filter, userID)
+	})
+}
+
+// respond parses the shared agent_id, client_id, from and to query
+// parameters, runs query and maps its errors.
+func (h *AnalyticsHandler) respond(c *gin.Context, query func(filter services.AnalyticsFilter, userID uint) (interface{}, error)) {
+	var filter services.AnalyticsFilter
+	var err error
+
+	if filter.AgentID, err = parseOptionalUint(c.Query("agent_id")); err != nil {
+		_ = c.Error(services.ErrInvalidAgentID)
+		return
+	}
+	if filter.ClientID, err = parseOptionalUint(c.Query("client_id")); err != nil {
+		_ = c.Error(services.ErrInvalidClientID)
+		return
+	}
+	if filter.From, err = parseOptionalTime(c.Query("from")); err != nil {
+		_ = c.Error(services.ErrInvalidDate)
+		return
+	}
+	if filter.To, err = parseOptionalTime(c.Query("to")); err != nil {
+		_ = c.Error(services.ErrInvalidDate)
+		return
+	}
+
+	loggedInUserID, err := middleware.GetLoggedInUserID(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	result, err := query(filter, loggedInUserID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"backend/internal/models"
+	"backend/internal/services"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditHandler struct {
+	auditService services.AuditService
+}
+
+func NewAuditHandler(auditService services.AuditService) *AuditHandler {
+	return &AuditHandler{auditService: auditService}
+}
+
+func (h *AuditHandler) GetEntries(c *gin.Context) {
+	filter, err := parseAuditFilter(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	entries, err := h.auditService.GetEntries(c.Request.Context(), filter)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// ExportEntries streams every matching entry; limit and offset are ignored.
+func (h *AuditHandler) ExportEntries(c *gin.Context) {
+	format := strings.ToLower(c.DefaultQuery("format", "json"))
+	if format != "csv" && format != "json" {
+		_ = c.Error(services.ErrInvalidExportType)
+		return
+	}
+
+	filter, err := parseAuditFilter(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	filename := "audit-" + time.Now().UTC().Format("20060102T150405Z") + "." + format
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+
+	var writer auditWriter
+	if format == "json" {
+		c.Header("Content-Type", "application/json; charset=utf-8")
+		writer = &auditJSONWriter{w: c.Writer}
+	} else {
+		c.Header("Content-Type", "text/csv")
+		writer = &auditCSVWriter{w: csv.NewWriter(c.Writer)}
+	}
+	c.Status(http.StatusOK)
+
+	// Once the first batch is written the status can no longer change, so
+	// a later failure only ends the file early.
+	err = h.auditService.ExportEntries(c.Request.Context(), filter, writer.write)
+	if err == nil {
+		err = writer.close()
+	}
+	if err != nil {
+		_ = c.Error(err)
+	}
+}
+
+type auditWriter interface {
+	write(entries []*models.AuditEntry) error
+	close() error
+}
+
+// auditJSONWriter writes the entries as one JSON array.
+type auditJSONWriter struct {
+	w       io.Writer
+	started bool
+}
+
+func (a *auditJSONWriter) write(entries []*models.AuditEntry) error {
+	for _, entry := range entries {
+		separator := ","
+		if !a.started {
+			separator = "["
+			a.started = true
+		}
+		body, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(a.w, separator); err != nil {
+			return err
+		}
+		if _, err := a.w.Write(body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *auditJSONWriter) close() error {
+	end := "]"
+	if !a.started {
+		end = "[]"
+	}
+	_, err := io.WriteString(a.w, end)
+	return err
+}
+
+type auditCSVWriter struct {
+	w       *csv.Writer
+	started bool
+}
+
+func (a *auditCSVWriter) write(entries []*models.AuditEntry) error {
+	if !a.started {
+		a.started = true
+		if err := a.w.Write(auditCSVHeader); err != nil {
+			return err
+		}
+	}
+
+	for _, entry := range entries {
+		record := []string{
+			strconv.FormatUint(uint64(entry.ID), 10),
+			entry.CreatedAt.UTC().Format(time.RFC3339),
+			strconv.FormatUint(uint64(entry.ActorID), 10),
+			entry.Action,
+			entry.EntityType,
+			strconv.FormatUint(uint64(entry.EntityID), 10),
+			entry.IP,
+			entry.RequestID,
+			entry.Before,
+			entry.After,
+			entry.Diff,
+		}
+		if err := a.w.Write(record); err != nil {
+			return err
+		}
+	}
+
+	a.w.Flush()
+	return a.w.Error()
+}
+
+func (a *auditCSVWriter) close() error {
+	if !a.started {
+		return a.write(nil)
+	}
+	return nil
+}
+
+var auditCSVHeader = []string{"id", "created_at", "actor_id", "action", "entity_type", "entity_id", "ip", "request_id", "before", "after", "diff"}
+
+func parseAuditFilter(c *gin.Context) (services.AuditFilter, error) {
+	var filter services.AuditFilter
+	var err error
+
+	if filter.ActorID, err = parseOptionalUint(c.Query("actor_id")); err != nil {
+		return filter, services.ErrInvalidAuditFilter
+	}
+	if filter.EntityID, err = parseOptionalUint(c.Query("entity_id")); err != nil {
+		return filter, services.ErrInvalidAuditFilter
+	}
+	if filter.From, err = parseOptionalTime(c.Query("from")); err != nil {
+		return filter, services.ErrInvalidDate
+	}
+	if filter.To, err = parseOptionalTime(c.Query("to")); err != nil {
+		return filter, services.ErrInvalidDate
+	}
+	if filter.Limit, err = parseOptionalInt(c.Query("limit")); err != nil {
+		return filter, services.ErrInvalidAuditFilter
+	}
+	if filter.Offset, err = parseOptionalInt(c.Query("offset")); err != nil {
+		return filter, services.ErrInvalidAuditFilter
+	}
+
+	filter.Action = strings.ToUpper(c.Query("action"))
+	filter.EntityType = c.Query("entity_type")
+	filter.RequestID = c.Query("request_id")
+
+	return filter, nil
+}
+
+func parseOptionalUint(value string) (uint, error) {
+	if value == "" {
+		return 0, nil
+	}
+	parsed, err := strconv.ParseUint(value, 10, 32)
+	return uint(parsed), err
+}
+
+func parseOptionalInt(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(value)
+}
+
+// parseOptionalTime accepts RFC3339 timestamps or plain YYYY-MM-DD dates.
+func parseOptionalTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"backend/internal/middleware"
+	"backend/internal/models"
+	"backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// batchAuditService exports its entries in batches of two.
+type batchAuditService struct {
+	services.AuditService
+	entries []*models.AuditEntry
+}
+
+func (s *batchAuditService) ExportEntries(ctx context.Context, filter services.AuditFilter, write func([]*models.AuditEntry) error) error {
+	for i := 0; i < len(s.entries); i += 2 {
+		if err := write(s.entries[i:min(i+2, len(s.entries))]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestAuditHandler_ExportEntries(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	export := func(entries []*models.AuditEntry, format string) *httptest.ResponseRecorder {
+		router := gin.New()
+		router.Use(middleware.ErrorHandler())
+		router.GET("/audit/export", NewAuditHandler(&batchAuditService{entries: entries}).ExportEntries)
+
+		req, _ := http.NewRequest(http.MethodGet, "/audit/export?format="+format, nil)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	var entries []*models.AuditEntry
+	for id := uint(5); id > 0; id-- {
+		entries = append(entries, &models.AuditEntry{ID: id, Action: models.AuditActionCreate, EntityType: "agent", Diff: `{"Name":"a,b"}`})
+	}
+
+	resp := export(entries, "json")
+	require.Equal(t, http.StatusOK, resp.Code)
+	var decoded []*models.AuditEntry
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &decoded))
+	require.Len(t, decoded, 5)
+	assert.EqualValues(t, 1, decoded[4].ID)
+
+	resp = export(entries, "csv")
+	require.Equal(t, http.StatusOK, resp.Code)
+	rows, err := csv.NewReader(resp.Body).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 6)
+	assert.Equal(t, "id", rows[0][0])
+	assert.Equal(t, `{"Name":"a,b"}`, rows[5][10])
+
+	resp = export(nil, "json")
+	assert.Equal(t, "[]", strings.TrimSpace(resp.Body.String()))
+	resp = export(nil, "csv")
+	assert.True(t, strings.HasPrefix(resp.Body.String(), "id,created_at"))
+}
@@ -26,42 +26,34 @@ func (h *AuthHandler) Register(c *gin.Context) {
 
 	if err := c.ShouldBindJSON(&input); err != nil {
 		if input.Username == "" {
-			services.RespondError(c, http.StatusBadRequest, ErrUsernameRequired)
+			_ = c.Error(ErrUsernameRequired.WithField("username"))
 			return
 		}
 		if input.Password == "" {
-			services.RespondError(c, http.StatusBadRequest, ErrPasswordRequired)
+			_ = c.Error(ErrPasswordRequired.WithField("password"))
 			return
 		}
 		if input.ConfirmPassword == "" {
-			services.RespondError(c, http.StatusBadRequest, ErrPasswordRequired)
+			_ = c.Error(ErrPasswordRequired.WithField("confirm_password"))
 			return
 		}
 		if input.Email == "" {
-			services.RespondError(c, http.StatusBadRequest, ErrEmailRequired)
+			_ = c.Error(ErrEmailRequired.WithField("email"))
 			return
 		}
 
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
 		return
 	}
 
 	if input.ConfirmPassword != input.Password {
-		services.RespondError(c, http.StatusBadRequest, ErrPasswordDoNotMatch)
+		_ = c.Error(ErrPasswordDoNotMatch.WithField("confirm_password"))
 		return
 	}
 
 	user, err := h.authService.Register(c.Request.Context(), input.Username, input.Email, input.Password)
-	if err != nil || user == nil {
-		if errors.Is(err, services.ErrUsernameTaken) {
-			services.RespondError(c, http.StatusConflict, err)
-			return
-		}
-		if errors.Is(err, services.ErrEmailTaken) {
-			services.RespondError(c, http.StatusConflict, err)
-			return
-		}
-		services.RespondError(c, http.StatusInternalServerError, err)
+	if err != nil {
+		_ = c.Error(err)
 		return
 	}
 
@@ -82,24 +74,26 @@ func (h *AuthHandler) Login(c *gin.Context) {
 
 	if err := c.ShouldBindJSON(&input); err != nil {
 		if input.Username == "" {
-			services.RespondError(c, http.StatusBadRequest, ErrUsernameRequired)
+			_ = c.Error(ErrUsernameRequired.WithField("username"))
 			return
 		}
 		if input.Password == "" {
-			services.RespondError(c, http.StatusBadRequest, ErrPasswordRequired)
+			_ = c.Error(ErrPasswordRequired.WithField("password"))
 			return
 		}
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
 		return
 	}
 
 	token, user, err := h.authService.Login(c.Request.Context(), input.Username, input.Password)
-	if err != nil || user == nil {
-		if errors.Is(err, services.ErrInvalidCredentials) {
-			services.RespondError(c, http.StatusUnauthorized, ErrInvalidCredentials)
-			return
+	if err != nil {
+		// Domain errors such as a locked account keep their status; anything
+		// else is reported without details.
+		var domainErr *services.Error
+		if !errors.As(err, &domainErr) {
+			err = ErrLoginFailed
 		}
-		services.RespondError(c, http.StatusInternalServerError, ErrLoginFailed)
+		_ = c.Error(err)
 		return
 	}
 
@@ -121,10 +115,10 @@ func (h *AuthHandler) Protected(c *gin.Context) {
 }
 
 var (
-	ErrUsernameRequired   = errors.New("username is required")
-	ErrPasswordRequired   = errors.New("password is required")
-	ErrEmailRequired      = errors.New("email is required")
-	ErrInvalidCredentials = errors.New("invalid credentials")
-	ErrLoginFailed        = errors.New("login failed")
-	ErrPasswordDoNotMatch = errors.New("passwords do not match")
+	ErrUsernameRequired   = services.NewError(http.StatusBadRequest, "username_required", "username is required")
+	ErrPasswordRequired   = services.NewError(http.StatusBadRequest, "password_required", "password is required")
+	ErrEmailRequired      = services.NewError(http.StatusBadRequest, "email_required", "email is required")
+	ErrInvalidCredentials = services.ErrInvalidCredentials
+	ErrLoginFailed        = services.NewError(http.StatusInternalServerError, "login_failed", "login failed")
+	ErrPasswordDoNotMatch = services.NewError(http.StatusBadRequest, "passwords_do_not_match", "passwords do not match")
 )
@@ -8,6 +8,7 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"backend/internal/middleware"
 	"backend/internal/models"
 	"backend/internal/services"
 
@@ -41,7 +42,8 @@ func TestAuthHandler_Register(t *testing.T) {
 		mockService.On("Register", mock.Anything, "testuser", "test@mail.com", "password123").
 			Return(&models.User{Username: "testuser"}, nil)
 
-		router := gin.Default()
+		router := gin.New()
+		router.Use(middleware.ErrorHandler())
 		router.POST("/register", handler.Register)
 
 		body := `{"username": "testuser", "email": "test@mail.com", "password": "password123", "confirm_password": "password123"}`
@@ -62,7 +64,8 @@ func TestAuthHandler_Register(t *testing.T) {
 		mockService.On("Register", mock.Anything, "takenuser", "test@mail.com", "password123").
 			Return((*models.User)(nil), services.ErrUsernameTaken)
 
-		router := gin.Default()
+		router := gin.New()
+		router.Use(middleware.ErrorHandler())
 		router.POST("/register", handler.Register)
 
 		body := `{"username": "takenuser", "email": "test@mail.com", "password": "password123", "confirm_password": "password123"}`
@@ -83,7 +86,8 @@ func TestAuthHandler_Register(t *testing.T) {
 		mockService.On("Register", mock.Anything, "testuser", "taken@mail.com", "password123").
 			Return((*models.User)(nil), services.ErrEmailTaken)
 
-		router := gin.Default()
+		router := gin.New()
+		router.Use(middleware.ErrorHandler())
 		router.POST("/register", handler.Register)
 
 		body := `{"username": "testuser", "email": "taken@mail.com", "password": "password123", "confirm_password": "password123"}`
@@ -101,7 +105,8 @@ func TestAuthHandler_Register(t *testing.T) {
 		mockService := new(MockAuthService)
 		handler := NewAuthHandler(mockService)
 
-		router := gin.Default()
+		router := gin.New()
+		router.Use(middleware.ErrorHandler())
 		router.POST("/register", handler.Register)
 
 		body := `{"password": "password123"}`
@@ -119,7 +124,8 @@ func TestAuthHandler_Register(t *testing.T) {
 		mockService := new(MockAuthService)
 		handler := NewAuthHandler(mockService)
 
-		router := gin.Default()
+		router := gin.New()
+		router.Use(middleware.ErrorHandler())
 		router.POST("/register", handler.Register)
 
 		body := `{"username": "testuser", "email": "test@mail.com", "confirm_password": "password123"}`
@@ -137,7 +143,8 @@ func TestAuthHandler_Register(t *testing.T) {
 		mockService := new(MockAuthService)
 		handler := NewAuthHandler(mockService)
 
-		router := gin.Default()
+		router := gin.New()
+		router.Use(middleware.ErrorHandler())
 		router.POST("/register", handler.Register)
 
 		body := `{"username": "testuser", "email": "test@mail.com", "password": "password123"}`
@@ -155,7 +162,8 @@ func TestAuthHandler_Register(t *testing.T) {
 		mockService := new(MockAuthService)
 		handler := NewAuthHandler(mockService)
 
-		router := gin.Default()
+		router := gin.New()
+		router.Use(middleware.ErrorHandler())
 		router.POST("/register", handler.Register)
 
 		body := `{"username": "testuser", "password": "password123", "confirm_password": "password123"}`
@@ -173,7 +181,8 @@ func TestAuthHandler_Register(t *testing.T) {
 		mockService := new(MockAuthService)
 		handler := NewAuthHandler(mockService)
 
-		router := gin.Default()
+		router := gin.New()
+		router.Use(middleware.ErrorHandler())
 		router.POST("/register", handler.Register)
 
 		body := `{"username": "testuser", "email": "test@mail.com", "password": "password123", "confirm_password": "password456"}`
@@ -199,7 +208,8 @@ func TestAuthHandler_Login(t *testing.T) {
 		mockService.On("Login", mock.Anything, "testuser", "password123").
 			Return("token", &models.User{Username: "testuser"}, nil)
 
-		router := gin.Default()
+		router := gin.New()
+		router.Use(middleware.ErrorHandler())
 		router.POST("/login", handler.Login)
 
 		body := `{"username": "testuser", "password": "password123"}`
@@ -222,7 +232,8 @@ func TestAuthHandler_Login(t *testing.T) {
 		mockService.On("Login", mock.Anything, "wronguser", "wrongpass").
 			Return("", (*models.User)(nil), services.ErrInvalidCredentials)
 
-		router := gin.Default()
+		router := gin.New()
+		router.Use(middleware.ErrorHandler())
 		router.POST("/login", handler.Login)
 
 		body := `{"username": "wronguser", "password": "wrongpass"}`
@@ -241,7 +252,8 @@ func TestAuthHandler_Login(t *testing.T) {
 		mockService := new(MockAuthService)
 		handler := NewAuthHandler(mockService)
 
-		router := gin.Default()
+		router := gin.New()
+		router.Use(middleware.ErrorHandler())
 		router.POST("/login", handler.Login)
 
 		body := `{"password": "password123"}`
@@ -259,7 +271,8 @@ func TestAuthHandler_Login(t *testing.T) {
 		mockService := new(MockAuthService)
 		handler := NewAuthHandler(mockService)
 
-		router := gin.Default()
+		router := gin.New()
+		router.Use(middleware.ErrorHandler())
 		router.POST("/login", handler.Login)
 
 		body := `{"username": "testuser"}`
@@ -280,7 +293,8 @@ func TestAuthHandler_Login(t *testing.T) {
 		mockService.On("Login", mock.Anything, "testuser", "password123").
 			Return("", (*models.User)(nil), errors.New("database error"))
 
-		router := gin.Default()
+		router := gin.New()
+		router.Use(middleware.ErrorHandler())
 		router.POST("/login", handler.Login)
 
 		body := `{"username": "testuser", "password": "password123"}`
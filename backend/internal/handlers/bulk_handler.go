@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"backend/internal/middleware"
+	"backend/internal/models"
+	"backend/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BulkHandler struct {
+	bulkService services.BulkService
+}
+
+func NewBulkHandler(bulkService services.BulkService) *BulkHandler {
+	return &BulkHandler{bulkService: bulkService}
+}
+
+type bulkClientFilterInput struct {
+	AgentID   string            `json:"agent_id"`
+	SegmentID string            `json:"segment_id"`
+	Name      string            `json:"name"`
+	Contact   string            `json:"contact"`
+	Tags      []string          `json:"tags"`
+	Fields    map[string]string `json:"fields"`
+	Archived  string            `json:"archived"`
+}
+
+func (input bulkClientFilterInput) filter() (*services.ClientFilter, error) {
+	filter := &services.ClientFilter{
+		Name:    input.Name,
+		Contact: input.Contact,
+		Tags:    input.Tags,
+		Fields:  input.Fields,
+	}
+	var err error
+	if filter.AgentID, err = parseOptionalUint(input.AgentID); err != nil {
+		return nil, services.ErrInvalidAgentID.WithField("filter.agent_id")
+	}
+	if filter.SegmentID, err = parseOptionalUint(input.SegmentID); err != nil {
+		return nil, services.ErrInvalidSegmentID.WithField("filter.segment_id")
+	}
+	switch input.Archived {
+	case "", "false":
+	case "true":
+		filter.Archived = services.ArchivedOnly
+	case "all":
+		filter.Archived = services.ArchivedInclude
+	default:
+		return nil, services.ErrInvalidArchivedFilter.WithField("filter.archived")
+	}
+	return filter, nil
+}
+
+func parseIDList(values []string, invalid *services.Error, field string) ([]uint, error) {
+	ids := make([]uint, 0, len(values))
+	for _, value := range values {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return nil, invalid.WithField(field)
+		}
+		ids = append(ids, uint(id))
+	}
+	return ids, nil
+}
+
+// submit runs the request and answers 200 with the finished job or 202
+// when it was queued.
+func (h *BulkHandler) submit(c *gin.Context, request services.BulkRequest) {
+	loggedInUserID, err := middleware.GetLoggedInUserID(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	job, err := h.bulkService.Submit(c.Request.Context(), request, loggedInUserID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	if job.Status == models.BulkJobStatusPending {
+		c.JSON(http.StatusAccepted, job)
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+func (h *BulkHandler) BulkClients(c *gin.Context) {
+	var input struct {
+		Action    string                 `json:"action" binding:"required"`
+		ClientIDs []string               `json:"client_ids"`
+		Filter    *bulkClientFilterInput `json:"filter"`
+		AgentID   string                 `json:"agent_id"`
+		Tags      []string               `json:"tags"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	request := services.BulkRequest{
+		Target: models.BulkTargetClients,
+		Action: input.Action,
+		Params: models.BulkParams{Tags: input.Tags},
+	}
+
+	var err error
+	if request.IDs, err = parseIDList(input.ClientIDs, services.ErrInvalidClientID, "client_ids"); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	if input.Filter != nil {
+		if request.ClientFilter, err = input.Filter.filter(); err != nil {
+			_ = c.Error(err)
+			return
+		}
+	}
+	if request.Params.AgentID, err = parseOptionalUint(input.AgentID); err != nil {
+		_ = c.Error(services.ErrInvalidAgentID.WithField("agent_id"))
+		return
+	}
+
+	h.submit(c, request)
+}
+
+func (h *BulkHandler) BulkAgents(c *gin.Context) {
+	var input struct {
+		Action   string   `json:"action" binding:"required"`
+		AgentIDs []string `json:"agent_ids"`
+		Filter   *struct {
+			Status string `json:"status"`
+			Name   string `json:"name"`
+		} `json:"filter"`
+		Status string `json:"status"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	request := services.BulkRequest{
+		Target: models.BulkTargetAgents,
+		Action: input.Action,
+		Params: models.BulkParams{Status: input.Status},
+	}
+
+	var err error
+	if request.IDs, err = parseIDList(input.AgentIDs, services.ErrInvalidAgentID, "agent_ids"); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	if input.Filter != nil {
+		request.AgentFilter = &services.AgentFilter{Status: input.Filter.Status, Name: input.Filter.Name}
+	}
+
+	h.submit(c, request)
+}
+
+func (h *BulkHandler) GetJobs(c *gin.Context) {
+	loggedInUserID, err := middleware.GetLoggedInUserID(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	jobs, err := h.bulkService.GetJobs(c.Request.Context(), loggedInUserID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, jobs)
+}
+
+func (h *BulkHandler) GetJobByID(c *gin.Context) {
+	jobID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		_ = c.Error(services.ErrInvalidBulkJobID)
+		return
+	}
+
+	loggedInUserID, err := middleware.GetLoggedInUserID(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	job, err := h.bulkService.GetJob(c.Request.Context(), uint(jobID), loggedInUserID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
@@ -4,7 +4,6 @@ import (
 	"backend/internal/middleware"
 	"backend/internal/models"
 	"backend/internal/services"
-	"errors"
 	"net/http"
 	"strconv"
 	"time"
@@ -24,29 +23,25 @@ func NewClientHandler(clientService services.ClientService) *ClientHandler {
 func (h *ClientHandler) GetClientByID(c *gin.Context) {
 	idParam := c.Param("id")
 	if idParam == "" {
-		services.RespondError(c, http.StatusBadRequest, services.ErrClientIDRequired)
+		_ = c.Error(services.ErrClientIDRequired)
 		return
 	}
 
 	clientID, err := strconv.ParseUint(idParam, 10, 32)
 	if err != nil {
-		services.RespondError(c, http.StatusBadRequest, services.ErrInvalidClientID)
+		_ = c.Error(services.ErrInvalidClientID)
 		return
 	}
 
 	loggedInUserID, err := middleware.GetLoggedInUserID(c)
 	if err != nil {
-		services.RespondError(c, http.StatusUnauthorized, err)
+		_ = c.Error(err)
 		return
 	}
 
 	client, err := h.clientService.GetClientByID(c, uint(clientID), loggedInUserID)
 	if err != nil {
-		if errors.Is(err, services.ErrClientNotFound) {
-			services.RespondError(c, http.StatusNotFound, err)
-			return
-		}
-		services.RespondError(c, http.StatusInternalServerError, err)
+		_ = c.Error(err)
 		return
 	}
 
@@ -56,36 +51,91 @@ func (h *ClientHandler) GetClientByID(c *gin.Context) {
 func (h *ClientHandler) GetClientsByAgentID(c *gin.Context) {
 	agentIDParam := c.Param("agent_id")
 	if agentIDParam == "" {
-		services.RespondError(c, http.StatusBadRequest, services.ErrAgentIDRequired)
+		_ = c.Error(services.ErrAgentIDRequired)
 		return
 	}
 
 	agentID, err := strconv.ParseUint(agentIDParam, 10, 32)
 
 	if err != nil {
-		services.RespondError(c, http.StatusBadRequest, services.ErrInvalidAgentID)
+		_ = c.Error(services.ErrInvalidAgentID)
 		return
 	}
 
 	loggedInUserID, err := middleware.GetLoggedInUserID(c)
 	if err != nil {
-		services.RespondError(c, http.StatusUnauthorized, err)
+		_ = c.Error(err)
 		return
 	}
 
-	clients, err := h.clientService.GetClientsByAgentID(c.Request.Context(), uint(agentID), loggedInUserID)
+	filter, err := parseClientFilter(c)
 	if err != nil {
-		if errors.Is(err, services.ErrUnauthorized) {
-			services.RespondError(c, http.StatusUnauthorized, err)
-			return
-		}
-		services.RespondError(c, http.StatusInternalServerError, err)
+		_ = c.Error(err)
+		return
+	}
+	filter.AgentID = uint(agentID)
+
+	clients, err := h.clientService.ListClients(c.Request.Context(), filter, loggedInUserID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, clients)
+}
+
+func (h *ClientHandler) ListClients(c *gin.Context) {
+	loggedInUserID, err := middleware.GetLoggedInUserID(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	filter, err := parseClientFilter(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	clients, err := h.clientService.ListClients(c.Request.Context(), filter, loggedInUserID)
+	if err != nil {
+		_ = c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, clients)
 }
 
+// parseClientFilter reads ?agent_id=, ?segment_id=, ?name=, ?contact=,
+// repeated ?tag=, ?field[key]=value and ?archived= from the query string.
+func parseClientFilter(c *gin.Context) (services.ClientFilter, error) {
+	var filter services.ClientFilter
+	var err error
+
+	if filter.AgentID, err = parseOptionalUint(c.Query("agent_id")); err != nil {
+		return filter, services.ErrInvalidAgentID
+	}
+	if filter.SegmentID, err = parseOptionalUint(c.Query("segment_id")); err != nil {
+		return filter, services.ErrInvalidSegmentID
+	}
+	filter.Name = c.Query("name")
+	filter.Contact = c.Query("contact")
+	filter.Tags = c.QueryArray("tag")
+	filter.Fields = c.QueryMap("field")
+
+	switch c.Query("archived") {
+	case "", "false":
+	case "true":
+		filter.Archived = services.ArchivedOnly
+	case "all":
+		filter.Archived = services.ArchivedInclude
+	default:
+		return filter, services.ErrInvalidArchivedFilter
+	}
+
+	return filter, nil
+}
+
 func (h *ClientHandler) CreateClient(c *gin.Context) {
 	var input struct {
 		Name      string    `json:"name" binding:"required"`
@@ -94,43 +144,26 @@ func (h *ClientHandler) CreateClient(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		if input.Name == "" {
-			services.RespondError(c, http.StatusBadRequest, services.ErrClientNameRequired)
-			return
-		}
-		if input.AgentID == "" {
-			services.RespondError(c, http.StatusBadRequest, services.ErrAgentIDRequired)
-			return
-		}
-		if input.StartDate.IsZero() {
-			services.RespondError(c, http.StatusBadRequest, services.ErrInvalidDate)
-		}
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
 	}
 
 	agentID, err := strconv.ParseUint(input.AgentID, 10, 32)
 	if err != nil {
-		services.RespondError(c, http.StatusBadRequest, services.ErrInvalidAgentID)
+		_ = c.Error(services.ErrInvalidAgentID)
 		return
 	}
 
 	loggedInUserID, err := middleware.GetLoggedInUserID(c)
 	if err != nil {
-		services.RespondError(c, http.StatusUnauthorized, err)
+		_ = c.Error(err)
 		return
 	}
 
 	client := &models.Client{Name: input.Name, AgentID: uint(agentID), StartDate: input.StartDate}
 	newClient, err := h.clientService.CreateClient(c.Request.Context(), client, uint(agentID), loggedInUserID)
 	if err != nil {
-		if errors.Is(err, services.ErrUnauthorized) {
-			services.RespondError(c, http.StatusUnauthorized, err)
-			return
-		}
-		if errors.Is(err, services.ErrClientNameRequired) {
-			services.RespondError(c, http.StatusBadRequest, err)
-			return
-		}
-		services.RespondError(c, http.StatusInternalServerError, err)
+		_ = c.Error(err)
 		return
 	}
 
@@ -145,29 +178,19 @@ func (h *ClientHandler) UpdateClient(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		if input.ID == "" {
-			services.RespondError(c, http.StatusBadRequest, services.ErrClientIDRequired)
-			return
-		}
-		if input.Name == "" {
-			services.RespondError(c, http.StatusBadRequest, services.ErrClientNameRequired)
-			return
-		}
-		if input.StartDate.IsZero() {
-			services.RespondError(c, http.StatusBadRequest, services.ErrInvalidDate)
-			return
-		}
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
 	}
 
 	clientID, err := strconv.ParseUint(input.ID, 10, 32)
 	if err != nil {
-		services.RespondError(c, http.StatusBadRequest, services.ErrInvalidClientID)
+		_ = c.Error(services.ErrInvalidClientID)
 		return
 	}
 
 	loggedInUserID, err := middleware.GetLoggedInUserID(c)
 	if err != nil {
-		services.RespondError(c, http.StatusUnauthorized, err)
+		_ = c.Error(err)
 		return
 	}
 
@@ -180,23 +203,7 @@ func (h *ClientHandler) UpdateClient(c *gin.Context) {
 	}
 	updatedClient, err := h.clientService.UpdateClient(c.Request.Context(), client, loggedInUserID)
 	if err != nil {
-		if errors.Is(err, services.ErrUnauthorized) {
-			services.RespondError(c, http.StatusUnauthorized, err)
-			return
-		}
-		if errors.Is(err, services.ErrClientNotFound) {
-			services.RespondError(c, http.StatusNotFound, err)
-			return
-		}
-		if errors.Is(err, services.ErrClientNameRequired) {
-			services.RespondError(c, http.StatusBadRequest, err)
-			return
-		}
-		if errors.Is(err, services.ErrInvalidDate) {
-			services.RespondError(c, http.StatusBadRequest, err)
-			return
-		}
-		services.RespondError(c, http.StatusInternalServerError, err)
+		_ = c.Error(err)
 		return
 	}
 
@@ -206,33 +213,157 @@ func (h *ClientHandler) UpdateClient(c *gin.Context) {
 func (h *ClientHandler) DeleteClient(c *gin.Context) {
 	idParam := c.Param("id")
 	if idParam == "" {
-		services.RespondError(c, http.StatusBadRequest, services.ErrClientIDRequired)
+		_ = c.Error(services.ErrClientIDRequired)
 		return
 	}
 
 	clientID, err := strconv.ParseUint(idParam, 10, 32)
 	if err != nil {
-		services.RespondError(c, http.StatusBadRequest, services.ErrInvalidClientID)
+		_ = c.Error(services.ErrInvalidClientID)
 		return
 	}
 
 	loggedInUserID, err := middleware.GetLoggedInUserID(c)
 	if err != nil {
-		services.RespondError(c, http.StatusUnauthorized, err)
+		_ = c.Error(err)
 		return
 	}
 
 	err = h.clientService.DeleteClient(c.Request.Context(), uint(clientID), loggedInUserID)
 	if err != nil {
-		if errors.Is(err, services.ErrUnauthorized) {
-			services.RespondError(c, http.StatusUnauthorized, err)
-			return
-		}
-		if errors.Is(err, services.ErrClientNotFound) {
-			services.RespondError(c, http.StatusNotFound, err)
-			return
-		}
-		services.RespondError(c, http.StatusInternalServerError, err)
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+func (h *ClientHandler) GetDeletedClients(c *gin.Context) {
+	loggedInUserID, err := middleware.GetLoggedInUserID(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	clients, err := h.clientService.GetDeletedClients(c.Request.Context(), loggedInUserID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, clients)
+}
+
+func (h *ClientHandler) RestoreClient(c *gin.Context) {
+	idParam := c.Param("id")
+	if idParam == "" {
+		_ = c.Error(services.ErrClientIDRequired)
+		return
+	}
+
+	clientID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		_ = c.Error(services.ErrInvalidClientID)
+		return
+	}
+
+	loggedInUserID, err := middleware.GetLoggedInUserID(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	restoredClient, err := h.clientService.RestoreClient(c.Request.Context(), uint(clientID), loggedInUserID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, restoredClient)
+}
+
+func (h *ClientHandler) TransferClient(c *gin.Context) {
+	clientID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		_ = c.Error(services.ErrInvalidClientID)
+		return
+	}
+
+	var input struct {
+		AgentID string `json:"agent_id" binding:"required"`
+		Reason  string `json:"reason"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	agentID, err := strconv.ParseUint(input.AgentID, 10, 32)
+	if err != nil {
+		_ = c.Error(services.ErrInvalidAgentID)
+		return
+	}
+
+	loggedInUserID, err := middleware.GetLoggedInUserID(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	transferredClient, err := h.clientService.TransferClient(c.Request.Context(), uint(clientID), uint(agentID), input.Reason, loggedInUserID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, transferredClient)
+}
+
+func (h *ClientHandler) GetAssignments(c *gin.Context) {
+	clientID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		_ = c.Error(services.ErrInvalidClientID)
+		return
+	}
+
+	loggedInUserID, err := middleware.GetLoggedInUserID(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	assignments, err := h.clientService.GetAssignments(c.Request.Context(), uint(clientID), loggedInUserID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, assignments)
+}
+
+func (h *ClientHandler) PurgeClient(c *gin.Context) {
+	idParam := c.Param("id")
+	if idParam == "" {
+		_ = c.Error(services.ErrClientIDRequired)
+		return
+	}
+
+	clientID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		_ = c.Error(services.ErrInvalidClientID)
+		return
+	}
+
+	loggedInUserID, err := middleware.GetLoggedInUserID(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	err = h.clientService.PurgeClient(c.Request.Context(), uint(clientID), loggedInUserID)
+	if err != nil {
+		_ = c.Error(err)
 		return
 	}
 
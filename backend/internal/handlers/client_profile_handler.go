@@ -0,0 +1,345 @@
+package handlers
+
+import (
+	"backend/internal/middleware"
+	"backend/internal/models"
+	"backend/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ClientProfileHandler struct {
+	profileService services.ClientProfileService
+}
+
+func NewClientProfileHandler(profileService services.ClientProfileService) *ClientProfileHandler {
+	return &ClientProfileHandler{profileService: profileService}
+}
+
+type contactInput struct {
+	Kind    string `json:"kind" binding:"required"`
+	Value   string `json:"value" binding:"required"`
+	Label   string `json:"label"`
+	Primary bool   `json:"primary"`
+}
+
+type tagsInput struct {
+	Tags []string `json:"tags" binding:"required"`
+}
+
+type noteInput struct {
+	Body string `json:"body" binding:"required"`
+}
+
+// profileRequest parses the client ID from the path and the logged-in user.
+// On failure the error has already been attached to c.
+func profileRequest(c *gin.Context) (clientID uint, userID uint, ok bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		_ = c.Error(services.ErrInvalidClientID)
+		return 0, 0, false
+	}
+
+	userID, err = middleware.GetLoggedInUserID(c)
+	if err != nil {
+		_ = c.Error(err)
+		return 0, 0, false
+	}
+
+	return uint(id), userID, true
+}
+
+func (h *ClientProfileHandler) GetContacts(c *gin.Context) {
+	clientID, userID, ok := profileRequest(c)
+	if !ok {
+		return
+	}
+
+	contacts, err := h.profileService.GetContacts(c.Request.Context(), clientID, userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, contacts)
+}
+
+func (h *ClientProfileHandler) CreateContact(c *gin.Context) {
+	clientID, userID, ok := profileRequest(c)
+	if !ok {
+		return
+	}
+
+	var input contactInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	contact := &models.ClientContact{
+		ClientID: clientID,
+		Kind:     input.Kind,
+		Value:    input.Value,
+		Label:    input.Label,
+		Primary:  input.Primary,
+	}
+	newContact, err := h.profileService.CreateContact(c.Request.Context(), contact, userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, newContact)
+}
+
+func (h *ClientProfileHandler) UpdateContact(c *gin.Context) {
+	clientID, userID, ok := profileRequest(c)
+	if !ok {
+		return
+	}
+
+	contactID, err := strconv.ParseUint(c.Param("contact_id"), 10, 32)
+	if err != nil {
+		_ = c.Error(services.ErrInvalidContactID)
+		return
+	}
+
+	var input contactInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	contact := &models.ClientContact{
+		ID:       uint(contactID),
+		ClientID: clientID,
+		Kind:     input.Kind,
+		Value:    input.Value,
+		Label:    input.Label,
+		Primary:  input.Primary,
+	}
+	updatedContact, err := h.profileService.UpdateContact(c.Request.Context(), contact, userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, updatedContact)
+}
+
+func (h *ClientProfileHandler) DeleteContact(c *gin.Context) {
+	clientID, userID, ok := profileRequest(c)
+	if !ok {
+		return
+	}
+
+	contactID, err := strconv.ParseUint(c.Param("contact_id"), 10, 32)
+	if err != nil {
+		_ = c.Error(services.ErrInvalidContactID)
+		return
+	}
+
+	if err := h.profileService.DeleteContact(c.Request.Context(), clientID, uint(contactID), userID); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+func (h *ClientProfileHandler) GetTags(c *gin.Context) {
+	clientID, userID, ok := profileRequest(c)
+	if !ok {
+		return
+	}
+
+	tags, err := h.profileService.GetTags(c.Request.Context(), clientID, userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tags)
+}
+
+func (h *ClientProfileHandler) AddTags(c *gin.Context) {
+	clientID, userID, ok := profileRequest(c)
+	if !ok {
+		return
+	}
+
+	var input tagsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	tags, err := h.profileService.AddTags(c.Request.Context(), clientID, input.Tags, userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tags)
+}
+
+func (h *ClientProfileHandler) SetTags(c *gin.Context) {
+	clientID, userID, ok := profileRequest(c)
+	if !ok {
+		return
+	}
+
+	var input tagsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	tags, err := h.profileService.SetTags(c.Request.Context(), clientID, input.Tags, userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tags)
+}
+
+func (h *ClientProfileHandler) RemoveTag(c *gin.Context) {
+	clientID, userID, ok := profileRequest(c)
+	if !ok {
+		return
+	}
+
+	tags, err := h.profileService.RemoveTag(c.Request.Context(), clientID, c.Param("tag"), userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tags)
+}
+
+func (h *ClientProfileHandler) GetFieldValues(c *gin.Context) {
+	clientID, userID, ok := profileRequest(c)
+	if !ok {
+		return
+	}
+
+	values, err := h.profileService.GetFieldValues(c.Request.Context(), clientID, userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, values)
+}
+
+func (h *ClientProfileHandler) SetFieldValues(c *gin.Context) {
+	clientID, userID, ok := profileRequest(c)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Values map[string]*string `json:"values" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	values, err := h.profileService.SetFieldValues(c.Request.Context(), clientID, input.Values, userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, values)
+}
+
+func (h *ClientProfileHandler) GetNotes(c *gin.Context) {
+	clientID, userID, ok := profileRequest(c)
+	if !ok {
+		return
+	}
+
+	notes, err := h.profileService.GetNotes(c.Request.Context(), clientID, userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, notes)
+}
+
+func (h *ClientProfileHandler) CreateNote(c *gin.Context) {
+	clientID, userID, ok := profileRequest(c)
+	if !ok {
+		return
+	}
+
+	var input noteInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	note := &models.ClientNote{ClientID: clientID, Body: input.Body}
+	newNote, err := h.profileService.CreateNote(c.Request.Context(), note, userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, newNote)
+}
+
+func (h *ClientProfileHandler) UpdateNote(c *gin.Context) {
+	clientID, userID, ok := profileRequest(c)
+	if !ok {
+		return
+	}
+
+	noteID, err := strconv.ParseUint(c.Param("note_id"), 10, 32)
+	if err != nil {
+		_ = c.Error(services.ErrInvalidNoteID)
+		return
+	}
+
+	var input noteInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	note := &models.ClientNote{ID: uint(noteID), ClientID: clientID, Body: input.Body}
+	updatedNote, err := h.profileService.UpdateNote(c.Request.Context(), note, userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, updatedNote)
+}
+
+func (h *ClientProfileHandler) DeleteNote(c *gin.Context) {
+	clientID, userID, ok := profileRequest(c)
+	if !ok {
+		return
+	}
+
+	noteID, err := strconv.ParseUint(c.Param("note_id"), 10, 32)
+	if err != nil {
+		_ = c.Error(services.ErrInvalidNoteID)
+		return
+	}
+
+	if err := h.profileService.DeleteNote(c.Request.Context(), clientID, uint(noteID), userID); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"backend/internal/middleware"
+	"backend/internal/models"
+	"backend/internal/services"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CustomFieldHandler struct {
+	customFieldService services.CustomFieldService
+}
+
+func NewCustomFieldHandler(customFieldService services.CustomFieldService) *CustomFieldHandler {
+	return &CustomFieldHandler{customFieldService: customFieldService}
+}
+
+func (h *CustomFieldHandler) GetFields(c *gin.Context) {
+	loggedInUserID, err := middleware.GetLoggedInUserID(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	fields, err := h.customFieldService.GetFields(c.Request.Context(), loggedInUserID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, fields)
+}
+
+func (h *CustomFieldHandler) CreateField(c *gin.Context) {
+	var input struct {
+		Key     string   `json:"key" binding:"required"`
+		Label   string   `json:"label" binding:"required"`
+		Type    string   `json:"type" binding:"required"`
+		Options []string `json:"options"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	loggedInUserID, err := middleware.GetLoggedInUserID(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	field := &models.CustomField{
+		Key:     input.Key,
+		Label:   input.Label,
+		Type:    strings.ToUpper(input.Type),
+		Options: input.Options,
+	}
+	newField, err := h.customFieldService.CreateField(c.Request.Context(), field, loggedInUserID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, newField)
+}
+
+func (h *CustomFieldHandler) UpdateField(c *gin.Context) {
+	fieldID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		_ = c.Error(services.ErrInvalidCustomFieldID)
+		return
+	}
+
+	var input struct {
+		Label   string   `json:"label"`
+		Options []string `json:"options"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	loggedInUserID, err := middleware.GetLoggedInUserID(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	field := &models.CustomField{ID: uint(fieldID), Label: input.Label, Options: input.Options}
+	updatedField, err := h.customFieldService.UpdateField(c.Request.Context(), field, loggedInUserID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, updatedField)
+}
+
+func (h *CustomFieldHandler) DeleteField(c *gin.Context) {
+	fieldID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		_ = c.Error(services.ErrInvalidCustomFieldID)
+		return
+	}
+
+	loggedInUserID, err := middleware.GetLoggedInUserID(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	if err := h.customFieldService.DeleteField(c.Request.Context(), uint(fieldID), loggedInUserID); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
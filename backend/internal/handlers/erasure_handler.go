@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"backend/internal/middleware"
+	"backend/internal/models"
+	"backend/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ErasureHandler struct {
+	erasureService services.ErasureService
+	exportService  services.ExportService
+}
+
+func NewErasureHandler(erasureService services.ErasureService, exportService services.ExportService) *ErasureHandler {
+	return &ErasureHandler{erasureService: erasureService, exportService: exportService}
+}
+
+// ExportPersonalData answers like CreateExport; the archive is downloaded
+// through the exports routes.
+func (h *ErasureHandler) ExportPersonalData(c *gin.Context) {
+	clientID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		_ = c.Error(services.ErrInvalidClientID)
+		return
+	}
+
+	loggedInUserID, err := middleware.GetLoggedInUserID(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	job, err := h.exportService.SubmitPersonalData(c.Request.Context(), uint(clientID), loggedInUserID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	if job.Status == models.ExportJobStatusPending {
+		c.JSON(http.StatusAccepted, job)
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// EraseClient needs "confirm": true in the body, as the erasure cannot be
+// undone.
+func (h *ErasureHandler) EraseClient(c *gin.Context) {
+	clientID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		_ = c.Error(services.ErrInvalidClientID)
+		return
+	}
+
+	var input struct {
+		Confirm bool   `json:"confirm" binding:"required"`
+		Reason  string `json:"reason"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	loggedInUserID, err := middleware.GetLoggedInUserID(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	erasure, err := h.erasureService.EraseClient(c.Request.Context(), uint(clientID), input.Reason, loggedInUserID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, erasure)
+}
+
+func (h *ErasureHandler) GetErasures(c *gin.Context) {
+	loggedInUserID, err := middleware.GetLoggedInUserID(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	erasures, err := h.erasureService.GetErasures(c.Request.Context(), loggedInUserID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, erasures)
+}
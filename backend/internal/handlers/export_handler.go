@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"backend/internal/middleware"
+	"backend/internal/models"
+	"backend/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ExportHandler struct {
+	exportService services.ExportService
+}
+
+func NewExportHandler(exportService services.ExportService) *ExportHandler {
+	return &ExportHandler{exportService: exportService}
+}
+
+// CreateExport answers 200 with the finished export or 202 when it was
+// queued.
+func (h *ExportHandler) CreateExport(c *gin.Context) {
+	var input struct {
+		Format   string `json:"format" binding:"required"`
+		ClientID string `json:"client_id"`
+		AgentID  string `json:"agent_id"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	request := services.ExportRequest{Format: input.Format}
+	var err error
+	if request.ClientID, err = parseOptionalUint(input.ClientID); err != nil {
+		_ = c.Error(services.ErrInvalidClientID.WithField("client_id"))
+		return
+	}
+	if request.AgentID, err = parseOptionalUint(input.AgentID); err != nil {
+		_ = c.Error(services.ErrInvalidAgentID.WithField("agent_id"))
+		return
+	}
+
+	loggedInUserID, err := middleware.GetLoggedInUserID(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	job, err := h.exportService.Submit(c.Request.Context(), request, loggedInUserID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	if job.Status == models.ExportJobStatusPending {
+		c.JSON(http.StatusAccepted, job)
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+func (h *ExportHandler) GetExports(c *gin.Context) {
+	loggedInUserID, err := middleware.GetLoggedInUserID(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	jobs, err := h.exportService.GetJobs(c.Request.Context(), loggedInUserID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, jobs)
+}
+
+func (h *ExportHandler) GetExportByID(c *gin.Context) {
+	jobID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		_ = c.Error(services.ErrInvalidExportJobID)
+		return
+	}
+
+	loggedInUserID, err := middleware.GetLoggedInUserID(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	job, err := h.exportService.GetJob(c.Request.Context(), uint(jobID), loggedInUserID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// DownloadExport sends the file of a completed export.
+func (h *ExportHandler) DownloadExport(c *gin.Context) {
+	jobID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		_ = c.Error(services.ErrInvalidExportJobID)
+		return
+	}
+
+	loggedInUserID, err := middleware.GetLoggedInUserID(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	job, err := h.exportService.Download(c.Request.Context(), uint(jobID), loggedInUserID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+job.Filename+`"`)
+	c.Data(http.StatusOK, job.ContentType, job.Content)
+}
@@ -1,14 +1,16 @@
 package handlers
 
 import (
+	"backend/internal/ai"
+	"backend/internal/middleware"
+	"backend/internal/models"
 	"backend/internal/services"
-	"bytes"
-	"encoding/json"
 	"github.com/gin-gonic/gin"
-	"io"
 	"net/http"
 )
 
+var ErrLLMUnavailable = services.NewError(http.StatusBadGateway, "llm_unavailable", "language model is unavailable")
+
 type LLMRequest struct {
 	Prompt string `json:"prompt" binding:"required"`
 }
@@ -19,47 +21,48 @@ type LLMResponse struct {
 	TotalDuration int64  `json:"total_duration,omitempty"`
 }
 
-func AskLLM(c *gin.Context) {
-	var request LLMRequest
-	if err := c.ShouldBindJSON(&request); err != nil {
-		services.RespondError(c, http.StatusBadRequest, err)
-		return
-	}
+type LLMHandler struct {
+	llm          ai.LLMProvider
+	quotaService services.QuotaService
+}
 
-	ollamaRequest := map[string]interface{}{
-		"prompt":     request.Prompt,
-		"model":      "deepseek",
-		"stream":     false,
-		"keep_alive": -1,
-	}
+func NewLLMHandler(llm ai.LLMProvider, quotaService services.QuotaService) *LLMHandler {
+	return &LLMHandler{llm: llm, quotaService: quotaService}
+}
 
-	body, _ := json.Marshal(ollamaRequest)
-	resp, err := http.Post("http://ollama:11434/api/generate", "application/json", bytes.NewBuffer(body))
+func (h *LLMHandler) AskLLM(c *gin.Context) {
+	userID, err := middleware.GetLoggedInUserID(c)
 	if err != nil {
-		services.RespondError(c, http.StatusInternalServerError, err)
+		_ = c.Error(err)
 		return
 	}
 
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		services.RespondError(c, http.StatusInternalServerError, err)
+	var request LLMRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
 		return
 	}
 
-	var ollamaResponse map[string]interface{}
-	if err := json.Unmarshal(respBody, &ollamaResponse); err != nil {
-		services.RespondError(c, http.StatusInternalServerError, err)
+	if err := h.quotaService.Check(c.Request.Context(), userID, models.UsageLLMTokens); err != nil {
+		_ = c.Error(err)
 		return
 	}
 
-	response := LLMResponse{
-		Model:    ollamaResponse["model"].(string),
-		Response: ollamaResponse["response"].(string),
+	completion, err := h.llm.Complete(c.Request.Context(), request.Prompt)
+	if err != nil {
+		_ = c.Error(ErrLLMUnavailable.Wrap(err))
+		return
 	}
 
-	if duration, ok := ollamaResponse["total_duration"]; ok {
-		response.TotalDuration = int64(duration.(float64))
+	tokens := completion.PromptTokens + completion.CompletionTokens
+	if err := h.quotaService.Consume(c.Request.Context(), userID, models.UsageLLMTokens, tokens); err != nil {
+		_ = c.Error(err)
+		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, LLMResponse{
+		Model:         completion.Model,
+		Response:      completion.Response,
+		TotalDuration: completion.TotalDuration,
+	})
 }
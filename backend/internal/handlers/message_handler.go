@@ -15,42 +15,40 @@ import (
 
 type MessageHandler struct {
 	messageService services.MessageService
+	importService  services.ConversationImportService
+	maxImportBytes int64
 }
 
-func NewMessageHandler(messageService services.MessageService) *MessageHandler {
-	return &MessageHandler{messageService: messageService}
+func NewMessageHandler(messageService services.MessageService, importService services.ConversationImportService, maxImportBytes int64) *MessageHandler {
+	return &MessageHandler{
+		messageService: messageService,
+		importService:  importService,
+		maxImportBytes: maxImportBytes,
+	}
 }
 
 func (h *MessageHandler) GetMessageByID(c *gin.Context) {
 	idParam := c.Param("id")
 	if idParam == "" {
-		services.RespondError(c, http.StatusBadRequest, services.ErrMessageIDRequired)
+		_ = c.Error(services.ErrMessageIDRequired)
 		return
 	}
 
 	messageID, err := strconv.ParseUint(idParam, 10, 32)
 	if err != nil {
-		services.RespondError(c, http.StatusBadRequest, services.ErrInvalidMessageID)
+		_ = c.Error(services.ErrInvalidMessageID)
 		return
 	}
 
 	loggedInUserID, err := middleware.GetLoggedInUserID(c)
 	if err != nil {
-		services.RespondError(c, http.StatusUnauthorized, err)
+		_ = c.Error(err)
 		return
 	}
 
 	message, err := h.messageService.GetMessageByID(c, uint(messageID), loggedInUserID)
 	if err != nil {
-		if errors.Is(err, services.ErrMessageNotFound) {
-			services.RespondError(c, http.StatusNotFound, err)
-			return
-		}
-		if errors.Is(err, services.ErrUnauthorized) {
-			services.RespondError(c, http.StatusUnauthorized, err)
-			return
-		}
-		services.RespondError(c, http.StatusInternalServerError, err)
+		_ = c.Error(err)
 		return
 	}
 
@@ -60,33 +58,25 @@ func (h *MessageHandler) GetMessageByID(c *gin.Context) {
 func (h *MessageHandler) GetMessageByAgentID(c *gin.Context) {
 	agentIDParam := c.Param("agent_id")
 	if agentIDParam == "" {
-		services.RespondError(c, http.StatusBadRequest, services.ErrAgentIDRequired)
+		_ = c.Error(services.ErrAgentIDRequired)
 		return
 	}
 
 	agentID, err := strconv.ParseUint(agentIDParam, 10, 32)
 	if err != nil {
-		services.RespondError(c, http.StatusBadRequest, services.ErrInvalidAgentID)
+		_ = c.Error(services.ErrInvalidAgentID)
 		return
 	}
 
 	loggedInUserID, err := middleware.GetLoggedInUserID(c)
 	if err != nil {
-		services.RespondError(c, http.StatusUnauthorized, err)
+		_ = c.Error(err)
 		return
 	}
 
 	messages, err := h.messageService.GetMessageByAgentID(c.Request.Context(), uint(agentID), loggedInUserID)
 	if err != nil {
-		if errors.Is(err, services.ErrUnauthorized) {
-			services.RespondError(c, http.StatusUnauthorized, err)
-			return
-		}
-		if errors.Is(err, services.ErrAgentNotFound) {
-			services.RespondError(c, http.StatusNotFound, err)
-			return
-		}
-		services.RespondError(c, http.StatusInternalServerError, err)
+		_ = c.Error(err)
 		return
 	}
 
@@ -96,33 +86,25 @@ func (h *MessageHandler) GetMessageByAgentID(c *gin.Context) {
 func (h *MessageHandler) GetMessageByClientID(c *gin.Context) {
 	clientIDParam := c.Param("client_id")
 	if clientIDParam == "" {
-		services.RespondError(c, http.StatusBadRequest, services.ErrClientIDRequired)
+		_ = c.Error(services.ErrClientIDRequired)
 		return
 	}
 
 	clientID, err := strconv.ParseUint(clientIDParam, 10, 32)
 	if err != nil {
-		services.RespondError(c, http.StatusBadRequest, services.ErrInvalidClientID)
+		_ = c.Error(services.ErrInvalidClientID)
 		return
 	}
 
 	loggedInUserID, err := middleware.GetLoggedInUserID(c)
 	if err != nil {
-		services.RespondError(c, http.StatusUnauthorized, err)
+		_ = c.Error(err)
 		return
 	}
 
 	messages, err := h.messageService.GetMessageByClientID(c.Request.Context(), uint(clientID), loggedInUserID)
 	if err != nil {
-		if errors.Is(err, services.ErrUnauthorized) {
-			services.RespondError(c, http.StatusUnauthorized, err)
-			return
-		}
-		if errors.Is(err, services.ErrClientNotFound) {
-			services.RespondError(c, http.StatusNotFound, err)
-			return
-		}
-		services.RespondError(c, http.StatusInternalServerError, err)
+		_ = c.Error(err)
 		return
 	}
 
@@ -132,41 +114,37 @@ func (h *MessageHandler) GetMessageByClientID(c *gin.Context) {
 func (h *MessageHandler) GetMessagesByAgentIDAndClientID(c *gin.Context) {
 	agentIDParam := c.Param("agent_id")
 	if agentIDParam == "" {
-		services.RespondError(c, http.StatusBadRequest, services.ErrAgentIDRequired)
+		_ = c.Error(services.ErrAgentIDRequired)
 		return
 	}
 
 	clientIDParam := c.Param("client_id")
 	if clientIDParam == "" {
-		services.RespondError(c, http.StatusBadRequest, services.ErrClientIDRequired)
+		_ = c.Error(services.ErrClientIDRequired)
 		return
 	}
 
 	agentID, err := strconv.ParseUint(agentIDParam, 10, 32)
 	if err != nil {
-		services.RespondError(c, http.StatusBadRequest, services.ErrInvalidAgentID)
+		_ = c.Error(services.ErrInvalidAgentID)
 		return
 	}
 
 	clientID, err := strconv.ParseUint(clientIDParam, 10, 32)
 	if err != nil {
-		services.RespondError(c, http.StatusBadRequest, services.ErrInvalidClientID)
+		_ = c.Error(services.ErrInvalidClientID)
 		return
 	}
 
 	loggedInUserID, err := middleware.GetLoggedInUserID(c)
 	if err != nil {
-		services.RespondError(c, http.StatusUnauthorized, err)
+		_ = c.Error(err)
 		return
 	}
 
 	messages, err := h.messageService.GetMessagesByAgentIDAndClientID(c.Request.Context(), uint(agentID), uint(clientID), loggedInUserID)
 	if err != nil {
-		if errors.Is(err, services.ErrUnauthorized) {
-			services.RespondError(c, http.StatusUnauthorized, err)
-			return
-		}
-		services.RespondError(c, http.StatusInternalServerError, err)
+		_ = c.Error(err)
 		return
 	}
 
@@ -183,73 +161,44 @@ func (h *MessageHandler) CreateMessage(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		if input.Content == "" {
-			services.RespondError(c, http.StatusBadRequest, services.ErrMessageContentRequired)
-			return
-		}
-		if input.Type == "" {
-			services.RespondError(c, http.StatusBadRequest, services.ErrMessageTypeRequired)
-			return
-		}
-		if input.AgentID == "" {
-			services.RespondError(c, http.StatusBadRequest, services.ErrAgentIDRequired)
-			return
-		}
-		if input.ClientID == "" {
-			services.RespondError(c, http.StatusBadRequest, services.ErrClientIDRequired)
-			return
-		}
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
 	}
 
 	if input.Type != models.MessageTypeAgentToClient && input.Type != models.MessageTypeClientToAgent {
-		services.RespondError(c, http.StatusBadRequest, services.ErrInvalidMessageType)
+		_ = c.Error(services.ErrInvalidMessageType)
 		return
 	}
 
 	agentID, err := strconv.ParseUint(input.AgentID, 10, 32)
 	if err != nil {
-		services.RespondError(c, http.StatusBadRequest, services.ErrInvalidAgentID)
+		_ = c.Error(services.ErrInvalidAgentID)
 		return
 	}
 
 	clientID, err := strconv.ParseUint(input.ClientID, 10, 32)
 	if err != nil {
-		services.RespondError(c, http.StatusBadRequest, services.ErrInvalidClientID)
+		_ = c.Error(services.ErrInvalidClientID)
 		return
 	}
 
 	loggedInUserID, err := middleware.GetLoggedInUserID(c)
 	if err != nil {
-		services.RespondError(c, http.StatusUnauthorized, err)
+		_ = c.Error(err)
 		return
 	}
 
-	date := input.Date
-	if date.IsZero() {
-		date = time.Now()
-	}
-
 	message := &models.Message{
 		Content:  input.Content,
 		Type:     input.Type,
 		AgentID:  uint(agentID),
 		ClientID: uint(clientID),
-		Date:     date,
+		Date:     input.Date,
 	}
 
 	newMessage, err := h.messageService.CreateMessage(c.Request.Context(), message, loggedInUserID)
 	if err != nil {
-		if errors.Is(err, services.ErrUnauthorized) {
-			services.RespondError(c, http.StatusUnauthorized, err)
-			return
-		}
-		if errors.Is(err, services.ErrInvalidMessageType) ||
-			errors.Is(err, services.ErrMessageContentRequired) ||
-			errors.Is(err, services.ErrMessageTypeRequired) {
-			services.RespondError(c, http.StatusBadRequest, err)
-			return
-		}
-		services.RespondError(c, http.StatusInternalServerError, err)
+		_ = c.Error(err)
 		return
 	}
 
@@ -259,13 +208,13 @@ func (h *MessageHandler) CreateMessage(c *gin.Context) {
 func (h *MessageHandler) UpdateMessage(c *gin.Context) {
 	idParam := c.Param("id")
 	if idParam == "" {
-		services.RespondError(c, http.StatusBadRequest, services.ErrMessageIDRequired)
+		_ = c.Error(services.ErrMessageIDRequired)
 		return
 	}
 
 	messageID, err := strconv.ParseUint(idParam, 10, 32)
 	if err != nil {
-		services.RespondError(c, http.StatusBadRequest, services.ErrInvalidMessageID)
+		_ = c.Error(services.ErrInvalidMessageID)
 		return
 	}
 
@@ -276,13 +225,13 @@ func (h *MessageHandler) UpdateMessage(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		services.RespondError(c, http.StatusBadRequest, err)
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
 		return
 	}
 
 	loggedInUserID, err := middleware.GetLoggedInUserID(c)
 	if err != nil {
-		services.RespondError(c, http.StatusUnauthorized, err)
+		_ = c.Error(err)
 		return
 	}
 
@@ -297,19 +246,7 @@ func (h *MessageHandler) UpdateMessage(c *gin.Context) {
 
 	updatedMessage, err := h.messageService.UpdateMessage(c.Request.Context(), message, loggedInUserID)
 	if err != nil {
-		if errors.Is(err, services.ErrUnauthorized) {
-			services.RespondError(c, http.StatusUnauthorized, err)
-			return
-		}
-		if errors.Is(err, services.ErrMessageNotFound) {
-			services.RespondError(c, http.StatusNotFound, err)
-			return
-		}
-		if errors.Is(err, services.ErrInvalidMessageType) {
-			services.RespondError(c, http.StatusBadRequest, err)
-			return
-		}
-		services.RespondError(c, http.StatusInternalServerError, err)
+		_ = c.Error(err)
 		return
 	}
 
@@ -319,35 +256,176 @@ func (h *MessageHandler) UpdateMessage(c *gin.Context) {
 func (h *MessageHandler) DeleteMessage(c *gin.Context) {
 	idParam := c.Param("id")
 	if idParam == "" {
-		services.RespondError(c, http.StatusBadRequest, services.ErrMessageIDRequired)
+		_ = c.Error(services.ErrMessageIDRequired)
 		return
 	}
 
 	messageID, err := strconv.ParseUint(idParam, 10, 32)
 	if err != nil {
-		services.RespondError(c, http.StatusBadRequest, services.ErrInvalidMessageID)
+		_ = c.Error(services.ErrInvalidMessageID)
 		return
 	}
 
 	loggedInUserID, err := middleware.GetLoggedInUserID(c)
 	if err != nil {
-		services.RespondError(c, http.StatusUnauthorized, err)
+		_ = c.Error(err)
 		return
 	}
 
 	err = h.messageService.DeleteMessage(c.Request.Context(), uint(messageID), loggedInUserID)
 	if err != nil {
-		if errors.Is(err, services.ErrUnauthorized) {
-			services.RespondError(c, http.StatusUnauthorized, err)
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+func (h *MessageHandler) GetDeletedMessages(c *gin.Context) {
+	loggedInUserID, err := middleware.GetLoggedInUserID(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	messages, err := h.messageService.GetDeletedMessages(c.Request.Context(), loggedInUserID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, messages)
+}
+
+func (h *MessageHandler) RestoreMessage(c *gin.Context) {
+	idParam := c.Param("id")
+	if idParam == "" {
+		_ = c.Error(services.ErrMessageIDRequired)
+		return
+	}
+
+	messageID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		_ = c.Error(services.ErrInvalidMessageID)
+		return
+	}
+
+	loggedInUserID, err := middleware.GetLoggedInUserID(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	restoredMessage, err := h.messageService.RestoreMessage(c.Request.Context(), uint(messageID), loggedInUserID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, restoredMessage)
+}
+
+func (h *MessageHandler) PurgeMessage(c *gin.Context) {
+	idParam := c.Param("id")
+	if idParam == "" {
+		_ = c.Error(services.ErrMessageIDRequired)
+		return
+	}
+
+	messageID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		_ = c.Error(services.ErrInvalidMessageID)
+		return
+	}
+
+	loggedInUserID, err := middleware.GetLoggedInUserID(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	err = h.messageService.PurgeMessage(c.Request.Context(), uint(messageID), loggedInUserID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// ImportConversation imports a chat export, sent as the "file" form field,
+// into a client's conversation. The other form fields map the export's
+// participants to the agent and the client. Bodies over the configured
+// limit are refused with 413.
+func (h *MessageHandler) ImportConversation(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxImportBytes)
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			_ = c.Error(services.ErrImportFileTooLarge)
+			return
+		}
+		_ = c.Error(services.ErrImportFileRequired)
+		return
+	}
+
+	clientID, err := strconv.ParseUint(c.PostForm("client_id"), 10, 32)
+	if err != nil {
+		_ = c.Error(services.ErrInvalidClientID.WithField("client_id"))
+		return
+	}
+
+	var agentID uint64
+	if value := c.PostForm("agent_id"); value != "" {
+		agentID, err = strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			_ = c.Error(services.ErrInvalidAgentID.WithField("agent_id"))
 			return
 		}
-		if errors.Is(err, services.ErrMessageNotFound) {
-			services.RespondError(c, http.StatusNotFound, err)
+	}
+
+	var dryRun bool
+	if value := c.PostForm("dry_run"); value != "" {
+		dryRun, err = strconv.ParseBool(value)
+		if err != nil {
+			_ = c.Error(services.ErrInvalidDryRun.WithField("dry_run"))
 			return
 		}
-		services.RespondError(c, http.StatusInternalServerError, err)
+	}
+
+	loggedInUserID, err := middleware.GetLoggedInUserID(c)
+	if err != nil {
+		_ = c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusNoContent, nil)
+	file, err := fileHeader.Open()
+	if err != nil {
+		_ = c.Error(services.ErrImportFileRequired)
+		return
+	}
+	defer file.Close()
+
+	report, err := h.importService.ImportConversation(c.Request.Context(), services.ConversationImport{
+		File:         file,
+		Filename:     fileHeader.Filename,
+		Format:       c.PostForm("format"),
+		ClientID:     uint(clientID),
+		AgentID:      uint(agentID),
+		AgentSender:  c.PostForm("agent_sender"),
+		ClientSender: c.PostForm("client_sender"),
+		Timezone:     c.PostForm("timezone"),
+		DryRun:       dryRun,
+	}, loggedInUserID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	status := http.StatusCreated
+	if dryRun {
+		status = http.StatusOK
+	}
+	c.JSON(status, report)
 }
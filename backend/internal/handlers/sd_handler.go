@@ -1,76 +1,55 @@
 package handlers
 
 import (
-	"backend/internal/config"
+	"backend/internal/ai"
+	"backend/internal/middleware"
+	"backend/internal/models"
 	"backend/internal/services"
-	"bytes"
-	"encoding/json"
 	"github.com/gin-gonic/gin"
-	"io"
 	"net/http"
 )
 
+var ErrImageServiceUnavailable = services.NewError(http.StatusBadGateway, "image_service_unavailable", "image generation service is unavailable")
+
 type SDRequest struct {
 	Prompt string `json:"prompt" binding:"required"`
 }
 
-func callSDAPI(cfg *config.Config, endpoint string, payload interface{}) (map[string]interface{}, error) {
-
-	body, err := json.Marshal(payload)
-	if err != nil {
-		return nil, err
-	}
-
-	client := &http.Client{}
-	req, err := http.NewRequest("POST", cfg.SDUrl+"/"+endpoint, bytes.NewBuffer(body))
-	if err != nil {
-		return nil, err
-	}
-
-	req.Header.Set("Content-Type", "application/json")
+type SDHandler struct {
+	images       ai.ImageProvider
+	quotaService services.QuotaService
+}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+func NewSDHandler(images ai.ImageProvider, quotaService services.QuotaService) *SDHandler {
+	return &SDHandler{images: images, quotaService: quotaService}
+}
 
-	respBody, err := io.ReadAll(resp.Body)
+func (h *SDHandler) TextToImage(c *gin.Context) {
+	userID, err := middleware.GetLoggedInUserID(c)
 	if err != nil {
-		return nil, err
-	}
-
-	var sdResponse map[string]interface{}
-	if err := json.Unmarshal(respBody, &sdResponse); err != nil {
-		return nil, err
+		_ = c.Error(err)
+		return
 	}
 
-	return sdResponse, nil
-}
-
-func TextToImage(c *gin.Context, cfg *config.Config) {
 	var request SDRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
-		services.RespondError(c, http.StatusBadRequest, err)
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
 		return
 	}
 
-	requestPayload := map[string]interface{}{
-		"prompt":          request.Prompt,
-		"negative_prompt": "sdxl_cyberrealistic_simpleneg-neg",
-		"width":           512,
-		"height":          512,
-		"steps":           30,
-		"cfg_scale":       3,
-		"sampler_name":    "DPM++ 2S a Karras",
-		"sampler_index":   "DPM++ 2S a Karras",
-		"batch_size":      1,
-		"n_iter":          1,
+	if err := h.quotaService.Check(c.Request.Context(), userID, models.UsageImages); err != nil {
+		_ = c.Error(err)
+		return
 	}
 
-	sdResponse, err := callSDAPI(cfg, "sdapi/v1/txt2img", requestPayload)
+	sdResponse, err := h.images.TextToImage(c.Request.Context(), request.Prompt)
 	if err != nil {
-		services.RespondError(c, http.StatusInternalServerError, err)
+		_ = c.Error(ErrImageServiceUnavailable.Wrap(err))
+		return
+	}
+
+	if err := h.quotaService.Consume(c.Request.Context(), userID, models.UsageImages, 1); err != nil {
+		_ = c.Error(err)
 		return
 	}
 
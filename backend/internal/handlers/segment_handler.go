@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"backend/internal/middleware"
+	"backend/internal/models"
+	"backend/internal/services"
+	"encoding/csv"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SegmentHandler struct {
+	segmentService services.SegmentService
+	clientService  services.ClientService
+}
+
+func NewSegmentHandler(segmentService services.SegmentService, clientService services.ClientService) *SegmentHandler {
+	return &SegmentHandler{segmentService: segmentService, clientService: clientService}
+}
+
+type segmentInput struct {
+	Name        string                  `json:"name" binding:"required"`
+	Description string                  `json:"description"`
+	Match       string                  `json:"match"`
+	Conditions  []segmentConditionInput `json:"conditions"`
+}
+
+type segmentConditionInput struct {
+	Field      string `json:"field" binding:"required"`
+	Op         string `json:"op" binding:"required"`
+	Value      string `json:"value"`
+	Key        string `json:"key"`
+	WindowDays int    `json:"window_days"`
+	Currency   string `json:"currency"`
+}
+
+func (input segmentInput) segment() *models.Segment {
+	segment := &models.Segment{
+		Name:        input.Name,
+		Description: input.Description,
+		Match:       input.Match,
+		Conditions:  models.SegmentConditions{},
+	}
+	for _, condition := range input.Conditions {
+		segment.Conditions = append(segment.Conditions, models.SegmentCondition{
+			Field:      condition.Field,
+			Op:         condition.Op,
+			Value:      condition.Value,
+			Key:        condition.Key,
+			WindowDays: condition.WindowDays,
+			Currency:   condition.Currency,
+		})
+	}
+	return segment
+}
+
+func (h *SegmentHandler) GetSegments(c *gin.Context) {
+	loggedInUserID, err := middleware.GetLoggedInUserID(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	segments, err := h.segmentService.GetSegments(c.Request.Context(), loggedInUserID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, segments)
+}
+
+func (h *SegmentHandler) GetSegmentByID(c *gin.Context) {
+	segmentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		_ = c.Error(services.ErrInvalidSegmentID)
+		return
+	}
+
+	loggedInUserID, err := middleware.GetLoggedInUserID(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	segment, err := h.segmentService.GetSegmentByID(c.Request.Context(), uint(segmentID), loggedInUserID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, segment)
+}
+
+func (h *SegmentHandler) CreateSegment(c *gin.Context) {
+	var input segmentInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	loggedInUserID, err := middleware.GetLoggedInUserID(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	newSegment, err := h.segmentService.CreateSegment(c.Request.Context(), input.segment(), loggedInUserID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, newSegment)
+}
+
+func (h *SegmentHandler) UpdateSegment(c *gin.Context) {
+	segmentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		_ = c.Error(services.ErrInvalidSegmentID)
+		return
+	}
+
+	var input segmentInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	loggedInUserID, err := middleware.GetLoggedInUserID(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	segment := input.segment()
+	segment.ID = uint(segmentID)
+	updatedSegment, err := h.segmentService.UpdateSegment(c.Request.Context(), segment, loggedInUserID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, updatedSegment)
+}
+
+func (h *SegmentHandler) DeleteSegment(c *gin.Context) {
+	segmentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		_ = c.Error(services.ErrInvalidSegmentID)
+		return
+	}
+
+	loggedInUserID, err := middleware.GetLoggedInUserID(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	if err := h.segmentService.DeleteSegment(c.Request.Context(), uint(segmentID), loggedInUserID); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+func (h *SegmentHandler) GetSegmentClients(c *gin.Context) {
+	segmentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		_ = c.Error(services.ErrInvalidSegmentID)
+		return
+	}
+
+	limit, err := parseOptionalInt(c.Query("limit"))
+	if err != nil {
+		_ = c.Error(services.ErrInvalidSegmentPagination)
+		return
+	}
+	offset, err := parseOptionalInt(c.Query("offset"))
+	if err != nil {
+		_ = c.Error(services.ErrInvalidSegmentPagination)
+		return
+	}
+
+	loggedInUserID, err := middleware.GetLoggedInUserID(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	page, err := h.segmentService.GetSegmentClients(c.Request.Context(), uint(segmentID), limit, offset, loggedInUserID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// ExportSegmentClients writes every current member of the segment as CSV.
+func (h *SegmentHandler) ExportSegmentClients(c *gin.Context) {
+	segmentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		_ = c.Error(services.ErrInvalidSegmentID)
+		return
+	}
+
+	loggedInUserID, err := middleware.GetLoggedInUserID(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	clients, err := h.clientService.ListClients(c.Request.Context(), services.ClientFilter{SegmentID: uint(segmentID)}, loggedInUserID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	filename := "segment-" + strconv.FormatUint(segmentID, 10) + "-" + time.Now().UTC().Format("20060102T150405Z") + ".csv"
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Header("Content-Type", "text/csv")
+	c.Status(http.StatusOK)
+	if err := writeClientsCSV(c.Writer, clients); err != nil {
+		_ = c.Error(err)
+	}
+}
+
+func writeClientsCSV(w http.ResponseWriter, clients []*models.Client) error {
+	writer := csv.NewWriter(w)
+	header := []string{"id", "name", "agent_id", "agent_name", "start_date", "score", "created_at"}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, client := range clients {
+		record := []string{
+			strconv.FormatUint(uint64(client.ID), 10),
+			client.Name,
+			strconv.FormatUint(uint64(client.AgentID), 10),
+			client.Agent.Name,
+			client.StartDate.UTC().Format(time.RFC3339),
+			strconv.FormatFloat(client.Score, 'f', -1, 64),
+			client.CreatedAt.UTC().Format(time.RFC3339),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"backend/internal/middleware"
+	"backend/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TimelineHandler struct {
+	timelineService services.TimelineService
+}
+
+func NewTimelineHandler(timelineService services.TimelineService) *TimelineHandler {
+	return &TimelineHandler{timelineService: timelineService}
+}
+
+func (h *TimelineHandler) GetTimeline(c *gin.Context) {
+	clientID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		_ = c.Error(services.ErrInvalidClientID)
+		return
+	}
+
+	limit, err := parseOptionalInt(c.Query("limit"))
+	if err != nil {
+		_ = c.Error(services.ErrInvalidTimelinePagination)
+		return
+	}
+
+	loggedInUserID, err := middleware.GetLoggedInUserID(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	query := services.TimelineQuery{
+		Kinds:  c.QueryArray("kind"),
+		Cursor: c.Query("cursor"),
+		Limit:  limit,
+	}
+	page, err := h.timelineService.GetTimeline(c.Request.Context(), uint(clientID), query, loggedInUserID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
@@ -3,10 +3,13 @@ package handlers
 import (
 	"backend/internal/middleware"
 	"backend/internal/models"
+	"backend/internal/money"
 	"backend/internal/services"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -14,43 +17,39 @@ import (
 )
 
 type TransactionHandler struct {
-	transactionService services.TransactionService
+	transactionService    services.TransactionService
+	reconciliationService services.ReconciliationService
 }
 
-func NewTransactionHandler(transactionService services.TransactionService) *TransactionHandler {
-	return &TransactionHandler{transactionService: transactionService}
+func NewTransactionHandler(transactionService services.TransactionService, reconciliationService services.ReconciliationService) *TransactionHandler {
+	return &TransactionHandler{
+		transactionService:    transactionService,
+		reconciliationService: reconciliationService,
+	}
 }
 
 func (h *TransactionHandler) GetTransactionByID(c *gin.Context) {
 	idParam := c.Param("id")
 	if idParam == "" {
-		services.RespondError(c, http.StatusBadRequest, services.ErrTransactionIDRequired)
+		_ = c.Error(services.ErrTransactionIDRequired)
 		return
 	}
 
 	transactionID, err := strconv.ParseUint(idParam, 10, 32)
 	if err != nil {
-		services.RespondError(c, http.StatusBadRequest, services.ErrInvalidTransactionID)
+		_ = c.Error(services.ErrInvalidTransactionID)
 		return
 	}
 
 	loggedInUserID, err := middleware.GetLoggedInUserID(c)
 	if err != nil {
-		services.RespondError(c, http.StatusUnauthorized, err)
+		_ = c.Error(err)
 		return
 	}
 
 	transaction, err := h.transactionService.GetTransactionByID(c.Request.Context(), uint(transactionID), loggedInUserID)
 	if err != nil {
-		if errors.Is(err, services.ErrTransactionNotFound) {
-			services.RespondError(c, http.StatusNotFound, err)
-			return
-		}
-		if errors.Is(err, services.ErrUnauthorized) {
-			services.RespondError(c, http.StatusUnauthorized, err)
-			return
-		}
-		services.RespondError(c, http.StatusInternalServerError, err)
+		_ = c.Error(err)
 		return
 	}
 
@@ -60,29 +59,25 @@ func (h *TransactionHandler) GetTransactionByID(c *gin.Context) {
 func (h *TransactionHandler) GetTransactionsByAgentID(c *gin.Context) {
 	agentIDParam := c.Param("agent_id")
 	if agentIDParam == "" {
-		services.RespondError(c, http.StatusBadRequest, services.ErrAgentIDRequired)
+		_ = c.Error(services.ErrAgentIDRequired)
 		return
 	}
 
 	agentID, err := strconv.ParseUint(agentIDParam, 10, 32)
 	if err != nil {
-		services.RespondError(c, http.StatusBadRequest, services.ErrInvalidAgentID)
+		_ = c.Error(services.ErrInvalidAgentID)
 		return
 	}
 
 	loggedInUserID, err := middleware.GetLoggedInUserID(c)
 	if err != nil {
-		services.RespondError(c, http.StatusUnauthorized, err)
+		_ = c.Error(err)
 		return
 	}
 
 	transactions, err := h.transactionService.GetTransactionsByAgentID(c.Request.Context(), uint(agentID), loggedInUserID)
 	if err != nil {
-		if errors.Is(err, services.ErrUnauthorized) {
-			services.RespondError(c, http.StatusUnauthorized, err)
-			return
-		}
-		services.RespondError(c, http.StatusInternalServerError, err)
+		_ = c.Error(err)
 		return
 	}
 
@@ -92,29 +87,25 @@ func (h *TransactionHandler) GetTransactionsByAgentID(c *gin.Context) {
 func (h *TransactionHandler) GetTransactionsByClientID(c *gin.Context) {
 	clientIDParam := c.Param("client_id")
 	if clientIDParam == "" {
-		services.RespondError(c, http.StatusBadRequest, services.ErrClientIDRequired)
+		_ = c.Error(services.ErrClientIDRequired)
 		return
 	}
 
 	clientID, err := strconv.ParseUint(clientIDParam, 10, 32)
 	if err != nil {
-		services.RespondError(c, http.StatusBadRequest, services.ErrInvalidClientID)
+		_ = c.Error(services.ErrInvalidClientID)
 		return
 	}
 
 	loggedInUserID, err := middleware.GetLoggedInUserID(c)
 	if err != nil {
-		services.RespondError(c, http.StatusUnauthorized, err)
+		_ = c.Error(err)
 		return
 	}
 
 	transactions, err := h.transactionService.GetTransactionsByClientID(c.Request.Context(), uint(clientID), loggedInUserID)
 	if err != nil {
-		if errors.Is(err, services.ErrUnauthorized) {
-			services.RespondError(c, http.StatusUnauthorized, err)
-			return
-		}
-		services.RespondError(c, http.StatusInternalServerError, err)
+		_ = c.Error(err)
 		return
 	}
 
@@ -124,45 +115,37 @@ func (h *TransactionHandler) GetTransactionsByClientID(c *gin.Context) {
 func (h *TransactionHandler) GetTransactionsByAgentIDAndClientID(c *gin.Context) {
 	agentIDParam := c.Param("agent_id")
 	if agentIDParam == "" {
-		services.RespondError(c, http.StatusBadRequest, services.ErrAgentIDRequired)
+		_ = c.Error(services.ErrAgentIDRequired)
 		return
 	}
 
 	clientIDParam := c.Param("client_id")
 	if clientIDParam == "" {
-		services.RespondError(c, http.StatusBadRequest, services.ErrClientIDRequired)
+		_ = c.Error(services.ErrClientIDRequired)
 		return
 	}
 
 	agentID, err := strconv.ParseUint(agentIDParam, 10, 32)
 	if err != nil {
-		services.RespondError(c, http.StatusBadRequest, services.ErrInvalidAgentID)
+		_ = c.Error(services.ErrInvalidAgentID)
 		return
 	}
 
 	clientID, err := strconv.ParseUint(clientIDParam, 10, 32)
 	if err != nil {
-		services.RespondError(c, http.StatusBadRequest, services.ErrInvalidClientID)
+		_ = c.Error(services.ErrInvalidClientID)
 		return
 	}
 
 	loggedInUserID, err := middleware.GetLoggedInUserID(c)
 	if err != nil {
-		services.RespondError(c, http.StatusUnauthorized, err)
+		_ = c.Error(err)
 		return
 	}
 
 	transactions, err := h.transactionService.GetTransactionsByAgentIDAndClientID(c.Request.Context(), uint(agentID), uint(clientID), loggedInUserID)
 	if err != nil {
-		if errors.Is(err, services.ErrUnauthorized) {
-			services.RespondError(c, http.StatusUnauthorized, err)
-			return
-		}
-		if errors.Is(err, services.ErrTransactionNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"message": "No transactions found"})
-			return
-		}
-		services.RespondError(c, http.StatusInternalServerError, err)
+		_ = c.Error(err)
 		return
 	}
 
@@ -171,63 +154,59 @@ func (h *TransactionHandler) GetTransactionsByAgentIDAndClientID(c *gin.Context)
 
 func (h *TransactionHandler) CreateTransaction(c *gin.Context) {
 	var input struct {
-		AgentID  string    `json:"agent_id" binding:"required"`
-		ClientID string    `json:"client_id" binding:"required"`
-		Amount   float64   `json:"amount" binding:"required"`
-		Date     time.Time `json:"date"`
+		AgentID  string      `json:"agent_id" binding:"required"`
+		ClientID string      `json:"client_id" binding:"required"`
+		Amount   json.Number `json:"amount" binding:"required"` // decimal in major units, e.g. "12.50"
+		Currency string      `json:"currency"`                  // ISO-4217 code, defaults to USD
+		Date     time.Time   `json:"date"`
+
+		Status            string `json:"status"` // defaults to COMPLETED
+		ExternalReference string `json:"external_reference"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		if input.AgentID == "" {
-			services.RespondError(c, http.StatusBadRequest, services.ErrAgentIDRequired)
-			return
-		}
-		if input.ClientID == "" {
-			services.RespondError(c, http.StatusBadRequest, services.ErrClientIDRequired)
-			return
-		}
-		if input.Amount == 0 {
-			services.RespondError(c, http.StatusBadRequest, services.ErrAmountRequired)
-			return
-		}
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	amountMinor, currency, err := parseAmount(input.Amount, input.Currency)
+	if err != nil {
+		_ = c.Error(err)
+		return
 	}
 
 	agentID, err := strconv.ParseUint(input.AgentID, 10, 32)
 	if err != nil {
-		services.RespondError(c, http.StatusBadRequest, services.ErrInvalidAgentID)
+		_ = c.Error(services.ErrInvalidAgentID)
 		return
 	}
 
 	clientID, err := strconv.ParseUint(input.ClientID, 10, 32)
 	if err != nil {
-		services.RespondError(c, http.StatusBadRequest, services.ErrInvalidClientID)
+		_ = c.Error(services.ErrInvalidClientID)
 		return
 	}
 
 	loggedInUserID, err := middleware.GetLoggedInUserID(c)
 	if err != nil {
-		services.RespondError(c, http.StatusUnauthorized, err)
+		_ = c.Error(err)
 		return
 	}
 
 	transaction := &models.Transaction{
-		AgentID:  uint(agentID),
-		ClientID: uint(clientID),
-		Amount:   input.Amount,
-		Date:     input.Date,
+		AgentID:     uint(agentID),
+		ClientID:    uint(clientID),
+		AmountMinor: amountMinor,
+		Currency:    currency,
+		Date:        input.Date,
+
+		Status:            strings.ToUpper(input.Status),
+		ExternalReference: input.ExternalReference,
 	}
 
 	newTransaction, err := h.transactionService.CreateTransaction(c.Request.Context(), transaction, loggedInUserID)
 	if err != nil {
-		if errors.Is(err, services.ErrUnauthorized) {
-			services.RespondError(c, http.StatusUnauthorized, err)
-			return
-		}
-		if errors.Is(err, services.ErrAmountRequired) {
-			services.RespondError(c, http.StatusBadRequest, err)
-			return
-		}
-		services.RespondError(c, http.StatusInternalServerError, err)
+		_ = c.Error(err)
 		return
 	}
 
@@ -237,35 +216,50 @@ func (h *TransactionHandler) CreateTransaction(c *gin.Context) {
 func (h *TransactionHandler) UpdateTransaction(c *gin.Context) {
 	idParam := c.Param("id")
 	if idParam == "" {
-		services.RespondError(c, http.StatusBadRequest, services.ErrTransactionIDRequired)
+		_ = c.Error(services.ErrTransactionIDRequired)
 		return
 	}
 
 	transactionID, err := strconv.ParseUint(idParam, 10, 32)
 	if err != nil {
-		services.RespondError(c, http.StatusBadRequest, services.ErrInvalidTransactionID)
+		_ = c.Error(services.ErrInvalidTransactionID)
 		return
 	}
 
 	var input struct {
-		Amount float64   `json:"amount" binding:"required"`
-		Date   time.Time `json:"date" binding:"required"`
+		Amount   json.Number `json:"amount" binding:"required"`
+		Currency string      `json:"currency"` // defaults to the transaction's currency
+		Date     time.Time   `json:"date" binding:"required"`
+
+		ExternalReference string `json:"external_reference"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		if input.Amount == 0 {
-			services.RespondError(c, http.StatusBadRequest, services.ErrAmountRequired)
-			return
-		}
-		if input.Date.IsZero() {
-			services.RespondError(c, http.StatusBadRequest, services.ErrInvalidDate)
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	loggedInUserID, err := middleware.GetLoggedInUserID(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	// The amount is parsed in the currency it will be stored in, which is
+	// the existing one unless another is given.
+	currency := input.Currency
+	if currency == "" {
+		existing, err := h.transactionService.GetTransactionByID(c.Request.Context(), uint(transactionID), loggedInUserID)
+		if err != nil {
+			_ = c.Error(err)
 			return
 		}
+		currency = existing.Currency
 	}
 
-	loggedInUserID, err := middleware.GetLoggedInUserID(c)
+	amountMinor, currency, err := parseAmount(input.Amount, currency)
 	if err != nil {
-		services.RespondError(c, http.StatusUnauthorized, err)
+		_ = c.Error(err)
 		return
 	}
 
@@ -273,25 +267,16 @@ func (h *TransactionHandler) UpdateTransaction(c *gin.Context) {
 		Model: gorm.Model{
 			ID: uint(transactionID),
 		},
-		Amount: input.Amount,
-		Date:   input.Date,
+		AmountMinor: amountMinor,
+		Currency:    currency,
+		Date:        input.Date,
+
+		ExternalReference: input.ExternalReference,
 	}
 
 	updatedTransaction, err := h.transactionService.UpdateTransaction(c.Request.Context(), transaction, loggedInUserID)
 	if err != nil {
-		if errors.Is(err, services.ErrUnauthorized) {
-			services.RespondError(c, http.StatusUnauthorized, err)
-			return
-		}
-		if errors.Is(err, services.ErrTransactionNotFound) {
-			services.RespondError(c, http.StatusNotFound, err)
-			return
-		}
-		if errors.Is(err, services.ErrAmountRequired) {
-			services.RespondError(c, http.StatusBadRequest, err)
-			return
-		}
-		services.RespondError(c, http.StatusInternalServerError, err)
+		_ = c.Error(err)
 		return
 	}
 
@@ -301,35 +286,320 @@ func (h *TransactionHandler) UpdateTransaction(c *gin.Context) {
 func (h *TransactionHandler) DeleteTransaction(c *gin.Context) {
 	idParam := c.Param("id")
 	if idParam == "" {
-		services.RespondError(c, http.StatusBadRequest, services.ErrTransactionIDRequired)
+		_ = c.Error(services.ErrTransactionIDRequired)
 		return
 	}
 
 	transactionID, err := strconv.ParseUint(idParam, 10, 32)
 	if err != nil {
-		services.RespondError(c, http.StatusBadRequest, services.ErrInvalidTransactionID)
+		_ = c.Error(services.ErrInvalidTransactionID)
 		return
 	}
 
 	loggedInUserID, err := middleware.GetLoggedInUserID(c)
 	if err != nil {
-		services.RespondError(c, http.StatusUnauthorized, err)
+		_ = c.Error(err)
 		return
 	}
 
 	err = h.transactionService.DeleteTransaction(c.Request.Context(), uint(transactionID), loggedInUserID)
 	if err != nil {
-		if errors.Is(err, services.ErrUnauthorized) {
-			services.RespondError(c, http.StatusUnauthorized, err)
-			return
-		}
-		if errors.Is(err, services.ErrTransactionNotFound) {
-			services.RespondError(c, http.StatusNotFound, err)
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+func (h *TransactionHandler) GetDeletedTransactions(c *gin.Context) {
+	loggedInUserID, err := middleware.GetLoggedInUserID(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	transactions, err := h.transactionService.GetDeletedTransactions(c.Request.Context(), loggedInUserID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, transactions)
+}
+
+func (h *TransactionHandler) RestoreTransaction(c *gin.Context) {
+	idParam := c.Param("id")
+	if idParam == "" {
+		_ = c.Error(services.ErrTransactionIDRequired)
+		return
+	}
+
+	transactionID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		_ = c.Error(services.ErrInvalidTransactionID)
+		return
+	}
+
+	loggedInUserID, err := middleware.GetLoggedInUserID(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	restoredTransaction, err := h.transactionService.RestoreTransaction(c.Request.Context(), uint(transactionID), loggedInUserID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, restoredTransaction)
+}
+
+func (h *TransactionHandler) PurgeTransaction(c *gin.Context) {
+	idParam := c.Param("id")
+	if idParam == "" {
+		_ = c.Error(services.ErrTransactionIDRequired)
+		return
+	}
+
+	transactionID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		_ = c.Error(services.ErrInvalidTransactionID)
+		return
+	}
+
+	loggedInUserID, err := middleware.GetLoggedInUserID(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	err = h.transactionService.PurgeTransaction(c.Request.Context(), uint(transactionID), loggedInUserID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+func (h *TransactionHandler) GetTotals(c *gin.Context) {
+	var filter services.TransactionTotalsFilter
+	var err error
+
+	if filter.AgentID, err = parseOptionalUint(c.Query("agent_id")); err != nil {
+		_ = c.Error(services.ErrInvalidAgentID)
+		return
+	}
+	if filter.ClientID, err = parseOptionalUint(c.Query("client_id")); err != nil {
+		_ = c.Error(services.ErrInvalidClientID)
+		return
+	}
+	if filter.From, err = parseOptionalTime(c.Query("from")); err != nil {
+		_ = c.Error(services.ErrInvalidDate)
+		return
+	}
+	if filter.To, err = parseOptionalTime(c.Query("to")); err != nil {
+		_ = c.Error(services.ErrInvalidDate)
+		return
+	}
+
+	loggedInUserID, err := middleware.GetLoggedInUserID(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	totals, err := h.transactionService.GetTotals(c.Request.Context(), filter, loggedInUserID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, totals)
+}
+
+func (h *TransactionHandler) UpdateTransactionStatus(c *gin.Context) {
+	idParam := c.Param("id")
+	if idParam == "" {
+		_ = c.Error(services.ErrTransactionIDRequired)
+		return
+	}
+
+	transactionID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		_ = c.Error(services.ErrInvalidTransactionID)
+		return
+	}
+
+	var input struct {
+		Status string `json:"status" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	loggedInUserID, err := middleware.GetLoggedInUserID(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	transaction, err := h.transactionService.UpdateTransactionStatus(c.Request.Context(), uint(transactionID), strings.ToUpper(input.Status), loggedInUserID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, transaction)
+}
+
+func (h *TransactionHandler) CreateRefund(c *gin.Context) {
+	idParam := c.Param("id")
+	if idParam == "" {
+		_ = c.Error(services.ErrTransactionIDRequired)
+		return
+	}
+
+	transactionID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		_ = c.Error(services.ErrInvalidTransactionID)
+		return
+	}
+
+	var input struct {
+		Amount            json.Number `json:"amount" binding:"required"`
+		Currency          string      `json:"currency"` // defaults to the transaction's currency
+		Reason            string      `json:"reason"`
+		ExternalReference string      `json:"external_reference"`
+		Date              time.Time   `json:"date"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	loggedInUserID, err := middleware.GetLoggedInUserID(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	currency := input.Currency
+	if currency == "" {
+		transaction, err := h.transactionService.GetTransactionByID(c.Request.Context(), uint(transactionID), loggedInUserID)
+		if err != nil {
+			_ = c.Error(err)
 			return
 		}
-		services.RespondError(c, http.StatusInternalServerError, err)
+		currency = transaction.Currency
+	}
+
+	amountMinor, currency, err := parseAmount(input.Amount, currency)
+	if err != nil {
+		_ = c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusNoContent, nil)
+	refund := &models.Refund{
+		TransactionID:     uint(transactionID),
+		AmountMinor:       amountMinor,
+		Currency:          currency,
+		Reason:            input.Reason,
+		ExternalReference: input.ExternalReference,
+		Date:              input.Date,
+	}
+
+	newRefund, err := h.transactionService.CreateRefund(c.Request.Context(), refund, loggedInUserID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, newRefund)
+}
+
+func (h *TransactionHandler) GetRefunds(c *gin.Context) {
+	idParam := c.Param("id")
+	if idParam == "" {
+		_ = c.Error(services.ErrTransactionIDRequired)
+		return
+	}
+
+	transactionID, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		_ = c.Error(services.ErrInvalidTransactionID)
+		return
+	}
+
+	loggedInUserID, err := middleware.GetLoggedInUserID(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	refunds, err := h.transactionService.GetRefunds(c.Request.Context(), uint(transactionID), loggedInUserID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, refunds)
+}
+
+// Reconcile compares an uploaded payment-processor CSV export, sent as the
+// "file" form field, against the user's transactions.
+func (h *TransactionHandler) Reconcile(c *gin.Context) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		_ = c.Error(services.ErrReconciliationFileRequired)
+		return
+	}
+
+	loggedInUserID, err := middleware.GetLoggedInUserID(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		_ = c.Error(services.ErrReconciliationFileRequired)
+		return
+	}
+	defer file.Close()
+
+	report, err := h.reconciliationService.Reconcile(c.Request.Context(), file, loggedInUserID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// parseAmount converts a decimal amount in major units into minor units of
+// the requested currency.
+func parseAmount(amount json.Number, currency string) (int64, string, error) {
+	if currency == "" {
+		currency = models.DefaultCurrency
+	}
+
+	currency, err := money.NormalizeCurrency(currency)
+	if err != nil {
+		return 0, "", services.ErrInvalidCurrency
+	}
+
+	amountMinor, err := money.Parse(amount.String(), currency)
+	if err != nil {
+		if errors.Is(err, money.ErrTooManyDecimals) {
+			return 0, "", services.ErrAmountPrecision
+		}
+		return 0, "", services.ErrInvalidAmount
+	}
+
+	return amountMinor, currency, nil
 }
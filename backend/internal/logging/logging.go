@@ -0,0 +1,64 @@
+package logging
+
+import (
+	"backend/internal/requestctx"
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// New returns a JSON logger writing to w at the given level (debug, info,
+// warn or error; anything else means info). Every record logged with a
+// context carries the request ID, client IP, authenticated user and trace ID
+// found in that context.
+func New(w io.Writer, level string) *slog.Logger {
+	handler := slog.NewJSONHandler(w, &slog.HandlerOptions{Level: ParseLevel(level)})
+	return slog.New(&contextHandler{Handler: handler})
+}
+
+func ParseLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// contextHandler adds request attributes from the context to each record.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	metadata := requestctx.FromContext(ctx)
+	if metadata.RequestID != "" {
+		record.AddAttrs(slog.String("request_id", metadata.RequestID))
+	}
+	if metadata.IP != "" {
+		record.AddAttrs(slog.String("ip", metadata.IP))
+	}
+	if metadata.UserID != 0 {
+		record.AddAttrs(slog.Uint64("user_id", uint64(metadata.UserID)))
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+		record.AddAttrs(slog.String("trace_id", spanContext.TraceID().String()))
+	}
+
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
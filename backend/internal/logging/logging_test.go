@@ -0,0 +1,34 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"backend/internal/requestctx"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew_AddsRequestContextAndFiltersLevel(t *testing.T) {
+	var out bytes.Buffer
+	logger := New(&out, "warn")
+
+	ctx := requestctx.WithMetadata(context.Background(), requestctx.Metadata{RequestID: "req-1", IP: "10.0.0.1"})
+	ctx = requestctx.WithUserID(ctx, 7)
+
+	logger.InfoContext(ctx, "dropped")
+	logger.With("component", "test").WarnContext(ctx, "kept", "attempt", 2)
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &entry), "exactly one JSON line is written")
+	assert.Equal(t, "kept", entry["msg"])
+	assert.Equal(t, "WARN", entry["level"])
+	assert.Equal(t, "req-1", entry["request_id"])
+	assert.Equal(t, "10.0.0.1", entry["ip"])
+	assert.Equal(t, float64(7), entry["user_id"])
+	assert.Equal(t, "test", entry["component"])
+	assert.Equal(t, float64(2), entry["attempt"])
+}
@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"backend/internal/models"
+	"backend/pkg/database"
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// activeClientWindow is how recently a client must have exchanged a message
+// to count as active.
+const activeClientWindow = 30 * 24 * time.Hour
+
+const businessQueryTimeout = 5 * time.Second
+
+var (
+	activeClientsDesc = prometheus.NewDesc(
+		"siren_active_clients",
+		"Clients that exchanged a message in the last 30 days.",
+		nil, nil,
+	)
+	unansweredMessagesDesc = prometheus.NewDesc(
+		"siren_unanswered_messages",
+		"Client messages that have not been followed by an agent reply.",
+		nil, nil,
+	)
+)
+
+// businessCollector queries the database on every scrape instead of keeping
+// gauges up to date from the services.
+type businessCollector struct {
+	db *database.DB
+}
+
+func newBusinessCollector(db *database.DB) prometheus.Collector {
+	return &businessCollector{db: db}
+}
+
+func (b *businessCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- activeClientsDesc
+	ch <- unansweredMessagesDesc
+}
+
+func (b *businessCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), businessQueryTimeout)
+	defer cancel()
+
+	var activeClients int64
+	err := b.db.WithContext(ctx).
+		Model(&models.Client{}).
+		Where("EXISTS (SELECT 1 FROM messages WHERE messages.client_id = clients.id AND messages.deleted_at IS NULL AND messages.date >= ?)", time.Now().Add(-activeClientWindow)).
+		Count(&activeClients).
+		Error
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(activeClientsDesc, err)
+	} else {
+		ch <- prometheus.MustNewConstMetric(activeClientsDesc, prometheus.GaugeValue, float64(activeClients))
+	}
+
+	var unansweredMessages int64
+	err = b.db.WithContext(ctx).
+		Model(&models.Message{}).
+		Where("messages.type = ?", models.MessageTypeClientToAgent).
+		Where("NOT EXISTS (SELECT 1 FROM messages replies WHERE replies.client_id = messages.client_id AND replies.type = ? AND replies.deleted_at IS NULL AND replies.date > messages.date)", models.MessageTypeAgentToClient).
+		Count(&unansweredMessages).
+		Error
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(unansweredMessagesDesc, err)
+	} else {
+		ch <- prometheus.MustNewConstMetric(unansweredMessagesDesc, prometheus.GaugeValue, float64(unansweredMessages))
+	}
+}
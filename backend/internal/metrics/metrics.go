@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"backend/pkg/database"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+var (
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "siren",
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of HTTP requests by route template, method and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestsInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "siren",
+		Subsystem: "http",
+		Name:      "requests_in_flight",
+		Help:      "Number of HTTP requests currently being served.",
+	})
+
+	DBQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "siren",
+		Subsystem: "db",
+		Name:      "query_duration_seconds",
+		Help:      "Duration of database statements by operation and table.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"operation", "table"})
+
+	DBQueryErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "siren",
+		Subsystem: "db",
+		Name:      "query_errors_total",
+		Help:      "Database statements that failed, excluding record-not-found.",
+	}, []string{"operation", "table"})
+
+	OllamaRequestDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "siren",
+		Subsystem: "ollama",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of Ollama generate calls.",
+		Buckets:   []float64{.25, .5, 1, 2.5, 5, 10, 20, 30, 60, 120},
+	})
+
+	OllamaRequestErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "siren",
+		Subsystem: "ollama",
+		Name:      "request_errors_total",
+		Help:      "Ollama generate calls that failed or returned an unusable response.",
+	})
+
+	OllamaTokens = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "siren",
+		Subsystem: "ollama",
+		Name:      "tokens_total",
+		Help:      "Tokens processed by Ollama, split into prompt and completion tokens.",
+	}, []string{"kind"})
+
+	SDRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "siren",
+		Subsystem: "sd",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of Stable Diffusion API calls by endpoint and outcome.",
+		Buckets:   []float64{1, 2.5, 5, 10, 20, 30, 60, 120, 300},
+	}, []string{"endpoint", "outcome"})
+
+	SDRequestsInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "siren",
+		Subsystem: "sd",
+		Name:      "requests_in_flight",
+		Help:      "Number of Stable Diffusion API calls currently running.",
+	})
+)
+
+// NewRegistry returns a registry holding the Go runtime and process
+// collectors, the request and client instrumentation above, and business
+// gauges computed from db at scrape time. The instrumentation collectors are
+// package-level so that every registry observes the same values.
+func NewRegistry(db *database.DB) *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequestDuration,
+		HTTPRequestsInFlight,
+		DBQueryDuration,
+		DBQueryErrors,
+		OllamaRequestDuration,
+		OllamaRequestErrors,
+		OllamaTokens,
+		SDRequestDuration,
+		SDRequestsInFlight,
+		newBusinessCollector(db),
+	)
+	return registry
+}
@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"backend/internal/models"
+	"backend/pkg/database"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_BusinessGaugesAndQueryDurations(t *testing.T) {
+	db := database.Connect(filepath.Join(t.TempDir(), "metrics.db"))
+	require.NoError(t, InstrumentDB(db.DB))
+
+	user := &models.User{Username: "owner", Email: "owner@mail.com", Password: "x"}
+	require.NoError(t, db.Create(user).Error)
+	agent := &models.Agent{UserID: user.ID, Name: "a", Characteristics: "c"}
+	require.NoError(t, db.Create(agent).Error)
+	client := &models.Client{AgentID: agent.ID, Name: "c", StartDate: time.Now()}
+	require.NoError(t, db.Create(client).Error)
+	dormant := &models.Client{AgentID: agent.ID, Name: "d", StartDate: time.Now()}
+	require.NoError(t, db.Create(dormant).Error)
+
+	// Messages count by their date, not by when they were stored: the reply
+	// is stored last but dated between the client's messages, and the
+	// dormant client's message is recent only in the database.
+	now := time.Now()
+	for _, entry := range []struct {
+		client    *models.Client
+		direction string
+		date      time.Time
+	}{
+		{client, models.MessageTypeClientToAgent, now.Add(-3 * time.Minute)},
+		{client, models.MessageTypeClientToAgent, now.Add(-time.Minute)},
+		{client, models.MessageTypeClientToAgent, now},
+		{client, models.MessageTypeAgentToClient, now.Add(-2 * time.Minute)},
+		{dormant, models.MessageTypeAgentToClient, now.Add(-2 * activeClientWindow)},
+	} {
+		message := &models.Message{
+			AgentID:  agent.ID,
+			ClientID: entry.client.ID,
+			Content:  "hi",
+			Type:     entry.direction,
+			Date:     entry.date,
+		}
+		require.NoError(t, db.Create(message).Error)
+	}
+
+	families, err := NewRegistry(db).Gather()
+	require.NoError(t, err)
+
+	values := map[string]float64{}
+	var insertObservations uint64
+	for _, family := range families {
+		switch family.GetName() {
+		case "siren_active_clients", "siren_unanswered_messages":
+			values[family.GetName()] = family.GetMetric()[0].GetGauge().GetValue()
+		case "siren_db_query_duration_seconds":
+			for _, metric := range family.GetMetric() {
+				for _, label := range metric.GetLabel() {
+					if label.GetName() == "table" && label.GetValue() == "messages" {
+						insertObservations += metric.GetHistogram().GetSampleCount()
+					}
+				}
+			}
+		}
+	}
+
+	assert.Equal(t, 1.0, values["siren_active_clients"])
+	assert.Equal(t, 2.0, values["siren_unanswered_messages"])
+	assert.GreaterOrEqual(t, insertObservations, uint64(4))
+}
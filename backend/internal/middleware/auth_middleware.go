@@ -2,11 +2,10 @@ package middleware
 
 import (
 	"backend/internal/config"
+	"backend/internal/models"
+	"backend/internal/requestctx"
 	"backend/internal/services"
 	"context"
-	"errors"
-
-	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -43,7 +42,8 @@ func (m *AuthMiddleware) JWTAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHandler := c.GetHeader("Authorization")
 		if authHandler == "" {
-			services.RespondError(c, http.StatusUnauthorized, errors.New("authorization header required"))
+			_ = c.Error(services.ErrAuthRequired)
+			c.Abort()
 			return
 		}
 
@@ -53,14 +53,14 @@ func (m *AuthMiddleware) JWTAuth() gin.HandlerFunc {
 		})
 
 		if err != nil || !tokenClaimed.Valid {
-			services.RespondError(c, http.StatusUnauthorized, errors.New("invalid token"))
+			_ = c.Error(services.ErrInvalidToken)
 			c.Abort()
 			return
 		}
 
 		claims, ok := tokenClaimed.Claims.(*CustomClaims)
 		if !ok {
-			services.RespondError(c, http.StatusUnauthorized, errors.New("invalid token claims"))
+			_ = c.Error(services.ErrInvalidToken)
 			c.Abort()
 			return
 		}
@@ -68,12 +68,13 @@ func (m *AuthMiddleware) JWTAuth() gin.HandlerFunc {
 		ctx := c.Request.Context()
 		user, err := m.userService.GetUserByID(ctx, claims.UserID)
 		if err != nil {
-			services.RespondError(c, http.StatusUnauthorized, errors.New("user not found"))
+			_ = c.Error(services.ErrInvalidToken)
 			c.Abort()
 			return
 		}
 
 		ctx = context.WithValue(ctx, UserIDKey, claims.UserID)
+		ctx = requestctx.WithUserID(ctx, claims.UserID)
 		ctx = context.WithValue(ctx, UsernameKey, claims.Username)
 
 		c.Set(string(UserIDKey), claims.UserID)
@@ -84,15 +85,28 @@ func (m *AuthMiddleware) JWTAuth() gin.HandlerFunc {
 	}
 }
 
+// RequireAdmin must run after JWTAuth and rejects users without the admin flag.
+func (m *AuthMiddleware) RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := c.Request.Context().Value(UserKey).(*models.User)
+		if !ok || !user.Admin {
+			_ = c.Error(services.ErrAdminRequired)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
 func GetLoggedInUserID(c *gin.Context) (uint, error) {
 	userID, ok := c.Get(string(UserIDKey))
 	if !ok {
-		return 0, errors.New("user ID not found in context")
+		return 0, services.ErrAuthRequired
 	}
 
 	id, ok := userID.(uint)
 	if !ok {
-		return 0, errors.New("user ID is not of type uint")
+		return 0, services.ErrAuthRequired
 	}
 
 	return id, nil
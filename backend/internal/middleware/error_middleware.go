@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"backend/internal/requestctx"
+	"backend/internal/services"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+var registerTagNames sync.Once
+
+// ErrorHandler renders the last error a handler recorded with c.Error, unless
+// the handler already wrote a response. Domain errors keep their status and
+// code, binding errors become validation errors with per-field details, and
+// anything else is reported as an internal error without leaking its message.
+func ErrorHandler() gin.HandlerFunc {
+	registerTagNames.Do(useJSONFieldNames)
+
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		abortWithError(c, toDomainError(c.Errors.Last()))
+	}
+}
+
+func abortWithError(c *gin.Context, domainErr *services.Error) {
+	if domainErr.RetryAfter > 0 {
+		seconds := int64(math.Ceil(domainErr.RetryAfter.Seconds()))
+		c.Header("Retry-After", strconv.FormatInt(seconds, 10))
+	}
+	c.AbortWithStatusJSON(domainErr.Status, services.ErrorResponse{
+		Error:     domainErr.Message,
+		Code:      domainErr.Code,
+		Fields:    domainErr.Fields,
+		RequestID: requestctx.FromContext(c.Request.Context()).RequestID,
+	})
+}
+
+func toDomainError(ginErr *gin.Error) *services.Error {
+	var domainErr *services.Error
+	if errors.As(ginErr.Err, &domainErr) {
+		return domainErr
+	}
+
+	if ginErr.IsType(gin.ErrorTypeBind) {
+		return bindingError(ginErr.Err)
+	}
+
+	return services.ErrInternal
+}
+
+func bindingError(err error) *services.Error {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fields := make([]services.FieldError, 0, len(validationErrs))
+		for _, fieldErr := range validationErrs {
+			fields = append(fields, services.FieldError{
+				Field:   fieldErr.Field(),
+				Message: validationMessage(fieldErr),
+			})
+		}
+		return services.NewValidationError(fields...)
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return services.NewValidationError(services.FieldError{
+			Field:   typeErr.Field,
+			Message: "must be a " + typeErr.Type.String(),
+		})
+	}
+
+	return services.ErrInvalidBody
+}
+
+func validationMessage(fieldErr validator.FieldError) string {
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "oneof":
+		return "must be one of: " + fieldErr.Param()
+	case "min":
+		return "must be at least " + fieldErr.Param()
+	case "max":
+		return "must be at most " + fieldErr.Param()
+	default:
+		return fmt.Sprintf("failed the %s check", fieldErr.Tag())
+	}
+}
+
+// useJSONFieldNames makes validation errors report the JSON name of a field
+// instead of the Go struct field name.
+func useJSONFieldNames() {
+	validate, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			return field.Name
+		}
+		return name
+	})
+}
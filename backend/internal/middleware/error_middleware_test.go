@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(RequestMetadata(), ErrorHandler())
+	router.POST("/bind", func(c *gin.Context) {
+		var input struct {
+			Name  string `json:"name" binding:"required"`
+			Email string `json:"email" binding:"required,email"`
+		}
+		if err := c.ShouldBindJSON(&input); err != nil {
+			_ = c.Error(err).SetType(gin.ErrorTypeBind)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+	router.GET("/domain", func(c *gin.Context) {
+		_ = c.Error(services.ErrClientNotFound)
+	})
+	router.GET("/internal", func(c *gin.Context) {
+		_ = c.Error(errors.New("database is locked"))
+	})
+
+	serve := func(method, path, body string) (*httptest.ResponseRecorder, services.ErrorResponse) {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		var envelope services.ErrorResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &envelope))
+		return resp, envelope
+	}
+
+	t.Run("ValidationFields", func(t *testing.T) {
+		resp, envelope := serve(http.MethodPost, "/bind", `{"email":"not-an-email"}`)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		assert.Equal(t, "validation_failed", envelope.Code)
+		assert.Equal(t, []services.FieldError{
+			{Field: "name", Message: "is required"},
+			{Field: "email", Message: "must be a valid email address"},
+		}, envelope.Fields)
+		assert.Equal(t, resp.Header().Get("X-Request-ID"), envelope.RequestID)
+	})
+
+	t.Run("MalformedBody", func(t *testing.T) {
+		resp, envelope := serve(http.MethodPost, "/bind", `{"name":`)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		assert.Equal(t, "invalid_body", envelope.Code)
+	})
+
+	t.Run("DomainError", func(t *testing.T) {
+		resp, envelope := serve(http.MethodGet, "/domain", "")
+
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+		assert.Equal(t, "client_not_found", envelope.Code)
+	})
+
+	t.Run("UnknownErrorIsMasked", func(t *testing.T) {
+		resp, envelope := serve(http.MethodGet, "/internal", "")
+
+		assert.Equal(t, http.StatusInternalServerError, resp.Code)
+		assert.Equal(t, "internal_error", envelope.Code)
+		assert.NotContains(t, resp.Body.String(), "database is locked")
+	})
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"backend/internal/config"
+	"backend/internal/ratelimit"
+	"backend/internal/services"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimits holds the token buckets shared by every API version, so a
+// client cannot double its allowance by mixing versioned and legacy paths.
+type RateLimits struct {
+	ip   *ratelimit.Limiter
+	auth *ratelimit.Limiter
+	user *ratelimit.Limiter
+	ai   *ratelimit.Limiter
+}
+
+func NewRateLimits(cfg *config.Config, now func() time.Time) *RateLimits {
+	return &RateLimits{
+		ip:   ratelimit.New(cfg.RateLimitIPPerMinute, cfg.RateLimitIPBurst, now),
+		auth: ratelimit.New(cfg.RateLimitAuthPerMinute, cfg.RateLimitAuthBurst, now),
+		user: ratelimit.New(cfg.RateLimitUserPerMinute, cfg.RateLimitUserBurst, now),
+		ai:   ratelimit.New(cfg.RateLimitAIPerMinute, cfg.RateLimitAIBurst, now),
+	}
+}
+
+// PerIP limits every request by client IP.
+func (l *RateLimits) PerIP() gin.HandlerFunc {
+	return limit(l.ip, clientIPKey)
+}
+
+// Auth is the stricter per-IP limit for endpoints that check passwords.
+func (l *RateLimits) Auth() gin.HandlerFunc {
+	return limit(l.auth, clientIPKey)
+}
+
+// PerUser limits requests by authenticated user and must run after JWTAuth.
+func (l *RateLimits) PerUser() gin.HandlerFunc {
+	return limit(l.user, userKey)
+}
+
+// AI limits calls to the GPU-backed endpoints by authenticated user and must
+// run after JWTAuth.
+func (l *RateLimits) AI() gin.HandlerFunc {
+	return limit(l.ai, userKey)
+}
+
+func limit(limiter *ratelimit.Limiter, key func(c *gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if allowed, wait := limiter.Allow(key(c)); !allowed {
+			_ = c.Error(services.ErrRateLimited.WithRetryAfter(wait))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+func clientIPKey(c *gin.Context) string {
+	return c.ClientIP()
+}
+
+func userKey(c *gin.Context) string {
+	userID, err := GetLoggedInUserID(c)
+	if err != nil {
+		return "ip:" + c.ClientIP()
+	}
+	return strconv.FormatUint(uint64(userID), 10)
+}
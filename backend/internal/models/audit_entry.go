@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	AuditActionCreate      = "CREATE"
+	AuditActionUpdate      = "UPDATE"
+	AuditActionDelete      = "DELETE"
+	AuditActionRestore     = "RESTORE"
+	AuditActionPurge       = "PURGE"
+	AuditActionErase       = "ERASE"
+	AuditActionLogin       = "LOGIN"
+	AuditActionLoginFailed = "LOGIN_FAILED"
+)
+
+// AuditEntry is append-only, so it deliberately does not embed gorm.Model and
+// cannot be soft-deleted.
+type AuditEntry struct {
+	ID         uint      `gorm:"primarykey"`
+	CreatedAt  time.Time `gorm:"index"`
+	ActorID    uint      `gorm:"index"`
+	Action     string    `gorm:"not null;index"`
+	EntityType string    `gorm:"not null;index"`
+	EntityID   uint      `gorm:"index"`
+	Before     string    `gorm:"type:text"`
+	After      string    `gorm:"type:text"`
+	Diff       string    `gorm:"type:text"`
+	IP         string
+	RequestID  string `gorm:"index"`
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"database/sql/driver"
+	"time"
+)
+
+const (
+	BulkTargetClients = "CLIENTS"
+	BulkTargetAgents  = "AGENTS"
+)
+
+const (
+	BulkActionReassign  = "REASSIGN"
+	BulkActionTag       = "TAG"
+	BulkActionUntag     = "UNTAG"
+	BulkActionArchive   = "ARCHIVE"
+	BulkActionUnarchive = "UNARCHIVE"
+	BulkActionDelete    = "DELETE"
+	BulkActionSetStatus = "SET_STATUS"
+)
+
+const (
+	BulkJobStatusPending   = "PENDING"
+	BulkJobStatusRunning   = "RUNNING"
+	BulkJobStatusCompleted = "COMPLETED"
+	BulkJobStatusFailed    = "FAILED"
+)
+
+// BulkJob applies one action to a fixed list of records. The list is
+// resolved when the job is submitted, so records matching a filter later are
+// not touched. Processed counts the items already applied; a job picked up
+// again after a restart continues from there.
+type BulkJob struct {
+	ID         uint `gorm:"primarykey"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	UserID     uint            `gorm:"not null;index"`
+	Target     string          `gorm:"not null"`
+	Action     string          `gorm:"not null"`
+	Params     BulkParams      `gorm:"embedded"`
+	ItemIDs    IDList          `gorm:"type:text"`
+	Status     string          `gorm:"not null;index"`
+	Total      int             `gorm:"not null"`
+	Processed  int             `gorm:"not null;default:0"`
+	Succeeded  int             `gorm:"not null;default:0"`
+	Failed     int             `gorm:"not null;default:0"`
+	Results    BulkItemResults `gorm:"type:text"`
+	Error      string          `gorm:"type:text"`
+	StartedAt  *time.Time
+	FinishedAt *time.Time
+}
+
+// BulkParams are the action's arguments: the agent clients are reassigned
+// to, the tags to add or remove, or the new agent status.
+type BulkParams struct {
+	AgentID uint       `gorm:"column:param_agent_id"`
+	Tags    StringList `gorm:"column:param_tags;type:text"`
+	Status  string     `gorm:"column:param_status"`
+}
+
+// BulkItemResult reports the outcome for one record. Code and Message carry
+// the API error when the item failed.
+type BulkItemResult struct {
+	ID      uint
+	OK      bool
+	Code    string `json:",omitempty"`
+	Message string `json:",omitempty"`
+}
+
+// IDList is stored as a JSON array in a text column.
+type IDList []uint
+
+func (l IDList) Value() (driver.Value, error) {
+	return jsonValue([]uint(l))
+}
+
+func (l *IDList) Scan(value interface{}) error {
+	return scanJSON(value, l)
+}
+
+// BulkItemResults is stored as a JSON array in a text column.
+type BulkItemResults []BulkItemResult
+
+func (r BulkItemResults) Value() (driver.Value, error) {
+	return jsonValue([]BulkItemResult(r))
+}
+
+func (r *BulkItemResults) Scan(value interface{}) error {
+	return scanJSON(value, r)
+}
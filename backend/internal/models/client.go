@@ -12,6 +12,8 @@ type Client struct {
 	Name         string        `gorm:"not null"`
 	StartDate    time.Time     `gorm:"not null"`
 	Score        float64       `gorm:"default:0"`
+	ArchivedAt   *time.Time    `gorm:"index"`
+	ErasedAt     *time.Time    // see ClientErasure
 	Messages     []Message     `gorm:"foreignKey:ClientID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
 	Transactions []Transaction `gorm:"foreignKey:ClientID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
 }
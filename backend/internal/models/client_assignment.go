@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// ClientAssignment records that a client was handed to an agent: once when
+// the client is created, with no FromAgentID, and again on every transfer.
+// Messages and transactions keep the agent that handled them, so together
+// with this history they show who looked after the client when.
+type ClientAssignment struct {
+	ID          uint `gorm:"primarykey"`
+	CreatedAt   time.Time
+	ClientID    uint  `gorm:"not null;index"`
+	FromAgentID *uint `gorm:"index"`
+	ToAgentID   uint  `gorm:"not null;index"`
+	ActorID     uint  `gorm:"not null"`
+	Reason      string
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// ErasedClientName replaces the name of an erased client.
+const ErasedClientName = "Erased client"
+
+// ClientErasure is the permanent record that a client's personal data was
+// erased. Like AuditEntry it is append-only; it has no foreign keys so that
+// it outlives the client when that is purged. The counts say how many
+// records were anonymized.
+type ClientErasure struct {
+	ID            uint      `gorm:"primarykey"`
+	CreatedAt     time.Time `gorm:"index"`
+	UserID        uint      `gorm:"not null;index"` // the owner of the client
+	ClientID      uint      `gorm:"not null;index"`
+	RequestedByID uint      `gorm:"not null"`
+	Reason        string    `gorm:"type:text"`
+	Messages      int
+	Transactions  int
+	Refunds       int
+	IP            string
+	RequestID     string
+}
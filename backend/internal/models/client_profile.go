@@ -0,0 +1,83 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	ContactKindEmail     = "EMAIL"
+	ContactKindPhone     = "PHONE"
+	ContactKindWhatsApp  = "WHATSAPP"
+	ContactKindTelegram  = "TELEGRAM"
+	ContactKindInstagram = "INSTAGRAM"
+	ContactKindOther     = "OTHER"
+)
+
+const (
+	CustomFieldTypeText    = "TEXT"
+	CustomFieldTypeNumber  = "NUMBER"
+	CustomFieldTypeDate    = "DATE"
+	CustomFieldTypeBoolean = "BOOLEAN"
+	CustomFieldTypeSelect  = "SELECT"
+)
+
+// The client profile records below are deleted outright rather than
+// soft-deleted; the audit log keeps their history. They disappear with the
+// client when it is purged.
+
+// ClientContact is one way to reach a client, such as an email address or a
+// messenger handle.
+type ClientContact struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	ClientID  uint   `gorm:"not null;index"`
+	Kind      string `gorm:"not null"`
+	Value     string `gorm:"not null"`
+	Label     string
+	Primary   bool `gorm:"not null;default:false"`
+}
+
+// ClientTag is a free-form label. Tags are stored lower-case so filtering
+// does not depend on how they were typed.
+type ClientTag struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	ClientID  uint   `gorm:"not null;uniqueIndex:idx_client_tag"`
+	Tag       string `gorm:"not null;uniqueIndex:idx_client_tag;index"`
+}
+
+// CustomField defines a typed attribute that every client of the user can
+// carry. Options lists the allowed values of a SELECT field.
+type CustomField struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uint       `gorm:"not null;uniqueIndex:idx_custom_field_key"`
+	Key       string     `gorm:"not null;uniqueIndex:idx_custom_field_key"`
+	Label     string     `gorm:"not null"`
+	Type      string     `gorm:"not null"`
+	Options   StringList `gorm:"type:text"`
+}
+
+// ClientFieldValue holds a client's value of a custom field in its canonical
+// text form, e.g. "2026-01-05" for a DATE or "true" for a BOOLEAN.
+type ClientFieldValue struct {
+	ID        uint `gorm:"primarykey"`
+	UpdatedAt time.Time
+	ClientID  uint        `gorm:"not null;uniqueIndex:idx_client_field"`
+	FieldID   uint        `gorm:"not null;uniqueIndex:idx_client_field;index"`
+	Field     CustomField `gorm:"foreignKey:FieldID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Value     string      `gorm:"type:text;not null"`
+}
+
+// ClientNote is an operator's note on a client; a client's notes form its
+// timeline.
+type ClientNote struct {
+	ID        uint      `gorm:"primarykey"`
+	CreatedAt time.Time `gorm:"index"`
+	UpdatedAt time.Time
+	ClientID  uint   `gorm:"not null;index"`
+	AuthorID  uint   `gorm:"not null"`
+	Body      string `gorm:"type:text;not null"`
+}
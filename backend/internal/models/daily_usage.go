@@ -0,0 +1,16 @@
+package models
+
+const (
+	UsageLLMTokens = "llm_tokens"
+	UsageImages    = "images"
+)
+
+// DailyUsage counts what a user consumed of a metered resource on one UTC
+// day. Day is formatted as YYYY-MM-DD.
+type DailyUsage struct {
+	ID     uint   `gorm:"primarykey"`
+	UserID uint   `gorm:"not null;uniqueIndex:idx_daily_usage"`
+	Day    string `gorm:"type:char(10);not null;uniqueIndex:idx_daily_usage"`
+	Kind   string `gorm:"not null;uniqueIndex:idx_daily_usage"`
+	Amount int64  `gorm:"not null;default:0"`
+}
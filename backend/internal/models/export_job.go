@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+const (
+	ExportScopeClient  = "CLIENT"
+	ExportScopeAgent   = "AGENT"
+	ExportScopeAccount = "ACCOUNT"
+	// ExportScopePersonalData is the archive of everything stored about
+	// one client.
+	ExportScopePersonalData = "PERSONAL_DATA"
+)
+
+const (
+	ExportJobStatusPending   = "PENDING"
+	ExportJobStatusRunning   = "RUNNING"
+	ExportJobStatusCompleted = "COMPLETED"
+	ExportJobStatusFailed    = "FAILED"
+)
+
+// ExportJob renders the conversations and ledgers of a client, an agent or
+// the whole account into a file. The file is kept in the database, so any
+// process can serve the download, until ExpiresAt.
+type ExportJob struct {
+	ID          uint `gorm:"primarykey"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	UserID      uint   `gorm:"not null;index"`
+	Scope       string `gorm:"not null"`
+	ScopeID     uint   // the client or agent; zero for the account
+	Format      string `gorm:"not null"`
+	Status      string `gorm:"not null;index"`
+	Records     int    // messages, transactions and refunds in the file
+	Filename    string
+	ContentType string
+	Size        int64
+	Content     []byte `json:"-"`
+	Error       string `gorm:"type:text"`
+	StartedAt   *time.Time
+	FinishedAt  *time.Time
+	ExpiresAt   *time.Time `gorm:"index"`
+}
@@ -20,4 +20,7 @@ type Message struct {
 	Date     time.Time
 	Content  string `gorm:"type:text;not null"`
 	Type     string `gorm:"not null"`
+	// ExternalReference identifies a message imported from a chat export,
+	// so importing the same export again skips it.
+	ExternalReference string `gorm:"index"`
 }
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type OutboxEvent struct {
+	gorm.Model
+	UserID        uint   `gorm:"not null;index"`
+	Type          string `gorm:"not null;index"`
+	AggregateType string `gorm:"not null"`
+	AggregateID   uint   `gorm:"not null"`
+	Payload       string `gorm:"type:text;not null"`
+	OccurredAt    time.Time
+	DispatchedAt  *time.Time `gorm:"index"`
+}
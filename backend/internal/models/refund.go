@@ -0,0 +1,32 @@
+package models
+
+import (
+	"backend/internal/money"
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Refund struct {
+	gorm.Model
+	TransactionID     uint        `gorm:"not null;index"`
+	Transaction       Transaction `gorm:"foreignKey:TransactionID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	AmountMinor       int64       `gorm:"not null"`
+	Currency          string      `gorm:"type:char(3);not null"`
+	Reason            string      `gorm:"type:text"`
+	ExternalReference string      `gorm:"index"`
+	Date              time.Time
+}
+
+// MarshalJSON adds the exact decimal representation of the amount as "Amount".
+func (r Refund) MarshalJSON() ([]byte, error) {
+	type refund Refund
+	return json.Marshal(struct {
+		refund
+		Amount string
+	}{
+		refund: refund(r),
+		Amount: money.Format(r.AmountMinor, r.Currency),
+	})
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"database/sql/driver"
+	"time"
+)
+
+const (
+	SegmentMatchAll = "ALL"
+	SegmentMatchAny = "ANY"
+)
+
+// Segment condition fields. The message and transaction based fields are
+// evaluated against the current time, so a client's membership changes as
+// time passes.
+const (
+	SegmentFieldScore                  = "SCORE"
+	SegmentFieldName                   = "NAME"
+	SegmentFieldAgent                  = "AGENT"
+	SegmentFieldTag                    = "TAG"
+	SegmentFieldCustomField            = "CUSTOM_FIELD"
+	SegmentFieldDaysSinceClientMessage = "DAYS_SINCE_CLIENT_MESSAGE"
+	SegmentFieldDaysSinceAgentMessage  = "DAYS_SINCE_AGENT_MESSAGE"
+	SegmentFieldMessageCount           = "MESSAGE_COUNT"
+	SegmentFieldTransactionCount       = "TRANSACTION_COUNT"
+	SegmentFieldSpent                  = "SPENT"
+)
+
+const (
+	SegmentOpEq       = "EQ"
+	SegmentOpNeq      = "NEQ"
+	SegmentOpGt       = "GT"
+	SegmentOpGte      = "GTE"
+	SegmentOpLt       = "LT"
+	SegmentOpLte      = "LTE"
+	SegmentOpContains = "CONTAINS"
+	SegmentOpHas      = "HAS"
+	SegmentOpNotHas   = "NOT_HAS"
+)
+
+// Segment is a saved client filter that is evaluated on every read.
+type Segment struct {
+	ID          uint `gorm:"primarykey"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	UserID      uint              `gorm:"not null;index"`
+	Name        string            `gorm:"not null"`
+	Description string            `gorm:"type:text"`
+	Match       string            `gorm:"not null;default:'ALL'"`
+	Conditions  SegmentConditions `gorm:"type:text"`
+}
+
+// SegmentCondition compares one client attribute with Value. Key names the
+// custom field of a CUSTOM_FIELD condition. WindowDays limits MESSAGE_COUNT,
+// TRANSACTION_COUNT and SPENT to the last days; zero means all time. SPENT
+// is the net amount in Currency, given in major units.
+type SegmentCondition struct {
+	Field      string
+	Op         string
+	Value      string
+	Key        string `json:",omitempty"`
+	WindowDays int    `json:",omitempty"`
+	Currency   string `json:",omitempty"`
+}
+
+// SegmentConditions is stored as a JSON array in a text column.
+type SegmentConditions []SegmentCondition
+
+func (c SegmentConditions) Value() (driver.Value, error) {
+	return jsonValue([]SegmentCondition(c))
+}
+
+func (c *SegmentConditions) Scan(value interface{}) error {
+	return scanJSON(value, c)
+}
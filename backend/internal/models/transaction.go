@@ -1,16 +1,44 @@
 package models
 
 import (
+	"backend/internal/money"
+	"encoding/json"
 	"gorm.io/gorm"
 	"time"
 )
 
+const DefaultCurrency = "USD"
+
+const (
+	TransactionStatusPending   = "PENDING"
+	TransactionStatusCompleted = "COMPLETED"
+	TransactionStatusRefunded  = "REFUNDED"
+	TransactionStatusDisputed  = "DISPUTED"
+)
+
 type Transaction struct {
 	gorm.Model
-	AgentID  uint    `gorm:"not null"`
-	ClientID uint    `gorm:"not null"`
-	Agent    Agent   `gorm:"foreignKey:AgentID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	Client   Client  `gorm:"foreignKey:ClientID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
-	Amount   float64 `gorm:"not null;default:0"`
-	Date     time.Time
+	AgentID     uint   `gorm:"not null"`
+	ClientID    uint   `gorm:"not null"`
+	Agent       Agent  `gorm:"foreignKey:AgentID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Client      Client `gorm:"foreignKey:ClientID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	AmountMinor int64  `gorm:"not null;default:0"` // amount in the currency's minor unit, e.g. cents
+	Currency    string `gorm:"type:char(3);not null;default:'USD'"`
+	Date        time.Time
+
+	Status            string   `gorm:"not null;default:'COMPLETED';index"`
+	ExternalReference string   `gorm:"index"` // payment processor ID used for reconciliation
+	Refunds           []Refund `gorm:"foreignKey:TransactionID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// MarshalJSON adds the exact decimal representation of the amount as "Amount".
+func (t Transaction) MarshalJSON() ([]byte, error) {
+	type transaction Transaction
+	return json.Marshal(struct {
+		transaction
+		Amount string
+	}{
+		transaction: transaction(t),
+		Amount:      money.Format(t.AmountMinor, t.Currency),
+	})
 }
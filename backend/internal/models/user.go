@@ -1,6 +1,8 @@
 package models
 
 import (
+	"time"
+
 	"gorm.io/gorm"
 )
 
@@ -10,4 +12,7 @@ type User struct {
 	Password string `gorm:"not null"`
 	Email    string `gorm:"unique;not null;"`
 	Admin    bool   `gorm:"default:false"`
+
+	FailedLogins int `gorm:"not null;default:0"` // consecutive failures since the last successful login
+	LockedUntil  *time.Time
 }
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	WebhookDeliveryPending   = "PENDING"
+	WebhookDeliveryDelivered = "DELIVERED"
+	WebhookDeliveryDead      = "DEAD"
+)
+
+type WebhookSubscription struct {
+	gorm.Model
+	UserID uint   `gorm:"not null;index"`
+	User   User   `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	URL    string `gorm:"not null"`
+	Secret string `gorm:"not null"`
+	Events string `gorm:"not null;default:'*'"` // comma-separated event types, "*" for all
+	Active bool   `gorm:"not null;default:true"`
+}
+
+type WebhookDelivery struct {
+	gorm.Model
+	SubscriptionID uint                `gorm:"not null;index"`
+	Subscription   WebhookSubscription `gorm:"foreignKey:SubscriptionID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	EventID        uint                `gorm:"not null;index"`
+	Event          OutboxEvent         `gorm:"foreignKey:EventID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Status         string              `gorm:"not null;index"`
+	Attempts       int                 `gorm:"not null;default:0"`
+	NextAttemptAt  time.Time           `gorm:"index"`
+	LastAttemptAt  *time.Time
+	ResponseStatus int
+	LastError      string `gorm:"type:text"`
+}
@@ -0,0 +1,123 @@
+package money
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidAmount   = errors.New("invalid amount")
+	ErrTooManyDecimals = errors.New("amount has more decimal places than the currency allows")
+	ErrInvalidCurrency = errors.New("invalid currency code")
+)
+
+// exponents maps ISO-4217 codes to the number of minor-unit digits.
+var exponents = map[string]int{
+	"AED": 2, "ARS": 2, "AUD": 2, "BGN": 2, "BHD": 3, "BRL": 2, "CAD": 2,
+	"CHF": 2, "CLP": 0, "CNY": 2, "COP": 2, "CZK": 2, "DKK": 2, "EGP": 2,
+	"EUR": 2, "GBP": 2, "HKD": 2, "HUF": 2, "IDR": 2, "ILS": 2, "INR": 2,
+	"ISK": 0, "JOD": 3, "JPY": 0, "KRW": 0, "KWD": 3, "MDL": 2, "MXN": 2,
+	"MYR": 2, "NGN": 2, "NOK": 2, "NZD": 2, "OMR": 3, "PHP": 2, "PLN": 2,
+	"RON": 2, "RSD": 2, "SAR": 2, "SEK": 2, "SGD": 2, "THB": 2, "TND": 3,
+	"TRY": 2, "TWD": 2, "UAH": 2, "USD": 2, "VND": 0, "ZAR": 2,
+}
+
+// NormalizeCurrency upper-cases code and checks it is a supported ISO-4217 code.
+func NormalizeCurrency(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if _, ok := exponents[code]; !ok {
+		return "", ErrInvalidCurrency
+	}
+	return code, nil
+}
+
+// Exponent returns the number of minor-unit digits for a supported currency.
+func Exponent(currency string) int {
+	return exponents[currency]
+}
+
+// Parse converts a decimal string such as "-12.50" into minor units of the
+// given currency without going through floating point.
+func Parse(amount string, currency string) (int64, error) {
+	exponent, ok := exponents[currency]
+	if !ok {
+		return 0, ErrInvalidCurrency
+	}
+
+	amount = strings.TrimSpace(amount)
+	negative := false
+	switch {
+	case strings.HasPrefix(amount, "-"):
+		negative = true
+		amount = amount[1:]
+	case strings.HasPrefix(amount, "+"):
+		amount = amount[1:]
+	}
+
+	whole, fraction, hasPoint := strings.Cut(amount, ".")
+	if whole == "" && fraction == "" || hasPoint && fraction == "" {
+		return 0, ErrInvalidAmount
+	}
+	if whole == "" {
+		whole = "0"
+	}
+	if !isDigits(whole) || !isDigits(fraction) {
+		return 0, ErrInvalidAmount
+	}
+
+	fraction = strings.TrimRight(fraction, "0")
+	if len(fraction) > exponent {
+		return 0, ErrTooManyDecimals
+	}
+	fraction += strings.Repeat("0", exponent-len(fraction))
+
+	minor, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidAmount
+	}
+
+	if negative {
+		minor = -minor
+	}
+	return minor, nil
+}
+
+// Format renders minor units as a plain decimal string, e.g. 1250 USD -> "12.50".
+func Format(minor int64, currency string) string {
+	exponent := exponents[currency]
+
+	sign := ""
+	magnitude := uint64(minor)
+	if minor < 0 {
+		sign = "-"
+		magnitude = uint64(-minor)
+	}
+
+	digits := strconv.FormatUint(magnitude, 10)
+	if exponent == 0 {
+		return sign + digits
+	}
+
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+	point := len(digits) - exponent
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// FromFloat converts a legacy floating-point amount, rounding half away from
+// zero to the nearest minor unit.
+func FromFloat(amount float64, currency string) int64 {
+	return int64(math.Round(amount * math.Pow10(exponents[currency])))
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,51 @@
+package money
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		amount   string
+		currency string
+		want     int64
+		err      error
+	}{
+		{"12.50", "USD", 1250, nil},
+		{"12.5", "USD", 1250, nil},
+		{"0.1", "EUR", 10, nil},
+		{"-3", "USD", -300, nil},
+		{".99", "USD", 99, nil},
+		{"1000", "JPY", 1000, nil},
+		{"1.234", "KWD", 1234, nil},
+		{"1.230", "USD", 123, nil},
+		{"1.234", "USD", 0, ErrTooManyDecimals},
+		{"1.5", "JPY", 0, ErrTooManyDecimals},
+		{"abc", "USD", 0, ErrInvalidAmount},
+		{"1.", "USD", 0, ErrInvalidAmount},
+		{"", "USD", 0, ErrInvalidAmount},
+		{"1", "XXX", 0, ErrInvalidCurrency},
+	}
+
+	for _, tc := range cases {
+		got, err := Parse(tc.amount, tc.currency)
+		assert.Equal(t, tc.err, err, tc.amount)
+		assert.Equal(t, tc.want, got, tc.amount)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	assert.Equal(t, "12.50", Format(1250, "USD"))
+	assert.Equal(t, "0.05", Format(5, "USD"))
+	assert.Equal(t, "-0.05", Format(-5, "USD"))
+	assert.Equal(t, "1000", Format(1000, "JPY"))
+	assert.Equal(t, "1.234", Format(1234, "KWD"))
+}
+
+func TestFromFloat(t *testing.T) {
+	assert.Equal(t, int64(1999), FromFloat(19.99, "USD"))
+	assert.Equal(t, int64(30), FromFloat(0.1+0.2, "USD"))
+	assert.Equal(t, int64(-1050), FromFloat(-10.5, "USD"))
+}
@@ -0,0 +1,41 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiter(t *testing.T) {
+	now := time.Date(2026, time.March, 2, 12, 0, 0, 0, time.UTC)
+	limiter := New(60, 2, func() time.Time { return now })
+
+	ok, _ := limiter.Allow("a")
+	assert.True(t, ok)
+	ok, _ = limiter.Allow("a")
+	assert.True(t, ok)
+
+	ok, wait := limiter.Allow("a")
+	assert.False(t, ok, "burst is used up")
+	assert.Equal(t, time.Second, wait)
+
+	ok, _ = limiter.Allow("b")
+	assert.True(t, ok, "keys have separate buckets")
+
+	now = now.Add(time.Second)
+	ok, _ = limiter.Allow("a")
+	assert.True(t, ok, "a token refilled, and the rejected call did not consume one")
+
+	now = now.Add(time.Hour)
+	limiter.Allow("c")
+	assert.Len(t, limiter.buckets, 1, "idle buckets are swept")
+}
+
+func TestLimiterDisabled(t *testing.T) {
+	limiter := New(0, 1, time.Now)
+	for range 10 {
+		ok, _ := limiter.Allow("a")
+		assert.True(t, ok)
+	}
+}
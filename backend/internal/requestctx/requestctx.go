@@ -0,0 +1,35 @@
+package requestctx
+
+import (
+	"context"
+)
+
+type contextKey string
+
+const metadataKey contextKey = "request-metadata"
+
+// Metadata describes the HTTP request a service call originates from. It is
+// attached by middleware so that services can record it without depending on
+// gin.
+type Metadata struct {
+	RequestID string
+	IP        string
+	UserAgent string
+	UserID    uint
+}
+
+func WithMetadata(ctx context.Context, metadata Metadata) context.Context {
+	return context.WithValue(ctx, metadataKey, metadata)
+}
+
+func FromContext(ctx context.Context) Metadata {
+	metadata, _ := ctx.Value(metadataKey).(Metadata)
+	return metadata
+}
+
+// WithUserID records the authenticated user on the request metadata.
+func WithUserID(ctx context.Context, userID uint) context.Context {
+	metadata := FromContext(ctx)
+	metadata.UserID = userID
+	return WithMetadata(ctx, metadata)
+}
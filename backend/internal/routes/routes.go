@@ -4,89 +4,325 @@ import (
 	"backend/internal/config"
 	"backend/internal/handlers"
 	"backend/internal/middleware"
+	"crypto/subtle"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-func RegisterAuthRoutes(router *gin.Engine, h *handlers.AuthHandler, m *middleware.AuthMiddleware) {
+// APIHandlers bundles the handlers an API version is built from. A new
+// version reuses the handlers it does not change and swaps in the ones it
+// does.
+type APIHandlers struct {
+	Auth        *handlers.AuthHandler
+	Agent       *handlers.AgentHandler
+	Client      *handlers.ClientHandler
+	Profile     *handlers.ClientProfileHandler
+	Timeline    *handlers.TimelineHandler
+	CustomField *handlers.CustomFieldHandler
+	Segment     *handlers.SegmentHandler
+	Bulk        *handlers.BulkHandler
+	Export      *handlers.ExportHandler
+	Erasure     *handlers.ErasureHandler
+	Transaction *handlers.TransactionHandler
+	Message     *handlers.MessageHandler
+	Webhook     *handlers.WebhookHandler
+	Audit       *handlers.AuditHandler
+	Analytics   *handlers.AnalyticsHandler
+	LLM         *handlers.LLMHandler
+	SD          *handlers.SDHandler
+}
+
+// RegisterAPIV1 mounts every v1 route group on router.
+func RegisterAPIV1(router gin.IRouter, h *APIHandlers, m *middleware.AuthMiddleware, l *middleware.RateLimits) {
+	RegisterAuthRoutes(router, h.Auth, m, l)
+	RegisterAgentRoutes(router, h.Agent, m, l)
+	RegisterClientRoutes(router, h.Client, m, l)
+	RegisterClientProfileRoutes(router, h.Profile, m, l)
+	RegisterTimelineRoutes(router, h.Timeline, m, l)
+	RegisterCustomFieldRoutes(router, h.CustomField, m, l)
+	RegisterSegmentRoutes(router, h.Segment, m, l)
+	RegisterBulkRoutes(router, h.Bulk, m, l)
+	RegisterExportRoutes(router, h.Export, m, l)
+	RegisterErasureRoutes(router, h.Erasure, m, l)
+	RegisterTransactionRoutes(router, h.Transaction, m, l)
+	RegisterMessageRoutes(router, h.Message, m, l)
+	RegisterWebhookRoutes(router, h.Webhook, m, l)
+	RegisterAuditRoutes(router, h.Audit, m, l)
+	RegisterAnalyticsRoutes(router, h.Analytics, m, l)
+	RegisterLLMRoutes(router, h.LLM, m, l)
+	RegisterSDRoutes(router, h.SD, m, l)
+}
+
+// RegisterLegacyAPI mounts the route groups that predate /api/v1 on the
+// deprecated unversioned root. Routes added since are only served under
+// /api/v1.
+func RegisterLegacyAPI(router gin.IRouter, h *APIHandlers, m *middleware.AuthMiddleware, l *middleware.RateLimits) {
+	RegisterAuthRoutes(router, h.Auth, m, l)
+	RegisterAgentRoutes(router, h.Agent, m, l)
+	RegisterClientRoutes(router, h.Client, m, l)
+	RegisterTransactionRoutes(router, h.Transaction, m, l)
+	RegisterMessageRoutes(router, h.Message, m, l)
+	RegisterLLMRoutes(router, h.LLM, m, l)
+	RegisterSDRoutes(router, h.SD, m, l)
+}
+
+func RegisterAuthRoutes(router gin.IRouter, h *handlers.AuthHandler, m *middleware.AuthMiddleware, l *middleware.RateLimits) {
 	authGroup := router.Group("/auth")
 	{
-		authGroup.POST("/register", h.Register)
-		authGroup.POST("/login", h.Login)
+		authGroup.POST("/register", l.Auth(), h.Register)
+		authGroup.POST("/login", l.Auth(), h.Login)
 		authGroup.POST("/logout", h.Logout)
 	}
 
 	protectedGroup := router.Group("/protected")
-	protectedGroup.Use(m.JWTAuth())
+	protectedGroup.Use(m.JWTAuth(), l.PerUser())
 	{
 		protectedGroup.GET("", h.Protected)
 	}
 }
 
-func RegisterAgentRoutes(router *gin.Engine, h *handlers.AgentHandler, m *middleware.AuthMiddleware) {
+func RegisterAgentRoutes(router gin.IRouter, h *handlers.AgentHandler, m *middleware.AuthMiddleware, l *middleware.RateLimits) {
 	agentGroup := router.Group("/agents")
-	agentGroup.Use(m.JWTAuth())
+	agentGroup.Use(m.JWTAuth(), l.PerUser())
 	{
 		agentGroup.GET("/:id", h.GetAgentByID)
 		agentGroup.GET("", h.GetAllAgents)
 		agentGroup.POST("", h.CreateAgent)
 		agentGroup.PUT("/:id", h.UpdateAgent)
 		agentGroup.DELETE("/:id", h.DeleteAgent)
+		agentGroup.GET("/trash", h.GetDeletedAgents)
+		agentGroup.POST("/:id/restore", h.RestoreAgent)
+		agentGroup.DELETE("/:id/purge", h.PurgeAgent)
 	}
 }
 
-func RegisterClientRoutes(router *gin.Engine, h *handlers.ClientHandler, m *middleware.AuthMiddleware) {
+func RegisterClientRoutes(router gin.IRouter, h *handlers.ClientHandler, m *middleware.AuthMiddleware, l *middleware.RateLimits) {
 	clientGroup := router.Group("/clients")
-	clientGroup.Use(m.JWTAuth())
+	clientGroup.Use(m.JWTAuth(), l.PerUser())
 	{
+		clientGroup.GET("", h.ListClients)
 		clientGroup.GET("/:id", h.GetClientByID)
 		clientGroup.GET("/agent/:agent_id", h.GetClientsByAgentID)
 		clientGroup.POST("", h.CreateClient)
 		clientGroup.PUT("/:id", h.UpdateClient)
 		clientGroup.DELETE("/:id", h.DeleteClient)
+		clientGroup.GET("/trash", h.GetDeletedClients)
+		clientGroup.POST("/:id/restore", h.RestoreClient)
+		clientGroup.DELETE("/:id/purge", h.PurgeClient)
+		clientGroup.POST("/:id/transfer", h.TransferClient)
+		clientGroup.GET("/:id/assignments", h.GetAssignments)
+	}
+}
+
+func RegisterClientProfileRoutes(router gin.IRouter, h *handlers.ClientProfileHandler, m *middleware.AuthMiddleware, l *middleware.RateLimits) {
+	profileGroup := router.Group("/clients/:id")
+	profileGroup.Use(m.JWTAuth(), l.PerUser())
+	{
+		profileGroup.GET("/contacts", h.GetContacts)
+		profileGroup.POST("/contacts", h.CreateContact)
+		profileGroup.PUT("/contacts/:contact_id", h.UpdateContact)
+		profileGroup.DELETE("/contacts/:contact_id", h.DeleteContact)
+		profileGroup.GET("/tags", h.GetTags)
+		profileGroup.POST("/tags", h.AddTags)
+		profileGroup.PUT("/tags", h.SetTags)
+		profileGroup.DELETE("/tags/:tag", h.RemoveTag)
+		profileGroup.GET("/fields", h.GetFieldValues)
+		profileGroup.PUT("/fields", h.SetFieldValues)
+		profileGroup.GET("/notes", h.GetNotes)
+		profileGroup.POST("/notes", h.CreateNote)
+		profileGroup.PUT("/notes/:note_id", h.UpdateNote)
+		profileGroup.DELETE("/notes/:note_id", h.DeleteNote)
+	}
+}
+
+func RegisterTimelineRoutes(router gin.IRouter, h *handlers.TimelineHandler, m *middleware.AuthMiddleware, l *middleware.RateLimits) {
+	timelineGroup := router.Group("/clients/:id")
+	timelineGroup.Use(m.JWTAuth(), l.PerUser())
+	{
+		timelineGroup.GET("/timeline", h.GetTimeline)
 	}
 }
 
-func RegisterTransactionRoutes(router *gin.Engine, h *handlers.TransactionHandler, m *middleware.AuthMiddleware) {
+func RegisterCustomFieldRoutes(router gin.IRouter, h *handlers.CustomFieldHandler, m *middleware.AuthMiddleware, l *middleware.RateLimits) {
+	customFieldGroup := router.Group("/custom-fields")
+	customFieldGroup.Use(m.JWTAuth(), l.PerUser())
+	{
+		customFieldGroup.GET("", h.GetFields)
+		customFieldGroup.POST("", h.CreateField)
+		customFieldGroup.PUT("/:id", h.UpdateField)
+		customFieldGroup.DELETE("/:id", h.DeleteField)
+	}
+}
+
+func RegisterSegmentRoutes(router gin.IRouter, h *handlers.SegmentHandler, m *middleware.AuthMiddleware, l *middleware.RateLimits) {
+	segmentGroup := router.Group("/segments")
+	segmentGroup.Use(m.JWTAuth(), l.PerUser())
+	{
+		segmentGroup.GET("", h.GetSegments)
+		segmentGroup.POST("", h.CreateSegment)
+		segmentGroup.GET("/:id", h.GetSegmentByID)
+		segmentGroup.PUT("/:id", h.UpdateSegment)
+		segmentGroup.DELETE("/:id", h.DeleteSegment)
+		segmentGroup.GET("/:id/clients", h.GetSegmentClients)
+		segmentGroup.GET("/:id/export", h.ExportSegmentClients)
+	}
+}
+
+func RegisterBulkRoutes(router gin.IRouter, h *handlers.BulkHandler, m *middleware.AuthMiddleware, l *middleware.RateLimits) {
+	bulkGroup := router.Group("/bulk")
+	bulkGroup.Use(m.JWTAuth(), l.PerUser())
+	{
+		bulkGroup.POST("/clients", h.BulkClients)
+		bulkGroup.POST("/agents", h.BulkAgents)
+		bulkGroup.GET("/jobs", h.GetJobs)
+		bulkGroup.GET("/jobs/:id", h.GetJobByID)
+	}
+}
+
+func RegisterExportRoutes(router gin.IRouter, h *handlers.ExportHandler, m *middleware.AuthMiddleware, l *middleware.RateLimits) {
+	exportGroup := router.Group("/exports")
+	exportGroup.Use(m.JWTAuth(), l.PerUser())
+	{
+		exportGroup.POST("", h.CreateExport)
+		exportGroup.GET("", h.GetExports)
+		exportGroup.GET("/:id", h.GetExportByID)
+		exportGroup.GET("/:id/download", h.DownloadExport)
+	}
+}
+
+func RegisterErasureRoutes(router gin.IRouter, h *handlers.ErasureHandler, m *middleware.AuthMiddleware, l *middleware.RateLimits) {
+	clientGroup := router.Group("/clients/:id")
+	clientGroup.Use(m.JWTAuth(), l.PerUser())
+	{
+		clientGroup.POST("/export-personal-data", h.ExportPersonalData)
+		clientGroup.POST("/erase", h.EraseClient)
+	}
+
+	erasureGroup := router.Group("/erasures")
+	erasureGroup.Use(m.JWTAuth(), l.PerUser())
+	{
+		erasureGroup.GET("", h.GetErasures)
+	}
+}
+
+func RegisterTransactionRoutes(router gin.IRouter, h *handlers.TransactionHandler, m *middleware.AuthMiddleware, l *middleware.RateLimits) {
 	transactionGroup := router.Group("/transactions")
-	transactionGroup.Use(m.JWTAuth())
+	transactionGroup.Use(m.JWTAuth(), l.PerUser())
 	{
 		transactionGroup.GET("/:id", h.GetTransactionByID)
+		transactionGroup.GET("/totals", h.GetTotals)
+		transactionGroup.POST("/reconcile", h.Reconcile)
 		transactionGroup.GET("/client/:client_id", h.GetTransactionsByClientID)
 		transactionGroup.GET("/agent/:agent_id", h.GetTransactionsByAgentID)
 		transactionGroup.GET("/agent/:agent_id/client/:client_id", h.GetTransactionsByAgentIDAndClientID)
 		transactionGroup.POST("", h.CreateTransaction)
 		transactionGroup.PUT("/:id", h.UpdateTransaction)
 		transactionGroup.DELETE("/:id", h.DeleteTransaction)
+		transactionGroup.GET("/trash", h.GetDeletedTransactions)
+		transactionGroup.POST("/:id/restore", h.RestoreTransaction)
+		transactionGroup.DELETE("/:id/purge", h.PurgeTransaction)
+		transactionGroup.PUT("/:id/status", h.UpdateTransactionStatus)
+		transactionGroup.GET("/:id/refunds", h.GetRefunds)
+		transactionGroup.POST("/:id/refunds", h.CreateRefund)
 	}
 }
 
-func RegisterMessageRoutes(router *gin.Engine, h *handlers.MessageHandler, m *middleware.AuthMiddleware) {
+func RegisterMessageRoutes(router gin.IRouter, h *handlers.MessageHandler, m *middleware.AuthMiddleware, l *middleware.RateLimits) {
 	messageGroup := router.Group("/messages")
-	messageGroup.Use(m.JWTAuth())
+	messageGroup.Use(m.JWTAuth(), l.PerUser())
 	{
 		messageGroup.GET("/:id", h.GetMessageByID)
 		messageGroup.GET("/client/:client_id", h.GetMessageByClientID)
 		messageGroup.GET("/agent/:agent_id", h.GetMessageByAgentID)
 		messageGroup.GET("/agent/:agent_id/client/:client_id", h.GetMessagesByAgentIDAndClientID)
 		messageGroup.POST("", h.CreateMessage)
+		messageGroup.POST("/import", h.ImportConversation)
 		messageGroup.PUT("/:id", h.UpdateMessage)
 		messageGroup.DELETE("/:id", h.DeleteMessage)
+		messageGroup.GET("/trash", h.GetDeletedMessages)
+		messageGroup.POST("/:id/restore", h.RestoreMessage)
+		messageGroup.DELETE("/:id/purge", h.PurgeMessage)
 	}
 }
 
-func RegisterLLMRoutes(router *gin.Engine) {
+func RegisterWebhookRoutes(router gin.IRouter, h *handlers.WebhookHandler, m *middleware.AuthMiddleware, l *middleware.RateLimits) {
+	webhookGroup := router.Group("/webhooks")
+	webhookGroup.Use(m.JWTAuth(), l.PerUser())
+	{
+		webhookGroup.GET("", h.GetAllSubscriptions)
+		webhookGroup.GET("/:id", h.GetSubscriptionByID)
+		webhookGroup.POST("", h.CreateSubscription)
+		webhookGroup.PUT("/:id", h.UpdateSubscription)
+		webhookGroup.DELETE("/:id", h.DeleteSubscription)
+		webhookGroup.GET("/deliveries", h.GetDeliveries)
+		webhookGroup.GET("/dead-letters", h.GetDeadLetters)
+		webhookGroup.POST("/deliveries/:id/retry", h.RetryDelivery)
+	}
+}
+
+func RegisterAuditRoutes(router gin.IRouter, h *handlers.AuditHandler, m *middleware.AuthMiddleware, l *middleware.RateLimits) {
+	auditGroup := router.Group("/audit")
+	auditGroup.Use(m.JWTAuth(), l.PerUser(), m.RequireAdmin())
+	{
+		auditGroup.GET("", h.GetEntries)
+		auditGroup.GET("/export", h.ExportEntries)
+	}
+}
+
+func RegisterAnalyticsRoutes(router gin.IRouter, h *handlers.AnalyticsHandler, m *middleware.AuthMiddleware, l *middleware.RateLimits) {
+	analyticsGroup := router.Group("/analytics")
+	analyticsGroup.Use(m.JWTAuth(), l.PerUser())
+	{
+		analyticsGroup.GET("/messages", h.GetMessageVolume)
+		analyticsGroup.GET("/reply-gaps", h.GetReplyGaps)
+		analyticsGroup.GET("/revenue", h.GetRevenue)
+		analyticsGroup.GET("/clients", h.GetClientEngagement)
+		analyticsGroup.GET("/agents", h.GetAgentSummaries)
+	}
+}
+
+// RegisterMetricsRoutes exposes the Prometheus registry at /metrics. Scrapers
+// must send the metrics token as a bearer token; without a configured token
+// the endpoint is only registered when it is explicitly made public.
+func RegisterMetricsRoutes(router *gin.Engine, registry *prometheus.Registry, cfg *config.Config) {
+	if cfg.MetricsToken == "" && !cfg.MetricsPublic {
+		return
+	}
+
+	handler := promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry})
+	expected := []byte("Bearer " + cfg.MetricsToken)
+	router.GET("/metrics", func(c *gin.Context) {
+		if cfg.MetricsToken != "" && subtle.ConstantTimeCompare([]byte(c.GetHeader("Authorization")), expected) != 1 {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(c.Writer, c.Request)
+	})
+}
+
+// RegisterOpenAPIRoutes serves the API specification at /openapi.json below
+// router.
+func RegisterOpenAPIRoutes(router gin.IRouter, spec []byte) {
+	router.GET("/openapi.json", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json", spec)
+	})
+}
+
+func RegisterLLMRoutes(router gin.IRouter, h *handlers.LLMHandler, m *middleware.AuthMiddleware, l *middleware.RateLimits) {
 	llmGroup := router.Group("/llm")
+	llmGroup.Use(m.JWTAuth(), l.PerUser(), l.AI())
 	{
-		llmGroup.POST("/ask", handlers.AskLLM)
+		llmGroup.POST("/ask", h.AskLLM)
 	}
 }
 
-func RegisterSDRoutes(router *gin.Engine, cfg *config.Config) {
+func RegisterSDRoutes(router gin.IRouter, h *handlers.SDHandler, m *middleware.AuthMiddleware, l *middleware.RateLimits) {
 	sdGroup := router.Group("/sd")
+	sdGroup.Use(m.JWTAuth(), l.PerUser(), l.AI())
 	{
-		sdGroup.POST("/generate", func(c *gin.Context) {
-			handlers.TextToImage(c, cfg)
-		})
+		sdGroup.POST("/generate", h.TextToImage)
 	}
 }
@@ -5,6 +5,8 @@ import (
 	"backend/pkg/database"
 	"context"
 	"errors"
+	"net/http"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -15,14 +17,19 @@ type AgentService interface {
 	CreateAgent(ctx context.Context, agent *models.Agent, userID uint) (*models.Agent, error)
 	UpdateAgent(ctx context.Context, agent *models.Agent, userID uint) (*models.Agent, error)
 	DeleteAgent(ctx context.Context, id uint, userID uint) error
+	GetDeletedAgents(ctx context.Context, userID uint) ([]*models.Agent, error)
+	RestoreAgent(ctx context.Context, id uint, userID uint) (*models.Agent, error)
+	PurgeAgent(ctx context.Context, id uint, userID uint) error
+	SetAgentStatus(ctx context.Context, id uint, status string, userID uint) (*models.Agent, error)
 }
 
 type agentServiceImpl struct {
-	db *database.DB
+	db  *database.DB
+	now func() time.Time
 }
 
-func NewAgentService(db *database.DB) AgentService {
-	return &agentServiceImpl{db: db}
+func NewAgentService(db *database.DB, now func() time.Time) AgentService {
+	return &agentServiceImpl{db: db, now: now}
 }
 
 func (a agentServiceImpl) GetAgentByID(ctx context.Context, id uint, userID uint) (*models.Agent, error) {
@@ -65,10 +72,19 @@ func (a agentServiceImpl) CreateAgent(ctx context.Context, agent *models.Agent,
 	}
 
 	agent.UserID = userID
+	if agent.Status == "" {
+		agent.Status = models.AgentStatusActive
+	}
 
-	err = a.db.WithContext(ctx).
-		Create(agent).
-		Error
+	err = a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(agent).Error; err != nil {
+			return err
+		}
+		if err := recordEvent(tx, userID, EventAgentCreated, agent.ID, agent); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, userID, models.AuditActionCreate, AuditEntityAgent, agent.ID, nil, agent)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -86,10 +102,16 @@ func (a agentServiceImpl) UpdateAgent(ctx context.Context, agent *models.Agent,
 		return nil, ErrUnauthorized
 	}
 
-	err = a.db.WithContext(ctx).
-		Model(existingAgent).
-		Updates(agent).
-		Error
+	before := *existingAgent
+	err = a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(existingAgent).Updates(agent).Error; err != nil {
+			return err
+		}
+		if err := recordEvent(tx, userID, EventAgentUpdated, existingAgent.ID, existingAgent); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, userID, models.AuditActionUpdate, AuditEntityAgent, existingAgent.ID, &before, existingAgent)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -107,9 +129,15 @@ func (a agentServiceImpl) DeleteAgent(ctx context.Context, id uint, userID uint)
 		return ErrUnauthorized
 	}
 
-	err = a.db.WithContext(ctx).
-		Delete(existingAgent).
-		Error
+	err = a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := softDeleteAgentTree(tx, existingAgent, a.now()); err != nil {
+			return err
+		}
+		if err := recordEvent(tx, userID, EventAgentDeleted, existingAgent.ID, existingAgent); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, userID, models.AuditActionDelete, AuditEntityAgent, existingAgent.ID, existingAgent, nil)
+	})
 	if err != nil {
 		return err
 	}
@@ -117,12 +145,104 @@ func (a agentServiceImpl) DeleteAgent(ctx context.Context, id uint, userID uint)
 	return nil
 }
 
+func (a agentServiceImpl) GetDeletedAgents(ctx context.Context, userID uint) ([]*models.Agent, error) {
+	var agents []*models.Agent
+	err := a.db.WithContext(ctx).
+		Unscoped().
+		Where("user_id = ? AND deleted_at IS NOT NULL", userID).
+		Order("deleted_at desc").
+		Find(&agents).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return agents, nil
+}
+
+// RestoreAgent undeletes an agent together with the clients, messages and
+// transactions that were deleted with it.
+func (a agentServiceImpl) RestoreAgent(ctx context.Context, id uint, userID uint) (*models.Agent, error) {
+	deletedAgent, err := findDeletedAgent(a.db.WithContext(ctx), id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := restoreAgentTree(tx, deletedAgent); err != nil {
+			return err
+		}
+		if err := recordEvent(tx, userID, EventAgentRestored, deletedAgent.ID, deletedAgent); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, userID, models.AuditActionRestore, AuditEntityAgent, deletedAgent.ID, nil, deletedAgent)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return deletedAgent, nil
+}
+
+// PurgeAgent permanently removes a soft-deleted agent, its clients and all of
+// their data.
+func (a agentServiceImpl) PurgeAgent(ctx context.Context, id uint, userID uint) error {
+	deletedAgent, err := findDeletedAgent(a.db.WithContext(ctx), id, userID)
+	if err != nil {
+		return err
+	}
+
+	return a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := purgeAgentTree(tx, deletedAgent.ID); err != nil {
+			return err
+		}
+		if err := recordEvent(tx, userID, EventAgentPurged, deletedAgent.ID, deletedAgent); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, userID, models.AuditActionPurge, AuditEntityAgent, deletedAgent.ID, deletedAgent, nil)
+	})
+}
+
 var (
-	ErrAgentNotFound                = errors.New("agent not found")
-	ErrAgentAlreadyExists           = errors.New("agent already exists")
-	ErrUnauthorized                 = errors.New("unauthorized access")
-	ErrAgentIDRequired              = errors.New("agent ID is required")
-	ErrInvalidAgentID               = errors.New("agent ID is invalid")
-	ErrAgentNameRequired            = errors.New("agent name is required")
-	ErrAgentCharacteristicsRequired = errors.New("agent characteristics are required")
+	ErrAgentNotFound                = NewError(http.StatusNotFound, "agent_not_found", "agent not found")
+	ErrAgentAlreadyExists           = NewError(http.StatusConflict, "agent_already_exists", "agent already exists")
+	ErrUnauthorized                 = NewError(http.StatusUnauthorized, "unauthorized", "unauthorized access")
+	ErrAgentIDRequired              = NewError(http.StatusBadRequest, "agent_id_required", "agent ID is required")
+	ErrInvalidAgentID               = NewError(http.StatusBadRequest, "invalid_agent_id", "agent ID is invalid")
+	ErrAgentNameRequired            = NewError(http.StatusBadRequest, "agent_name_required", "agent name is required")
+	ErrAgentCharacteristicsRequired = NewError(http.StatusBadRequest, "agent_characteristics_required", "agent characteristics are required")
+	ErrInvalidAgentStatus           = NewError(http.StatusBadRequest, "invalid_agent_status", "agent status must be ACTIVE, PAUSED or RETIRED")
 )
+
+// SetAgentStatus moves an agent between ACTIVE, PAUSED and RETIRED.
+func (a agentServiceImpl) SetAgentStatus(ctx context.Context, id uint, status string, userID uint) (*models.Agent, error) {
+	switch status {
+	case models.AgentStatusActive, models.AgentStatusPaused, models.AgentStatusRetired:
+	default:
+		return nil, ErrInvalidAgentStatus
+	}
+
+	existingAgent, err := a.GetAgentByID(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if existingAgent.Status == status {
+		return existingAgent, nil
+	}
+
+	before := *existingAgent
+	err = a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(existingAgent).Update("status", status).Error; err != nil {
+			return err
+		}
+		if err := recordEvent(tx, userID, EventAgentUpdated, existingAgent.ID, existingAgent); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, userID, models.AuditActionUpdate, AuditEntityAgent, existingAgent.ID, &before, existingAgent)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return existingAgent, nil
+}
@@ -0,0 +1,557 @@
+package services
+
+import (
+	"backend/internal/models"
+	"backend/internal/money"
+	"backend/pkg/database"
+	"context"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AnalyticsFilter narrows analytics to one agent or client and to a date
+// range. From is inclusive and To is exclusive; zero values are ignored.
+type AnalyticsFilter struct {
+	AgentID  uint
+	ClientID uint
+	From     time.Time
+	To       time.Time
+}
+
+type DailyMessageCount struct {
+	Day       string `json:"day"` // YYYY-MM-DD in UTC
+	Direction string `json:"direction"`
+	Count     int64  `json:"count"`
+}
+
+type WeekdayReplyGap struct {
+	Weekday       int     `json:"weekday"` // 0 is Sunday
+	Replies       int64   `json:"replies"`
+	MedianSeconds float64 `json:"median_seconds"`
+}
+
+// ReplyGapStats describes how long agents take to answer a client. A reply is
+// an agent message that directly follows a client message in the same
+// conversation.
+type ReplyGapStats struct {
+	Replies       int64              `json:"replies"`
+	MedianSeconds float64            `json:"median_seconds"`
+	ByWeekday     []*WeekdayReplyGap `json:"by_weekday"`
+}
+
+type RevenuePoint struct {
+	Day           string `json:"day"`
+	Currency      string `json:"currency"`
+	Count         int64  `json:"count"`
+	TotalMinor    int64  `json:"total_minor"`
+	RefundedMinor int64  `json:"refunded_minor"`
+	NetMinor      int64  `json:"net_minor"`
+	Total         string `json:"total"`
+	Refunded      string `json:"refunded"`
+	Net           string `json:"net"`
+}
+
+type ClientEngagement struct {
+	ClientID               uint    `json:"client_id"`
+	AgentID                uint    `json:"agent_id"`
+	Name                   string  `json:"name"`
+	Score                  float64 `json:"score"`
+	AgentMessages          int64   `json:"agent_messages"`
+	ClientMessages         int64   `json:"client_messages"`
+	SymmetryRatio          float64 `json:"symmetry_ratio"` // agent messages per client message
+	Transactions           int64   `json:"transactions"`
+	TransactionsPerMessage float64 `json:"transactions_per_message"`
+	MedianReplySeconds     float64 `json:"median_reply_seconds"`
+}
+
+type AgentSummary struct {
+	AgentID            uint             `json:"agent_id"`
+	Name               string           `json:"name"`
+	Clients            int64            `json:"clients"`
+	MessagesSent       int64            `json:"messages_sent"`
+	MessagesReceived   int64            `json:"messages_received"`
+	Transactions       int64            `json:"transactions"`
+	MedianReplySeconds float64          `json:"median_reply_seconds"`
+	Revenue            []*CurrencyTotal `json:"revenue" gorm:"-"`
+}
+
+type AnalyticsService interface {
+	GetMessageVolume(ctx context.Context, filter AnalyticsFilter, userID uint) ([]*DailyMessageCount, error)
+	GetReplyGaps(ctx context.Context, filter AnalyticsFilter, userID uint) (*ReplyGapStats, error)
+	GetRevenue(ctx context.Context, filter AnalyticsFilter, userID uint) ([]*RevenuePoint, error)
+	GetClientEngagement(ctx context.Context, filter AnalyticsFilter, userID uint) ([]*ClientEngagement, error)
+	GetAgentSummaries(ctx context.Context, filter AnalyticsFilter, userID uint) ([]*AgentSummary, error)
+}
+
+type analyticsServiceImpl struct {
+	db            *database.DB
+	agentService  AgentService
+	clientService ClientService
+}
+
+func NewAnalyticsService(db *database.DB, agentService AgentService, clientService ClientService) AnalyticsService {
+	return &analyticsServiceImpl{
+		db:            db,
+		agentService:  agentService,
+		clientService: clientService,
+	}
+}
+
+// GetMessageVolume counts messages per day and direction.
+func (a *analyticsServiceImpl) GetMessageVolume(ctx context.Context, filter AnalyticsFilter, userID uint) ([]*DailyMessageCount, error) {
+	if err := a.checkFilter(ctx, filter, userID); err != nil {
+		return nil, err
+	}
+
+	counts := []*DailyMessageCount{}
+	err := a.messageScope(ctx, filter, userID).
+		Select("strftime('%Y-%m-%d', messages.date) AS day, messages.type AS direction, COUNT(*) AS count").
+		Group("day, direction").
+		Order("day, direction").
+		Scan(&counts).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// GetReplyGaps computes the median reply gap overall and per weekday of the
+// reply.
+func (a *analyticsServiceImpl) GetReplyGaps(ctx context.Context, filter AnalyticsFilter, userID uint) (*ReplyGapStats, error) {
+	if err := a.checkFilter(ctx, filter, userID); err != nil {
+		return nil, err
+	}
+
+	stats := &ReplyGapStats{ByWeekday: []*WeekdayReplyGap{}}
+
+	var overall []*groupedMedian
+	if err := a.replyGapMedians(ctx, filter, userID, "0").Scan(&overall).Error; err != nil {
+		return nil, err
+	}
+	if len(overall) > 0 {
+		stats.Replies = overall[0].Replies
+		stats.MedianSeconds = overall[0].MedianSeconds
+	}
+
+	var byWeekday []*groupedMedian
+	if err := a.replyGapMedians(ctx, filter, userID, "weekday").Scan(&byWeekday).Error; err != nil {
+		return nil, err
+	}
+	for _, median := range byWeekday {
+		stats.ByWeekday = append(stats.ByWeekday, &WeekdayReplyGap{
+			Weekday:       int(median.GroupKey),
+			Replies:       median.Replies,
+			MedianSeconds: median.MedianSeconds,
+		})
+	}
+
+	return stats, nil
+}
+
+// GetRevenue returns settled revenue per day and currency. Refunds are
+// counted on the day they were issued.
+func (a *analyticsServiceImpl) GetRevenue(ctx context.Context, filter AnalyticsFilter, userID uint) ([]*RevenuePoint, error) {
+	if err := a.checkFilter(ctx, filter, userID); err != nil {
+		return nil, err
+	}
+
+	var gross []*RevenuePoint
+	err := a.transactionScope(ctx, filter, userID).
+		Select("strftime('%Y-%m-%d', transactions.date) AS day, transactions.currency AS currency, COUNT(*) AS count, SUM(transactions.amount_minor) AS total_minor").
+		Group("day, transactions.currency").
+		Scan(&gross).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	var refunds []*RevenuePoint
+	err = a.refundScope(ctx, filter, userID).
+		Select("strftime('%Y-%m-%d', refunds.date) AS day, refunds.currency AS currency, SUM(refunds.amount_minor) AS refunded_minor").
+		Group("day, refunds.currency").
+		Scan(&refunds).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	points := map[[2]string]*RevenuePoint{}
+	for _, point := range gross {
+		points[[2]string{point.Day, point.Currency}] = point
+	}
+	for _, refund := range refunds {
+		key := [2]string{refund.Day, refund.Currency}
+		if point, ok := points[key]; ok {
+			point.RefundedMinor = refund.RefundedMinor
+			continue
+		}
+		points[key] = refund
+	}
+
+	series := make([]*RevenuePoint, 0, len(points))
+	for _, point := range points {
+		point.NetMinor = point.TotalMinor - point.RefundedMinor
+		point.Total = money.Format(point.TotalMinor, point.Currency)
+		point.Refunded = money.Format(point.RefundedMinor, point.Currency)
+		point.Net = money.Format(point.NetMinor, point.Currency)
+		series = append(series, point)
+	}
+	sort.Slice(series, func(i, j int) bool {
+		if series[i].Day != series[j].Day {
+			return series[i].Day < series[j].Day
+		}
+		return series[i].Currency < series[j].Currency
+	})
+
+	return series, nil
+}
+
+// GetClientEngagement returns message, transaction and reply statistics for
+// every client matching the filter, ordered by score.
+func (a *analyticsServiceImpl) GetClientEngagement(ctx context.Context, filter AnalyticsFilter, userID uint) ([]*ClientEngagement, error) {
+	if err := a.checkFilter(ctx, filter, userID); err != nil {
+		return nil, err
+	}
+
+	engagement := []*ClientEngagement{}
+	clients := a.db.WithContext(ctx).
+		Model(&models.Client{}).
+		Joins("JOIN agents ON agents.id = clients.agent_id AND agents.deleted_at IS NULL").
+		Where("agents.user_id = ?", userID)
+	if filter.AgentID != 0 {
+		clients = clients.Where("clients.agent_id = ?", filter.AgentID)
+	}
+	if filter.ClientID != 0 {
+		clients = clients.Where("clients.id = ?", filter.ClientID)
+	}
+	err := clients.
+		Select("clients.id AS client_id, clients.agent_id AS agent_id, clients.name AS name, clients.score AS score").
+		Order("clients.score DESC, clients.id").
+		Scan(&engagement).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []*directionCounts
+	err = a.messageScope(ctx, filter, userID).
+		Select("messages.client_id AS group_key, " + directionCountColumns).
+		Group("messages.client_id").
+		Scan(&messages).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	var transactions []*groupedCount
+	err = a.transactionScope(ctx, filter, userID).
+		Select("transactions.client_id AS group_key, COUNT(*) AS count").
+		Group("transactions.client_id").
+		Scan(&transactions).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	var replies []*groupedMedian
+	if err := a.replyGapMedians(ctx, filter, userID, "client_id").Scan(&replies).Error; err != nil {
+		return nil, err
+	}
+
+	messagesByClient := map[uint]*directionCounts{}
+	for _, count := range messages {
+		messagesByClient[count.GroupKey] = count
+	}
+	transactionsByClient := map[uint]int64{}
+	for _, count := range transactions {
+		transactionsByClient[count.GroupKey] = count.Count
+	}
+	repliesByClient := map[uint]float64{}
+	for _, median := range replies {
+		repliesByClient[uint(median.GroupKey)] = median.MedianSeconds
+	}
+
+	for _, client := range engagement {
+		if count, ok := messagesByClient[client.ClientID]; ok {
+			client.AgentMessages = count.AgentMessages
+			client.ClientMessages = count.ClientMessages
+		}
+		client.Transactions = transactionsByClient[client.ClientID]
+		client.MedianReplySeconds = repliesByClient[client.ClientID]
+
+		if client.ClientMessages > 0 {
+			client.SymmetryRatio = float64(client.AgentMessages) / float64(client.ClientMessages)
+		}
+		if total := client.AgentMessages + client.ClientMessages; total > 0 {
+			client.TransactionsPerMessage = float64(client.Transactions) / float64(total)
+		}
+	}
+
+	return engagement, nil
+}
+
+// GetAgentSummaries returns per-agent totals for the user's agents.
+func (a *analyticsServiceImpl) GetAgentSummaries(ctx context.Context, filter AnalyticsFilter, userID uint) ([]*AgentSummary, error) {
+	if err := a.checkFilter(ctx, filter, userID); err != nil {
+		return nil, err
+	}
+
+	summaries := []*AgentSummary{}
+	agents := a.db.WithContext(ctx).
+		Model(&models.Agent{}).
+		Where("agents.user_id = ?", userID)
+	if filter.AgentID != 0 {
+		agents = agents.Where("agents.id = ?", filter.AgentID)
+	}
+	err := agents.
+		Select("agents.id AS agent_id, agents.name AS name").
+		Order("agents.id").
+		Scan(&summaries).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	var clients []*groupedCount
+	err = a.db.WithContext(ctx).
+		Model(&models.Client{}).
+		Joins("JOIN agents ON agents.id = clients.agent_id AND agents.deleted_at IS NULL").
+		Where("agents.user_id = ?", userID).
+		Select("clients.agent_id AS group_key, COUNT(*) AS count").
+		Group("clients.agent_id").
+		Scan(&clients).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []*directionCounts
+	err = a.messageScope(ctx, filter, userID).
+		Select("messages.agent_id AS group_key, " + directionCountColumns).
+		Group("messages.agent_id").
+		Scan(&messages).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	var revenue []*agentCurrencyTotal
+	err = a.transactionScope(ctx, filter, userID).
+		Select("transactions.agent_id AS agent_id, transactions.currency AS currency, COUNT(*) AS count, SUM(transactions.amount_minor) AS total_minor").
+		Group("transactions.agent_id, transactions.currency").
+		Order("transactions.currency").
+		Scan(&revenue).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	var refunds []*agentCurrencyTotal
+	err = a.refundScope(ctx, filter, userID).
+		Select("transactions.agent_id AS agent_id, refunds.currency AS currency, SUM(refunds.amount_minor) AS refunded_minor").
+		Group("transactions.agent_id, refunds.currency").
+		Scan(&refunds).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	var replies []*groupedMedian
+	if err := a.replyGapMedians(ctx, filter, userID, "agent_id").Scan(&replies).Error; err != nil {
+		return nil, err
+	}
+
+	byAgent := map[uint]*AgentSummary{}
+	for _, summary := range summaries {
+		summary.Revenue = []*CurrencyTotal{}
+		byAgent[summary.AgentID] = summary
+	}
+	for _, count := range clients {
+		if summary, ok := byAgent[count.GroupKey]; ok {
+			summary.Clients = count.Count
+		}
+	}
+	for _, count := range messages {
+		if summary, ok := byAgent[count.GroupKey]; ok {
+			summary.MessagesSent = count.AgentMessages
+			summary.MessagesReceived = count.ClientMessages
+		}
+	}
+	for _, median := range replies {
+		if summary, ok := byAgent[uint(median.GroupKey)]; ok {
+			summary.MedianReplySeconds = median.MedianSeconds
+		}
+	}
+
+	refunded := map[uint]map[string]int64{}
+	for _, refund := range refunds {
+		if refunded[refund.AgentID] == nil {
+			refunded[refund.AgentID] = map[string]int64{}
+		}
+		refunded[refund.AgentID][refund.Currency] = refund.RefundedMinor
+	}
+	for _, total := range revenue {
+		summary, ok := byAgent[total.AgentID]
+		if !ok {
+			continue
+		}
+		total.RefundedMinor = refunded[total.AgentID][total.Currency]
+		total.NetMinor = total.TotalMinor - total.RefundedMinor
+		total.Total = money.Format(total.TotalMinor, total.Currency)
+		total.Refunded = money.Format(total.RefundedMinor, total.Currency)
+		total.Net = money.Format(total.NetMinor, total.Currency)
+		summary.Transactions += total.Count
+		summary.Revenue = append(summary.Revenue, &total.CurrencyTotal)
+	}
+
+	return summaries, nil
+}
+
+// checkFilter verifies that the agent and client named in the filter belong
+// to the user.
+func (a *analyticsServiceImpl) checkFilter(ctx context.Context, filter AnalyticsFilter, userID uint) error {
+	if filter.AgentID != 0 {
+		if _, err := a.agentService.GetAgentByID(ctx, filter.AgentID, userID); err != nil {
+			return err
+		}
+	}
+	if filter.ClientID != 0 {
+		if _, err := a.clientService.GetClientByID(ctx, filter.ClientID, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *analyticsServiceImpl) messageScope(ctx context.Context, filter AnalyticsFilter, userID uint) *gorm.DB {
+	query := a.db.WithContext(ctx).
+		Model(&models.Message{}).
+		Joins("JOIN agents ON agents.id = messages.agent_id AND agents.deleted_at IS NULL").
+		Where("agents.user_id = ?", userID)
+
+	if filter.AgentID != 0 {
+		query = query.Where("messages.agent_id = ?", filter.AgentID)
+	}
+	if filter.ClientID != 0 {
+		query = query.Where("messages.client_id = ?", filter.ClientID)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("messages.date >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("messages.date < ?", filter.To)
+	}
+
+	return query
+}
+
+// transactionScope selects the user's settled transactions; pending ones are
+// not revenue yet.
+func (a *analyticsServiceImpl) transactionScope(ctx context.Context, filter AnalyticsFilter, userID uint) *gorm.DB {
+	query := a.db.WithContext(ctx).
+		Model(&models.Transaction{}).
+		Joins("JOIN agents ON agents.id = transactions.agent_id AND agents.deleted_at IS NULL").
+		Where("agents.user_id = ?", userID).
+		Where("transactions.status <> ?", models.TransactionStatusPending)
+
+	if filter.AgentID != 0 {
+		query = query.Where("transactions.agent_id = ?", filter.AgentID)
+	}
+	if filter.ClientID != 0 {
+		query = query.Where("transactions.client_id = ?", filter.ClientID)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("transactions.date >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("transactions.date < ?", filter.To)
+	}
+
+	return query
+}
+
+// refundScope selects refunds of the user's transactions issued inside the
+// filter's date range.
+func (a *analyticsServiceImpl) refundScope(ctx context.Context, filter AnalyticsFilter, userID uint) *gorm.DB {
+	query := a.db.WithContext(ctx).
+		Model(&models.Refund{}).
+		Joins("JOIN transactions ON transactions.id = refunds.transaction_id AND transactions.deleted_at IS NULL").
+		Joins("JOIN agents ON agents.id = transactions.agent_id AND agents.deleted_at IS NULL").
+		Where("agents.user_id = ?", userID)
+
+	if filter.AgentID != 0 {
+		query = query.Where("transactions.agent_id = ?", filter.AgentID)
+	}
+	if filter.ClientID != 0 {
+		query = query.Where("transactions.client_id = ?", filter.ClientID)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("refunds.date >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("refunds.date < ?", filter.To)
+	}
+
+	return query
+}
+
+// replyGapMedians builds a query returning the median reply gap in seconds
+// for each value of groupColumn, which is one of the columns of the reply gap
+// rows (agent_id, client_id or weekday) or a constant for a single overall
+// group. The median is the mean of the middle one or two gaps, which works
+// without a percentile function.
+func (a *analyticsServiceImpl) replyGapMedians(ctx context.Context, filter AnalyticsFilter, userID uint, groupColumn string) *gorm.DB {
+	conversation := a.messageScope(ctx, filter, userID).
+		Select("messages.agent_id, messages.client_id, messages.type, messages.date, " +
+			"LAG(messages.type) OVER (PARTITION BY messages.client_id ORDER BY messages.date, messages.id) AS previous_type, " +
+			"LAG(messages.date) OVER (PARTITION BY messages.client_id ORDER BY messages.date, messages.id) AS previous_at")
+
+	gaps := a.db.WithContext(ctx).
+		Table("(?) AS conversation", conversation).
+		Where("conversation.previous_type = ? AND conversation.type = ?", models.MessageTypeClientToAgent, models.MessageTypeAgentToClient).
+		Select("conversation.agent_id, conversation.client_id, " +
+			"CAST(strftime('%w', conversation.date) AS INTEGER) AS weekday, " +
+			"(julianday(conversation.date) - julianday(conversation.previous_at)) * 86400 AS gap_seconds")
+
+	ranked := a.db.WithContext(ctx).
+		Table("(?) AS gaps", gaps).
+		Select(groupColumn + " AS group_key, gap_seconds, " +
+			"ROW_NUMBER() OVER (PARTITION BY " + groupColumn + " ORDER BY gap_seconds) AS position, " +
+			"COUNT(*) OVER (PARTITION BY " + groupColumn + ") AS replies")
+
+	return a.db.WithContext(ctx).
+		Table("(?) AS ranked", ranked).
+		Where("position IN ((replies + 1) / 2, (replies + 2) / 2)").
+		Select("group_key, MAX(replies) AS replies, AVG(gap_seconds) AS median_seconds").
+		Group("group_key").
+		Order("group_key")
+}
+
+const directionCountColumns = "SUM(CASE WHEN messages.type = '" + models.MessageTypeAgentToClient + "' THEN 1 ELSE 0 END) AS agent_messages, " +
+	"SUM(CASE WHEN messages.type = '" + models.MessageTypeClientToAgent + "' THEN 1 ELSE 0 END) AS client_messages"
+
+type groupedCount struct {
+	GroupKey uint
+	Count    int64
+}
+
+type directionCounts struct {
+	GroupKey       uint
+	AgentMessages  int64
+	ClientMessages int64
+}
+
+type groupedMedian struct {
+	GroupKey      int64
+	Replies       int64
+	MedianSeconds float64
+}
+
+type agentCurrencyTotal struct {
+	AgentID uint
+	CurrencyTotal
+}
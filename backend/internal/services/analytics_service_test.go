@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalytics_AggregatesConversationsAndRevenue(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	user := &models.User{Username: "owner", Email: "owner@mail.com", Password: "x"}
+	require.NoError(t, NewUserService(db).CreateUser(ctx, user))
+
+	agentService := NewAgentService(db, time.Now)
+	clientService := NewClientService(db, agentService, time.Now)
+	messageService := NewMessageService(db, agentService, clientService, time.Now)
+	transactionService := NewTransactionService(db, agentService, clientService, time.Now)
+	analyticsService := NewAnalyticsService(db, agentService, clientService)
+
+	agent, err := agentService.CreateAgent(ctx, &models.Agent{Name: "a", Characteristics: "c"}, user.ID)
+	require.NoError(t, err)
+	client, err := clientService.CreateClient(ctx, &models.Client{Name: "c", AgentID: agent.ID, Score: 3}, agent.ID, user.ID)
+	require.NoError(t, err)
+
+	// Monday 2024-03-04: replies after 60s, 120s and 600s.
+	start := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	conversation := []struct {
+		offset    time.Duration
+		direction string
+	}{
+		{0, models.MessageTypeClientToAgent},
+		{time.Minute, models.MessageTypeAgentToClient},
+		{time.Hour, models.MessageTypeClientToAgent},
+		{time.Hour + 2*time.Minute, models.MessageTypeAgentToClient},
+		{time.Hour + 3*time.Minute, models.MessageTypeAgentToClient},
+		{2 * time.Hour, models.MessageTypeClientToAgent},
+		{2*time.Hour + 10*time.Minute, models.MessageTypeAgentToClient},
+	}
+	for _, entry := range conversation {
+		_, err := messageService.CreateMessage(ctx, &models.Message{
+			Date:     start.Add(entry.offset),
+			AgentID:  agent.ID,
+			ClientID: client.ID,
+			Content:  "hi",
+			Type:     entry.direction,
+		}, user.ID)
+		require.NoError(t, err)
+	}
+
+	transaction, err := transactionService.CreateTransaction(ctx, &models.Transaction{AgentID: agent.ID, ClientID: client.ID, AmountMinor: 2000, Currency: "USD", Date: start}, user.ID)
+	require.NoError(t, err)
+	_, err = transactionService.CreateRefund(ctx, &models.Refund{TransactionID: transaction.ID, AmountMinor: 500, Date: start}, user.ID)
+	require.NoError(t, err)
+	_, err = transactionService.CreateTransaction(ctx, &models.Transaction{AgentID: agent.ID, ClientID: client.ID, AmountMinor: 999, Currency: "USD", Date: start, Status: models.TransactionStatusPending}, user.ID)
+	require.NoError(t, err)
+
+	filter := AnalyticsFilter{AgentID: agent.ID, From: start.Add(-time.Hour), To: start.Add(24 * time.Hour)}
+
+	volume, err := analyticsService.GetMessageVolume(ctx, filter, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []*DailyMessageCount{
+		{Day: "2024-03-04", Direction: models.MessageTypeAgentToClient, Count: 4},
+		{Day: "2024-03-04", Direction: models.MessageTypeClientToAgent, Count: 3},
+	}, volume)
+
+	gaps, err := analyticsService.GetReplyGaps(ctx, filter, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), gaps.Replies)
+	assert.InDelta(t, 120, gaps.MedianSeconds, 0.01)
+	require.Len(t, gaps.ByWeekday, 1)
+	assert.Equal(t, 1, gaps.ByWeekday[0].Weekday)
+
+	revenue, err := analyticsService.GetRevenue(ctx, filter, user.ID)
+	require.NoError(t, err)
+	require.Len(t, revenue, 1)
+	assert.Equal(t, "15.00", revenue[0].Net)
+
+	engagement, err := analyticsService.GetClientEngagement(ctx, filter, user.ID)
+	require.NoError(t, err)
+	require.Len(t, engagement, 1)
+	assert.Equal(t, int64(4), engagement[0].AgentMessages)
+	assert.Equal(t, int64(1), engagement[0].Transactions)
+	assert.InDelta(t, 4.0/3.0, engagement[0].SymmetryRatio, 0.001)
+
+	summaries, err := analyticsService.GetAgentSummaries(ctx, AnalyticsFilter{}, user.ID)
+	require.NoError(t, err)
+	require.Len(t, summaries, 1)
+	assert.Equal(t, int64(1), summaries[0].Clients)
+	assert.Equal(t, int64(3), summaries[0].MessagesReceived)
+	require.Len(t, summaries[0].Revenue, 1)
+	assert.Equal(t, int64(1500), summaries[0].Revenue[0].NetMinor)
+
+	_, err = analyticsService.GetRevenue(ctx, AnalyticsFilter{AgentID: agent.ID}, user.ID+1)
+	assert.Error(t, err)
+}
@@ -0,0 +1,255 @@
+package services
+
+import (
+	"backend/internal/models"
+	"backend/internal/requestctx"
+	"backend/pkg/database"
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	AuditEntityUser        = "user"
+	AuditEntityAgent       = "agent"
+	AuditEntityClient      = "client"
+	AuditEntityMessage     = "message"
+	AuditEntityTransaction = "transaction"
+	AuditEntityRefund      = "refund"
+	AuditEntityWebhook     = "webhook_subscription"
+	AuditEntityContact     = "client_contact"
+	AuditEntityClientTags  = "client_tags"
+	AuditEntityCustomField = "custom_field"
+	AuditEntityFieldValues = "client_field_values"
+	AuditEntityNote        = "client_note"
+	AuditEntitySegment     = "segment"
+
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+	auditExportBatch  = 500
+)
+
+type AuditFilter struct {
+	ActorID    uint
+	Action     string
+	EntityType string
+	EntityID   uint
+	RequestID  string
+	From       time.Time
+	To         time.Time
+	Limit      int
+	Offset     int
+}
+
+type AuditService interface {
+	Record(ctx context.Context, actorID uint, action, entityType string, entityID uint, before, after interface{}) error
+	GetEntries(ctx context.Context, filter AuditFilter) ([]*models.AuditEntry, error)
+	// ExportEntries passes every entry matching filter, newest first, to
+	// write in batches. Limit and Offset are ignored.
+	ExportEntries(ctx context.Context, filter AuditFilter, write func([]*models.AuditEntry) error) error
+}
+
+type auditServiceImpl struct {
+	db *database.DB
+}
+
+// NewAuditService creates the audit service; the entries it records are
+// dated by now.
+func NewAuditService(db *database.DB, now func() time.Time) AuditService {
+	return &auditServiceImpl{db: db.WithClock(now)}
+}
+
+var (
+	ErrInvalidAuditFilter = NewError(http.StatusBadRequest, "invalid_audit_filter", "invalid audit filter")
+	ErrInvalidExportType  = NewError(http.StatusBadRequest, "invalid_export_type", "export format must be csv or json")
+)
+
+func (a *auditServiceImpl) Record(ctx context.Context, actorID uint, action, entityType string, entityID uint, before, after interface{}) error {
+	return recordAudit(ctx, a.db.WithContext(ctx), actorID, action, entityType, entityID, before, after)
+}
+
+func (a *auditServiceImpl) GetEntries(ctx context.Context, filter AuditFilter) ([]*models.AuditEntry, error) {
+	if filter.Limit < 0 || filter.Offset < 0 {
+		return nil, ErrInvalidAuditFilter
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultAuditLimit
+	}
+	if filter.Limit > maxAuditLimit {
+		filter.Limit = maxAuditLimit
+	}
+
+	var entries []*models.AuditEntry
+	err := a.filterEntries(ctx, filter).
+		Order("id desc").
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		Find(&entries).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func (a *auditServiceImpl) ExportEntries(ctx context.Context, filter AuditFilter, write func([]*models.AuditEntry) error) error {
+	// Each batch continues below the last ID seen rather than at an offset,
+	// so entries recorded during the export do not shift the batches.
+	var lastID uint
+	for {
+		query := a.filterEntries(ctx, filter)
+		if lastID != 0 {
+			query = query.Where("id < ?", lastID)
+		}
+
+		var entries []*models.AuditEntry
+		if err := query.Order("id desc").Limit(auditExportBatch).Find(&entries).Error; err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+		if err := write(entries); err != nil {
+			return err
+		}
+		if len(entries) < auditExportBatch {
+			return nil
+		}
+		lastID = entries[len(entries)-1].ID
+	}
+}
+
+// filterEntries applies everything in filter but the pagination.
+func (a *auditServiceImpl) filterEntries(ctx context.Context, filter AuditFilter) *gorm.DB {
+	query := a.db.WithContext(ctx).Model(&models.AuditEntry{})
+	if filter.ActorID != 0 {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.EntityType != "" {
+		query = query.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != 0 {
+		query = query.Where("entity_id = ?", filter.EntityID)
+	}
+	if filter.RequestID != "" {
+		query = query.Where("request_id = ?", filter.RequestID)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at < ?", filter.To)
+	}
+	return query
+}
+
+// recordAudit writes an audit entry through tx, which is normally the
+// transaction of the mutation being audited. Request metadata is taken from ctx.
+func recordAudit(ctx context.Context, tx *gorm.DB, actorID uint, action, entityType string, entityID uint, before, after interface{}) error {
+	beforeJSON, err := marshalAuditState(before)
+	if err != nil {
+		return err
+	}
+
+	afterJSON, err := marshalAuditState(after)
+	if err != nil {
+		return err
+	}
+
+	diff, err := auditDiff(beforeJSON, afterJSON)
+	if err != nil {
+		return err
+	}
+
+	metadata := requestctx.FromContext(ctx)
+	entry := &models.AuditEntry{
+		ActorID:    actorID,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Before:     beforeJSON,
+		After:      afterJSON,
+		Diff:       diff,
+		IP:         metadata.IP,
+		RequestID:  metadata.RequestID,
+	}
+
+	return tx.Create(entry).Error
+}
+
+func marshalAuditState(state interface{}) (string, error) {
+	if state == nil || (reflect.ValueOf(state).Kind() == reflect.Ptr && reflect.ValueOf(state).IsNil()) {
+		return "", nil
+	}
+
+	body, err := json.Marshal(state)
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+// auditDiff returns a JSON object of the top-level fields that differ between
+// two JSON documents, as {"Field": {"before": x, "after": y}}.
+func auditDiff(beforeJSON, afterJSON string) (string, error) {
+	before := map[string]interface{}{}
+	after := map[string]interface{}{}
+
+	if beforeJSON != "" {
+		if err := json.Unmarshal([]byte(beforeJSON), &before); err != nil {
+			return "", err
+		}
+	}
+	if afterJSON != "" {
+		if err := json.Unmarshal([]byte(afterJSON), &after); err != nil {
+			return "", err
+		}
+	}
+
+	type change struct {
+		Before interface{} `json:"before"`
+		After  interface{} `json:"after"`
+	}
+
+	changes := map[string]change{}
+	for field, value := range after {
+		if field == "UpdatedAt" {
+			continue
+		}
+		if previous, ok := before[field]; !ok || !reflect.DeepEqual(previous, value) {
+			changes[field] = change{Before: before[field], After: value}
+		}
+	}
+	for field, value := range before {
+		if _, ok := after[field]; !ok {
+			changes[field] = change{Before: value}
+		}
+	}
+
+	if len(changes) == 0 {
+		return "", nil
+	}
+
+	body, err := json.Marshal(changes)
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+// actorFromContext returns the authenticated user making the request, or
+// fallback when the call does not originate from an authenticated request.
+func actorFromContext(ctx context.Context, fallback uint) uint {
+	if userID := requestctx.FromContext(ctx).UserID; userID != 0 {
+		return userID
+	}
+	return fallback
+}
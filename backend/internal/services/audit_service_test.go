@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/requestctx"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestAuditService_RecordsMutationsWithDiff(t *testing.T) {
+	db := newTestDB(t)
+	ctx := requestctx.WithMetadata(context.Background(), requestctx.Metadata{RequestID: "req-1", IP: "10.0.0.1"})
+
+	user := &models.User{Username: "owner", Email: "owner@mail.com", Password: "x"}
+	require.NoError(t, NewUserService(db).CreateUser(ctx, user))
+
+	agentService := NewAgentService(db, time.Now)
+	agent, err := agentService.CreateAgent(ctx, &models.Agent{Name: "before", Characteristics: "c"}, user.ID)
+	require.NoError(t, err)
+
+	_, err = agentService.UpdateAgent(ctx, &models.Agent{Model: gorm.Model{ID: agent.ID}, Name: "after"}, user.ID)
+	require.NoError(t, err)
+	require.NoError(t, agentService.DeleteAgent(ctx, agent.ID, user.ID))
+
+	entries, err := NewAuditService(db, time.Now).GetEntries(ctx, AuditFilter{EntityType: AuditEntityAgent})
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+
+	update := entries[1]
+	assert.Equal(t, models.AuditActionUpdate, update.Action)
+	assert.Equal(t, user.ID, update.ActorID)
+	assert.Equal(t, "10.0.0.1", update.IP)
+	assert.Equal(t, "req-1", update.RequestID)
+	assert.JSONEq(t, `{"Name":{"before":"before","after":"after"}}`, update.Diff)
+
+	assert.Equal(t, models.AuditActionDelete, entries[0].Action)
+	assert.Empty(t, entries[0].After)
+}
+
+func TestAuditService_ExportEntriesIsNotLimited(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	entries := make([]*models.AuditEntry, 1200)
+	for i := range entries {
+		entries[i] = &models.AuditEntry{ActorID: 1, Action: models.AuditActionUpdate, EntityType: AuditEntityAgent, EntityID: uint(i + 1)}
+	}
+	require.NoError(t, db.CreateInBatches(entries, 200).Error)
+	require.NoError(t, db.Create(&models.AuditEntry{ActorID: 2, Action: models.AuditActionLogin, EntityType: AuditEntityUser}).Error)
+
+	var exported []*models.AuditEntry
+	batches := 0
+	err := NewAuditService(db, time.Now).ExportEntries(ctx, AuditFilter{EntityType: AuditEntityAgent, Limit: 10}, func(batch []*models.AuditEntry) error {
+		batches++
+		exported = append(exported, batch...)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, batches)
+	require.Len(t, exported, 1200)
+	assert.EqualValues(t, 1200, exported[0].EntityID, "newest first")
+	assert.EqualValues(t, 1, exported[len(exported)-1].EntityID)
+}
@@ -3,6 +3,9 @@ package services
 import (
 	"context"
 	"errors"
+	"log/slog"
+	"net/http"
+	"time"
 
 	"backend/internal/config"
 	"backend/internal/models"
@@ -16,14 +19,18 @@ type AuthService interface {
 }
 
 type authService struct {
-	userService UserService
-	cfg         *config.Config
+	userService  UserService
+	auditService AuditService
+	cfg          *config.Config
+	now          func() time.Time
 }
 
-func NewAuthService(userService UserService, cfg *config.Config) AuthService {
+func NewAuthService(userService UserService, auditService AuditService, cfg *config.Config, now func() time.Time) AuthService {
 	return &authService{
-		userService: userService,
-		cfg:         cfg,
+		userService:  userService,
+		auditService: auditService,
+		cfg:          cfg,
+		now:          now,
 	}
 }
 
@@ -73,19 +80,76 @@ func (s *authService) Login(ctx context.Context, username, password string) (str
 		return "", nil, err
 	}
 
+	now := s.now()
+	if user.LockedUntil != nil && now.Before(*user.LockedUntil) {
+		return "", nil, ErrAccountLocked.WithRetryAfter(user.LockedUntil.Sub(now))
+	}
+
 	if !utils.CheckPasswordHash(password, user.Password) {
+		slog.WarnContext(ctx, "login failed", "username", user.Username, "target_user_id", user.ID)
+		if err := s.auditService.Record(ctx, user.ID, models.AuditActionLoginFailed, AuditEntityUser, user.ID, nil, nil); err != nil {
+			return "", nil, err
+		}
+		if err := s.recordFailedLogin(ctx, user, now); err != nil {
+			return "", nil, err
+		}
 		return "", nil, ErrInvalidCredentials
 	}
 
+	if user.FailedLogins > 0 || user.LockedUntil != nil {
+		if err := s.userService.UpdateLoginState(ctx, user.ID, 0, nil); err != nil {
+			return "", nil, err
+		}
+	}
+
 	generatedToken, err := token.GenerateToken(user.ID, user.Username, s.cfg.JWTSecret, s.cfg.TokenExpiry)
 	if err != nil {
 		return "", nil, err
 	}
 
+	if err := s.auditService.Record(ctx, user.ID, models.AuditActionLogin, AuditEntityUser, user.ID, nil, nil); err != nil {
+		return "", nil, err
+	}
+
+	slog.InfoContext(ctx, "user logged in", "target_user_id", user.ID)
 	return generatedToken, user, nil
 }
 
+// recordFailedLogin counts a failed attempt and locks the account once the
+// configured threshold is reached. The lockout follows the count stored by
+// the database, not the one read with the user, which concurrent attempts
+// may have overtaken.
+func (s *authService) recordFailedLogin(ctx context.Context, user *models.User, now time.Time) error {
+	failures, err := s.userService.IncrementFailedLogins(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	lockout := lockoutDuration(failures, s.cfg.LoginLockoutThreshold, s.cfg.LoginLockoutBase, s.cfg.LoginLockoutMax)
+	if lockout <= 0 {
+		return nil
+	}
+	until := now.Add(lockout)
+	slog.WarnContext(ctx, "account locked", "target_user_id", user.ID, "failed_logins", failures, "locked_until", until)
+	return s.userService.LockUntil(ctx, user.ID, until)
+}
+
+// lockoutDuration is base once failures reaches threshold and doubles with
+// every further failure, capped at max. A threshold of zero disables lockout.
+func lockoutDuration(failures, threshold int, base, max time.Duration) time.Duration {
+	if threshold <= 0 || failures < threshold {
+		return 0
+	}
+
+	lockout := base
+	for i := threshold; i < failures && lockout < max; i++ {
+		lockout *= 2
+	}
+	return min(lockout, max)
+}
+
 var (
-	ErrInvalidCredentials = errors.New("invalid credentials")
-	ErrUsernameTaken      = errors.New("username taken")
+	ErrAccountLocked      = NewError(http.StatusTooManyRequests, "account_locked", "too many failed logins, try again later")
+	ErrInvalidCredentials = NewError(http.StatusUnauthorized, "invalid_credentials", "invalid credentials")
+	ErrUsernameTaken      = NewError(http.StatusConflict, "username_taken", "username taken")
 )
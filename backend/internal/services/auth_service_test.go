@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"backend/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockoutDuration(t *testing.T) {
+	assert.Zero(t, lockoutDuration(2, 3, time.Minute, time.Hour))
+	assert.Equal(t, time.Minute, lockoutDuration(3, 3, time.Minute, time.Hour))
+	assert.Equal(t, 4*time.Minute, lockoutDuration(5, 3, time.Minute, time.Hour))
+	assert.Equal(t, time.Hour, lockoutDuration(20, 3, time.Minute, time.Hour))
+	assert.Zero(t, lockoutDuration(20, 0, time.Minute, time.Hour), "a zero threshold disables lockout")
+}
+
+func TestAuthService_LocksAccountAfterFailedLogins(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	cfg := &config.Config{
+		JWTSecret:             "secret",
+		LoginLockoutThreshold: 2,
+		LoginLockoutBase:      time.Minute,
+		LoginLockoutMax:       time.Hour,
+	}
+	userService := NewUserService(db)
+	auth := NewAuthService(userService, NewAuditService(db, time.Now), cfg, time.Now)
+
+	_, err := auth.Register(ctx, "alice", "alice@example.com", "password123")
+	require.NoError(t, err)
+
+	for range 2 {
+		_, _, err = auth.Login(ctx, "alice", "wrong")
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+
+	_, _, err = auth.Login(ctx, "alice", "password123")
+	require.ErrorIs(t, err, ErrAccountLocked, "the right password is refused while locked")
+	var locked *Error
+	require.ErrorAs(t, err, &locked)
+	assert.InDelta(t, time.Minute, locked.RetryAfter, float64(time.Second))
+
+	user, err := userService.GetUserByUsername(ctx, "alice")
+	require.NoError(t, err)
+	past := time.Now().Add(-time.Second)
+	require.NoError(t, userService.UpdateLoginState(ctx, user.ID, user.FailedLogins, &past))
+
+	_, _, err = auth.Login(ctx, "alice", "password123")
+	require.NoError(t, err)
+
+	user, err = userService.GetUserByUsername(ctx, "alice")
+	require.NoError(t, err)
+	assert.Zero(t, user.FailedLogins)
+	assert.Nil(t, user.LockedUntil)
+}
+
+func TestAuthService_CountsConcurrentFailedLogins(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	cfg := &config.Config{
+		JWTSecret:             "secret",
+		LoginLockoutThreshold: 2,
+		LoginLockoutBase:      time.Minute,
+		LoginLockoutMax:       time.Hour,
+	}
+	userService := NewUserService(db)
+	auth := NewAuthService(userService, NewAuditService(db, time.Now), cfg, time.Now).(*authService)
+
+	_, err := auth.Register(ctx, "alice", "alice@example.com", "password123")
+	require.NoError(t, err)
+
+	// Two attempts that read the user before either failure was stored.
+	user, err := userService.GetUserByUsername(ctx, "alice")
+	require.NoError(t, err)
+	require.NoError(t, auth.recordFailedLogin(ctx, user, time.Now()))
+	require.NoError(t, auth.recordFailedLogin(ctx, user, time.Now()))
+
+	user, err = userService.GetUserByUsername(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, 2, user.FailedLogins)
+	assert.NotNil(t, user.LockedUntil)
+}
@@ -0,0 +1,429 @@
+package services
+
+import (
+	"backend/internal/config"
+	"backend/internal/models"
+	"backend/pkg/database"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// BulkRequest applies one action to many clients or agents. The records are
+// either listed in IDs or selected by ClientFilter or AgentFilter, matching
+// Target.
+type BulkRequest struct {
+	Target       string
+	Action       string
+	IDs          []uint
+	ClientFilter *ClientFilter
+	AgentFilter  *AgentFilter
+	Params       models.BulkParams
+}
+
+// AgentFilter selects agents for a bulk action. Zero fields do not filter.
+type AgentFilter struct {
+	Status string
+	Name   string // case-insensitive substring of the name
+}
+
+// BulkService runs bulk actions. Small ones run inside the request, in one
+// database transaction in which every item is applied and can fail on its
+// own; larger ones are queued as jobs for the BulkJobRunner. Either way the
+// caller gets a job with a result for every item.
+type BulkService interface {
+	Submit(ctx context.Context, request BulkRequest, userID uint) (*models.BulkJob, error)
+	GetJobs(ctx context.Context, userID uint) ([]*models.BulkJob, error)
+	GetJob(ctx context.Context, id uint, userID uint) (*models.BulkJob, error)
+}
+
+type bulkServiceImpl struct {
+	db            *database.DB
+	cfg           *config.Config
+	agentService  AgentService
+	clientService ClientService
+	now           func() time.Time
+}
+
+func NewBulkService(db *database.DB, cfg *config.Config, agentService AgentService, clientService ClientService, now func() time.Time) BulkService {
+	return &bulkServiceImpl{
+		db:            db,
+		cfg:           cfg,
+		agentService:  agentService,
+		clientService: clientService,
+		now:           now,
+	}
+}
+
+// bulkChunkSize is how many items a background job applies per transaction.
+const bulkChunkSize = 100
+
+var (
+	ErrBulkJobNotFound        = NewError(http.StatusNotFound, "bulk_job_not_found", "bulk job not found")
+	ErrInvalidBulkJobID       = NewError(http.StatusBadRequest, "invalid_bulk_job_id", "bulk job ID is invalid")
+	ErrInvalidBulkAction      = NewError(http.StatusBadRequest, "invalid_bulk_action", "action is not supported for this kind of record")
+	ErrBulkSelectionRequired  = NewError(http.StatusBadRequest, "bulk_selection_required", "give either a list of IDs or a filter")
+	ErrBulkTooLarge           = NewError(http.StatusBadRequest, "bulk_too_large", "too many records for one bulk action")
+	ErrBulkTagsRequired       = NewError(http.StatusBadRequest, "bulk_tags_required", "tags are required")
+	ErrBulkTargetAgentMissing = NewError(http.StatusBadRequest, "bulk_agent_required", "the agent to reassign to is required")
+)
+
+var bulkActions = map[string][]string{
+	models.BulkTargetClients: {
+		models.BulkActionReassign,
+		models.BulkActionTag,
+		models.BulkActionUntag,
+		models.BulkActionArchive,
+		models.BulkActionUnarchive,
+		models.BulkActionDelete,
+	},
+	models.BulkTargetAgents: {
+		models.BulkActionSetStatus,
+	},
+}
+
+func (s *bulkServiceImpl) Submit(ctx context.Context, request BulkRequest, userID uint) (*models.BulkJob, error) {
+	request.Action = strings.ToUpper(strings.TrimSpace(request.Action))
+	if !slices.Contains(bulkActions[request.Target], request.Action) {
+		return nil, ErrInvalidBulkAction
+	}
+	if err := s.checkParams(ctx, &request, userID); err != nil {
+		return nil, err
+	}
+
+	ids, err := s.resolve(ctx, request, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) > s.cfg.BulkMaxItems {
+		return nil, ErrBulkTooLarge
+	}
+
+	job := &models.BulkJob{
+		UserID:  userID,
+		Target:  request.Target,
+		Action:  request.Action,
+		Params:  request.Params,
+		ItemIDs: ids,
+		Status:  models.BulkJobStatusPending,
+		Total:   len(ids),
+		Results: models.BulkItemResults{},
+	}
+	if err := s.db.WithContext(ctx).Create(job).Error; err != nil {
+		return nil, err
+	}
+	if len(ids) > s.cfg.BulkSyncLimit {
+		return job, nil
+	}
+
+	started := s.now()
+	job.Status = models.BulkJobStatusRunning
+	job.StartedAt = &started
+	if err := applyBulkItems(ctx, s.db, job, ids, s.now); err != nil {
+		return nil, err
+	}
+	return finishBulkJob(ctx, s.db, job, s.now())
+}
+
+func (s *bulkServiceImpl) checkParams(ctx context.Context, request *BulkRequest, userID uint) error {
+	params := &request.Params
+	switch request.Action {
+	case models.BulkActionReassign:
+		if params.AgentID == 0 {
+			return ErrBulkTargetAgentMissing
+		}
+		agent, err := s.agentService.GetAgentByID(ctx, params.AgentID, userID)
+		if err != nil {
+			return err
+		}
+		if agent.UserID != userID {
+			return ErrUnauthorized
+		}
+	case models.BulkActionTag, models.BulkActionUntag:
+		tags, err := normalizeTags(params.Tags)
+		if err != nil {
+			return err
+		}
+		if len(tags) == 0 {
+			return ErrBulkTagsRequired
+		}
+		params.Tags = tags
+	case models.BulkActionSetStatus:
+		params.Status = strings.ToUpper(strings.TrimSpace(params.Status))
+		switch params.Status {
+		case models.AgentStatusActive, models.AgentStatusPaused, models.AgentStatusRetired:
+		default:
+			return ErrInvalidAgentStatus
+		}
+	}
+	return nil
+}
+
+// resolve turns the request's selection into a list of distinct IDs. IDs
+// that do not exist or belong to someone else are kept and fail per item.
+func (s *bulkServiceImpl) resolve(ctx context.Context, request BulkRequest, userID uint) ([]uint, error) {
+	hasFilter := request.ClientFilter != nil || request.AgentFilter != nil
+	if (len(request.IDs) > 0) == hasFilter {
+		return nil, ErrBulkSelectionRequired
+	}
+
+	if len(request.IDs) > 0 {
+		ids := make([]uint, 0, len(request.IDs))
+		for _, id := range request.IDs {
+			if !slices.Contains(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+		return ids, nil
+	}
+
+	ids := []uint{}
+	switch {
+	case request.Target == models.BulkTargetClients && request.ClientFilter != nil:
+		clients, err := s.clientService.ListClients(ctx, *request.ClientFilter, userID)
+		if err != nil {
+			return nil, err
+		}
+		for _, client := range clients {
+			ids = append(ids, client.ID)
+		}
+	case request.Target == models.BulkTargetAgents && request.AgentFilter != nil:
+		agents, err := s.agentService.GetAllAgents(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		status := strings.ToUpper(request.AgentFilter.Status)
+		name := strings.ToLower(request.AgentFilter.Name)
+		for _, agent := range agents {
+			if status != "" && agent.Status != status {
+				continue
+			}
+			if name != "" && !strings.Contains(strings.ToLower(agent.Name), name) {
+				continue
+			}
+			ids = append(ids, agent.ID)
+		}
+	default:
+		return nil, ErrBulkSelectionRequired
+	}
+	return ids, nil
+}
+
+func (s *bulkServiceImpl) GetJobs(ctx context.Context, userID uint) ([]*models.BulkJob, error) {
+	var jobs []*models.BulkJob
+	err := s.db.WithContext(ctx).
+		Omit("item_ids", "results").
+		Where("user_id = ?", userID).
+		Order("created_at desc, id desc").
+		Find(&jobs).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func (s *bulkServiceImpl) GetJob(ctx context.Context, id uint, userID uint) (*models.BulkJob, error) {
+	var job models.BulkJob
+	err := s.db.WithContext(ctx).Where("id = ?", id).First(&job).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrBulkJobNotFound
+		}
+		return nil, err
+	}
+	if job.UserID != userID {
+		return nil, ErrUnauthorized
+	}
+	return &job, nil
+}
+
+// applyBulkItems applies the job's action to ids in one transaction and
+// saves the job's progress in the same transaction. The services are built
+// on the transaction, so each item runs in a savepoint of its own: a failed
+// item is rolled back and reported while the others go through.
+func applyBulkItems(ctx context.Context, db *database.DB, job *models.BulkJob, ids []uint, now func() time.Time) error {
+	progress := *job
+	progress.Results = slices.Clone(job.Results)
+
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		scoped := &database.DB{DB: tx}
+		agentService := NewAgentService(scoped, now)
+		clientService := NewClientService(scoped, agentService, now)
+		profileService := NewClientProfileService(scoped, clientService, NewCustomFieldService(scoped))
+
+		for _, id := range ids {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			var err error
+			switch job.Action {
+			case models.BulkActionReassign:
+				_, err = clientService.TransferClient(ctx, id, job.Params.AgentID, fmt.Sprintf("bulk job %d", job.ID), job.UserID)
+			case models.BulkActionTag:
+				_, err = profileService.AddTags(ctx, id, job.Params.Tags, job.UserID)
+			case models.BulkActionUntag:
+				_, err = profileService.RemoveTags(ctx, id, job.Params.Tags, job.UserID)
+			case models.BulkActionArchive:
+				_, err = clientService.ArchiveClient(ctx, id, job.UserID)
+			case models.BulkActionUnarchive:
+				_, err = clientService.UnarchiveClient(ctx, id, job.UserID)
+			case models.BulkActionDelete:
+				err = clientService.DeleteClient(ctx, id, job.UserID)
+			case models.BulkActionSetStatus:
+				_, err = agentService.SetAgentStatus(ctx, id, job.Params.Status, job.UserID)
+			default:
+				err = ErrInvalidBulkAction
+			}
+
+			result := models.BulkItemResult{ID: id, OK: err == nil}
+			if err != nil {
+				progress.Failed++
+				apiErr := ErrInternal
+				if !errors.As(err, &apiErr) {
+					slog.ErrorContext(ctx, "bulk item failed", "job_id", job.ID, "item_id", id, "error", err)
+				}
+				result.Code = apiErr.Code
+				result.Message = apiErr.Message
+			} else {
+				progress.Succeeded++
+			}
+			progress.Results = append(progress.Results, result)
+		}
+
+		progress.Processed += len(ids)
+		return tx.Save(&progress).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	*job = progress
+	return nil
+}
+
+func finishBulkJob(ctx context.Context, db *database.DB, job *models.BulkJob, finishedAt time.Time) (*models.BulkJob, error) {
+	job.Status = models.BulkJobStatusCompleted
+	job.FinishedAt = &finishedAt
+	if err := db.WithContext(ctx).Save(job).Error; err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// BulkJobRunner works through queued bulk jobs, oldest first, one chunk
+// per transaction.
+type BulkJobRunner struct {
+	db  *database.DB
+	cfg *config.Config
+	now func() time.Time
+}
+
+func NewBulkJobRunner(db *database.DB, cfg *config.Config, now func() time.Time) *BulkJobRunner {
+	return &BulkJobRunner{db: db, cfg: cfg, now: now}
+}
+
+// Run polls until ctx is cancelled. Jobs left running by a previous process
+// are resumed where they stopped.
+func (r *BulkJobRunner) Run(ctx context.Context) {
+	err := r.db.WithContext(ctx).
+		Model(&models.BulkJob{}).
+		Where("status = ?", models.BulkJobStatusRunning).
+		Update("status", models.BulkJobStatusPending).
+		Error
+	if err != nil {
+		slog.ErrorContext(ctx, "resuming bulk jobs failed", "error", err)
+	}
+
+	ticker := time.NewTicker(r.cfg.BulkPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.Tick(ctx); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "bulk job failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick runs every pending job to completion.
+func (r *BulkJobRunner) Tick(ctx context.Context) error {
+	for {
+		job, err := r.claim(ctx)
+		if err != nil || job == nil {
+			return err
+		}
+		if err := r.process(ctx, job); err != nil {
+			return err
+		}
+	}
+}
+
+func (r *BulkJobRunner) claim(ctx context.Context) (*models.BulkJob, error) {
+	var job models.BulkJob
+	err := r.db.WithContext(ctx).
+		Where("status = ?", models.BulkJobStatusPending).
+		Order("id").
+		First(&job).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	started := r.now()
+	if job.StartedAt == nil {
+		job.StartedAt = &started
+	}
+	claimed := r.db.WithContext(ctx).
+		Model(&job).
+		Where("status = ?", models.BulkJobStatusPending).
+		Updates(map[string]interface{}{"status": models.BulkJobStatusRunning, "started_at": job.StartedAt})
+	if claimed.Error != nil {
+		return nil, claimed.Error
+	}
+	if claimed.RowsAffected == 0 {
+		// Another worker took it.
+		return r.claim(ctx)
+	}
+	job.Status = models.BulkJobStatusRunning
+	return &job, nil
+}
+
+func (r *BulkJobRunner) process(ctx context.Context, job *models.BulkJob) error {
+	for job.Processed < len(job.ItemIDs) {
+		end := min(job.Processed+bulkChunkSize, len(job.ItemIDs))
+		if err := applyBulkItems(ctx, r.db, job, job.ItemIDs[job.Processed:end], r.now); err != nil {
+			if ctx.Err() != nil {
+				// Shutting down; the job is resumed on the next start.
+				return err
+			}
+			finishedAt := r.now()
+			job.Status = models.BulkJobStatusFailed
+			job.Error = err.Error()
+			job.FinishedAt = &finishedAt
+			if saveErr := r.db.WithContext(ctx).Save(job).Error; saveErr != nil {
+				return saveErr
+			}
+			return err
+		}
+	}
+
+	_, err := finishBulkJob(ctx, r.db, job, r.now())
+	return err
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"backend/internal/config"
+	"backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBulk_ClientsInOneTransaction(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	cfg := &config.Config{BulkSyncLimit: 10, BulkMaxItems: 100}
+
+	userService := NewUserService(db)
+	owner := &models.User{Username: "owner", Email: "owner@mail.com", Password: "x"}
+	require.NoError(t, userService.CreateUser(ctx, owner))
+	other := &models.User{Username: "other", Email: "other@mail.com", Password: "x"}
+	require.NoError(t, userService.CreateUser(ctx, other))
+
+	agentService := NewAgentService(db, time.Now)
+	clientService := NewClientService(db, agentService, time.Now)
+	profileService := NewClientProfileService(db, clientService, NewCustomFieldService(db))
+	bulkService := NewBulkService(db, cfg, agentService, clientService, time.Now)
+
+	from, err := agentService.CreateAgent(ctx, &models.Agent{Name: "from", Characteristics: "c"}, owner.ID)
+	require.NoError(t, err)
+	to, err := agentService.CreateAgent(ctx, &models.Agent{Name: "to", Characteristics: "c"}, owner.ID)
+	require.NoError(t, err)
+	foreignAgent, err := agentService.CreateAgent(ctx, &models.Agent{Name: "foreign", Characteristics: "c"}, other.ID)
+	require.NoError(t, err)
+
+	first, err := clientService.CreateClient(ctx, &models.Client{Name: "first", AgentID: from.ID}, from.ID, owner.ID)
+	require.NoError(t, err)
+	second, err := clientService.CreateClient(ctx, &models.Client{Name: "second", AgentID: from.ID}, from.ID, owner.ID)
+	require.NoError(t, err)
+	foreign, err := clientService.CreateClient(ctx, &models.Client{Name: "foreign", AgentID: foreignAgent.ID}, foreignAgent.ID, other.ID)
+	require.NoError(t, err)
+
+	job, err := bulkService.Submit(ctx, BulkRequest{
+		Target: models.BulkTargetClients,
+		Action: models.BulkActionReassign,
+		IDs:    []uint{first.ID, foreign.ID, second.ID, first.ID, 9999},
+		Params: models.BulkParams{AgentID: to.ID},
+	}, owner.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.BulkJobStatusCompleted, job.Status)
+	assert.Equal(t, 4, job.Total)
+	assert.Equal(t, 2, job.Succeeded)
+	assert.Equal(t, 2, job.Failed)
+	require.Len(t, job.Results, 4)
+	assert.True(t, job.Results[0].OK)
+	assert.Equal(t, ErrUnauthorized.Code, job.Results[1].Code)
+	assert.Equal(t, ErrClientNotFound.Code, job.Results[3].Code)
+
+	moved, err := clientService.ListClients(ctx, ClientFilter{AgentID: to.ID}, owner.ID)
+	require.NoError(t, err)
+	assert.Len(t, moved, 2)
+	untouched, err := clientService.GetClientByID(ctx, foreign.ID, other.ID)
+	require.NoError(t, err)
+	assert.Equal(t, foreignAgent.ID, untouched.AgentID)
+
+	// A filter selects the clients at submission time.
+	_, err = bulkService.Submit(ctx, BulkRequest{
+		Target:       models.BulkTargetClients,
+		Action:       models.BulkActionTag,
+		ClientFilter: &ClientFilter{AgentID: to.ID},
+		Params:       models.BulkParams{Tags: models.StringList{"VIP"}},
+	}, owner.ID)
+	require.NoError(t, err)
+	tags, err := profileService.GetTags(ctx, second.ID, owner.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"vip"}, tags)
+
+	job, err = bulkService.Submit(ctx, BulkRequest{
+		Target:       models.BulkTargetClients,
+		Action:       models.BulkActionArchive,
+		ClientFilter: &ClientFilter{Tags: []string{"vip"}},
+	}, owner.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, job.Succeeded)
+	active, err := clientService.ListClients(ctx, ClientFilter{}, owner.ID)
+	require.NoError(t, err)
+	assert.Empty(t, active)
+
+	_, err = bulkService.Submit(ctx, BulkRequest{
+		Target: models.BulkTargetClients,
+		Action: models.BulkActionSetStatus,
+		IDs:    []uint{first.ID},
+	}, owner.ID)
+	assert.ErrorIs(t, err, ErrInvalidBulkAction)
+
+	_, err = bulkService.Submit(ctx, BulkRequest{
+		Target: models.BulkTargetClients,
+		Action: models.BulkActionReassign,
+		IDs:    []uint{first.ID},
+		Params: models.BulkParams{AgentID: foreignAgent.ID},
+	}, owner.ID)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	_, err = bulkService.Submit(ctx, BulkRequest{
+		Target:       models.BulkTargetClients,
+		Action:       models.BulkActionDelete,
+		IDs:          []uint{first.ID},
+		ClientFilter: &ClientFilter{},
+	}, owner.ID)
+	assert.ErrorIs(t, err, ErrBulkSelectionRequired)
+}
+
+func TestBulk_LargeSelectionRunsAsJob(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	cfg := &config.Config{BulkSyncLimit: 2, BulkMaxItems: 5}
+
+	user := &models.User{Username: "owner", Email: "owner@mail.com", Password: "x"}
+	require.NoError(t, NewUserService(db).CreateUser(ctx, user))
+
+	agentService := NewAgentService(db, time.Now)
+	clientService := NewClientService(db, agentService, time.Now)
+	bulkService := NewBulkService(db, cfg, agentService, clientService, time.Now)
+
+	var ids []uint
+	for _, name := range []string{"a", "b", "c", "d"} {
+		agent, err := agentService.CreateAgent(ctx, &models.Agent{Name: name, Characteristics: "c"}, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.AgentStatusActive, agent.Status)
+		ids = append(ids, agent.ID)
+	}
+
+	job, err := bulkService.Submit(ctx, BulkRequest{
+		Target: models.BulkTargetAgents,
+		Action: models.BulkActionSetStatus,
+		IDs:    ids[:3],
+		Params: models.BulkParams{Status: "paused"},
+	}, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.BulkJobStatusPending, job.Status)
+	assert.Zero(t, job.Processed)
+
+	require.NoError(t, NewBulkJobRunner(db, cfg, time.Now).Tick(ctx))
+
+	job, err = bulkService.GetJob(ctx, job.ID, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.BulkJobStatusCompleted, job.Status)
+	assert.Equal(t, 3, job.Processed)
+	assert.Equal(t, 3, job.Succeeded)
+	assert.NotNil(t, job.FinishedAt)
+
+	paused, err := bulkService.Submit(ctx, BulkRequest{
+		Target:      models.BulkTargetAgents,
+		Action:      models.BulkActionSetStatus,
+		AgentFilter: &AgentFilter{Status: models.AgentStatusPaused},
+		Params:      models.BulkParams{Status: models.AgentStatusRetired},
+	}, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.BulkJobStatusPending, paused.Status, "three paused agents exceed the sync limit")
+	assert.Equal(t, 3, paused.Total)
+
+	_, err = bulkService.Submit(ctx, BulkRequest{
+		Target: models.BulkTargetAgents,
+		Action: models.BulkActionSetStatus,
+		IDs:    []uint{1, 2, 3, 4, 5, 6},
+		Params: models.BulkParams{Status: models.AgentStatusActive},
+	}, user.ID)
+	assert.ErrorIs(t, err, ErrBulkTooLarge)
+}
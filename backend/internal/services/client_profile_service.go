@@ -0,0 +1,498 @@
+package services
+
+import (
+	"backend/internal/models"
+	"backend/pkg/database"
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+// ClientProfileService manages what operators record about a client beyond
+// its core fields: contact points, tags, custom field values and notes.
+// Every method first checks that the client belongs to userID.
+type ClientProfileService interface {
+	GetContacts(ctx context.Context, clientID uint, userID uint) ([]*models.ClientContact, error)
+	CreateContact(ctx context.Context, contact *models.ClientContact, userID uint) (*models.ClientContact, error)
+	UpdateContact(ctx context.Context, contact *models.ClientContact, userID uint) (*models.ClientContact, error)
+	DeleteContact(ctx context.Context, clientID uint, contactID uint, userID uint) error
+
+	GetTags(ctx context.Context, clientID uint, userID uint) ([]string, error)
+	AddTags(ctx context.Context, clientID uint, tags []string, userID uint) ([]string, error)
+	SetTags(ctx context.Context, clientID uint, tags []string, userID uint) ([]string, error)
+	RemoveTag(ctx context.Context, clientID uint, tag string, userID uint) ([]string, error)
+	RemoveTags(ctx context.Context, clientID uint, tags []string, userID uint) ([]string, error)
+
+	GetFieldValues(ctx context.Context, clientID uint, userID uint) ([]*models.ClientFieldValue, error)
+	SetFieldValues(ctx context.Context, clientID uint, values map[string]*string, userID uint) ([]*models.ClientFieldValue, error)
+
+	GetNotes(ctx context.Context, clientID uint, userID uint) ([]*models.ClientNote, error)
+	CreateNote(ctx context.Context, note *models.ClientNote, userID uint) (*models.ClientNote, error)
+	UpdateNote(ctx context.Context, note *models.ClientNote, userID uint) (*models.ClientNote, error)
+	DeleteNote(ctx context.Context, clientID uint, noteID uint, userID uint) error
+}
+
+type clientProfileServiceImpl struct {
+	db                 *database.DB
+	clientService      ClientService
+	customFieldService CustomFieldService
+}
+
+func NewClientProfileService(db *database.DB, clientService ClientService, customFieldService CustomFieldService) ClientProfileService {
+	return &clientProfileServiceImpl{
+		db:                 db,
+		clientService:      clientService,
+		customFieldService: customFieldService,
+	}
+}
+
+const maxTagLength = 50
+
+var (
+	ErrContactNotFound      = NewError(http.StatusNotFound, "contact_not_found", "contact not found")
+	ErrInvalidContactID     = NewError(http.StatusBadRequest, "invalid_contact_id", "contact ID is invalid")
+	ErrInvalidContactKind   = NewError(http.StatusBadRequest, "invalid_contact_kind", "contact kind must be EMAIL, PHONE, WHATSAPP, TELEGRAM, INSTAGRAM or OTHER")
+	ErrContactValueRequired = NewError(http.StatusBadRequest, "contact_value_required", "contact value is required")
+	ErrInvalidTag           = NewError(http.StatusBadRequest, "invalid_tag", "tags must be 1 to 50 characters long")
+	ErrNoteNotFound         = NewError(http.StatusNotFound, "note_not_found", "note not found")
+	ErrInvalidNoteID        = NewError(http.StatusBadRequest, "invalid_note_id", "note ID is invalid")
+	ErrNoteBodyRequired     = NewError(http.StatusBadRequest, "note_body_required", "note body is required")
+)
+
+var contactKinds = []string{
+	models.ContactKindEmail,
+	models.ContactKindPhone,
+	models.ContactKindWhatsApp,
+	models.ContactKindTelegram,
+	models.ContactKindInstagram,
+	models.ContactKindOther,
+}
+
+func (s *clientProfileServiceImpl) GetContacts(ctx context.Context, clientID uint, userID uint) ([]*models.ClientContact, error) {
+	if _, err := s.clientService.GetClientByID(ctx, clientID, userID); err != nil {
+		return nil, err
+	}
+
+	var contacts []*models.ClientContact
+	err := s.db.WithContext(ctx).
+		Where("client_id = ?", clientID).
+		Order("kind, id").
+		Find(&contacts).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return contacts, nil
+}
+
+func (s *clientProfileServiceImpl) CreateContact(ctx context.Context, contact *models.ClientContact, userID uint) (*models.ClientContact, error) {
+	if _, err := s.clientService.GetClientByID(ctx, contact.ClientID, userID); err != nil {
+		return nil, err
+	}
+	contact.ID = 0
+	if err := validateContact(contact); err != nil {
+		return nil, err
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(contact).Error; err != nil {
+			return err
+		}
+		if err := demoteOtherPrimaries(tx, contact); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, userID, models.AuditActionCreate, AuditEntityContact, contact.ID, nil, contact)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return contact, nil
+}
+
+func (s *clientProfileServiceImpl) UpdateContact(ctx context.Context, contact *models.ClientContact, userID uint) (*models.ClientContact, error) {
+	existing, err := s.findContact(ctx, contact.ClientID, contact.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	before := *existing
+	if contact.Kind != "" {
+		existing.Kind = contact.Kind
+	}
+	if contact.Value != "" {
+		existing.Value = contact.Value
+	}
+	existing.Label = contact.Label
+	existing.Primary = contact.Primary
+	if err := validateContact(existing); err != nil {
+		return nil, err
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(existing).Error; err != nil {
+			return err
+		}
+		if err := demoteOtherPrimaries(tx, existing); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, userID, models.AuditActionUpdate, AuditEntityContact, existing.ID, &before, existing)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+func (s *clientProfileServiceImpl) DeleteContact(ctx context.Context, clientID uint, contactID uint, userID uint) error {
+	existing, err := s.findContact(ctx, clientID, contactID, userID)
+	if err != nil {
+		return err
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(existing).Error; err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, userID, models.AuditActionDelete, AuditEntityContact, existing.ID, existing, nil)
+	})
+}
+
+func (s *clientProfileServiceImpl) findContact(ctx context.Context, clientID uint, contactID uint, userID uint) (*models.ClientContact, error) {
+	if _, err := s.clientService.GetClientByID(ctx, clientID, userID); err != nil {
+		return nil, err
+	}
+
+	var contact models.ClientContact
+	err := s.db.WithContext(ctx).
+		Where("id = ? AND client_id = ?", contactID, clientID).
+		First(&contact).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrContactNotFound
+		}
+		return nil, err
+	}
+	return &contact, nil
+}
+
+func validateContact(contact *models.ClientContact) error {
+	contact.Kind = strings.ToUpper(strings.TrimSpace(contact.Kind))
+	contact.Value = strings.TrimSpace(contact.Value)
+	contact.Label = strings.TrimSpace(contact.Label)
+	if !slices.Contains(contactKinds, contact.Kind) {
+		return ErrInvalidContactKind
+	}
+	if contact.Value == "" {
+		return ErrContactValueRequired
+	}
+	return nil
+}
+
+// demoteOtherPrimaries keeps at most one primary contact per kind.
+func demoteOtherPrimaries(tx *gorm.DB, contact *models.ClientContact) error {
+	if !contact.Primary {
+		return nil
+	}
+	return tx.Model(&models.ClientContact{}).
+		Where("client_id = ? AND kind = ? AND id <> ?", contact.ClientID, contact.Kind, contact.ID).
+		Update("primary", false).
+		Error
+}
+
+func (s *clientProfileServiceImpl) GetTags(ctx context.Context, clientID uint, userID uint) ([]string, error) {
+	if _, err := s.clientService.GetClientByID(ctx, clientID, userID); err != nil {
+		return nil, err
+	}
+	return clientTags(s.db.WithContext(ctx), clientID)
+}
+
+func (s *clientProfileServiceImpl) AddTags(ctx context.Context, clientID uint, tags []string, userID uint) ([]string, error) {
+	return s.changeTags(ctx, clientID, userID, func(current []string) ([]string, error) {
+		added, err := normalizeTags(tags)
+		if err != nil {
+			return nil, err
+		}
+		return normalizeTags(append(current, added...))
+	})
+}
+
+func (s *clientProfileServiceImpl) SetTags(ctx context.Context, clientID uint, tags []string, userID uint) ([]string, error) {
+	return s.changeTags(ctx, clientID, userID, func([]string) ([]string, error) {
+		return normalizeTags(tags)
+	})
+}
+
+func (s *clientProfileServiceImpl) RemoveTag(ctx context.Context, clientID uint, tag string, userID uint) ([]string, error) {
+	return s.RemoveTags(ctx, clientID, []string{tag}, userID)
+}
+
+func (s *clientProfileServiceImpl) RemoveTags(ctx context.Context, clientID uint, tags []string, userID uint) ([]string, error) {
+	removed := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		removed = append(removed, normalizeTag(tag))
+	}
+	return s.changeTags(ctx, clientID, userID, func(current []string) ([]string, error) {
+		return slices.DeleteFunc(current, func(t string) bool { return slices.Contains(removed, t) }), nil
+	})
+}
+
+// changeTags replaces the client's tags with what change makes of the
+// current ones and records the difference as a single audit entry.
+func (s *clientProfileServiceImpl) changeTags(ctx context.Context, clientID uint, userID uint, change func([]string) ([]string, error)) ([]string, error) {
+	if _, err := s.clientService.GetClientByID(ctx, clientID, userID); err != nil {
+		return nil, err
+	}
+
+	var after []string
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		before, err := clientTags(tx, clientID)
+		if err != nil {
+			return err
+		}
+		after, err = change(slices.Clone(before))
+		if err != nil {
+			return err
+		}
+		if slices.Equal(before, after) {
+			return nil
+		}
+
+		if err := tx.Where("client_id = ?", clientID).Delete(&models.ClientTag{}).Error; err != nil {
+			return err
+		}
+		if len(after) > 0 {
+			rows := make([]models.ClientTag, 0, len(after))
+			for _, tag := range after {
+				rows = append(rows, models.ClientTag{ClientID: clientID, Tag: tag})
+			}
+			if err := tx.Create(&rows).Error; err != nil {
+				return err
+			}
+		}
+		return recordAudit(ctx, tx, userID, models.AuditActionUpdate, AuditEntityClientTags, clientID, tagSet{before}, tagSet{after})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return after, nil
+}
+
+// tagSet is how a client's tags appear in the audit log, which records
+// objects rather than bare lists.
+type tagSet struct {
+	Tags []string
+}
+
+func clientTags(db *gorm.DB, clientID uint) ([]string, error) {
+	tags := []string{}
+	err := db.Model(&models.ClientTag{}).
+		Where("client_id = ?", clientID).
+		Order("tag").
+		Pluck("tag", &tags).
+		Error
+	return tags, err
+}
+
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// normalizeTags lower-cases, de-duplicates and sorts tags.
+func normalizeTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = normalizeTag(tag)
+		if tag == "" || utf8.RuneCountInString(tag) > maxTagLength {
+			return nil, ErrInvalidTag.WithField("tags")
+		}
+		normalized = append(normalized, tag)
+	}
+	slices.Sort(normalized)
+	return slices.Compact(normalized), nil
+}
+
+func (s *clientProfileServiceImpl) GetFieldValues(ctx context.Context, clientID uint, userID uint) ([]*models.ClientFieldValue, error) {
+	if _, err := s.clientService.GetClientByID(ctx, clientID, userID); err != nil {
+		return nil, err
+	}
+	return clientFieldValues(s.db.WithContext(ctx), clientID)
+}
+
+// SetFieldValues writes the given values, keyed by custom field key. A nil
+// or empty value clears the field; fields not mentioned are left alone.
+func (s *clientProfileServiceImpl) SetFieldValues(ctx context.Context, clientID uint, values map[string]*string, userID uint) ([]*models.ClientFieldValue, error) {
+	if _, err := s.clientService.GetClientByID(ctx, clientID, userID); err != nil {
+		return nil, err
+	}
+
+	type change struct {
+		field *models.CustomField
+		value string
+	}
+	changes := make([]change, 0, len(values))
+	for key, raw := range values {
+		field, err := s.customFieldService.GetFieldByKey(ctx, key, userID)
+		if err != nil {
+			return nil, err
+		}
+		c := change{field: field}
+		if raw != nil && strings.TrimSpace(*raw) != "" {
+			if c.value, err = normalizeFieldValue(field, *raw); err != nil {
+				return nil, err
+			}
+		}
+		changes = append(changes, c)
+	}
+
+	var after []*models.ClientFieldValue
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		before, err := clientFieldValues(tx, clientID)
+		if err != nil {
+			return err
+		}
+
+		for _, c := range changes {
+			if err := tx.Where("client_id = ? AND field_id = ?", clientID, c.field.ID).Delete(&models.ClientFieldValue{}).Error; err != nil {
+				return err
+			}
+			if c.value == "" {
+				continue
+			}
+			if err := tx.Create(&models.ClientFieldValue{ClientID: clientID, FieldID: c.field.ID, Value: c.value}).Error; err != nil {
+				return err
+			}
+		}
+
+		if after, err = clientFieldValues(tx, clientID); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, userID, models.AuditActionUpdate, AuditEntityFieldValues, clientID, fieldValueMap(before), fieldValueMap(after))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return after, nil
+}
+
+func clientFieldValues(db *gorm.DB, clientID uint) ([]*models.ClientFieldValue, error) {
+	values := []*models.ClientFieldValue{}
+	err := db.Preload("Field").
+		Joins("JOIN custom_fields ON custom_fields.id = client_field_values.field_id").
+		Where("client_field_values.client_id = ?", clientID).
+		Order("custom_fields.key").
+		Find(&values).
+		Error
+	return values, err
+}
+
+func fieldValueMap(values []*models.ClientFieldValue) map[string]string {
+	m := make(map[string]string, len(values))
+	for _, v := range values {
+		m[v.Field.Key] = v.Value
+	}
+	return m
+}
+
+// GetNotes returns the client's notes, newest first.
+func (s *clientProfileServiceImpl) GetNotes(ctx context.Context, clientID uint, userID uint) ([]*models.ClientNote, error) {
+	if _, err := s.clientService.GetClientByID(ctx, clientID, userID); err != nil {
+		return nil, err
+	}
+
+	var notes []*models.ClientNote
+	err := s.db.WithContext(ctx).
+		Where("client_id = ?", clientID).
+		Order("created_at desc, id desc").
+		Find(&notes).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
+
+func (s *clientProfileServiceImpl) CreateNote(ctx context.Context, note *models.ClientNote, userID uint) (*models.ClientNote, error) {
+	if _, err := s.clientService.GetClientByID(ctx, note.ClientID, userID); err != nil {
+		return nil, err
+	}
+	note.ID = 0
+	note.AuthorID = userID
+	note.Body = strings.TrimSpace(note.Body)
+	if note.Body == "" {
+		return nil, ErrNoteBodyRequired
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(note).Error; err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, userID, models.AuditActionCreate, AuditEntityNote, note.ID, nil, note)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return note, nil
+}
+
+func (s *clientProfileServiceImpl) UpdateNote(ctx context.Context, note *models.ClientNote, userID uint) (*models.ClientNote, error) {
+	existing, err := s.findNote(ctx, note.ClientID, note.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+	body := strings.TrimSpace(note.Body)
+	if body == "" {
+		return nil, ErrNoteBodyRequired
+	}
+
+	before := *existing
+	existing.Body = body
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(existing).Error; err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, userID, models.AuditActionUpdate, AuditEntityNote, existing.ID, &before, existing)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+func (s *clientProfileServiceImpl) DeleteNote(ctx context.Context, clientID uint, noteID uint, userID uint) error {
+	existing, err := s.findNote(ctx, clientID, noteID, userID)
+	if err != nil {
+		return err
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(existing).Error; err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, userID, models.AuditActionDelete, AuditEntityNote, existing.ID, existing, nil)
+	})
+}
+
+func (s *clientProfileServiceImpl) findNote(ctx context.Context, clientID uint, noteID uint, userID uint) (*models.ClientNote, error) {
+	if _, err := s.clientService.GetClientByID(ctx, clientID, userID); err != nil {
+		return nil, err
+	}
+
+	var note models.ClientNote
+	err := s.db.WithContext(ctx).
+		Where("id = ? AND client_id = ?", noteID, clientID).
+		First(&note).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNoteNotFound
+		}
+		return nil, err
+	}
+	return &note, nil
+}
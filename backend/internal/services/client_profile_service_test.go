@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientProfile_FiltersByTagsContactsAndFields(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	user := &models.User{Username: "owner", Email: "owner@mail.com", Password: "x"}
+	require.NoError(t, NewUserService(db).CreateUser(ctx, user))
+
+	agentService := NewAgentService(db, time.Now)
+	clientService := NewClientService(db, agentService, time.Now)
+	customFieldService := NewCustomFieldService(db)
+	profileService := NewClientProfileService(db, clientService, customFieldService)
+
+	agent, err := agentService.CreateAgent(ctx, &models.Agent{Name: "a", Characteristics: "c"}, user.ID)
+	require.NoError(t, err)
+	daniel, err := clientService.CreateClient(ctx, &models.Client{Name: "Daniel", AgentID: agent.ID}, agent.ID, user.ID)
+	require.NoError(t, err)
+	maria, err := clientService.CreateClient(ctx, &models.Client{Name: "Maria", AgentID: agent.ID}, agent.ID, user.ID)
+	require.NoError(t, err)
+
+	tags, err := profileService.AddTags(ctx, daniel.ID, []string{" VIP ", "night-owl", "vip"}, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"night-owl", "vip"}, tags)
+	_, err = profileService.SetTags(ctx, maria.ID, []string{"vip"}, user.ID)
+	require.NoError(t, err)
+
+	_, err = profileService.CreateContact(ctx, &models.ClientContact{ClientID: daniel.ID, Kind: "email", Value: "daniel@example.com", Primary: true}, user.ID)
+	require.NoError(t, err)
+	second, err := profileService.CreateContact(ctx, &models.ClientContact{ClientID: daniel.ID, Kind: "EMAIL", Value: "dan@work.example", Primary: true}, user.ID)
+	require.NoError(t, err)
+	contacts, err := profileService.GetContacts(ctx, daniel.ID, user.ID)
+	require.NoError(t, err)
+	require.Len(t, contacts, 2)
+	for _, contact := range contacts {
+		assert.Equal(t, contact.ID == second.ID, contact.Primary, "only the newest primary email stays primary")
+	}
+
+	_, err = customFieldService.CreateField(ctx, &models.CustomField{Key: "birthday", Label: "Birthday", Type: models.CustomFieldTypeDate}, user.ID)
+	require.NoError(t, err)
+	_, err = customFieldService.CreateField(ctx, &models.CustomField{Key: "birthday", Label: "Again", Type: models.CustomFieldTypeText}, user.ID)
+	assert.ErrorIs(t, err, ErrCustomFieldKeyTaken)
+
+	bad := "not a date"
+	_, err = profileService.SetFieldValues(ctx, daniel.ID, map[string]*string{"birthday": &bad}, user.ID)
+	assert.ErrorIs(t, err, ErrInvalidCustomFieldValue)
+	birthday := "1990-04-01"
+	values, err := profileService.SetFieldValues(ctx, daniel.ID, map[string]*string{"birthday": &birthday}, user.ID)
+	require.NoError(t, err)
+	require.Len(t, values, 1)
+	assert.Equal(t, "birthday", values[0].Field.Key)
+
+	names := func(filter ClientFilter) []string {
+		t.Helper()
+		clients, err := clientService.ListClients(ctx, filter, user.ID)
+		require.NoError(t, err)
+		var names []string
+		for _, client := range clients {
+			names = append(names, client.Name)
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"Daniel", "Maria"}, names(ClientFilter{Tags: []string{"VIP"}}))
+	assert.Equal(t, []string{"Daniel"}, names(ClientFilter{Tags: []string{"vip", "night-owl"}}))
+	assert.Equal(t, []string{"Daniel"}, names(ClientFilter{Contact: "WORK.example"}))
+	assert.Equal(t, []string{"Daniel"}, names(ClientFilter{Fields: map[string]string{"birthday": "1990-04-01"}}))
+	assert.Empty(t, names(ClientFilter{Name: "mar", Tags: []string{"night-owl"}}))
+
+	_, err = clientService.ListClients(ctx, ClientFilter{Fields: map[string]string{"missing": "x"}}, user.ID)
+	assert.ErrorIs(t, err, ErrUnknownCustomField)
+
+	stranger := &models.User{Username: "stranger", Email: "stranger@mail.com", Password: "x"}
+	require.NoError(t, NewUserService(db).CreateUser(ctx, stranger))
+	_, err = profileService.GetTags(ctx, daniel.ID, stranger.ID)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+	clients, err := clientService.ListClients(ctx, ClientFilter{}, stranger.ID)
+	require.NoError(t, err)
+	assert.Empty(t, clients)
+}
+
+func TestClientProfile_PurgedWithClient(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	user := &models.User{Username: "owner", Email: "owner@mail.com", Password: "x"}
+	require.NoError(t, NewUserService(db).CreateUser(ctx, user))
+
+	agentService := NewAgentService(db, time.Now)
+	clientService := NewClientService(db, agentService, time.Now)
+	profileService := NewClientProfileService(db, clientService, NewCustomFieldService(db))
+
+	agent, err := agentService.CreateAgent(ctx, &models.Agent{Name: "a", Characteristics: "c"}, user.ID)
+	require.NoError(t, err)
+	client, err := clientService.CreateClient(ctx, &models.Client{Name: "c", AgentID: agent.ID}, agent.ID, user.ID)
+	require.NoError(t, err)
+
+	_, err = profileService.AddTags(ctx, client.ID, []string{"vip"}, user.ID)
+	require.NoError(t, err)
+	_, err = profileService.CreateNote(ctx, &models.ClientNote{ClientID: client.ID, Body: "prefers evenings"}, user.ID)
+	require.NoError(t, err)
+
+	require.NoError(t, clientService.DeleteClient(ctx, client.ID, user.ID))
+	require.NoError(t, clientService.PurgeClient(ctx, client.ID, user.ID))
+
+	var tags, notes int64
+	require.NoError(t, db.Model(&models.ClientTag{}).Count(&tags).Error)
+	require.NoError(t, db.Model(&models.ClientNote{}).Count(&notes).Error)
+	assert.Zero(t, tags)
+	assert.Zero(t, notes)
+}
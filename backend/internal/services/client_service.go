@@ -5,6 +5,9 @@ import (
 	"backend/pkg/database"
 	"context"
 	"errors"
+	"net/http"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -12,30 +15,44 @@ import (
 type ClientService interface {
 	GetClientByID(ctx context.Context, id uint, userID uint) (*models.Client, error)
 	GetClientsByAgentID(ctx context.Context, agentID uint, userID uint) ([]*models.Client, error)
+	ListClients(ctx context.Context, filter ClientFilter, userID uint) ([]*models.Client, error)
 	CreateClient(ctx context.Context, client *models.Client, agentID uint, userID uint) (*models.Client, error)
 	UpdateClient(ctx context.Context, client *models.Client, userID uint) (*models.Client, error)
 	DeleteClient(ctx context.Context, id uint, userID uint) error
+	GetDeletedClients(ctx context.Context, userID uint) ([]*models.Client, error)
+	RestoreClient(ctx context.Context, id uint, userID uint) (*models.Client, error)
+	PurgeClient(ctx context.Context, id uint, userID uint) error
+	TransferClient(ctx context.Context, id uint, agentID uint, reason string, userID uint) (*models.Client, error)
+	GetAssignments(ctx context.Context, id uint, userID uint) ([]*models.ClientAssignment, error)
+	ArchiveClient(ctx context.Context, id uint, userID uint) (*models.Client, error)
+	UnarchiveClient(ctx context.Context, id uint, userID uint) (*models.Client, error)
 }
 
 type clientServiceImpl struct {
 	db           *database.DB
 	agentService AgentService
+	now          func() time.Time
 }
 
-func NewClientService(db *database.DB, agentService AgentService) ClientService {
+// NewClientService creates the client service. now is the clock segment
+// filters are evaluated against and deletions are dated by.
+func NewClientService(db *database.DB, agentService AgentService, now func() time.Time) ClientService {
 	return &clientServiceImpl{
 		db:           db,
 		agentService: agentService,
+		now:          now,
 	}
 }
 
 var (
-	ErrClientNotFound      = errors.New("client not found")
-	ErrClientAlreadyExists = errors.New("client already exists")
-	ErrClientNameRequired  = errors.New("client name is required")
-	ErrClientIDRequired    = errors.New("client ID is required")
-	ErrInvalidClientID     = errors.New("client ID is invalid")
-	ErrInvalidDate         = errors.New("invalid date")
+	ErrClientNotFound      = NewError(http.StatusNotFound, "client_not_found", "client not found")
+	ErrClientAlreadyExists = NewError(http.StatusConflict, "client_already_exists", "client already exists")
+	ErrClientNameRequired  = NewError(http.StatusBadRequest, "client_name_required", "client name is required")
+	ErrClientIDRequired    = NewError(http.StatusBadRequest, "client_id_required", "client ID is required")
+	ErrInvalidClientID     = NewError(http.StatusBadRequest, "invalid_client_id", "client ID is invalid")
+	ErrInvalidDate         = NewError(http.StatusBadRequest, "invalid_date", "invalid date")
+
+	ErrInvalidArchivedFilter = NewError(http.StatusBadRequest, "invalid_archived_filter", "archived must be false, true or all")
 )
 
 func (c *clientServiceImpl) GetClientByID(ctx context.Context, id uint, userID uint) (*models.Client, error) {
@@ -92,6 +109,100 @@ func (c *clientServiceImpl) GetClientsByAgentID(ctx context.Context, agentID uin
 	return clients, nil
 }
 
+// ClientFilter narrows a client list. Zero fields do not filter. A client
+// must carry every tag in Tags and match every entry of Fields, which maps a
+// custom field key to a value in any form the field's type accepts.
+// SegmentID keeps only the members of one of the user's segments. Archived
+// clients are left out unless Archived is ArchivedOnly or ArchivedInclude.
+type ClientFilter struct {
+	AgentID   uint
+	SegmentID uint
+	Archived  string
+	Name      string // case-insensitive substring of the name
+	Contact   string // case-insensitive substring of any contact value
+	Tags      []string
+	Fields    map[string]string
+}
+
+const (
+	ArchivedOnly    = "only"
+	ArchivedInclude = "include"
+)
+
+func (c *clientServiceImpl) ListClients(ctx context.Context, filter ClientFilter, userID uint) ([]*models.Client, error) {
+	db := c.db.WithContext(ctx)
+	query := db.Preload("Agent").
+		Where("clients.agent_id IN (?)", db.Model(&models.Agent{}).Select("id").Where("user_id = ?", userID))
+
+	if filter.AgentID != 0 {
+		agent, err := c.agentService.GetAgentByID(ctx, filter.AgentID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if agent.UserID != userID {
+			return nil, ErrUnauthorized
+		}
+		query = query.Where("clients.agent_id = ?", filter.AgentID)
+	}
+	switch filter.Archived {
+	case "":
+		query = query.Where("clients.archived_at IS NULL")
+	case ArchivedOnly:
+		query = query.Where("clients.archived_at IS NOT NULL")
+	case ArchivedInclude:
+	default:
+		return nil, ErrInvalidArchivedFilter
+	}
+	if filter.Name != "" {
+		query = query.Where("LOWER(clients.name) LIKE ?", "%"+strings.ToLower(filter.Name)+"%")
+	}
+	if filter.Contact != "" {
+		query = query.Where(
+			"EXISTS (SELECT 1 FROM client_contacts WHERE client_contacts.client_id = clients.id AND LOWER(client_contacts.value) LIKE ?)",
+			"%"+strings.ToLower(filter.Contact)+"%",
+		)
+	}
+	for _, tag := range filter.Tags {
+		query = query.Where(
+			"EXISTS (SELECT 1 FROM client_tags WHERE client_tags.client_id = clients.id AND client_tags.tag = ?)",
+			normalizeTag(tag),
+		)
+	}
+	if filter.SegmentID != 0 {
+		segment, err := findSegment(db, filter.SegmentID, userID)
+		if err != nil {
+			return nil, err
+		}
+		members, err := segmentExpression(db, segment, userID, c.now())
+		if err != nil {
+			return nil, err
+		}
+		if members != nil {
+			query = query.Where(members)
+		}
+	}
+	for key, raw := range filter.Fields {
+		field, err := findCustomField(db, key, userID)
+		if err != nil {
+			return nil, err
+		}
+		value, err := normalizeFieldValue(field, raw)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where(
+			"EXISTS (SELECT 1 FROM client_field_values WHERE client_field_values.client_id = clients.id AND client_field_values.field_id = ? AND client_field_values.value = ?)",
+			field.ID, value,
+		)
+	}
+
+	var clients []*models.Client
+	if err := query.Order("clients.id").Find(&clients).Error; err != nil {
+		return nil, err
+	}
+	return clients, nil
+}
+
 func (c *clientServiceImpl) CreateClient(ctx context.Context, client *models.Client, agentID uint, userID uint) (*models.Client, error) {
 	if client.Name == "" {
 		return nil, ErrClientNameRequired
@@ -113,9 +224,19 @@ func (c *clientServiceImpl) CreateClient(ctx context.Context, client *models.Cli
 
 	client.AgentID = agentID
 
-	err = c.db.WithContext(ctx).
-		Create(client).
-		Error
+	err = c.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(client).Error; err != nil {
+			return err
+		}
+		assignment := &models.ClientAssignment{ClientID: client.ID, ToAgentID: agentID, ActorID: userID}
+		if err := tx.Create(assignment).Error; err != nil {
+			return err
+		}
+		if err := recordEvent(tx, userID, EventClientCreated, client.ID, client); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, userID, models.AuditActionCreate, AuditEntityClient, client.ID, nil, client)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -139,10 +260,30 @@ func (c *clientServiceImpl) UpdateClient(ctx context.Context, client *models.Cli
 		return nil, ErrUnauthorized
 	}
 
-	err = c.db.WithContext(ctx).
-		Model(existingClient).
-		Updates(client).
-		Error
+	// Only these columns are editable here; the agent changes through
+	// TransferClient so that the move is checked and recorded.
+	updates := map[string]interface{}{
+		"name":       client.Name,
+		"start_date": client.StartDate,
+	}
+
+	before := *existingClient
+	err = c.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Client{}).
+			Where("id = ?", existingClient.ID).
+			Updates(updates).
+			Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Preload("Agent").First(existingClient, existingClient.ID).Error; err != nil {
+			return err
+		}
+		if err := recordEvent(tx, userID, EventClientUpdated, existingClient.ID, existingClient); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, userID, models.AuditActionUpdate, AuditEntityClient, existingClient.ID, &before, existingClient)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -165,12 +306,214 @@ func (c *clientServiceImpl) DeleteClient(ctx context.Context, id uint, userID ui
 		return ErrUnauthorized
 	}
 
-	err = c.db.WithContext(ctx).
-		Delete(existingClient).
-		Error
+	err = c.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := softDeleteClientTree(tx, existingClient, c.now()); err != nil {
+			return err
+		}
+		if err := recordEvent(tx, userID, EventClientDeleted, existingClient.ID, existingClient); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, userID, models.AuditActionDelete, AuditEntityClient, existingClient.ID, existingClient, nil)
+	})
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+func (c *clientServiceImpl) GetDeletedClients(ctx context.Context, userID uint) ([]*models.Client, error) {
+	var clients []*models.Client
+	err := trashedForUser(c.db.WithContext(ctx), "clients", userID).
+		Order("deleted_at desc").
+		Find(&clients).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return clients, nil
+}
+
+// RestoreClient undeletes a client together with the messages and
+// transactions that were deleted with it. The client's agent must be live.
+func (c *clientServiceImpl) RestoreClient(ctx context.Context, id uint, userID uint) (*models.Client, error) {
+	deletedClient, err := c.findDeletedClient(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	parentDeleted, err := agentIsDeleted(c.db.WithContext(ctx), deletedClient.AgentID)
+	if err != nil {
+		return nil, err
+	}
+	if parentDeleted {
+		return nil, ErrParentDeleted
+	}
+
+	err = c.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := restoreClientTree(tx, deletedClient); err != nil {
+			return err
+		}
+		if err := recordEvent(tx, userID, EventClientRestored, deletedClient.ID, deletedClient); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, userID, models.AuditActionRestore, AuditEntityClient, deletedClient.ID, nil, deletedClient)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return deletedClient, nil
+}
+
+// PurgeClient permanently removes a soft-deleted client and all of its data.
+func (c *clientServiceImpl) PurgeClient(ctx context.Context, id uint, userID uint) error {
+	deletedClient, err := c.findDeletedClient(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+
+	return c.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := purgeClientTree(tx, deletedClient.ID); err != nil {
+			return err
+		}
+		if err := recordEvent(tx, userID, EventClientPurged, deletedClient.ID, deletedClient); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, userID, models.AuditActionPurge, AuditEntityClient, deletedClient.ID, deletedClient, nil)
+	})
+}
+
+func (c *clientServiceImpl) findDeletedClient(ctx context.Context, id uint, userID uint) (*models.Client, error) {
+	var client models.Client
+	err := c.db.WithContext(ctx).
+		Unscoped().
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		First(&client).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrClientNotFound
+		}
+		return nil, err
+	}
+
+	if err := agentOwnedBy(c.db.WithContext(ctx), client.AgentID, userID); err != nil {
+		return nil, err
+	}
+
+	return &client, nil
+}
+
+// TransferClient assigns the client to another of the user's agents and
+// records the move in the client's assignment history. GetClientByID checks
+// the current agent, so the user must own both. The client's messages and
+// transactions stay with the agent that handled them.
+func (c *clientServiceImpl) TransferClient(ctx context.Context, id uint, agentID uint, reason string, userID uint) (*models.Client, error) {
+	existingClient, err := c.GetClientByID(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	agent, err := c.agentService.GetAgentByID(ctx, agentID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if agent.UserID != userID {
+		return nil, ErrUnauthorized
+	}
+	if existingClient.AgentID == agent.ID {
+		return existingClient, nil
+	}
+
+	before := *existingClient
+	err = c.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Not tx.Model(existingClient): the preloaded Agent would write the
+		// old agent_id back.
+		err := tx.Model(&models.Client{}).
+			Where("id = ?", existingClient.ID).
+			Update("agent_id", agent.ID).
+			Error
+		if err != nil {
+			return err
+		}
+		fromAgentID := existingClient.AgentID
+		assignment := &models.ClientAssignment{
+			ClientID:    existingClient.ID,
+			FromAgentID: &fromAgentID,
+			ToAgentID:   agent.ID,
+			ActorID:     userID,
+			Reason:      reason,
+		}
+		if err := tx.Create(assignment).Error; err != nil {
+			return err
+		}
+		existingClient.AgentID = agent.ID
+		existingClient.Agent = *agent
+		if err := recordEvent(tx, userID, EventClientTransferred, existingClient.ID, assignment); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, userID, models.AuditActionUpdate, AuditEntityClient, existingClient.ID, &before, existingClient)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return existingClient, nil
+}
+
+// GetAssignments returns the client's assignment history, oldest first.
+func (c *clientServiceImpl) GetAssignments(ctx context.Context, id uint, userID uint) ([]*models.ClientAssignment, error) {
+	if _, err := c.GetClientByID(ctx, id, userID); err != nil {
+		return nil, err
+	}
+
+	var assignments []*models.ClientAssignment
+	err := c.db.WithContext(ctx).
+		Where("client_id = ?", id).
+		Order("created_at asc, id asc").
+		Find(&assignments).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return assignments, nil
+}
+
+// ArchiveClient hides a client from client lists without deleting it.
+func (c *clientServiceImpl) ArchiveClient(ctx context.Context, id uint, userID uint) (*models.Client, error) {
+	now := c.now()
+	return c.setArchivedAt(ctx, id, &now, userID)
+}
+
+func (c *clientServiceImpl) UnarchiveClient(ctx context.Context, id uint, userID uint) (*models.Client, error) {
+	return c.setArchivedAt(ctx, id, nil, userID)
+}
+
+func (c *clientServiceImpl) setArchivedAt(ctx context.Context, id uint, archivedAt *time.Time, userID uint) (*models.Client, error) {
+	existingClient, err := c.GetClientByID(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if (existingClient.ArchivedAt == nil) == (archivedAt == nil) {
+		return existingClient, nil
+	}
+
+	before := *existingClient
+	err = c.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(existingClient).Update("archived_at", archivedAt).Error; err != nil {
+			return err
+		}
+		if err := recordEvent(tx, userID, EventClientUpdated, existingClient.ID, existingClient); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, userID, models.AuditActionUpdate, AuditEntityClient, existingClient.ID, &before, existingClient)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return existingClient, nil
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClients_TransferKeepsHistory(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	userService := NewUserService(db)
+	owner := &models.User{Username: "owner", Email: "owner@mail.com", Password: "x"}
+	require.NoError(t, userService.CreateUser(ctx, owner))
+	other := &models.User{Username: "other", Email: "other@mail.com", Password: "x"}
+	require.NoError(t, userService.CreateUser(ctx, other))
+
+	agentService := NewAgentService(db, time.Now)
+	clientService := NewClientService(db, agentService, time.Now)
+	messageService := NewMessageService(db, agentService, clientService, time.Now)
+	transactionService := NewTransactionService(db, agentService, clientService, time.Now)
+
+	first, err := agentService.CreateAgent(ctx, &models.Agent{Name: "first", Characteristics: "c"}, owner.ID)
+	require.NoError(t, err)
+	second, err := agentService.CreateAgent(ctx, &models.Agent{Name: "second", Characteristics: "c"}, owner.ID)
+	require.NoError(t, err)
+	foreign, err := agentService.CreateAgent(ctx, &models.Agent{Name: "foreign", Characteristics: "c"}, other.ID)
+	require.NoError(t, err)
+
+	client, err := clientService.CreateClient(ctx, &models.Client{Name: "client", AgentID: first.ID}, first.ID, owner.ID)
+	require.NoError(t, err)
+	_, err = messageService.CreateMessage(ctx, &models.Message{AgentID: first.ID, ClientID: client.ID, Content: "hi", Type: models.MessageTypeAgentToClient}, owner.ID)
+	require.NoError(t, err)
+	_, err = transactionService.CreateTransaction(ctx, &models.Transaction{AgentID: first.ID, ClientID: client.ID, AmountMinor: 500, Currency: "USD"}, owner.ID)
+	require.NoError(t, err)
+
+	// Updating the client never moves it, whatever the model carries.
+	updated, err := clientService.UpdateClient(ctx, &models.Client{Model: client.Model, AgentID: second.ID, Name: "renamed"}, owner.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "renamed", updated.Name)
+	assert.Equal(t, first.ID, updated.AgentID)
+
+	_, err = clientService.TransferClient(ctx, client.ID, foreign.ID, "", owner.ID)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+	_, err = clientService.TransferClient(ctx, client.ID, second.ID, "", other.ID)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	transferred, err := clientService.TransferClient(ctx, client.ID, second.ID, "holiday cover", owner.ID)
+	require.NoError(t, err)
+	assert.Equal(t, second.ID, transferred.AgentID)
+
+	assignments, err := clientService.GetAssignments(ctx, client.ID, owner.ID)
+	require.NoError(t, err)
+	require.Len(t, assignments, 2)
+	assert.Nil(t, assignments[0].FromAgentID)
+	assert.Equal(t, first.ID, assignments[0].ToAgentID)
+	require.NotNil(t, assignments[1].FromAgentID)
+	assert.Equal(t, first.ID, *assignments[1].FromAgentID)
+	assert.Equal(t, second.ID, assignments[1].ToAgentID)
+	assert.Equal(t, "holiday cover", assignments[1].Reason)
+
+	// New transactions go through the new agent only.
+	_, err = transactionService.CreateTransaction(ctx, &models.Transaction{AgentID: first.ID, ClientID: client.ID, AmountMinor: 100, Currency: "USD"}, owner.ID)
+	assert.ErrorIs(t, err, ErrClientAgentMismatch)
+	_, err = transactionService.CreateTransaction(ctx, &models.Transaction{AgentID: second.ID, ClientID: client.ID, AmountMinor: 100, Currency: "USD"}, owner.ID)
+	require.NoError(t, err)
+
+	// The previous agent's transactions with the client stay listable.
+	previous, err := transactionService.GetTransactionsByAgentIDAndClientID(ctx, first.ID, client.ID, owner.ID)
+	require.NoError(t, err)
+	require.Len(t, previous, 1)
+	assert.Equal(t, int64(500), previous[0].AmountMinor)
+
+	// Deleting the previous agent leaves the transferred client's history.
+	require.NoError(t, agentService.DeleteAgent(ctx, first.ID, owner.ID))
+
+	messages, err := messageService.GetMessageByClientID(ctx, client.ID, owner.ID)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, "first", messages[0].Agent.Name)
+
+	transactions, err := transactionService.GetTransactionsByClientID(ctx, client.ID, owner.ID)
+	require.NoError(t, err)
+	require.Len(t, transactions, 2)
+	assert.ElementsMatch(t, []uint{first.ID, second.ID}, []uint{transactions[0].AgentID, transactions[1].AgentID})
+
+	require.NoError(t, agentService.PurgeAgent(ctx, first.ID, owner.ID))
+	messages, err = messageService.GetMessageByClientID(ctx, client.ID, owner.ID)
+	require.NoError(t, err)
+	assert.Len(t, messages, 1)
+}
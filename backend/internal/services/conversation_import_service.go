@@ -0,0 +1,252 @@
+package services
+
+import (
+	"backend/internal/chatimport"
+	"backend/internal/models"
+	"backend/pkg/database"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ConversationImport describes a chat export to import into one client's
+// conversation. Format may be left empty when Filename tells it apart;
+// AgentID zero means the client's current agent. AgentSender and ClientSender
+// are the names the two sides appear under in the export.
+type ConversationImport struct {
+	File         io.Reader
+	Filename     string
+	Format       string
+	ClientID     uint
+	AgentID      uint
+	AgentSender  string
+	ClientSender string
+	// Timezone is the IANA name used for timestamps the export stores
+	// without one. Defaults to UTC.
+	Timezone string
+	// DryRun parses and matches the export without storing anything.
+	DryRun bool
+}
+
+type ConversationImportReport struct {
+	Format       string               `json:"format"`
+	DryRun       bool                 `json:"dry_run"`
+	Participants []string             `json:"participants"`
+	Parsed       int                  `json:"parsed"`
+	Imported     int                  `json:"imported"`
+	Duplicates   int                  `json:"duplicates"`
+	Skipped      []chatimport.Skipped `json:"skipped"`
+}
+
+type ConversationImportService interface {
+	ImportConversation(ctx context.Context, conversation ConversationImport, userID uint) (*ConversationImportReport, error)
+}
+
+type conversationImportServiceImpl struct {
+	db            *database.DB
+	agentService  AgentService
+	clientService ClientService
+	now           func() time.Time
+}
+
+func NewConversationImportService(db *database.DB, agentService AgentService, clientService ClientService, now func() time.Time) ConversationImportService {
+	return &conversationImportServiceImpl{
+		db:            db,
+		agentService:  agentService,
+		clientService: clientService,
+		now:           now,
+	}
+}
+
+var (
+	ErrImportFileRequired        = NewError(http.StatusBadRequest, "import_file_required", "import file is required")
+	ErrImportFileTooLarge        = NewError(http.StatusRequestEntityTooLarge, "import_file_too_large", "import file is too large")
+	ErrInvalidImportFormat       = NewError(http.StatusBadRequest, "invalid_import_format", "format must be whatsapp, telegram or csv")
+	ErrInvalidImportFile         = NewError(http.StatusBadRequest, "invalid_import_file", "the file is not a valid chat export")
+	ErrInvalidImportTimezone     = NewError(http.StatusBadRequest, "invalid_timezone", "timezone is not a known IANA time zone")
+	ErrImportAgentSenderRequired = NewError(http.StatusBadRequest, "agent_sender_required", "agent_sender must name the participant who is the agent")
+	ErrImportUnknownSender       = NewError(http.StatusBadRequest, "unknown_sender", "sender is not a participant of the conversation")
+	ErrInvalidDryRun             = NewError(http.StatusBadRequest, "invalid_dry_run", "dry_run must be true or false")
+)
+
+// importReferenceBatch keeps the IN lists of the duplicate lookup well under
+// SQLite's variable limit.
+const importReferenceBatch = 500
+
+// ImportConversation parses a chat export and stores its messages in the
+// client's conversation. Messages from AgentSender become AGENT_TO_CLIENT and
+// the rest CLIENT_TO_AGENT; when ClientSender is given, messages from anyone
+// else are skipped instead. Messages imported before, including ones deleted
+// since, are counted as duplicates and left alone. All messages are stored in
+// one transaction.
+func (s *conversationImportServiceImpl) ImportConversation(ctx context.Context, conversation ConversationImport, userID uint) (*ConversationImportReport, error) {
+	if conversation.File == nil {
+		return nil, ErrImportFileRequired
+	}
+
+	format := strings.ToLower(strings.TrimSpace(conversation.Format))
+	if format == "" {
+		detected, err := chatimport.DetectFormat(conversation.Filename)
+		if err != nil {
+			return nil, ErrInvalidImportFormat
+		}
+		format = detected
+	}
+
+	location := time.UTC
+	if conversation.Timezone != "" {
+		loaded, err := time.LoadLocation(conversation.Timezone)
+		if err != nil {
+			return nil, ErrInvalidImportTimezone
+		}
+		location = loaded
+	}
+
+	client, err := s.clientService.GetClientByID(ctx, conversation.ClientID, userID)
+	if err != nil {
+		return nil, err
+	}
+	agentID := conversation.AgentID
+	if agentID == 0 {
+		agentID = client.AgentID
+	}
+	if _, err := s.agentService.GetAgentByID(ctx, agentID, userID); err != nil {
+		return nil, err
+	}
+
+	transcript, err := chatimport.Parse(format, conversation.File, location)
+	if err != nil {
+		if errors.Is(err, chatimport.ErrUnknownFormat) {
+			return nil, ErrInvalidImportFormat
+		}
+		invalid := *ErrInvalidImportFile
+		invalid.Fields = []FieldError{{Field: "file", Message: err.Error()}}
+		return nil, &invalid
+	}
+
+	report := &ConversationImportReport{
+		Format:       format,
+		DryRun:       conversation.DryRun,
+		Participants: transcript.Participants(),
+		Parsed:       len(transcript.Messages),
+		Skipped:      transcript.Skipped,
+	}
+
+	agentSender, err := matchParticipant(report.Participants, conversation.AgentSender, "agent_sender")
+	if err != nil {
+		return nil, err
+	}
+	clientSender, err := matchParticipant(report.Participants, conversation.ClientSender, "client_sender")
+	if err != nil {
+		return nil, err
+	}
+	// A dry run without senders is how the participants are found out.
+	if agentSender == "" && !conversation.DryRun {
+		return nil, ErrImportAgentSenderRequired
+	}
+
+	existing, err := s.existingReferences(ctx, client.ID, transcript.Messages)
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []*models.Message
+	for _, parsed := range transcript.Messages {
+		messageType := models.MessageTypeClientToAgent
+		switch {
+		case parsed.Sender == agentSender:
+			messageType = models.MessageTypeAgentToClient
+		case clientSender != "" && parsed.Sender != clientSender:
+			report.Skipped = append(report.Skipped, chatimport.Skipped{Line: parsed.Line, Text: parsed.Sender, Reason: "unknown participant"})
+			continue
+		}
+		if existing[parsed.ExternalReference] {
+			report.Duplicates++
+			continue
+		}
+		existing[parsed.ExternalReference] = true
+
+		messages = append(messages, &models.Message{
+			AgentID:           agentID,
+			ClientID:          client.ID,
+			Date:              parsed.Date,
+			Content:           parsed.Content,
+			Type:              messageType,
+			ExternalReference: parsed.ExternalReference,
+		})
+	}
+	report.Imported = len(messages)
+	if report.Skipped == nil {
+		report.Skipped = []chatimport.Skipped{}
+	}
+
+	if conversation.DryRun || len(messages) == 0 {
+		return report, nil
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		scoped := &database.DB{DB: tx}
+		agentService := NewAgentService(scoped, s.now)
+		messageService := NewMessageService(scoped, agentService, NewClientService(scoped, agentService, s.now), s.now)
+		for _, message := range messages {
+			if _, err := messageService.CreateMessage(ctx, message, userID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// existingReferences returns the references of the messages already stored
+// for the client, soft-deleted ones included so an import does not bring
+// back what was deleted.
+func (s *conversationImportServiceImpl) existingReferences(ctx context.Context, clientID uint, messages []chatimport.Message) (map[string]bool, error) {
+	existing := map[string]bool{}
+	for start := 0; start < len(messages); start += importReferenceBatch {
+		batch := messages[start:min(start+importReferenceBatch, len(messages))]
+		references := make([]string, 0, len(batch))
+		for _, message := range batch {
+			references = append(references, message.ExternalReference)
+		}
+
+		var stored []string
+		err := s.db.WithContext(ctx).
+			Unscoped().
+			Model(&models.Message{}).
+			Where("client_id = ? AND external_reference IN ?", clientID, references).
+			Pluck("external_reference", &stored).
+			Error
+		if err != nil {
+			return nil, err
+		}
+		for _, reference := range stored {
+			existing[reference] = true
+		}
+	}
+	return existing, nil
+}
+
+// matchParticipant finds the participant a sender name refers to, ignoring
+// case and surrounding spaces. An empty name matches nobody.
+func matchParticipant(participants []string, name, field string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", nil
+	}
+	for _, participant := range participants {
+		if strings.EqualFold(participant, name) {
+			return participant, nil
+		}
+	}
+	return "", ErrImportUnknownSender.WithField(field)
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConversationImport_DeduplicatesReimports(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	userService := NewUserService(db)
+	owner := &models.User{Username: "owner", Email: "owner@mail.com", Password: "x"}
+	require.NoError(t, userService.CreateUser(ctx, owner))
+	other := &models.User{Username: "other", Email: "other@mail.com", Password: "x"}
+	require.NoError(t, userService.CreateUser(ctx, other))
+
+	agentService := NewAgentService(db, time.Now)
+	clientService := NewClientService(db, agentService, time.Now)
+	messageService := NewMessageService(db, agentService, clientService, time.Now)
+	importService := NewConversationImportService(db, agentService, clientService, time.Now)
+
+	agent, err := agentService.CreateAgent(ctx, &models.Agent{Name: "a", Characteristics: "c"}, owner.ID)
+	require.NoError(t, err)
+	client, err := clientService.CreateClient(ctx, &models.Client{Name: "client", AgentID: agent.ID}, agent.ID, owner.ID)
+	require.NoError(t, err)
+
+	export := "12/01/2024, 14:32 - Daniel: hello\n" +
+		"12/01/2024, 14:33 - Nova: hi Daniel\n" +
+		"12/01/2024, 14:34 - Mallory: spam\n" +
+		"garbage before anything?\n"
+	conversation := func() ConversationImport {
+		return ConversationImport{
+			File:         strings.NewReader(export),
+			Filename:     "WhatsApp Chat with Daniel.txt",
+			ClientID:     client.ID,
+			AgentSender:  "nova",
+			ClientSender: "Daniel",
+			Timezone:     "Europe/Madrid",
+		}
+	}
+
+	dryRun := conversation()
+	dryRun.DryRun = true
+	dryRun.AgentSender, dryRun.ClientSender = "", ""
+	report, err := importService.ImportConversation(ctx, dryRun, owner.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Daniel", "Nova", "Mallory"}, report.Participants)
+	assert.Equal(t, 3, report.Imported)
+
+	report, err = importService.ImportConversation(ctx, conversation(), owner.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "whatsapp", report.Format)
+	assert.Equal(t, 3, report.Parsed)
+	assert.Equal(t, 2, report.Imported)
+	assert.Equal(t, 0, report.Duplicates)
+	require.Len(t, report.Skipped, 1)
+	assert.Equal(t, "unknown participant", report.Skipped[0].Reason)
+
+	messages, err := messageService.GetMessageByClientID(ctx, client.ID, owner.ID)
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	assert.Equal(t, models.MessageTypeClientToAgent, messages[0].Type)
+	assert.Equal(t, models.MessageTypeAgentToClient, messages[1].Type)
+	assert.Equal(t, time.Date(2024, time.January, 12, 13, 32, 0, 0, time.UTC), messages[0].Date.UTC())
+
+	// A deleted message stays deleted on re-import.
+	require.NoError(t, messageService.DeleteMessage(ctx, messages[0].ID, owner.ID))
+	report, err = importService.ImportConversation(ctx, conversation(), owner.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, report.Imported)
+	assert.Equal(t, 2, report.Duplicates)
+
+	noAgent := conversation()
+	noAgent.AgentSender = ""
+	_, err = importService.ImportConversation(ctx, noAgent, owner.ID)
+	assert.ErrorIs(t, err, ErrImportAgentSenderRequired)
+
+	stranger := conversation()
+	stranger.AgentSender = "Eve"
+	_, err = importService.ImportConversation(ctx, stranger, owner.ID)
+	assert.ErrorIs(t, err, ErrImportUnknownSender)
+
+	_, err = importService.ImportConversation(ctx, conversation(), other.ID)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+}
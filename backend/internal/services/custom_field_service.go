@@ -0,0 +1,242 @@
+package services
+
+import (
+	"backend/internal/models"
+	"backend/pkg/database"
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CustomFieldService manages the custom fields a user defines for their
+// clients. A field's key and type are fixed once created, because stored
+// values are only valid for the type they were written with.
+type CustomFieldService interface {
+	GetFields(ctx context.Context, userID uint) ([]*models.CustomField, error)
+	GetFieldByID(ctx context.Context, id uint, userID uint) (*models.CustomField, error)
+	GetFieldByKey(ctx context.Context, key string, userID uint) (*models.CustomField, error)
+	CreateField(ctx context.Context, field *models.CustomField, userID uint) (*models.CustomField, error)
+	UpdateField(ctx context.Context, field *models.CustomField, userID uint) (*models.CustomField, error)
+	DeleteField(ctx context.Context, id uint, userID uint) error
+}
+
+type customFieldServiceImpl struct {
+	db *database.DB
+}
+
+func NewCustomFieldService(db *database.DB) CustomFieldService {
+	return &customFieldServiceImpl{db: db}
+}
+
+var (
+	ErrCustomFieldNotFound        = NewError(http.StatusNotFound, "custom_field_not_found", "custom field not found")
+	ErrInvalidCustomFieldID       = NewError(http.StatusBadRequest, "invalid_custom_field_id", "custom field ID is invalid")
+	ErrCustomFieldKeyTaken        = NewError(http.StatusConflict, "custom_field_key_taken", "a custom field with this key already exists")
+	ErrInvalidCustomFieldKey      = NewError(http.StatusBadRequest, "invalid_custom_field_key", "key must start with a letter and contain only lower-case letters, digits and underscores")
+	ErrCustomFieldLabelRequired   = NewError(http.StatusBadRequest, "custom_field_label_required", "custom field label is required")
+	ErrInvalidCustomFieldType     = NewError(http.StatusBadRequest, "invalid_custom_field_type", "custom field type must be TEXT, NUMBER, DATE, BOOLEAN or SELECT")
+	ErrCustomFieldOptionsRequired = NewError(http.StatusBadRequest, "custom_field_options_required", "a SELECT field needs at least one option")
+	ErrCustomFieldTypeChanged     = NewError(http.StatusBadRequest, "custom_field_type_immutable", "the key and type of a custom field cannot be changed")
+	ErrUnknownCustomField         = NewError(http.StatusBadRequest, "unknown_custom_field", "no custom field with this key")
+	ErrInvalidCustomFieldValue    = NewError(http.StatusBadRequest, "invalid_custom_field_value", "value does not match the custom field type")
+)
+
+var customFieldKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+func (s *customFieldServiceImpl) GetFields(ctx context.Context, userID uint) ([]*models.CustomField, error) {
+	var fields []*models.CustomField
+	err := s.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("key").
+		Find(&fields).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+func (s *customFieldServiceImpl) GetFieldByID(ctx context.Context, id uint, userID uint) (*models.CustomField, error) {
+	var field models.CustomField
+	err := s.db.WithContext(ctx).Where("id = ?", id).First(&field).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCustomFieldNotFound
+		}
+		return nil, err
+	}
+	if field.UserID != userID {
+		return nil, ErrUnauthorized
+	}
+	return &field, nil
+}
+
+func (s *customFieldServiceImpl) GetFieldByKey(ctx context.Context, key string, userID uint) (*models.CustomField, error) {
+	return findCustomField(s.db.WithContext(ctx), key, userID)
+}
+
+func (s *customFieldServiceImpl) CreateField(ctx context.Context, field *models.CustomField, userID uint) (*models.CustomField, error) {
+	field.ID = 0
+	field.UserID = userID
+	if err := validateCustomField(field); err != nil {
+		return nil, err
+	}
+
+	_, err := findCustomField(s.db.WithContext(ctx), field.Key, userID)
+	if err == nil {
+		return nil, ErrCustomFieldKeyTaken
+	}
+	if !errors.Is(err, ErrUnknownCustomField) {
+		return nil, err
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(field).Error; err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, userID, models.AuditActionCreate, AuditEntityCustomField, field.ID, nil, field)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return field, nil
+}
+
+// UpdateField changes a field's label and, for SELECT fields, its options.
+// Values that are no longer among the options are kept; they fail
+// validation only when they are written again.
+func (s *customFieldServiceImpl) UpdateField(ctx context.Context, field *models.CustomField, userID uint) (*models.CustomField, error) {
+	existing, err := s.GetFieldByID(ctx, field.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if (field.Key != "" && field.Key != existing.Key) || (field.Type != "" && field.Type != existing.Type) {
+		return nil, ErrCustomFieldTypeChanged
+	}
+
+	before := *existing
+	if field.Label != "" {
+		existing.Label = field.Label
+	}
+	if field.Options != nil {
+		existing.Options = field.Options
+	}
+	if err := validateCustomField(existing); err != nil {
+		return nil, err
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(existing).Error; err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, userID, models.AuditActionUpdate, AuditEntityCustomField, existing.ID, &before, existing)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+// DeleteField removes the field and every client's value for it.
+func (s *customFieldServiceImpl) DeleteField(ctx context.Context, id uint, userID uint) error {
+	existing, err := s.GetFieldByID(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("field_id = ?", existing.ID).Delete(&models.ClientFieldValue{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(existing).Error; err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, userID, models.AuditActionDelete, AuditEntityCustomField, existing.ID, existing, nil)
+	})
+}
+
+func findCustomField(db *gorm.DB, key string, userID uint) (*models.CustomField, error) {
+	var field models.CustomField
+	err := db.Where("user_id = ? AND key = ?", userID, key).First(&field).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUnknownCustomField.WithField(key)
+		}
+		return nil, err
+	}
+	return &field, nil
+}
+
+func validateCustomField(field *models.CustomField) error {
+	field.Key = strings.TrimSpace(field.Key)
+	field.Label = strings.TrimSpace(field.Label)
+	if !customFieldKeyPattern.MatchString(field.Key) {
+		return ErrInvalidCustomFieldKey
+	}
+	if field.Label == "" {
+		return ErrCustomFieldLabelRequired
+	}
+
+	switch field.Type {
+	case models.CustomFieldTypeText, models.CustomFieldTypeNumber, models.CustomFieldTypeDate, models.CustomFieldTypeBoolean:
+		field.Options = nil
+	case models.CustomFieldTypeSelect:
+		options := make(models.StringList, 0, len(field.Options))
+		for _, option := range field.Options {
+			option = strings.TrimSpace(option)
+			if option != "" && !slices.Contains(options, option) {
+				options = append(options, option)
+			}
+		}
+		if len(options) == 0 {
+			return ErrCustomFieldOptionsRequired
+		}
+		field.Options = options
+	default:
+		return ErrInvalidCustomFieldType
+	}
+	return nil
+}
+
+// normalizeFieldValue checks raw against the field's type and returns the
+// canonical form that is stored and compared in filters.
+func normalizeFieldValue(field *models.CustomField, raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	invalid := ErrInvalidCustomFieldValue.WithField(field.Key)
+
+	switch field.Type {
+	case models.CustomFieldTypeText:
+		return raw, nil
+	case models.CustomFieldTypeNumber:
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return "", invalid
+		}
+		return strconv.FormatFloat(n, 'f', -1, 64), nil
+	case models.CustomFieldTypeDate:
+		date, err := time.Parse(time.DateOnly, raw)
+		if err != nil {
+			return "", invalid
+		}
+		return date.Format(time.DateOnly), nil
+	case models.CustomFieldTypeBoolean:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return "", invalid
+		}
+		return strconv.FormatBool(b), nil
+	case models.CustomFieldTypeSelect:
+		if !slices.Contains(field.Options, raw) {
+			return "", invalid
+		}
+		return raw, nil
+	}
+	return "", invalid
+}
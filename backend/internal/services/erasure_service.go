@@ -0,0 +1,186 @@
+package services
+
+import (
+	"backend/internal/models"
+	"backend/internal/requestctx"
+	"backend/pkg/database"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErasureService carries out requests to erase a client's personal data.
+type ErasureService interface {
+	// EraseClient irreversibly anonymizes a client, trashed or not. Message
+	// texts, references and refund reasons are blanked, contacts, tags,
+	// field values and notes deleted, and the snapshots in the audit log and
+	// the event outbox cleared. Dates, agents, amounts and statuses stay, so
+	// analytics keep counting the client. The erasure itself is recorded
+	// permanently.
+	EraseClient(ctx context.Context, id uint, reason string, userID uint) (*models.ClientErasure, error)
+	GetErasures(ctx context.Context, userID uint) ([]*models.ClientErasure, error)
+}
+
+type erasureServiceImpl struct {
+	db  *database.DB
+	now func() time.Time
+}
+
+func NewErasureService(db *database.DB, now func() time.Time) ErasureService {
+	return &erasureServiceImpl{db: db, now: now}
+}
+
+var (
+	ErrClientAlreadyErased = NewError(http.StatusConflict, "client_already_erased", "client has already been erased")
+)
+
+func (s *erasureServiceImpl) EraseClient(ctx context.Context, id uint, reason string, userID uint) (*models.ClientErasure, error) {
+	var client models.Client
+	err := s.db.WithContext(ctx).Unscoped().Where("id = ?", id).First(&client).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrClientNotFound
+		}
+		return nil, err
+	}
+	if err := agentOwnedBy(s.db.WithContext(ctx), client.AgentID, userID); err != nil {
+		return nil, err
+	}
+	if client.ErasedAt != nil {
+		return nil, ErrClientAlreadyErased
+	}
+
+	metadata := requestctx.FromContext(ctx)
+	erasure := &models.ClientErasure{
+		UserID:        userID,
+		ClientID:      client.ID,
+		RequestedByID: actorFromContext(ctx, userID),
+		Reason:        strings.TrimSpace(reason),
+		IP:            metadata.IP,
+		RequestID:     metadata.RequestID,
+	}
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := eraseClientRecords(tx, &client, userID, erasure); err != nil {
+			return err
+		}
+
+		erasedAt := s.now()
+		updates := map[string]interface{}{"name": models.ErasedClientName, "erased_at": erasedAt}
+		if client.ArchivedAt == nil {
+			updates["archived_at"] = erasedAt
+		}
+		if err := tx.Unscoped().Model(&client).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Create(erasure).Error; err != nil {
+			return err
+		}
+		if err := recordEvent(tx, userID, EventClientErased, client.ID, erasure); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, userID, models.AuditActionErase, AuditEntityClient, client.ID, nil, erasure)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return erasure, nil
+}
+
+// eraseClientRecords anonymizes everything linked to the client and counts
+// the messages, transactions and refunds on the erasure record.
+func eraseClientRecords(tx *gorm.DB, client *models.Client, userID uint, erasure *models.ClientErasure) error {
+	// The snapshots are cleared first; they are found through the records
+	// they describe.
+	err := clientAuditScope(tx, client.ID).
+		Updates(map[string]interface{}{"before": "", "after": "", "diff": ""}).
+		Error
+	if err != nil {
+		return err
+	}
+
+	messages := clientRecordIDs(tx, &models.Message{}, client.ID)
+	transactions := clientRecordIDs(tx, &models.Transaction{}, client.ID)
+	err = tx.Model(&models.OutboxEvent{}).
+		Where("(aggregate_type = ? AND aggregate_id = ?) OR (aggregate_type = ? AND aggregate_id IN (?)) OR (aggregate_type = ? AND aggregate_id IN (?))",
+			"client", client.ID, "message", messages, "transaction", transactions).
+		Update("payload", "{}").
+		Error
+	if err != nil {
+		return err
+	}
+
+	result := tx.Unscoped().
+		Model(&models.Refund{}).
+		Where("transaction_id IN (?)", transactions).
+		Updates(map[string]interface{}{"reason": "", "external_reference": ""})
+	if result.Error != nil {
+		return result.Error
+	}
+	erasure.Refunds = int(result.RowsAffected)
+
+	result = tx.Unscoped().
+		Model(&models.Transaction{}).
+		Where("client_id = ?", client.ID).
+		Update("external_reference", "")
+	if result.Error != nil {
+		return result.Error
+	}
+	erasure.Transactions = int(result.RowsAffected)
+
+	result = tx.Unscoped().
+		Model(&models.Message{}).
+		Where("client_id = ?", client.ID).
+		Updates(map[string]interface{}{"content": "", "external_reference": ""})
+	if result.Error != nil {
+		return result.Error
+	}
+	erasure.Messages = int(result.RowsAffected)
+
+	for _, model := range []interface{}{&models.ClientContact{}, &models.ClientTag{}, &models.ClientFieldValue{}, &models.ClientNote{}} {
+		if err := tx.Where("client_id = ?", client.ID).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	err = tx.Model(&models.ClientAssignment{}).
+		Where("client_id = ?", client.ID).
+		Update("reason", "").
+		Error
+	if err != nil {
+		return err
+	}
+
+	// Stored export files that may include the client are discarded.
+	handledBy := func(model interface{}) *gorm.DB {
+		return tx.Session(&gorm.Session{NewDB: true}).
+			Unscoped().
+			Model(model).
+			Select("agent_id").
+			Where("client_id = ?", client.ID)
+	}
+	return tx.Where("user_id = ?", userID).
+		Where("scope = ? OR (scope IN ? AND scope_id = ?) OR (scope = ? AND (scope_id IN (?) OR scope_id IN (?)))",
+			models.ExportScopeAccount,
+			[]string{models.ExportScopeClient, models.ExportScopePersonalData}, client.ID,
+			models.ExportScopeAgent, handledBy(&models.Message{}), handledBy(&models.Transaction{})).
+		Delete(&models.ExportJob{}).
+		Error
+}
+
+func (s *erasureServiceImpl) GetErasures(ctx context.Context, userID uint) ([]*models.ClientErasure, error) {
+	var erasures []*models.ClientErasure
+	err := s.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at desc, id desc").
+		Find(&erasures).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return erasures, nil
+}
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"backend/internal/config"
+	"backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPersonalDataExportAndErasure(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	cfg := &config.Config{ExportSyncLimit: 100, ExportRetention: time.Hour}
+
+	userService := NewUserService(db)
+	owner := &models.User{Username: "owner", Email: "owner@mail.com", Password: "x"}
+	require.NoError(t, userService.CreateUser(ctx, owner))
+	other := &models.User{Username: "other", Email: "other@mail.com", Password: "x"}
+	require.NoError(t, userService.CreateUser(ctx, other))
+
+	agentService := NewAgentService(db, time.Now)
+	clientService := NewClientService(db, agentService, time.Now)
+	profileService := NewClientProfileService(db, clientService, NewCustomFieldService(db))
+	messageService := NewMessageService(db, agentService, clientService, time.Now)
+	transactionService := NewTransactionService(db, agentService, clientService, time.Now)
+	analyticsService := NewAnalyticsService(db, agentService, clientService)
+	exportService := NewExportService(db, cfg, agentService, clientService, time.Now)
+	erasureService := NewErasureService(db, time.Now)
+
+	agent, err := agentService.CreateAgent(ctx, &models.Agent{Name: "agent", Characteristics: "c"}, owner.ID)
+	require.NoError(t, err)
+	client, err := clientService.CreateClient(ctx, &models.Client{Name: "Daniel Moreau", AgentID: agent.ID}, agent.ID, owner.ID)
+	require.NoError(t, err)
+	bystander, err := clientService.CreateClient(ctx, &models.Client{Name: "Bystander", AgentID: agent.ID}, agent.ID, owner.ID)
+	require.NoError(t, err)
+
+	_, err = profileService.CreateContact(ctx, &models.ClientContact{ClientID: client.ID, Kind: models.ContactKindEmail, Value: "daniel@mail.com"}, owner.ID)
+	require.NoError(t, err)
+	note, err := profileService.CreateNote(ctx, &models.ClientNote{ClientID: client.ID, Body: "lives in Lyon"}, owner.ID)
+	require.NoError(t, err)
+	require.NoError(t, profileService.DeleteNote(ctx, client.ID, note.ID, owner.ID))
+	_, err = profileService.AddTags(ctx, client.ID, []string{"vip"}, owner.ID)
+	require.NoError(t, err)
+	// Someone other than the owner, such as an admin or a previous owner,
+	// changed a note on the client.
+	require.NoError(t, recordAudit(ctx, db.DB, other.ID, models.AuditActionUpdate, AuditEntityNote, note.ID+100, nil, &models.ClientNote{ClientID: client.ID, Body: "moved to Marseille"}))
+
+	message, err := messageService.CreateMessage(ctx, &models.Message{AgentID: agent.ID, ClientID: client.ID, Content: "my address is 3 rue Neuve", Type: models.MessageTypeClientToAgent}, owner.ID)
+	require.NoError(t, err)
+	trashed, err := messageService.CreateMessage(ctx, &models.Message{AgentID: agent.ID, ClientID: client.ID, Content: "call me on 0612", Type: models.MessageTypeClientToAgent}, owner.ID)
+	require.NoError(t, err)
+	require.NoError(t, messageService.DeleteMessage(ctx, trashed.ID, owner.ID))
+	_, err = messageService.CreateMessage(ctx, &models.Message{AgentID: agent.ID, ClientID: bystander.ID, Content: "untouched", Type: models.MessageTypeClientToAgent}, owner.ID)
+	require.NoError(t, err)
+	transaction, err := transactionService.CreateTransaction(ctx, &models.Transaction{AgentID: agent.ID, ClientID: client.ID, AmountMinor: 2000, Currency: "USD", ExternalReference: "ch_daniel"}, owner.ID)
+	require.NoError(t, err)
+	_, err = transactionService.CreateRefund(ctx, &models.Refund{TransactionID: transaction.ID, AmountMinor: 500, Currency: "USD", Reason: "Daniel moved abroad"}, owner.ID)
+	require.NoError(t, err)
+
+	// The archive holds the profile, the history and the records.
+	job, err := exportService.SubmitPersonalData(ctx, client.ID, owner.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ExportJobStatusCompleted, job.Status)
+	assert.Equal(t, "application/zip", job.ContentType)
+	downloaded, err := exportService.Download(ctx, job.ID, owner.ID)
+	require.NoError(t, err)
+	archive, err := zip.NewReader(bytes.NewReader(downloaded.Content), downloaded.Size)
+	require.NoError(t, err)
+	files := map[string]string{}
+	for _, file := range archive.File {
+		r, err := file.Open()
+		require.NoError(t, err)
+		body, err := io.ReadAll(r)
+		require.NoError(t, err)
+		files[file.Name] = string(body)
+	}
+	assert.Contains(t, files["client.json"], "daniel@mail.com")
+	assert.Contains(t, files["history.json"], "lives in Lyon", "deleted notes are in the history")
+	assert.Contains(t, files["history.json"], "moved to Marseille", "entries by other actors are in the history")
+	assert.Contains(t, files["records.jsonl"], "call me on 0612")
+	assert.NotContains(t, files["records.jsonl"], "untouched")
+
+	_, err = exportService.SubmitPersonalData(ctx, client.ID, other.ID)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+	_, err = erasureService.EraseClient(ctx, client.ID, "", other.ID)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	before, err := analyticsService.GetAgentSummaries(ctx, AnalyticsFilter{}, owner.ID)
+	require.NoError(t, err)
+
+	erasure, err := erasureService.EraseClient(ctx, client.ID, " subject request ", owner.ID)
+	require.NoError(t, err)
+	assert.Equal(t, owner.ID, erasure.RequestedByID)
+	assert.Equal(t, "subject request", erasure.Reason)
+	assert.Equal(t, 2, erasure.Messages)
+	assert.Equal(t, 1, erasure.Transactions)
+	assert.Equal(t, 1, erasure.Refunds)
+
+	// Aggregates are unchanged.
+	after, err := analyticsService.GetAgentSummaries(ctx, AnalyticsFilter{}, owner.ID)
+	require.NoError(t, err)
+	assert.Equal(t, before, after)
+
+	erased, err := clientService.GetClientByID(ctx, client.ID, owner.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ErasedClientName, erased.Name)
+	assert.NotNil(t, erased.ErasedAt)
+	assert.NotNil(t, erased.ArchivedAt)
+
+	var messages []*models.Message
+	require.NoError(t, db.Unscoped().Where("client_id = ?", client.ID).Find(&messages).Error)
+	require.Len(t, messages, 2)
+	for _, m := range messages {
+		assert.Empty(t, m.Content)
+	}
+	kept, err := messageService.GetMessageByID(ctx, message.ID, owner.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.MessageTypeClientToAgent, kept.Type)
+
+	var refund models.Refund
+	require.NoError(t, db.Where("transaction_id = ?", transaction.ID).First(&refund).Error)
+	assert.Empty(t, refund.Reason)
+	assert.EqualValues(t, 500, refund.AmountMinor)
+	var storedTransaction models.Transaction
+	require.NoError(t, db.First(&storedTransaction, transaction.ID).Error)
+	assert.Empty(t, storedTransaction.ExternalReference)
+	assert.EqualValues(t, 2000, storedTransaction.AmountMinor)
+
+	contacts, err := profileService.GetContacts(ctx, client.ID, owner.ID)
+	require.NoError(t, err)
+	assert.Empty(t, contacts)
+	tags, err := profileService.GetTags(ctx, client.ID, owner.ID)
+	require.NoError(t, err)
+	assert.Empty(t, tags)
+
+	// Nothing personal is left in the audit log, the outbox or stored exports.
+	var entries []*models.AuditEntry
+	require.NoError(t, db.Find(&entries).Error)
+	for _, entry := range entries {
+		for _, state := range []string{entry.Before, entry.After, entry.Diff} {
+			for _, secret := range []string{"Daniel", "daniel@mail.com", "Lyon", "Marseille", "rue Neuve", "0612", "ch_daniel"} {
+				assert.NotContains(t, state, secret, "audit entry %d", entry.ID)
+			}
+		}
+	}
+	var events []*models.OutboxEvent
+	require.NoError(t, db.Find(&events).Error)
+	for _, event := range events {
+		assert.NotContains(t, event.Payload, "Daniel", "event %s", event.Type)
+	}
+	_, err = exportService.GetJob(ctx, job.ID, owner.ID)
+	assert.ErrorIs(t, err, ErrExportJobNotFound)
+
+	// The cleared snapshots do not break queries that read them.
+	_, err = NewTimelineService(db, clientService).GetTimeline(ctx, bystander.ID, TimelineQuery{}, owner.ID)
+	require.NoError(t, err)
+
+	var entry models.AuditEntry
+	require.NoError(t, db.Where("action = ?", models.AuditActionErase).First(&entry).Error)
+	var recorded models.ClientErasure
+	require.NoError(t, json.Unmarshal([]byte(entry.After), &recorded))
+	assert.Equal(t, erasure.ID, recorded.ID)
+
+	_, err = erasureService.EraseClient(ctx, client.ID, "", owner.ID)
+	assert.ErrorIs(t, err, ErrClientAlreadyErased)
+
+	// The record outlives the client.
+	require.NoError(t, clientService.DeleteClient(ctx, client.ID, owner.ID))
+	require.NoError(t, clientService.PurgeClient(ctx, client.ID, owner.ID))
+	erasures, err := erasureService.GetErasures(ctx, owner.ID)
+	require.NoError(t, err)
+	require.Len(t, erasures, 1)
+	assert.Equal(t, client.ID, erasures[0].ClientID)
+}
@@ -1,12 +1,96 @@
 package services
 
-import "github.com/gin-gonic/gin"
+import (
+	"net/http"
+	"time"
+)
 
-type ErrorResponse struct {
-	Error string `json:"error"`
+// Error is a domain error that knows how it is presented over HTTP: the
+// status code, a stable machine-readable code that clients can switch on, and
+// for validation failures the offending fields. Handlers pass these errors to
+// c.Error and the error middleware renders them.
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+	Fields  []FieldError
+	// Cause is the underlying error. It is logged but never sent to clients.
+	Cause error
+	// RetryAfter tells throttled clients when to try again.
+	RetryAfter time.Duration
+}
+
+// FieldError describes why one request field was rejected. Field uses the
+// JSON name of the field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func NewError(status int, code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	if e.Cause != nil {
+		return e.Message + ": " + e.Cause.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
+
+// Is matches errors by code, so an error carrying field details still
+// matches the sentinel it was derived from.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
 }
 
-func RespondError(c *gin.Context, statusCode int, err error) {
-	c.JSON(statusCode, ErrorResponse{Error: err.Error()})
-	c.Abort()
+// WithField returns a copy of e that names the field it applies to.
+func (e *Error) WithField(field string) *Error {
+	withField := *e
+	withField.Fields = append([]FieldError{{Field: field, Message: e.Message}}, e.Fields...)
+	return &withField
+}
+
+var (
+	ErrValidation    = NewError(http.StatusBadRequest, "validation_failed", "request validation failed")
+	ErrInvalidBody   = NewError(http.StatusBadRequest, "invalid_body", "request body is not valid JSON")
+	ErrInternal      = NewError(http.StatusInternalServerError, "internal_error", "internal server error")
+	ErrAuthRequired  = NewError(http.StatusUnauthorized, "authentication_required", "authentication required")
+	ErrInvalidToken  = NewError(http.StatusUnauthorized, "invalid_token", "invalid token")
+	ErrAdminRequired = NewError(http.StatusForbidden, "admin_required", "admin access required")
+	ErrRateLimited   = NewError(http.StatusTooManyRequests, "rate_limited", "too many requests")
+)
+
+// Wrap returns a copy of e caused by cause.
+func (e *Error) Wrap(cause error) *Error {
+	wrapped := *e
+	wrapped.Cause = cause
+	return &wrapped
+}
+
+// WithRetryAfter returns a copy of e that asks the client to wait for d.
+func (e *Error) WithRetryAfter(d time.Duration) *Error {
+	throttled := *e
+	throttled.RetryAfter = d
+	return &throttled
+}
+
+// NewValidationError returns a validation error listing the rejected fields.
+func NewValidationError(fields ...FieldError) *Error {
+	validationErr := *ErrValidation
+	validationErr.Fields = fields
+	return &validationErr
+}
+
+// ErrorResponse is the body of every error response.
+type ErrorResponse struct {
+	Error     string       `json:"error"`
+	Code      string       `json:"code"`
+	Fields    []FieldError `json:"fields,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
 }
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"backend/internal/models"
+	"encoding/json"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+const (
+	EventUserCreated = "user.created"
+	EventUserUpdated = "user.updated"
+	EventUserDeleted = "user.deleted"
+
+	EventAgentCreated  = "agent.created"
+	EventAgentUpdated  = "agent.updated"
+	EventAgentDeleted  = "agent.deleted"
+	EventAgentRestored = "agent.restored"
+	EventAgentPurged   = "agent.purged"
+
+	EventClientCreated  = "client.created"
+	EventClientUpdated  = "client.updated"
+	EventClientDeleted  = "client.deleted"
+	EventClientRestored = "client.restored"
+	EventClientPurged   = "client.purged"
+
+	EventClientTransferred = "client.transferred"
+	EventClientErased      = "client.erased"
+
+	EventMessageCreated  = "message.created"
+	EventMessageUpdated  = "message.updated"
+	EventMessageDeleted  = "message.deleted"
+	EventMessageRestored = "message.restored"
+	EventMessagePurged   = "message.purged"
+
+	EventTransactionCreated  = "transaction.created"
+	EventTransactionUpdated  = "transaction.updated"
+	EventTransactionDeleted  = "transaction.deleted"
+	EventTransactionRestored = "transaction.restored"
+	EventTransactionPurged   = "transaction.purged"
+
+	EventTransactionStatusChanged = "transaction.status_changed"
+	EventTransactionRefunded      = "transaction.refunded"
+)
+
+// recordEvent appends a domain event to the outbox using the given transaction,
+// so the event is only persisted if the surrounding mutation commits. It is
+// dated by the transaction's clock.
+func recordEvent(tx *gorm.DB, userID uint, eventType string, aggregateID uint, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	aggregateType, _, _ := strings.Cut(eventType, ".")
+
+	event := &models.OutboxEvent{
+		UserID:        userID,
+		Type:          eventType,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		Payload:       string(body),
+		OccurredAt:    tx.NowFunc(),
+	}
+
+	return tx.Create(event).Error
+}
@@ -0,0 +1,453 @@
+package services
+
+import (
+	"backend/internal/config"
+	"backend/internal/export"
+	"backend/internal/models"
+	"backend/pkg/database"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ExportRequest selects what to export: one client with ClientID, what one
+// agent handled with AgentID, or the whole account when both are zero.
+type ExportRequest struct {
+	Format   string
+	ClientID uint
+	AgentID  uint
+}
+
+// ExportService renders conversations and ledgers into downloadable files.
+// Small exports are rendered when submitted; larger ones are queued for the
+// ExportJobRunner.
+type ExportService interface {
+	Submit(ctx context.Context, request ExportRequest, userID uint) (*models.ExportJob, error)
+	// SubmitPersonalData exports everything stored about a client as a ZIP
+	// archive, for a data-subject access request.
+	SubmitPersonalData(ctx context.Context, clientID uint, userID uint) (*models.ExportJob, error)
+	GetJobs(ctx context.Context, userID uint) ([]*models.ExportJob, error)
+	GetJob(ctx context.Context, id uint, userID uint) (*models.ExportJob, error)
+	// Download returns a completed job with its file.
+	Download(ctx context.Context, id uint, userID uint) (*models.ExportJob, error)
+}
+
+type exportServiceImpl struct {
+	db            *database.DB
+	cfg           *config.Config
+	agentService  AgentService
+	clientService ClientService
+	now           func() time.Time
+}
+
+func NewExportService(db *database.DB, cfg *config.Config, agentService AgentService, clientService ClientService, now func() time.Time) ExportService {
+	return &exportServiceImpl{
+		db:            db,
+		cfg:           cfg,
+		agentService:  agentService,
+		clientService: clientService,
+		now:           now,
+	}
+}
+
+var (
+	ErrExportJobNotFound   = NewError(http.StatusNotFound, "export_not_found", "export not found")
+	ErrInvalidExportJobID  = NewError(http.StatusBadRequest, "invalid_export_id", "export ID is invalid")
+	ErrInvalidExportFormat = NewError(http.StatusBadRequest, "invalid_export_format", "format must be csv, jsonl or pdf")
+	ErrExportScopeConflict = NewError(http.StatusBadRequest, "export_scope_conflict", "give client_id or agent_id, not both")
+	ErrExportNotReady      = NewError(http.StatusConflict, "export_not_ready", "the export has not finished")
+	ErrExportExpired       = NewError(http.StatusGone, "export_expired", "the export has expired")
+)
+
+func (s *exportServiceImpl) Submit(ctx context.Context, request ExportRequest, userID uint) (*models.ExportJob, error) {
+	format := strings.ToLower(strings.TrimSpace(request.Format))
+	if !slices.Contains(export.Formats, format) {
+		return nil, ErrInvalidExportFormat
+	}
+
+	job := &models.ExportJob{
+		UserID: userID,
+		Scope:  models.ExportScopeAccount,
+		Format: format,
+		Status: models.ExportJobStatusPending,
+	}
+	switch {
+	case request.ClientID != 0 && request.AgentID != 0:
+		return nil, ErrExportScopeConflict
+	case request.ClientID != 0:
+		if _, err := s.clientService.GetClientByID(ctx, request.ClientID, userID); err != nil {
+			return nil, err
+		}
+		job.Scope, job.ScopeID = models.ExportScopeClient, request.ClientID
+	case request.AgentID != 0:
+		agent, err := s.agentService.GetAgentByID(ctx, request.AgentID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if agent.UserID != userID {
+			return nil, ErrUnauthorized
+		}
+		job.Scope, job.ScopeID = models.ExportScopeAgent, request.AgentID
+	}
+
+	return s.start(ctx, job)
+}
+
+func (s *exportServiceImpl) SubmitPersonalData(ctx context.Context, clientID uint, userID uint) (*models.ExportJob, error) {
+	if _, err := s.clientService.GetClientByID(ctx, clientID, userID); err != nil {
+		return nil, err
+	}
+
+	return s.start(ctx, &models.ExportJob{
+		UserID:  userID,
+		Scope:   models.ExportScopePersonalData,
+		ScopeID: clientID,
+		Format:  export.FormatZIP,
+		Status:  models.ExportJobStatusPending,
+	})
+}
+
+// start saves a new job and renders it right away unless it is too large.
+func (s *exportServiceImpl) start(ctx context.Context, job *models.ExportJob) (*models.ExportJob, error) {
+	records, err := countExportRecords(ctx, s.db, job)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.WithContext(ctx).Create(job).Error; err != nil {
+		return nil, err
+	}
+	if records > s.cfg.ExportSyncLimit {
+		return job, nil
+	}
+
+	started := s.now()
+	job.Status = models.ExportJobStatusRunning
+	job.StartedAt = &started
+	if err := renderExport(ctx, s.db, s.cfg, job, s.now); err != nil {
+		return nil, err
+	}
+	job.Content = nil
+	return job, nil
+}
+
+func (s *exportServiceImpl) GetJobs(ctx context.Context, userID uint) ([]*models.ExportJob, error) {
+	var jobs []*models.ExportJob
+	err := s.db.WithContext(ctx).
+		Omit("content").
+		Where("user_id = ?", userID).
+		Order("created_at desc, id desc").
+		Find(&jobs).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func (s *exportServiceImpl) GetJob(ctx context.Context, id uint, userID uint) (*models.ExportJob, error) {
+	return s.findJob(ctx, s.db.WithContext(ctx).Omit("content"), id, userID)
+}
+
+func (s *exportServiceImpl) Download(ctx context.Context, id uint, userID uint) (*models.ExportJob, error) {
+	job, err := s.findJob(ctx, s.db.WithContext(ctx), id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != models.ExportJobStatusCompleted {
+		return nil, ErrExportNotReady
+	}
+	if job.ExpiresAt != nil && !s.now().Before(*job.ExpiresAt) {
+		return nil, ErrExportExpired
+	}
+	return job, nil
+}
+
+func (s *exportServiceImpl) findJob(ctx context.Context, db *gorm.DB, id uint, userID uint) (*models.ExportJob, error) {
+	var job models.ExportJob
+	err := db.Where("id = ?", id).First(&job).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrExportJobNotFound
+		}
+		return nil, err
+	}
+	if job.UserID != userID {
+		return nil, ErrUnauthorized
+	}
+	return &job, nil
+}
+
+// exportRecords limits a query on messages or transactions to the job's
+// scope. An agent's export holds what that agent handled; the account's
+// includes what agents deleted since handled for clients that remain.
+func exportRecords(db *gorm.DB, job *models.ExportJob) *gorm.DB {
+	switch job.Scope {
+	case models.ExportScopeClient:
+		return db.Where("client_id = ?", job.ScopeID)
+	case models.ExportScopePersonalData:
+		// Records in the trash are still stored, so they are included.
+		return db.Unscoped().Where("client_id = ?", job.ScopeID)
+	case models.ExportScopeAgent:
+		return db.Where("agent_id = ?", job.ScopeID)
+	}
+	agents := db.Session(&gorm.Session{NewDB: true}).
+		Unscoped().
+		Model(&models.Agent{}).
+		Select("id").
+		Where("user_id = ?", job.UserID)
+	return db.Where("agent_id IN (?)", agents)
+}
+
+// countExportRecords counts the messages and transactions an export would
+// hold, which decides whether it runs in the background.
+func countExportRecords(ctx context.Context, db *database.DB, job *models.ExportJob) (int, error) {
+	var messages, transactions int64
+	if err := exportRecords(db.WithContext(ctx).Model(&models.Message{}), job).Count(&messages).Error; err != nil {
+		return 0, err
+	}
+	if err := exportRecords(db.WithContext(ctx).Model(&models.Transaction{}), job).Count(&transactions).Error; err != nil {
+		return 0, err
+	}
+	return int(messages + transactions), nil
+}
+
+// loadExportDocument gathers the job's records, grouped by client.
+func loadExportDocument(ctx context.Context, db *database.DB, job *models.ExportJob, now time.Time) (*export.Document, int, error) {
+	var messages []*models.Message
+	err := exportRecords(db.WithContext(ctx).Preload("Agent", withDeleted), job).
+		Order("date asc, id asc").
+		Find(&messages).
+		Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var transactions []*models.Transaction
+	err = exportRecords(db.WithContext(ctx).Preload("Agent", withDeleted).Preload("Refunds"), job).
+		Order("date asc, id asc").
+		Find(&transactions).
+		Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	conversations := map[uint]*export.Conversation{}
+	conversation := func(clientID uint) *export.Conversation {
+		if conversations[clientID] == nil {
+			conversations[clientID] = &export.Conversation{}
+		}
+		return conversations[clientID]
+	}
+	if job.Scope == models.ExportScopeClient || job.Scope == models.ExportScopePersonalData {
+		conversation(job.ScopeID)
+	}
+	records := 0
+	for _, message := range messages {
+		c := conversation(message.ClientID)
+		c.Messages = append(c.Messages, message)
+		records++
+	}
+	for _, transaction := range transactions {
+		c := conversation(transaction.ClientID)
+		c.Transactions = append(c.Transactions, transaction)
+		records += 1 + len(transaction.Refunds)
+	}
+
+	clientIDs := make([]uint, 0, len(conversations))
+	for id := range conversations {
+		clientIDs = append(clientIDs, id)
+	}
+	var clients []*models.Client
+	err = db.WithContext(ctx).
+		Unscoped().
+		Preload("Agent", withDeleted).
+		Where("id IN ?", clientIDs).
+		Order("id").
+		Find(&clients).
+		Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	doc := &export.Document{GeneratedAt: now}
+	for _, client := range clients {
+		c := conversations[client.ID]
+		c.Client = client
+		doc.Conversations = append(doc.Conversations, c)
+	}
+
+	switch job.Scope {
+	case models.ExportScopeClient, models.ExportScopePersonalData:
+		if len(clients) > 0 {
+			doc.Title = "Client " + clients[0].Name
+		}
+	case models.ExportScopeAgent:
+		var agent models.Agent
+		if err := db.WithContext(ctx).Unscoped().First(&agent, job.ScopeID).Error; err != nil {
+			return nil, 0, err
+		}
+		doc.Title = "Agent " + agent.Name
+	default:
+		var user models.User
+		if err := db.WithContext(ctx).First(&user, job.UserID).Error; err != nil {
+			return nil, 0, err
+		}
+		doc.Title = "Account " + user.Username
+	}
+	return doc, records, nil
+}
+
+// renderExport renders the job's file and saves the completed job.
+func renderExport(ctx context.Context, db *database.DB, cfg *config.Config, job *models.ExportJob, now func() time.Time) error {
+	doc, records, err := loadExportDocument(ctx, db, job, now())
+	if err != nil {
+		return err
+	}
+
+	var file bytes.Buffer
+	if job.Scope == models.ExportScopePersonalData {
+		data, err := loadPersonalData(ctx, db, job, doc)
+		if err != nil {
+			return err
+		}
+		if err := export.WriteArchive(&file, data); err != nil {
+			return err
+		}
+	} else if err := export.Write(&file, job.Format, doc); err != nil {
+		return err
+	}
+
+	finishedAt := now()
+	expiresAt := finishedAt.Add(cfg.ExportRetention)
+	name := strings.ReplaceAll(strings.ToLower(job.Scope), "_", "-")
+	if job.ScopeID != 0 {
+		name = fmt.Sprintf("%s-%d", name, job.ScopeID)
+	}
+	job.Status = models.ExportJobStatusCompleted
+	job.Records = records
+	job.Filename = name + "-" + finishedAt.UTC().Format("20060102T150405Z") + "." + job.Format
+	job.ContentType = export.ContentType(job.Format)
+	job.Content = file.Bytes()
+	job.Size = int64(file.Len())
+	job.FinishedAt = &finishedAt
+	job.ExpiresAt = &expiresAt
+	return db.WithContext(ctx).Save(job).Error
+}
+
+// ExportJobRunner renders queued exports in the background and deletes
+// expired files.
+type ExportJobRunner struct {
+	db  *database.DB
+	cfg *config.Config
+	now func() time.Time
+}
+
+func NewExportJobRunner(db *database.DB, cfg *config.Config, now func() time.Time) *ExportJobRunner {
+	return &ExportJobRunner{db: db, cfg: cfg, now: now}
+}
+
+// Run polls until ctx is cancelled. Jobs left running by a previous process
+// are started again.
+func (r *ExportJobRunner) Run(ctx context.Context) {
+	err := r.db.WithContext(ctx).
+		Model(&models.ExportJob{}).
+		Where("status = ?", models.ExportJobStatusRunning).
+		Update("status", models.ExportJobStatusPending).
+		Error
+	if err != nil {
+		slog.ErrorContext(ctx, "resuming export jobs failed", "error", err)
+	}
+
+	ticker := time.NewTicker(r.cfg.ExportPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.Tick(ctx); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "export job failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick deletes expired exports and renders every pending one.
+func (r *ExportJobRunner) Tick(ctx context.Context) error {
+	err := r.db.WithContext(ctx).
+		Where("expires_at < ?", r.now()).
+		Delete(&models.ExportJob{}).
+		Error
+	if err != nil {
+		return err
+	}
+
+	for {
+		job, err := r.claim(ctx)
+		if err != nil || job == nil {
+			return err
+		}
+		if err := r.process(ctx, job); err != nil {
+			return err
+		}
+	}
+}
+
+func (r *ExportJobRunner) claim(ctx context.Context) (*models.ExportJob, error) {
+	var job models.ExportJob
+	err := r.db.WithContext(ctx).
+		Where("status = ?", models.ExportJobStatusPending).
+		Order("id").
+		First(&job).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	started := r.now()
+	claimed := r.db.WithContext(ctx).
+		Model(&job).
+		Where("status = ?", models.ExportJobStatusPending).
+		Updates(map[string]interface{}{"status": models.ExportJobStatusRunning, "started_at": started})
+	if claimed.Error != nil {
+		return nil, claimed.Error
+	}
+	if claimed.RowsAffected == 0 {
+		// Another worker took it.
+		return r.claim(ctx)
+	}
+	job.Status = models.ExportJobStatusRunning
+	job.StartedAt = &started
+	return &job, nil
+}
+
+func (r *ExportJobRunner) process(ctx context.Context, job *models.ExportJob) error {
+	err := renderExport(ctx, r.db, r.cfg, job, r.now)
+	if err == nil || ctx.Err() != nil {
+		// When shutting down, the job is started again on the next run.
+		return err
+	}
+
+	finishedAt := r.now()
+	job.Status = models.ExportJobStatusFailed
+	job.Error = err.Error()
+	job.Content = nil
+	job.FinishedAt = &finishedAt
+	if saveErr := r.db.WithContext(ctx).Save(job).Error; saveErr != nil {
+		return saveErr
+	}
+	return err
+}
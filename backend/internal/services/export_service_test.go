@@ -0,0 +1,119 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/internal/config"
+	"backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExports_ScopesAndBackgroundJobs(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	cfg := &config.Config{ExportSyncLimit: 3, ExportRetention: time.Hour}
+	now := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	userService := NewUserService(db)
+	owner := &models.User{Username: "owner", Email: "owner@mail.com", Password: "x"}
+	require.NoError(t, userService.CreateUser(ctx, owner))
+	other := &models.User{Username: "other", Email: "other@mail.com", Password: "x"}
+	require.NoError(t, userService.CreateUser(ctx, other))
+
+	agentService := NewAgentService(db, time.Now)
+	clientService := NewClientService(db, agentService, time.Now)
+	messageService := NewMessageService(db, agentService, clientService, time.Now)
+	transactionService := NewTransactionService(db, agentService, clientService, time.Now)
+	exportService := NewExportService(db, cfg, agentService, clientService, clock)
+	runner := NewExportJobRunner(db, cfg, clock)
+
+	first, err := agentService.CreateAgent(ctx, &models.Agent{Name: "first", Characteristics: "c"}, owner.ID)
+	require.NoError(t, err)
+	second, err := agentService.CreateAgent(ctx, &models.Agent{Name: "second", Characteristics: "c"}, owner.ID)
+	require.NoError(t, err)
+	client, err := clientService.CreateClient(ctx, &models.Client{Name: "client", AgentID: first.ID}, first.ID, owner.ID)
+	require.NoError(t, err)
+	quiet, err := clientService.CreateClient(ctx, &models.Client{Name: "quiet", AgentID: second.ID}, second.ID, owner.ID)
+	require.NoError(t, err)
+	silent, err := clientService.CreateClient(ctx, &models.Client{Name: "silent", AgentID: second.ID}, second.ID, owner.ID)
+	require.NoError(t, err)
+
+	_, err = messageService.CreateMessage(ctx, &models.Message{AgentID: first.ID, ClientID: client.ID, Content: "hi", Type: models.MessageTypeClientToAgent}, owner.ID)
+	require.NoError(t, err)
+	_, err = transactionService.CreateTransaction(ctx, &models.Transaction{AgentID: first.ID, ClientID: client.ID, AmountMinor: 500, Currency: "USD"}, owner.ID)
+	require.NoError(t, err)
+	_, err = clientService.TransferClient(ctx, client.ID, second.ID, "", owner.ID)
+	require.NoError(t, err)
+	_, err = messageService.CreateMessage(ctx, &models.Message{AgentID: second.ID, ClientID: client.ID, Content: "welcome", Type: models.MessageTypeAgentToClient}, owner.ID)
+	require.NoError(t, err)
+	_, err = messageService.CreateMessage(ctx, &models.Message{AgentID: second.ID, ClientID: quiet.ID, Content: "ping", Type: models.MessageTypeAgentToClient}, owner.ID)
+	require.NoError(t, err)
+
+	// The client's export covers both agents.
+	job, err := exportService.Submit(ctx, ExportRequest{Format: "CSV", ClientID: client.ID}, owner.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ExportJobStatusCompleted, job.Status)
+	assert.Equal(t, 3, job.Records)
+	assert.Equal(t, "text/csv", job.ContentType)
+	assert.True(t, strings.HasPrefix(job.Filename, "client-"))
+
+	downloaded, err := exportService.Download(ctx, job.ID, owner.ID)
+	require.NoError(t, err)
+	rows, err := csv.NewReader(bytes.NewReader(downloaded.Content)).ReadAll()
+	require.NoError(t, err)
+	assert.Len(t, rows, 4)
+
+	// An agent's export holds only what that agent handled.
+	job, err = exportService.Submit(ctx, ExportRequest{Format: "jsonl", AgentID: first.ID}, owner.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, job.Records)
+
+	// A client without records still gets a transcript.
+	job, err = exportService.Submit(ctx, ExportRequest{Format: "pdf", ClientID: silent.ID}, owner.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, job.Records)
+	assert.Positive(t, job.Size)
+
+	// The account has more records than the sync limit.
+	job, err = exportService.Submit(ctx, ExportRequest{Format: "pdf"}, owner.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ExportJobStatusPending, job.Status)
+	_, err = exportService.Download(ctx, job.ID, owner.ID)
+	assert.ErrorIs(t, err, ErrExportNotReady)
+
+	require.NoError(t, runner.Tick(ctx))
+	downloaded, err = exportService.Download(ctx, job.ID, owner.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 4, downloaded.Records)
+	assert.True(t, bytes.HasPrefix(downloaded.Content, []byte("%PDF-")))
+	assert.Contains(t, string(downloaded.Content), "(Account owner)")
+
+	jobs, err := exportService.GetJobs(ctx, owner.ID)
+	require.NoError(t, err)
+	assert.Len(t, jobs, 4)
+
+	_, err = exportService.GetJob(ctx, job.ID, other.ID)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+	_, err = exportService.Submit(ctx, ExportRequest{Format: "csv", ClientID: client.ID}, other.ID)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+	_, err = exportService.Submit(ctx, ExportRequest{Format: "xlsx"}, owner.ID)
+	assert.ErrorIs(t, err, ErrInvalidExportFormat)
+	_, err = exportService.Submit(ctx, ExportRequest{Format: "csv", ClientID: client.ID, AgentID: first.ID}, owner.ID)
+	assert.ErrorIs(t, err, ErrExportScopeConflict)
+
+	// Expired files can no longer be downloaded and are then deleted.
+	now = now.Add(2 * time.Hour)
+	_, err = exportService.Download(ctx, job.ID, owner.ID)
+	assert.ErrorIs(t, err, ErrExportExpired)
+	require.NoError(t, runner.Tick(ctx))
+	_, err = exportService.GetJob(ctx, job.ID, owner.ID)
+	assert.ErrorIs(t, err, ErrExportJobNotFound)
+}
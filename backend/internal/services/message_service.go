@@ -5,6 +5,7 @@ import (
 	"backend/pkg/database"
 	"context"
 	"errors"
+	"net/http"
 	"time"
 
 	"gorm.io/gorm"
@@ -18,29 +19,34 @@ type MessageService interface {
 	CreateMessage(ctx context.Context, message *models.Message, userID uint) (*models.Message, error)
 	UpdateMessage(ctx context.Context, message *models.Message, userID uint) (*models.Message, error)
 	DeleteMessage(ctx context.Context, id uint, userID uint) error
+	GetDeletedMessages(ctx context.Context, userID uint) ([]*models.Message, error)
+	RestoreMessage(ctx context.Context, id uint, userID uint) (*models.Message, error)
+	PurgeMessage(ctx context.Context, id uint, userID uint) error
 }
 
 type messageServiceImpl struct {
 	db            *database.DB
 	agentService  AgentService
 	clientService ClientService
+	now           func() time.Time
 }
 
-func NewMessageService(db *database.DB, agentService AgentService, clientService ClientService) MessageService {
+func NewMessageService(db *database.DB, agentService AgentService, clientService ClientService, now func() time.Time) MessageService {
 	return &messageServiceImpl{
 		db:            db,
 		agentService:  agentService,
 		clientService: clientService,
+		now:           now,
 	}
 }
 
 var (
-	ErrMessageNotFound        = errors.New("message not found")
-	ErrMessageContentRequired = errors.New("message content is required")
-	ErrMessageTypeRequired    = errors.New("message type is required")
-	ErrInvalidMessageType     = errors.New("invalid message type")
-	ErrMessageIDRequired      = errors.New("message ID is required")
-	ErrInvalidMessageID       = errors.New("message ID is invalid")
+	ErrMessageNotFound        = NewError(http.StatusNotFound, "message_not_found", "message not found")
+	ErrMessageContentRequired = NewError(http.StatusBadRequest, "message_content_required", "message content is required")
+	ErrMessageTypeRequired    = NewError(http.StatusBadRequest, "message_type_required", "message type is required")
+	ErrInvalidMessageType     = NewError(http.StatusBadRequest, "invalid_message_type", "invalid message type")
+	ErrMessageIDRequired      = NewError(http.StatusBadRequest, "message_id_required", "message ID is required")
+	ErrInvalidMessageID       = NewError(http.StatusBadRequest, "invalid_message_id", "message ID is invalid")
 )
 
 func (m *messageServiceImpl) GetMessageByID(ctx context.Context, id uint, userID uint) (*models.Message, error) {
@@ -92,9 +98,11 @@ func (m *messageServiceImpl) GetMessageByClientID(ctx context.Context, clientID
 		return nil, ErrUnauthorized
 	}
 
+	// Includes messages handled by agents the client was transferred away
+	// from.
 	var messages []*models.Message
 	err = m.db.WithContext(ctx).
-		Preload("Agent").
+		Preload("Agent", withDeleted).
 		Preload("Client").
 		Where("client_id = ?", clientID).
 		Order("date asc").
@@ -196,12 +204,18 @@ func (m *messageServiceImpl) CreateMessage(ctx context.Context, message *models.
 	}
 
 	if message.Date.IsZero() {
-		message.Date = time.Now()
+		message.Date = m.now()
 	}
 
-	err = m.db.WithContext(ctx).
-		Create(message).
-		Error
+	err = m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(message).Error; err != nil {
+			return err
+		}
+		if err := recordEvent(tx, userID, EventMessageCreated, message.ID, message); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, userID, models.AuditActionCreate, AuditEntityMessage, message.ID, nil, message)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -228,10 +242,16 @@ func (m *messageServiceImpl) UpdateMessage(ctx context.Context, message *models.
 		return nil, ErrInvalidMessageType
 	}
 
-	err = m.db.WithContext(ctx).
-		Model(existingMessage).
-		Updates(message).
-		Error
+	before := *existingMessage
+	err = m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(existingMessage).Updates(message).Error; err != nil {
+			return err
+		}
+		if err := recordEvent(tx, userID, EventMessageUpdated, existingMessage.ID, existingMessage); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, userID, models.AuditActionUpdate, AuditEntityMessage, existingMessage.ID, &before, existingMessage)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -254,12 +274,102 @@ func (m *messageServiceImpl) DeleteMessage(ctx context.Context, id uint, userID
 		return ErrUnauthorized
 	}
 
-	err = m.db.WithContext(ctx).
-		Delete(existingMessage).
-		Error
+	err = m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(existingMessage).Error; err != nil {
+			return err
+		}
+		if err := recordEvent(tx, userID, EventMessageDeleted, existingMessage.ID, existingMessage); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, userID, models.AuditActionDelete, AuditEntityMessage, existingMessage.ID, existingMessage, nil)
+	})
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+func (m *messageServiceImpl) GetDeletedMessages(ctx context.Context, userID uint) ([]*models.Message, error) {
+	var messages []*models.Message
+	err := trashedForUser(m.db.WithContext(ctx), "messages", userID).
+		Order("deleted_at desc").
+		Find(&messages).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+// RestoreMessage undeletes a message. Its client must be live.
+func (m *messageServiceImpl) RestoreMessage(ctx context.Context, id uint, userID uint) (*models.Message, error) {
+	deletedMessage, err := m.findDeletedMessage(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	parentDeleted, err := clientIsDeleted(m.db.WithContext(ctx), deletedMessage.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	if parentDeleted {
+		return nil, ErrParentDeleted
+	}
+
+	err = m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(deletedMessage).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		deletedMessage.DeletedAt = gorm.DeletedAt{}
+		if err := recordEvent(tx, userID, EventMessageRestored, deletedMessage.ID, deletedMessage); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, userID, models.AuditActionRestore, AuditEntityMessage, deletedMessage.ID, nil, deletedMessage)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return deletedMessage, nil
+}
+
+// PurgeMessage permanently removes a soft-deleted message.
+func (m *messageServiceImpl) PurgeMessage(ctx context.Context, id uint, userID uint) error {
+	deletedMessage, err := m.findDeletedMessage(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Delete(&models.Message{}, deletedMessage.ID).Error; err != nil {
+			return err
+		}
+		if err := recordEvent(tx, userID, EventMessagePurged, deletedMessage.ID, deletedMessage); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, userID, models.AuditActionPurge, AuditEntityMessage, deletedMessage.ID, deletedMessage, nil)
+	})
+}
+
+func (m *messageServiceImpl) findDeletedMessage(ctx context.Context, id uint, userID uint) (*models.Message, error) {
+	var message models.Message
+	err := m.db.WithContext(ctx).
+		Unscoped().
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		First(&message).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMessageNotFound
+		}
+		return nil, err
+	}
+
+	if err := agentOwnedBy(m.db.WithContext(ctx), message.AgentID, userID); err != nil {
+		return nil, err
+	}
+
+	return &message, nil
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"backend/internal/export"
+	"backend/internal/models"
+	"backend/pkg/database"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// personalDataProfile is client.json in a personal data archive.
+type personalDataProfile struct {
+	Client      *models.Client
+	Contacts    []*models.ClientContact
+	Tags        []string
+	Fields      map[string]string
+	Notes       []*models.ClientNote
+	Assignments []*models.ClientAssignment
+}
+
+// loadPersonalData adds the client's profile and change history to the
+// document of a personal data export.
+func loadPersonalData(ctx context.Context, db *database.DB, job *models.ExportJob, doc *export.Document) (*export.PersonalData, error) {
+	tx := db.WithContext(ctx)
+	if len(doc.Conversations) == 0 {
+		return nil, ErrClientNotFound
+	}
+	profile := &personalDataProfile{Client: doc.Conversations[0].Client}
+
+	if err := tx.Where("client_id = ?", job.ScopeID).Order("id").Find(&profile.Contacts).Error; err != nil {
+		return nil, err
+	}
+	tags, err := clientTags(tx, job.ScopeID)
+	if err != nil {
+		return nil, err
+	}
+	profile.Tags = tags
+	values, err := clientFieldValues(tx, job.ScopeID)
+	if err != nil {
+		return nil, err
+	}
+	profile.Fields = fieldValueMap(values)
+	if err := tx.Where("client_id = ?", job.ScopeID).Order("created_at, id").Find(&profile.Notes).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("client_id = ?", job.ScopeID).Order("id").Find(&profile.Assignments).Error; err != nil {
+		return nil, err
+	}
+
+	history := []*models.AuditEntry{}
+	if err := clientAuditScope(tx, job.ScopeID).Order("id").Find(&history).Error; err != nil {
+		return nil, err
+	}
+
+	return &export.PersonalData{Document: doc, Profile: profile, History: history}, nil
+}
+
+// clientRecordIDs selects the IDs of a client's messages or transactions,
+// deleted or not.
+func clientRecordIDs(db *gorm.DB, model interface{}, clientID uint) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true}).
+		Unscoped().
+		Model(model).
+		Select("id").
+		Where("client_id = ?", clientID)
+}
+
+// clientAuditScope limits a query on audit entries to those about a client:
+// the client itself with its tags and field values, its messages,
+// transactions and refunds, and its contacts and notes. Contacts and notes
+// are audited under their own IDs, which are gone once they are deleted, so
+// their entries are matched on the ClientID in their snapshots, whoever
+// wrote them.
+func clientAuditScope(db *gorm.DB, clientID uint) *gorm.DB {
+	transactions := clientRecordIDs(db, &models.Transaction{}, clientID)
+	refunds := db.Session(&gorm.Session{NewDB: true}).
+		Unscoped().
+		Model(&models.Refund{}).
+		Select("id").
+		Where("transaction_id IN (?)", transactions)
+
+	return db.Model(&models.AuditEntry{}).Where(
+		"(entity_type IN ? AND entity_id = ?) OR (entity_type = ? AND entity_id IN (?)) OR (entity_type = ? AND entity_id IN (?)) OR (entity_type = ? AND entity_id IN (?)) OR (entity_type IN ? AND ("+snapshotClientID("before")+" = ? OR "+snapshotClientID("after")+" = ?))",
+		[]string{AuditEntityClient, AuditEntityClientTags, AuditEntityFieldValues}, clientID,
+		AuditEntityMessage, clientRecordIDs(db, &models.Message{}, clientID),
+		AuditEntityTransaction, transactions,
+		AuditEntityRefund, refunds,
+		[]string{AuditEntityContact, AuditEntityNote}, clientID, clientID,
+	)
+}
+
+// snapshotClientID is the SQL for the ClientID recorded in an audit
+// snapshot column. Snapshots cleared by an erasure are empty, which
+// json_extract would reject as malformed.
+func snapshotClientID(column string) string {
+	return "CASE WHEN json_valid(" + column + ") THEN json_extract(" + column + ", '$.ClientID') END"
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"backend/internal/config"
+	"backend/internal/models"
+	"backend/pkg/database"
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrQuotaExceeded = NewError(http.StatusTooManyRequests, "quota_exceeded", "daily quota exceeded")
+
+// QuotaService meters the AI endpoints per user and UTC day. A quota of zero
+// means unlimited.
+type QuotaService interface {
+	Check(ctx context.Context, userID uint, kind string) error
+	Consume(ctx context.Context, userID uint, kind string, amount int64) error
+}
+
+type quotaServiceImpl struct {
+	db     *database.DB
+	limits map[string]int64
+	now    func() time.Time
+}
+
+func NewQuotaService(db *database.DB, cfg *config.Config, now func() time.Time) QuotaService {
+	return &quotaServiceImpl{
+		db: db,
+		limits: map[string]int64{
+			models.UsageLLMTokens: int64(cfg.LLMDailyTokenQuota),
+			models.UsageImages:    int64(cfg.SDDailyImageQuota),
+		},
+		now: now,
+	}
+}
+
+// Check fails once the user has used up today's quota. Usage is only known
+// after a call completes, so the call that crosses the limit still succeeds.
+func (q *quotaServiceImpl) Check(ctx context.Context, userID uint, kind string) error {
+	limit := q.limits[kind]
+	if limit <= 0 {
+		return nil
+	}
+
+	now := q.now().UTC()
+	var usage models.DailyUsage
+	err := q.db.WithContext(ctx).
+		Where("user_id = ? AND day = ? AND kind = ?", userID, now.Format(time.DateOnly), kind).
+		First(&usage).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if usage.Amount >= limit {
+		midnight := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+		return ErrQuotaExceeded.WithRetryAfter(midnight.Sub(now))
+	}
+	return nil
+}
+
+func (q *quotaServiceImpl) Consume(ctx context.Context, userID uint, kind string, amount int64) error {
+	if amount <= 0 {
+		return nil
+	}
+
+	usage := models.DailyUsage{
+		UserID: userID,
+		Day:    q.now().UTC().Format(time.DateOnly),
+		Kind:   kind,
+		Amount: amount,
+	}
+	return q.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "day"}, {Name: "kind"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"amount": gorm.Expr("daily_usages.amount + ?", amount)}),
+	}).Create(&usage).Error
+}
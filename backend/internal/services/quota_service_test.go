@@ -0,0 +1,37 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"backend/internal/config"
+	"backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuotaService(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	now := time.Date(2026, time.March, 2, 18, 0, 0, 0, time.UTC)
+	quotas := NewQuotaService(db, &config.Config{LLMDailyTokenQuota: 100}, func() time.Time { return now })
+
+	require.NoError(t, quotas.Check(ctx, 1, models.UsageLLMTokens))
+	require.NoError(t, quotas.Consume(ctx, 1, models.UsageLLMTokens, 60))
+	require.NoError(t, quotas.Check(ctx, 1, models.UsageLLMTokens))
+	require.NoError(t, quotas.Consume(ctx, 1, models.UsageLLMTokens, 60))
+
+	err := quotas.Check(ctx, 1, models.UsageLLMTokens)
+	require.ErrorIs(t, err, ErrQuotaExceeded)
+	var exceeded *Error
+	require.ErrorAs(t, err, &exceeded)
+	assert.Equal(t, 6*time.Hour, exceeded.RetryAfter, "the quota resets at UTC midnight")
+
+	assert.NoError(t, quotas.Check(ctx, 2, models.UsageLLMTokens), "quotas are per user")
+	assert.NoError(t, quotas.Check(ctx, 1, models.UsageImages), "a zero quota is unlimited")
+
+	now = now.Add(6 * time.Hour)
+	assert.NoError(t, quotas.Check(ctx, 1, models.UsageLLMTokens), "a new day starts fresh")
+}
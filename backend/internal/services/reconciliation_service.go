@@ -0,0 +1,283 @@
+package services
+
+import (
+	"backend/internal/models"
+	"backend/internal/money"
+	"backend/pkg/database"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	MismatchMissingInSystem    = "missing_in_system"
+	MismatchMissingInProcessor = "missing_in_processor"
+	MismatchAmount             = "amount_mismatch"
+	MismatchCurrency           = "currency_mismatch"
+	MismatchStatus             = "status_mismatch"
+	MismatchInvalidRow         = "invalid_row"
+)
+
+// processorStatuses maps the status vocabulary used by common payment
+// processors onto transaction statuses.
+var processorStatuses = map[string]string{
+	"pending":    models.TransactionStatusPending,
+	"processing": models.TransactionStatusPending,
+	"completed":  models.TransactionStatusCompleted,
+	"succeeded":  models.TransactionStatusCompleted,
+	"paid":       models.TransactionStatusCompleted,
+	"settled":    models.TransactionStatusCompleted,
+	"refunded":   models.TransactionStatusRefunded,
+	"disputed":   models.TransactionStatusDisputed,
+	"chargeback": models.TransactionStatusDisputed,
+}
+
+type ReconciliationMismatch struct {
+	Line              int    `json:"line,omitempty"`
+	ExternalReference string `json:"external_reference"`
+	TransactionID     uint   `json:"transaction_id,omitempty"`
+	Issue             string `json:"issue"`
+	Expected          string `json:"expected,omitempty"` // value reported by the processor
+	Actual            string `json:"actual,omitempty"`   // value stored in Siren-Net
+}
+
+type ReconciliationReport struct {
+	Rows       int                       `json:"rows"`
+	Matched    int                       `json:"matched"`
+	Mismatches []*ReconciliationMismatch `json:"mismatches"`
+}
+
+type ReconciliationService interface {
+	Reconcile(ctx context.Context, records io.Reader, userID uint) (*ReconciliationReport, error)
+}
+
+type reconciliationServiceImpl struct {
+	db *database.DB
+}
+
+func NewReconciliationService(db *database.DB) ReconciliationService {
+	return &reconciliationServiceImpl{db: db}
+}
+
+var (
+	ErrReconciliationFileRequired = NewError(http.StatusBadRequest, "reconciliation_file_required", "reconciliation file is required")
+	ErrReconciliationHeader       = NewError(http.StatusBadRequest, "invalid_reconciliation_header", "reconciliation file must have external_reference, amount and currency columns")
+)
+
+type processorRecord struct {
+	line      int
+	reference string
+	amount    int64
+	currency  string
+	status    string
+	date      time.Time
+}
+
+// Reconcile compares a processor CSV export against the user's stored
+// transactions. The CSV needs a header with external_reference, amount and
+// currency columns; status and date columns are optional. Stored transactions
+// that carry an external reference and fall inside the file's date range but
+// are absent from the file are reported as missing_in_processor.
+func (r *reconciliationServiceImpl) Reconcile(ctx context.Context, records io.Reader, userID uint) (*ReconciliationReport, error) {
+	reader := csv.NewReader(records)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, ErrReconciliationFileRequired
+		}
+		return nil, err
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"external_reference", "amount", "currency"} {
+		if _, ok := columns[required]; !ok {
+			return nil, ErrReconciliationHeader
+		}
+	}
+
+	report := &ReconciliationReport{Mismatches: []*ReconciliationMismatch{}}
+	var parsed []*processorRecord
+	var earliest, latest time.Time
+
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		report.Rows++
+		if err != nil {
+			report.Mismatches = append(report.Mismatches, &ReconciliationMismatch{Line: line, Issue: MismatchInvalidRow, Expected: err.Error()})
+			continue
+		}
+
+		record, err := parseProcessorRecord(row, columns, line)
+		if err != nil {
+			report.Mismatches = append(report.Mismatches, &ReconciliationMismatch{Line: line, ExternalReference: field(row, columns, "external_reference"), Issue: MismatchInvalidRow, Expected: err.Error()})
+			continue
+		}
+
+		if !record.date.IsZero() {
+			if earliest.IsZero() || record.date.Before(earliest) {
+				earliest = record.date
+			}
+			if record.date.After(latest) {
+				latest = record.date
+			}
+		}
+		parsed = append(parsed, record)
+	}
+
+	references := make([]string, 0, len(parsed))
+	for _, record := range parsed {
+		references = append(references, record.reference)
+	}
+
+	stored := map[string]*models.Transaction{}
+	if len(references) > 0 {
+		var transactions []*models.Transaction
+		err := r.db.WithContext(ctx).
+			Joins("JOIN agents ON agents.id = transactions.agent_id").
+			Where("agents.user_id = ? AND transactions.external_reference IN ?", userID, references).
+			Find(&transactions).
+			Error
+		if err != nil {
+			return nil, err
+		}
+		for _, transaction := range transactions {
+			stored[transaction.ExternalReference] = transaction
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, record := range parsed {
+		seen[record.reference] = true
+		transaction, ok := stored[record.reference]
+		if !ok {
+			report.Mismatches = append(report.Mismatches, &ReconciliationMismatch{
+				Line:              record.line,
+				ExternalReference: record.reference,
+				Issue:             MismatchMissingInSystem,
+				Expected:          money.Format(record.amount, record.currency) + " " + record.currency,
+			})
+			continue
+		}
+
+		mismatches := compareProcessorRecord(record, transaction)
+		if len(mismatches) == 0 {
+			report.Matched++
+		}
+		report.Mismatches = append(report.Mismatches, mismatches...)
+	}
+
+	if !earliest.IsZero() {
+		var unmatched []*models.Transaction
+		err := r.db.WithContext(ctx).
+			Joins("JOIN agents ON agents.id = transactions.agent_id").
+			Where("agents.user_id = ? AND transactions.external_reference <> ''", userID).
+			Where("transactions.date >= ? AND transactions.date < ?", earliest, latest.Add(24*time.Hour)).
+			Order("transactions.date asc").
+			Find(&unmatched).
+			Error
+		if err != nil {
+			return nil, err
+		}
+		for _, transaction := range unmatched {
+			if seen[transaction.ExternalReference] {
+				continue
+			}
+			report.Mismatches = append(report.Mismatches, &ReconciliationMismatch{
+				ExternalReference: transaction.ExternalReference,
+				TransactionID:     transaction.ID,
+				Issue:             MismatchMissingInProcessor,
+				Actual:            money.Format(transaction.AmountMinor, transaction.Currency) + " " + transaction.Currency,
+			})
+		}
+	}
+
+	return report, nil
+}
+
+func compareProcessorRecord(record *processorRecord, transaction *models.Transaction) []*ReconciliationMismatch {
+	var mismatches []*ReconciliationMismatch
+	mismatch := func(issue, expected, actual string) {
+		mismatches = append(mismatches, &ReconciliationMismatch{
+			Line:              record.line,
+			ExternalReference: record.reference,
+			TransactionID:     transaction.ID,
+			Issue:             issue,
+			Expected:          expected,
+			Actual:            actual,
+		})
+	}
+
+	if record.currency != transaction.Currency {
+		mismatch(MismatchCurrency, record.currency, transaction.Currency)
+	} else if record.amount != transaction.AmountMinor {
+		mismatch(MismatchAmount, money.Format(record.amount, record.currency), money.Format(transaction.AmountMinor, transaction.Currency))
+	}
+
+	if record.status != "" && record.status != transaction.Status {
+		mismatch(MismatchStatus, record.status, transaction.Status)
+	}
+
+	return mismatches
+}
+
+func parseProcessorRecord(row []string, columns map[string]int, line int) (*processorRecord, error) {
+	reference := field(row, columns, "external_reference")
+	if reference == "" {
+		return nil, errors.New("external_reference is empty")
+	}
+
+	currency, err := money.NormalizeCurrency(field(row, columns, "currency"))
+	if err != nil {
+		return nil, err
+	}
+
+	amount, err := money.Parse(field(row, columns, "amount"), currency)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &processorRecord{line: line, reference: reference, amount: amount, currency: currency}
+
+	if rawStatus := field(row, columns, "status"); rawStatus != "" {
+		status, ok := processorStatuses[strings.ToLower(rawStatus)]
+		if !ok {
+			return nil, fmt.Errorf("unknown status %q", rawStatus)
+		}
+		record.status = status
+	}
+
+	if rawDate := field(row, columns, "date"); rawDate != "" {
+		date, err := time.Parse(time.RFC3339, rawDate)
+		if err != nil {
+			date, err = time.Parse(time.DateOnly, rawDate)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid date %q", rawDate)
+		}
+		record.date = date
+	}
+
+	return record, nil
+}
+
+func field(row []string, columns map[string]int, name string) string {
+	index, ok := columns[name]
+	if !ok || index >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[index])
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefunds_FullRefundMarksTransactionRefunded(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	user := &models.User{Username: "owner", Email: "owner@mail.com", Password: "x"}
+	require.NoError(t, NewUserService(db).CreateUser(ctx, user))
+
+	agentService := NewAgentService(db, time.Now)
+	clientService := NewClientService(db, agentService, time.Now)
+	transactionService := NewTransactionService(db, agentService, clientService, time.Now)
+
+	agent, err := agentService.CreateAgent(ctx, &models.Agent{Name: "a", Characteristics: "c"}, user.ID)
+	require.NoError(t, err)
+	client, err := clientService.CreateClient(ctx, &models.Client{Name: "c", AgentID: agent.ID}, agent.ID, user.ID)
+	require.NoError(t, err)
+	transaction, err := transactionService.CreateTransaction(ctx, &models.Transaction{AgentID: agent.ID, ClientID: client.ID, AmountMinor: 1000, Currency: "USD", Date: time.Now()}, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.TransactionStatusCompleted, transaction.Status)
+
+	_, err = transactionService.CreateRefund(ctx, &models.Refund{TransactionID: transaction.ID, AmountMinor: 400, Currency: "USD"}, user.ID)
+	require.NoError(t, err)
+	_, err = transactionService.CreateRefund(ctx, &models.Refund{TransactionID: transaction.ID, AmountMinor: 700, Currency: "USD"}, user.ID)
+	assert.ErrorIs(t, err, ErrRefundExceedsTransaction)
+	_, err = transactionService.CreateRefund(ctx, &models.Refund{TransactionID: transaction.ID, AmountMinor: 600, Currency: "USD"}, user.ID)
+	require.NoError(t, err)
+
+	refunded, err := transactionService.GetTransactionByID(ctx, transaction.ID, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.TransactionStatusRefunded, refunded.Status)
+
+	_, err = transactionService.UpdateTransactionStatus(ctx, transaction.ID, models.TransactionStatusCompleted, user.ID)
+	assert.ErrorIs(t, err, ErrInvalidStatusTransition)
+
+	totals, err := transactionService.GetTotals(ctx, TransactionTotalsFilter{}, user.ID)
+	require.NoError(t, err)
+	require.Len(t, totals, 1)
+	assert.Equal(t, int64(0), totals[0].NetMinor)
+}
+
+func TestReconcile_ReportsMismatches(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	user := &models.User{Username: "owner", Email: "owner@mail.com", Password: "x"}
+	require.NoError(t, NewUserService(db).CreateUser(ctx, user))
+
+	agentService := NewAgentService(db, time.Now)
+	clientService := NewClientService(db, agentService, time.Now)
+	transactionService := NewTransactionService(db, agentService, clientService, time.Now)
+
+	agent, err := agentService.CreateAgent(ctx, &models.Agent{Name: "a", Characteristics: "c"}, user.ID)
+	require.NoError(t, err)
+	client, err := clientService.CreateClient(ctx, &models.Client{Name: "c", AgentID: agent.ID}, agent.ID, user.ID)
+	require.NoError(t, err)
+
+	day := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	for _, transaction := range []*models.Transaction{
+		{AgentID: agent.ID, ClientID: client.ID, AmountMinor: 1250, Currency: "USD", Date: day, ExternalReference: "ch_ok"},
+		{AgentID: agent.ID, ClientID: client.ID, AmountMinor: 500, Currency: "USD", Date: day, ExternalReference: "ch_amount"},
+		{AgentID: agent.ID, ClientID: client.ID, AmountMinor: 900, Currency: "USD", Date: day, ExternalReference: "ch_absent"},
+	} {
+		_, err := transactionService.CreateTransaction(ctx, transaction, user.ID)
+		require.NoError(t, err)
+	}
+
+	_, err = transactionService.CreateTransaction(ctx, &models.Transaction{AgentID: agent.ID, ClientID: client.ID, AmountMinor: 1, Currency: "USD", ExternalReference: "ch_ok"}, user.ID)
+	assert.ErrorIs(t, err, ErrDuplicateExternalReference)
+
+	file := strings.NewReader("external_reference,amount,currency,status,date\n" +
+		"ch_ok,12.50,USD,succeeded,2024-03-01\n" +
+		"ch_amount,5.10,USD,succeeded,2024-03-01\n" +
+		"ch_unknown,3.00,USD,succeeded,2024-03-01\n" +
+		"ch_bad,abc,USD,succeeded,2024-03-01\n")
+
+	report, err := NewReconciliationService(db).Reconcile(ctx, file, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 4, report.Rows)
+	assert.Equal(t, 1, report.Matched)
+
+	issues := map[string]string{}
+	for _, mismatch := range report.Mismatches {
+		issues[mismatch.ExternalReference] = mismatch.Issue
+	}
+	assert.Equal(t, map[string]string{
+		"ch_amount":  MismatchAmount,
+		"ch_unknown": MismatchMissingInSystem,
+		"ch_bad":     MismatchInvalidRow,
+		"ch_absent":  MismatchMissingInProcessor,
+	}, issues)
+}
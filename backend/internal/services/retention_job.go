@@ -0,0 +1,119 @@
+package services
+
+import (
+	"backend/internal/config"
+	"backend/internal/models"
+	"backend/pkg/database"
+	"context"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RetentionJob permanently removes soft-deleted records once they have been in
+// the trash for longer than the configured retention period.
+type RetentionJob struct {
+	db  *database.DB
+	cfg *config.Config
+	now func() time.Time
+}
+
+func NewRetentionJob(db *database.DB, cfg *config.Config, now func() time.Time) *RetentionJob {
+	return &RetentionJob{db: db, cfg: cfg, now: now}
+}
+
+// Run purges on every interval until ctx is cancelled. A zero retention
+// disables the job.
+func (j *RetentionJob) Run(ctx context.Context) {
+	if j.cfg.TrashRetention <= 0 || j.cfg.TrashPurgeInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(j.cfg.TrashPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := j.PurgeExpired(ctx, j.now().Add(-j.cfg.TrashRetention)); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "trash purge failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PurgeExpired hard-deletes everything soft-deleted before cutoff, parents
+// together with all of their children.
+func (j *RetentionJob) PurgeExpired(ctx context.Context, cutoff time.Time) error {
+	var agents []*models.Agent
+	err := j.db.WithContext(ctx).
+		Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+		Find(&agents).
+		Error
+	if err != nil {
+		return err
+	}
+
+	for _, agent := range agents {
+		err := j.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := purgeAgentTree(tx, agent.ID); err != nil {
+				return err
+			}
+			if err := recordEvent(tx, agent.UserID, EventAgentPurged, agent.ID, agent); err != nil {
+				return err
+			}
+			return recordAudit(ctx, tx, 0, models.AuditActionPurge, AuditEntityAgent, agent.ID, agent, nil)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	var clients []*models.Client
+	err = j.db.WithContext(ctx).
+		Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+		Find(&clients).
+		Error
+	if err != nil {
+		return err
+	}
+
+	for _, client := range clients {
+		err := j.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := purgeClientTree(tx, client.ID); err != nil {
+				return err
+			}
+			return recordAudit(ctx, tx, 0, models.AuditActionPurge, AuditEntityClient, client.ID, client, nil)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return j.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		expiredTransactions := tx.Unscoped().
+			Model(&models.Transaction{}).
+			Select("id").
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff)
+		if err := tx.Unscoped().Where("transaction_id IN (?)", expiredTransactions).Delete(&models.Refund{}).Error; err != nil {
+			return err
+		}
+
+		for _, model := range []interface{}{&models.Message{}, &models.Transaction{}} {
+			err := tx.Unscoped().
+				Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+				Delete(model).
+				Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,458 @@
+package services
+
+import (
+	"backend/internal/models"
+	"backend/internal/money"
+	"backend/pkg/database"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SegmentService stores segments, saved client filters over clients, their
+// messages and transactions, and evaluates them.
+type SegmentService interface {
+	GetSegments(ctx context.Context, userID uint) ([]*models.Segment, error)
+	GetSegmentByID(ctx context.Context, id uint, userID uint) (*models.Segment, error)
+	CreateSegment(ctx context.Context, segment *models.Segment, userID uint) (*models.Segment, error)
+	UpdateSegment(ctx context.Context, segment *models.Segment, userID uint) (*models.Segment, error)
+	DeleteSegment(ctx context.Context, id uint, userID uint) error
+	GetSegmentClients(ctx context.Context, id uint, limit int, offset int, userID uint) (*SegmentClientsPage, error)
+}
+
+type segmentServiceImpl struct {
+	db  *database.DB
+	now func() time.Time
+}
+
+func NewSegmentService(db *database.DB, now func() time.Time) SegmentService {
+	return &segmentServiceImpl{db: db, now: now}
+}
+
+// SegmentClientsPage is one page of a segment's members, ordered by ID.
+type SegmentClientsPage struct {
+	Total   int64            `json:"total"`
+	Limit   int              `json:"limit"`
+	Offset  int              `json:"offset"`
+	Clients []*models.Client `json:"clients"`
+}
+
+const (
+	defaultSegmentPageLimit = 50
+	maxSegmentPageLimit     = 500
+)
+
+var (
+	ErrSegmentNotFound          = NewError(http.StatusNotFound, "segment_not_found", "segment not found")
+	ErrInvalidSegmentID         = NewError(http.StatusBadRequest, "invalid_segment_id", "segment ID is invalid")
+	ErrSegmentNameRequired      = NewError(http.StatusBadRequest, "segment_name_required", "segment name is required")
+	ErrInvalidSegmentMatch      = NewError(http.StatusBadRequest, "invalid_segment_match", "match must be ALL or ANY")
+	ErrInvalidSegmentCondition  = NewError(http.StatusBadRequest, "invalid_segment_condition", "segment condition is invalid")
+	ErrInvalidSegmentPagination = NewError(http.StatusBadRequest, "invalid_pagination", "limit and offset must be non-negative integers")
+)
+
+func (s *segmentServiceImpl) GetSegments(ctx context.Context, userID uint) ([]*models.Segment, error) {
+	var segments []*models.Segment
+	err := s.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("name, id").
+		Find(&segments).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return segments, nil
+}
+
+func (s *segmentServiceImpl) GetSegmentByID(ctx context.Context, id uint, userID uint) (*models.Segment, error) {
+	return findSegment(s.db.WithContext(ctx), id, userID)
+}
+
+func (s *segmentServiceImpl) CreateSegment(ctx context.Context, segment *models.Segment, userID uint) (*models.Segment, error) {
+	segment.ID = 0
+	segment.UserID = userID
+	if err := s.validate(ctx, segment); err != nil {
+		return nil, err
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(segment).Error; err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, userID, models.AuditActionCreate, AuditEntitySegment, segment.ID, nil, segment)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return segment, nil
+}
+
+func (s *segmentServiceImpl) UpdateSegment(ctx context.Context, segment *models.Segment, userID uint) (*models.Segment, error) {
+	existing, err := s.GetSegmentByID(ctx, segment.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	before := *existing
+	existing.Name = segment.Name
+	existing.Description = segment.Description
+	existing.Match = segment.Match
+	existing.Conditions = segment.Conditions
+	if err := s.validate(ctx, existing); err != nil {
+		return nil, err
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(existing).Error; err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, userID, models.AuditActionUpdate, AuditEntitySegment, existing.ID, &before, existing)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+func (s *segmentServiceImpl) DeleteSegment(ctx context.Context, id uint, userID uint) error {
+	existing, err := s.GetSegmentByID(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(existing).Error; err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, userID, models.AuditActionDelete, AuditEntitySegment, existing.ID, existing, nil)
+	})
+}
+
+// GetSegmentClients evaluates the segment now. Archived clients are never
+// members. A zero limit takes the default page size.
+func (s *segmentServiceImpl) GetSegmentClients(ctx context.Context, id uint, limit int, offset int, userID uint) (*SegmentClientsPage, error) {
+	if limit < 0 || offset < 0 {
+		return nil, ErrInvalidSegmentPagination
+	}
+	if limit == 0 {
+		limit = defaultSegmentPageLimit
+	}
+	limit = min(limit, maxSegmentPageLimit)
+
+	db := s.db.WithContext(ctx)
+	segment, err := findSegment(db, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	members, err := segmentExpression(db, segment, userID, s.now())
+	if err != nil {
+		return nil, err
+	}
+
+	scope := func() *gorm.DB {
+		query := db.Model(&models.Client{}).
+			Where("clients.agent_id IN (?)", db.Model(&models.Agent{}).Select("id").Where("user_id = ?", userID)).
+			Where("clients.archived_at IS NULL")
+		if members != nil {
+			query = query.Where(members)
+		}
+		return query
+	}
+
+	page := &SegmentClientsPage{Limit: limit, Offset: offset, Clients: []*models.Client{}}
+	if err := scope().Count(&page.Total).Error; err != nil {
+		return nil, err
+	}
+	err = scope().
+		Preload("Agent").
+		Order("clients.id").
+		Limit(limit).
+		Offset(offset).
+		Find(&page.Clients).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+func (s *segmentServiceImpl) validate(ctx context.Context, segment *models.Segment) error {
+	segment.Name = strings.TrimSpace(segment.Name)
+	if segment.Name == "" {
+		return ErrSegmentNameRequired
+	}
+
+	segment.Match = strings.ToUpper(strings.TrimSpace(segment.Match))
+	if segment.Match == "" {
+		segment.Match = models.SegmentMatchAll
+	}
+	if segment.Match != models.SegmentMatchAll && segment.Match != models.SegmentMatchAny {
+		return ErrInvalidSegmentMatch
+	}
+
+	for i := range segment.Conditions {
+		condition := &segment.Conditions[i]
+		condition.Field = strings.ToUpper(strings.TrimSpace(condition.Field))
+		condition.Op = strings.ToUpper(strings.TrimSpace(condition.Op))
+		condition.Value = strings.TrimSpace(condition.Value)
+	}
+
+	// Compiling the conditions checks them against the user's custom fields.
+	_, err := segmentExpression(s.db.WithContext(ctx), segment, segment.UserID, s.now())
+	return err
+}
+
+func findSegment(db *gorm.DB, id uint, userID uint) (*models.Segment, error) {
+	var segment models.Segment
+	err := db.Where("id = ?", id).First(&segment).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSegmentNotFound
+		}
+		return nil, err
+	}
+	if segment.UserID != userID {
+		return nil, ErrUnauthorized
+	}
+	return &segment, nil
+}
+
+// segmentExpression compiles the segment's conditions into a condition
+// group over the clients table. It returns nil for a segment without
+// conditions, which matches every client.
+func segmentExpression(db *gorm.DB, segment *models.Segment, userID uint, now time.Time) (*gorm.DB, error) {
+	if len(segment.Conditions) == 0 {
+		return nil, nil
+	}
+
+	compiler := segmentCompiler{db: db, userID: userID, now: now.UTC()}
+	group := db.Session(&gorm.Session{NewDB: true})
+	for i, condition := range segment.Conditions {
+		sql, args, err := compiler.condition(i, condition)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 && segment.Match == models.SegmentMatchAny {
+			group = group.Or(sql, args...)
+		} else {
+			group = group.Where(sql, args...)
+		}
+	}
+	return group, nil
+}
+
+type segmentCompiler struct {
+	db     *gorm.DB
+	userID uint
+	now    time.Time
+}
+
+var segmentComparisons = map[string]string{
+	models.SegmentOpEq:  "=",
+	models.SegmentOpNeq: "<>",
+	models.SegmentOpGt:  ">",
+	models.SegmentOpGte: ">=",
+	models.SegmentOpLt:  "<",
+	models.SegmentOpLte: "<=",
+}
+
+func (s segmentCompiler) condition(i int, c models.SegmentCondition) (string, []interface{}, error) {
+	invalid := func(attribute string) error {
+		return ErrInvalidSegmentCondition.WithField(fmt.Sprintf("conditions[%d].%s", i, attribute))
+	}
+	if c.WindowDays < 0 {
+		return "", nil, invalid("window_days")
+	}
+
+	switch c.Field {
+	case models.SegmentFieldScore:
+		op, ok := segmentComparisons[c.Op]
+		if !ok {
+			return "", nil, invalid("op")
+		}
+		score, err := strconv.ParseFloat(c.Value, 64)
+		if err != nil {
+			return "", nil, invalid("value")
+		}
+		return "clients.score " + op + " ?", []interface{}{score}, nil
+
+	case models.SegmentFieldName:
+		switch c.Op {
+		case models.SegmentOpContains:
+			return "LOWER(clients.name) LIKE ?", []interface{}{"%" + strings.ToLower(c.Value) + "%"}, nil
+		case models.SegmentOpEq, models.SegmentOpNeq:
+			return "clients.name " + segmentComparisons[c.Op] + " ?", []interface{}{c.Value}, nil
+		}
+		return "", nil, invalid("op")
+
+	case models.SegmentFieldAgent:
+		if c.Op != models.SegmentOpEq && c.Op != models.SegmentOpNeq {
+			return "", nil, invalid("op")
+		}
+		agentID, err := strconv.ParseUint(c.Value, 10, 32)
+		if err != nil {
+			return "", nil, invalid("value")
+		}
+		return "clients.agent_id " + segmentComparisons[c.Op] + " ?", []interface{}{agentID}, nil
+
+	case models.SegmentFieldTag:
+		tag := normalizeTag(c.Value)
+		if tag == "" {
+			return "", nil, invalid("value")
+		}
+		exists := "EXISTS (SELECT 1 FROM client_tags WHERE client_tags.client_id = clients.id AND client_tags.tag = ?)"
+		switch c.Op {
+		case models.SegmentOpHas:
+			return exists, []interface{}{tag}, nil
+		case models.SegmentOpNotHas:
+			return "NOT " + exists, []interface{}{tag}, nil
+		}
+		return "", nil, invalid("op")
+
+	case models.SegmentFieldCustomField:
+		return s.customField(c, invalid)
+
+	case models.SegmentFieldDaysSinceClientMessage, models.SegmentFieldDaysSinceAgentMessage:
+		return s.daysSinceMessage(c, invalid)
+
+	case models.SegmentFieldMessageCount, models.SegmentFieldTransactionCount:
+		op, ok := segmentComparisons[c.Op]
+		if !ok {
+			return "", nil, invalid("op")
+		}
+		count, err := strconv.ParseInt(c.Value, 10, 64)
+		if err != nil || count < 0 {
+			return "", nil, invalid("value")
+		}
+		table := "messages"
+		sql := "(SELECT COUNT(*) FROM messages WHERE messages.client_id = clients.id AND messages.deleted_at IS NULL"
+		args := []interface{}{}
+		if c.Field == models.SegmentFieldTransactionCount {
+			table = "transactions"
+			sql = "(SELECT COUNT(*) FROM transactions WHERE transactions.client_id = clients.id AND transactions.deleted_at IS NULL AND transactions.status <> ?"
+			args = append(args, models.TransactionStatusPending)
+		}
+		if c.WindowDays > 0 {
+			sql += " AND " + table + ".date >= ?"
+			args = append(args, s.windowStart(c.WindowDays))
+		}
+		return sql + ") " + op + " ?", append(args, count), nil
+
+	case models.SegmentFieldSpent:
+		return s.spent(c, invalid)
+	}
+
+	return "", nil, invalid("field")
+}
+
+func (s segmentCompiler) customField(c models.SegmentCondition, invalid func(string) error) (string, []interface{}, error) {
+	field, err := findCustomField(s.db, c.Key, s.userID)
+	if err != nil {
+		return "", nil, err
+	}
+	op, ok := segmentComparisons[c.Op]
+	if !ok {
+		return "", nil, invalid("op")
+	}
+	value, err := normalizeFieldValue(field, c.Value)
+	if err != nil {
+		return "", nil, invalid("value")
+	}
+
+	exists := "EXISTS (SELECT 1 FROM client_field_values WHERE client_field_values.client_id = clients.id AND client_field_values.field_id = ? AND "
+	switch {
+	case c.Op == models.SegmentOpEq:
+		return exists + "client_field_values.value = ?)", []interface{}{field.ID, value}, nil
+	case c.Op == models.SegmentOpNeq:
+		// Clients without a value differ from any value.
+		return "NOT " + exists + "client_field_values.value = ?)", []interface{}{field.ID, value}, nil
+	case field.Type == models.CustomFieldTypeNumber:
+		number, _ := strconv.ParseFloat(value, 64)
+		return exists + "CAST(client_field_values.value AS REAL) " + op + " ?)", []interface{}{field.ID, number}, nil
+	case field.Type == models.CustomFieldTypeDate:
+		// Canonical dates sort as text.
+		return exists + "client_field_values.value " + op + " ?)", []interface{}{field.ID, value}, nil
+	}
+	return "", nil, invalid("op")
+}
+
+// daysSinceMessage compares the time since the client's last message in one
+// direction. A client without such messages has waited forever, so it
+// matches GT and GTE and never LT or LTE.
+func (s segmentCompiler) daysSinceMessage(c models.SegmentCondition, invalid func(string) error) (string, []interface{}, error) {
+	days, err := strconv.ParseFloat(c.Value, 64)
+	if err != nil || days < 0 || math.IsInf(days, 0) {
+		return "", nil, invalid("value")
+	}
+	bound := s.now.Add(-time.Duration(days * float64(24*time.Hour)))
+
+	messageType := models.MessageTypeClientToAgent
+	if c.Field == models.SegmentFieldDaysSinceAgentMessage {
+		messageType = models.MessageTypeAgentToClient
+	}
+	exists := "EXISTS (SELECT 1 FROM messages WHERE messages.client_id = clients.id AND messages.deleted_at IS NULL AND messages.type = ? AND messages.date "
+
+	switch c.Op {
+	case models.SegmentOpGt:
+		return "NOT " + exists + ">= ?)", []interface{}{messageType, bound}, nil
+	case models.SegmentOpGte:
+		return "NOT " + exists + "> ?)", []interface{}{messageType, bound}, nil
+	case models.SegmentOpLt:
+		return exists + "> ?)", []interface{}{messageType, bound}, nil
+	case models.SegmentOpLte:
+		return exists + ">= ?)", []interface{}{messageType, bound}, nil
+	}
+	return "", nil, invalid("op")
+}
+
+// spent compares the client's net spend in one currency: settled
+// transactions minus refunds, both inside the window.
+func (s segmentCompiler) spent(c models.SegmentCondition, invalid func(string) error) (string, []interface{}, error) {
+	op, ok := segmentComparisons[c.Op]
+	if !ok {
+		return "", nil, invalid("op")
+	}
+	currency := models.DefaultCurrency
+	if c.Currency != "" {
+		normalized, err := money.NormalizeCurrency(c.Currency)
+		if err != nil {
+			return "", nil, invalid("currency")
+		}
+		currency = normalized
+	}
+	amount, err := money.Parse(c.Value, currency)
+	if err != nil {
+		return "", nil, invalid("value")
+	}
+
+	gross := "(SELECT COALESCE(SUM(transactions.amount_minor), 0) FROM transactions" +
+		" WHERE transactions.client_id = clients.id AND transactions.deleted_at IS NULL" +
+		" AND transactions.status <> ? AND transactions.currency = ?"
+	grossArgs := []interface{}{models.TransactionStatusPending, currency}
+	refunded := "(SELECT COALESCE(SUM(refunds.amount_minor), 0) FROM refunds" +
+		" JOIN transactions ON transactions.id = refunds.transaction_id" +
+		" WHERE transactions.client_id = clients.id AND transactions.deleted_at IS NULL" +
+		" AND refunds.deleted_at IS NULL AND refunds.currency = ?"
+	refundedArgs := []interface{}{currency}
+	if c.WindowDays > 0 {
+		start := s.windowStart(c.WindowDays)
+		gross += " AND transactions.date >= ?"
+		grossArgs = append(grossArgs, start)
+		refunded += " AND refunds.date >= ?"
+		refundedArgs = append(refundedArgs, start)
+	}
+
+	args := append(grossArgs, refundedArgs...)
+	return gross + ") - " + refunded + ") " + op + " ?", append(args, amount), nil
+}
+
+func (s segmentCompiler) windowStart(days int) time.Time {
+	return s.now.AddDate(0, 0, -days)
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSegments_Evaluate(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	now := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	user := &models.User{Username: "owner", Email: "owner@mail.com", Password: "x"}
+	require.NoError(t, NewUserService(db).CreateUser(ctx, user))
+
+	agentService := NewAgentService(db, time.Now)
+	clientService := NewClientService(db, agentService, func() time.Time { return now })
+	messageService := NewMessageService(db, agentService, clientService, time.Now)
+	transactionService := NewTransactionService(db, agentService, clientService, time.Now)
+	segmentService := NewSegmentService(db, func() time.Time { return now })
+
+	agent, err := agentService.CreateAgent(ctx, &models.Agent{Name: "a", Characteristics: "c"}, user.ID)
+	require.NoError(t, err)
+	newClient := func(name string, score float64) *models.Client {
+		client, err := clientService.CreateClient(ctx, &models.Client{Name: name, AgentID: agent.ID, Score: score}, agent.ID, user.ID)
+		require.NoError(t, err)
+		return client
+	}
+	message := func(client *models.Client, messageType string, daysAgo int) {
+		_, err := messageService.CreateMessage(ctx, &models.Message{
+			AgentID: agent.ID, ClientID: client.ID, Content: "hi", Type: messageType, Date: now.AddDate(0, 0, -daysAgo),
+		}, user.ID)
+		require.NoError(t, err)
+	}
+	spend := func(client *models.Client, minor int64, daysAgo int) {
+		_, err := transactionService.CreateTransaction(ctx, &models.Transaction{
+			AgentID: agent.ID, ClientID: client.ID, AmountMinor: minor, Currency: "USD",
+			Date: now.AddDate(0, 0, -daysAgo), Status: models.TransactionStatusCompleted,
+		}, user.ID)
+		require.NoError(t, err)
+	}
+
+	waiting := newClient("waiting", 8)
+	message(waiting, models.MessageTypeAgentToClient, 5)
+	message(waiting, models.MessageTypeClientToAgent, 4)
+	answered := newClient("answered", 9)
+	message(answered, models.MessageTypeAgentToClient, 1)
+	newClient("silent", 6)
+	low := newClient("low", 2)
+
+	spend(answered, 6000, 10)
+	spend(low, 9000, 45)
+	spend(low, 1000, 3)
+
+	names := func(segment *models.Segment) []string {
+		t.Helper()
+		page, err := segmentService.GetSegmentClients(ctx, segment.ID, 0, 0, user.ID)
+		require.NoError(t, err)
+		var names []string
+		for _, client := range page.Clients {
+			names = append(names, client.Name)
+		}
+		return names
+	}
+
+	neglected, err := segmentService.CreateSegment(ctx, &models.Segment{
+		Name: "score > 5 and no reply in 3 days",
+		Conditions: models.SegmentConditions{
+			{Field: "score", Op: "gt", Value: "5"},
+			{Field: models.SegmentFieldDaysSinceAgentMessage, Op: models.SegmentOpGt, Value: "3"},
+		},
+	}, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.SegmentMatchAll, neglected.Match)
+	assert.Equal(t, []string{"waiting", "silent"}, names(neglected))
+
+	bigSpenders, err := segmentService.CreateSegment(ctx, &models.Segment{
+		Name: "spent over 50 in the last 30 days",
+		Conditions: models.SegmentConditions{
+			{Field: models.SegmentFieldSpent, Op: models.SegmentOpGt, Value: "50", WindowDays: 30},
+		},
+	}, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"answered"}, names(bigSpenders))
+
+	either, err := segmentService.CreateSegment(ctx, &models.Segment{
+		Name:  "either",
+		Match: models.SegmentMatchAny,
+		Conditions: models.SegmentConditions{
+			{Field: models.SegmentFieldScore, Op: models.SegmentOpLt, Value: "3"},
+			{Field: models.SegmentFieldMessageCount, Op: models.SegmentOpGte, Value: "2"},
+		},
+	}, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"waiting", "low"}, names(either))
+
+	page, err := segmentService.GetSegmentClients(ctx, neglected.ID, 1, 1, user.ID)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, page.Total)
+	require.Len(t, page.Clients, 1)
+	assert.Equal(t, "silent", page.Clients[0].Name)
+
+	filtered, err := clientService.ListClients(ctx, ClientFilter{SegmentID: bigSpenders.ID}, user.ID)
+	require.NoError(t, err)
+	require.Len(t, filtered, 1)
+	assert.Equal(t, answered.ID, filtered[0].ID)
+
+	_, err = segmentService.CreateSegment(ctx, &models.Segment{
+		Name:       "broken",
+		Conditions: models.SegmentConditions{{Field: models.SegmentFieldTag, Op: models.SegmentOpGt, Value: "vip"}},
+	}, user.ID)
+	require.ErrorIs(t, err, ErrInvalidSegmentCondition)
+	assert.Equal(t, "conditions[0].op", err.(*Error).Fields[0].Field)
+
+	stranger := &models.User{Username: "stranger", Email: "stranger@mail.com", Password: "x"}
+	require.NoError(t, NewUserService(db).CreateUser(ctx, stranger))
+	_, err = segmentService.GetSegmentClients(ctx, neglected.ID, 0, 0, stranger.ID)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+}
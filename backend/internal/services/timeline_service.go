@@ -0,0 +1,244 @@
+package services
+
+import (
+	"backend/internal/models"
+	"backend/pkg/database"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Timeline entry kinds. A CHANGE is an audit entry for the client record, its
+// tags, custom field values or contacts.
+const (
+	TimelineKindChange      = "CHANGE"
+	TimelineKindMessage     = "MESSAGE"
+	TimelineKindNote        = "NOTE"
+	TimelineKindTransaction = "TRANSACTION"
+)
+
+var timelineKinds = []string{TimelineKindChange, TimelineKindMessage, TimelineKindNote, TimelineKindTransaction}
+
+// TimelineEntry is one item of a client's timeline. Kind says which of the
+// record fields is set; ID is that record's ID.
+type TimelineEntry struct {
+	Kind        string              `json:"kind"`
+	At          time.Time           `json:"at"`
+	ID          uint                `json:"id"`
+	Message     *models.Message     `json:"message,omitempty"`
+	Transaction *models.Transaction `json:"transaction,omitempty"`
+	Note        *models.ClientNote  `json:"note,omitempty"`
+	Change      *models.AuditEntry  `json:"change,omitempty"`
+}
+
+// TimelinePage is one page of a timeline, newest first. NextCursor is empty
+// on the last page.
+type TimelinePage struct {
+	Entries    []*TimelineEntry `json:"entries"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+}
+
+// TimelineQuery selects a page of a client's timeline. Empty Kinds means
+// every kind; Cursor is the NextCursor of the previous page.
+type TimelineQuery struct {
+	Kinds  []string
+	Cursor string
+	Limit  int
+}
+
+// TimelineService merges a client's messages, transactions, notes and record
+// changes into one stream.
+type TimelineService interface {
+	GetTimeline(ctx context.Context, clientID uint, query TimelineQuery, userID uint) (*TimelinePage, error)
+}
+
+type timelineServiceImpl struct {
+	db            *database.DB
+	clientService ClientService
+}
+
+func NewTimelineService(db *database.DB, clientService ClientService) TimelineService {
+	return &timelineServiceImpl{db: db, clientService: clientService}
+}
+
+const (
+	defaultTimelinePageLimit = 50
+	maxTimelinePageLimit     = 200
+)
+
+var (
+	ErrInvalidTimelineKind       = NewError(http.StatusBadRequest, "invalid_timeline_kind", "kind must be CHANGE, MESSAGE, NOTE or TRANSACTION")
+	ErrInvalidTimelineCursor     = NewError(http.StatusBadRequest, "invalid_cursor", "cursor is invalid")
+	ErrInvalidTimelinePagination = NewError(http.StatusBadRequest, "invalid_pagination", "limit must be a non-negative integer")
+)
+
+// timelineCursor is the position of the last entry of a page. Entries are
+// ordered by time, then kind, then ID, all descending, so the position is
+// unique even when entries share a timestamp.
+type timelineCursor struct {
+	At   time.Time `json:"at"`
+	Kind string    `json:"kind"`
+	ID   uint      `json:"id"`
+}
+
+func (c timelineCursor) encode() string {
+	body, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(body)
+}
+
+func decodeTimelineCursor(value string) (*timelineCursor, error) {
+	if value == "" {
+		return nil, nil
+	}
+	body, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidTimelineCursor
+	}
+	var cursor timelineCursor
+	if err := json.Unmarshal(body, &cursor); err != nil || !slices.Contains(timelineKinds, cursor.Kind) {
+		return nil, ErrInvalidTimelineCursor
+	}
+	return &cursor, nil
+}
+
+// before limits a query on one kind to the rows after the cursor in
+// timeline order, and orders and limits it to one page plus one row.
+func (c *timelineCursor) before(db *gorm.DB, kind, column string, limit int) *gorm.DB {
+	if c != nil {
+		switch {
+		case kind < c.Kind:
+			db = db.Where(column+" <= ?", c.At)
+		case kind == c.Kind:
+			db = db.Where("("+column+" < ? OR ("+column+" = ? AND id < ?))", c.At, c.At, c.ID)
+		default:
+			db = db.Where(column+" < ?", c.At)
+		}
+	}
+	return db.Order(column + " desc, id desc").Limit(limit + 1)
+}
+
+func (s *timelineServiceImpl) GetTimeline(ctx context.Context, clientID uint, query TimelineQuery, userID uint) (*TimelinePage, error) {
+	if query.Limit < 0 {
+		return nil, ErrInvalidTimelinePagination
+	}
+	limit := query.Limit
+	if limit == 0 {
+		limit = defaultTimelinePageLimit
+	}
+	limit = min(limit, maxTimelinePageLimit)
+
+	kinds := timelineKinds
+	if len(query.Kinds) > 0 {
+		kinds = nil
+		for _, kind := range query.Kinds {
+			kind = strings.ToUpper(strings.TrimSpace(kind))
+			if !slices.Contains(timelineKinds, kind) {
+				return nil, ErrInvalidTimelineKind
+			}
+			kinds = append(kinds, kind)
+		}
+	}
+
+	cursor, err := decodeTimelineCursor(query.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.clientService.GetClientByID(ctx, clientID, userID); err != nil {
+		return nil, err
+	}
+
+	db := s.db.WithContext(ctx)
+	var entries []*TimelineEntry
+
+	if slices.Contains(kinds, TimelineKindMessage) {
+		var messages []*models.Message
+		err := cursor.before(db.Preload("Agent", withDeleted), TimelineKindMessage, "date", limit).
+			Where("client_id = ?", clientID).
+			Find(&messages).
+			Error
+		if err != nil {
+			return nil, err
+		}
+		for _, message := range messages {
+			entries = append(entries, &TimelineEntry{Kind: TimelineKindMessage, At: message.Date, ID: message.ID, Message: message})
+		}
+	}
+
+	if slices.Contains(kinds, TimelineKindTransaction) {
+		var transactions []*models.Transaction
+		err := cursor.before(db.Preload("Agent", withDeleted), TimelineKindTransaction, "date", limit).
+			Where("client_id = ?", clientID).
+			Find(&transactions).
+			Error
+		if err != nil {
+			return nil, err
+		}
+		for _, transaction := range transactions {
+			entries = append(entries, &TimelineEntry{Kind: TimelineKindTransaction, At: transaction.Date, ID: transaction.ID, Transaction: transaction})
+		}
+	}
+
+	if slices.Contains(kinds, TimelineKindNote) {
+		var notes []*models.ClientNote
+		err := cursor.before(db, TimelineKindNote, "created_at", limit).
+			Where("client_id = ?", clientID).
+			Find(&notes).
+			Error
+		if err != nil {
+			return nil, err
+		}
+		for _, note := range notes {
+			entries = append(entries, &TimelineEntry{Kind: TimelineKindNote, At: note.CreatedAt, ID: note.ID, Note: note})
+		}
+	}
+
+	if slices.Contains(kinds, TimelineKindChange) {
+		// Contacts are audited under their own ID, and deleted ones no
+		// longer exist, so they are matched on the ClientID they recorded.
+		var changes []*models.AuditEntry
+		err := cursor.before(db, TimelineKindChange, "created_at", limit).
+			Where(
+				"((entity_type IN ? AND entity_id = ?) OR (entity_type = ? AND "+snapshotClientID("COALESCE(NULLIF(after, ''), before)")+" = ?))",
+				[]string{AuditEntityClient, AuditEntityClientTags, AuditEntityFieldValues}, clientID,
+				AuditEntityContact, clientID,
+			).
+			Find(&changes).
+			Error
+		if err != nil {
+			return nil, err
+		}
+		for _, change := range changes {
+			entries = append(entries, &TimelineEntry{Kind: TimelineKindChange, At: change.CreatedAt, ID: change.ID, Change: change})
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if !a.At.Equal(b.At) {
+			return a.At.After(b.At)
+		}
+		if a.Kind != b.Kind {
+			return a.Kind > b.Kind
+		}
+		return a.ID > b.ID
+	})
+
+	page := &TimelinePage{Entries: entries}
+	if len(entries) > limit {
+		page.Entries = entries[:limit]
+		last := page.Entries[limit-1]
+		page.NextCursor = timelineCursor{At: last.At, Kind: last.Kind, ID: last.ID}.encode()
+	}
+	if page.Entries == nil {
+		page.Entries = []*TimelineEntry{}
+	}
+	return page, nil
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeline_MergesAndPaginates(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	userService := NewUserService(db)
+	owner := &models.User{Username: "owner", Email: "owner@mail.com", Password: "x"}
+	require.NoError(t, userService.CreateUser(ctx, owner))
+	other := &models.User{Username: "other", Email: "other@mail.com", Password: "x"}
+	require.NoError(t, userService.CreateUser(ctx, other))
+
+	agentService := NewAgentService(db, time.Now)
+	clientService := NewClientService(db, agentService, time.Now)
+	messageService := NewMessageService(db, agentService, clientService, time.Now)
+	transactionService := NewTransactionService(db, agentService, clientService, time.Now)
+	profileService := NewClientProfileService(db, clientService, NewCustomFieldService(db))
+	timelineService := NewTimelineService(db, clientService)
+
+	agent, err := agentService.CreateAgent(ctx, &models.Agent{Name: "a", Characteristics: "c"}, owner.ID)
+	require.NoError(t, err)
+	client, err := clientService.CreateClient(ctx, &models.Client{Name: "client", AgentID: agent.ID}, agent.ID, owner.ID)
+	require.NoError(t, err)
+	otherClient, err := clientService.CreateClient(ctx, &models.Client{Name: "other", AgentID: agent.ID}, agent.ID, owner.ID)
+	require.NoError(t, err)
+
+	// Two messages share a timestamp, so only the cursor's ID tells them apart.
+	sameTime := time.Date(2026, time.January, 10, 9, 0, 0, 0, time.UTC)
+	for _, date := range []time.Time{sameTime, sameTime, sameTime.AddDate(0, 0, 1)} {
+		_, err := messageService.CreateMessage(ctx, &models.Message{AgentID: agent.ID, ClientID: client.ID, Content: "hi", Type: models.MessageTypeClientToAgent, Date: date}, owner.ID)
+		require.NoError(t, err)
+	}
+	_, err = messageService.CreateMessage(ctx, &models.Message{AgentID: agent.ID, ClientID: otherClient.ID, Content: "elsewhere", Type: models.MessageTypeClientToAgent, Date: sameTime}, owner.ID)
+	require.NoError(t, err)
+	_, err = transactionService.CreateTransaction(ctx, &models.Transaction{AgentID: agent.ID, ClientID: client.ID, AmountMinor: 500, Currency: "USD", Date: sameTime.AddDate(0, 0, -1)}, owner.ID)
+	require.NoError(t, err)
+	_, err = profileService.CreateNote(ctx, &models.ClientNote{ClientID: client.ID, Body: "called"}, owner.ID)
+	require.NoError(t, err)
+	_, err = profileService.AddTags(ctx, client.ID, []string{"vip"}, owner.ID)
+	require.NoError(t, err)
+	contact, err := profileService.CreateContact(ctx, &models.ClientContact{ClientID: client.ID, Kind: models.ContactKindEmail, Value: "c@mail.com"}, owner.ID)
+	require.NoError(t, err)
+	require.NoError(t, profileService.DeleteContact(ctx, client.ID, contact.ID, owner.ID))
+
+	full, err := timelineService.GetTimeline(ctx, client.ID, TimelineQuery{}, owner.ID)
+	require.NoError(t, err)
+	assert.Empty(t, full.NextCursor)
+	counts := map[string]int{}
+	for _, entry := range full.Entries {
+		counts[entry.Kind]++
+	}
+	// CHANGE: client created, tag added, contact created and deleted.
+	assert.Equal(t, map[string]int{TimelineKindMessage: 3, TimelineKindTransaction: 1, TimelineKindNote: 1, TimelineKindChange: 4}, counts)
+	for i := 1; i < len(full.Entries); i++ {
+		assert.False(t, full.Entries[i].At.After(full.Entries[i-1].At), "entries are newest first")
+	}
+
+	var paged []*TimelineEntry
+	query := TimelineQuery{Limit: 2}
+	for {
+		page, err := timelineService.GetTimeline(ctx, client.ID, query, owner.ID)
+		require.NoError(t, err)
+		require.LessOrEqual(t, len(page.Entries), 2)
+		paged = append(paged, page.Entries...)
+		if page.NextCursor == "" {
+			break
+		}
+		query.Cursor = page.NextCursor
+	}
+	require.Len(t, paged, len(full.Entries))
+	for i := range paged {
+		assert.Equal(t, full.Entries[i].Kind, paged[i].Kind)
+		assert.Equal(t, full.Entries[i].ID, paged[i].ID)
+	}
+
+	messages, err := timelineService.GetTimeline(ctx, client.ID, TimelineQuery{Kinds: []string{"message"}}, owner.ID)
+	require.NoError(t, err)
+	require.Len(t, messages.Entries, 3)
+	assert.Equal(t, "a", messages.Entries[0].Message.Agent.Name)
+
+	_, err = timelineService.GetTimeline(ctx, client.ID, TimelineQuery{Kinds: []string{"CALL"}}, owner.ID)
+	assert.ErrorIs(t, err, ErrInvalidTimelineKind)
+	_, err = timelineService.GetTimeline(ctx, client.ID, TimelineQuery{Cursor: "not a cursor"}, owner.ID)
+	assert.ErrorIs(t, err, ErrInvalidTimelineCursor)
+	_, err = timelineService.GetTimeline(ctx, client.ID, TimelineQuery{}, other.ID)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+}
@@ -2,9 +2,11 @@ package services
 
 import (
 	"backend/internal/models"
+	"backend/internal/money"
 	"backend/pkg/database"
 	"context"
 	"errors"
+	"net/http"
 	"time"
 
 	"gorm.io/gorm"
@@ -15,34 +17,86 @@ type TransactionService interface {
 	GetTransactionsByAgentID(ctx context.Context, agentID uint, userID uint) ([]*models.Transaction, error)
 	GetTransactionsByClientID(ctx context.Context, clientID uint, userID uint) ([]*models.Transaction, error)
 	GetTransactionsByAgentIDAndClientID(ctx context.Context, agentID uint, clientID uint, userID uint) ([]*models.Transaction, error)
+	GetTotals(ctx context.Context, filter TransactionTotalsFilter, userID uint) ([]*CurrencyTotal, error)
 	CreateTransaction(ctx context.Context, transaction *models.Transaction, userID uint) (*models.Transaction, error)
 	UpdateTransaction(ctx context.Context, transaction *models.Transaction, userID uint) (*models.Transaction, error)
 	DeleteTransaction(ctx context.Context, id uint, userID uint) error
+	GetDeletedTransactions(ctx context.Context, userID uint) ([]*models.Transaction, error)
+	RestoreTransaction(ctx context.Context, id uint, userID uint) (*models.Transaction, error)
+	PurgeTransaction(ctx context.Context, id uint, userID uint) error
+	UpdateTransactionStatus(ctx context.Context, id uint, status string, userID uint) (*models.Transaction, error)
+	CreateRefund(ctx context.Context, refund *models.Refund, userID uint) (*models.Refund, error)
+	GetRefunds(ctx context.Context, transactionID uint, userID uint) ([]*models.Refund, error)
 }
 
 type transactionServiceImpl struct {
 	db            *database.DB
 	agentService  AgentService
 	clientService ClientService
+	now           func() time.Time
 }
 
-func NewTransactionService(db *database.DB, agentService AgentService, clientService ClientService) TransactionService {
+func NewTransactionService(db *database.DB, agentService AgentService, clientService ClientService, now func() time.Time) TransactionService {
 	return &transactionServiceImpl{
 		db:            db,
 		agentService:  agentService,
 		clientService: clientService,
+		now:           now,
 	}
 }
 
 var (
-	ErrTransactionNotFound      = errors.New("transaction not found")
-	ErrTransactionAlreadyExists = errors.New("transaction already exists")
-	ErrAmountRequired           = errors.New("transaction amount is required")
-	ErrTransactionIDRequired    = errors.New("transaction ID is required")
-	ErrInvalidTransactionID     = errors.New("transaction ID is invalid")
-	ErrInvalidAmount            = errors.New("invalid amount")
+	ErrTransactionNotFound      = NewError(http.StatusNotFound, "transaction_not_found", "transaction not found")
+	ErrTransactionAlreadyExists = NewError(http.StatusConflict, "transaction_already_exists", "transaction already exists")
+	ErrAmountRequired           = NewError(http.StatusBadRequest, "amount_required", "transaction amount is required")
+	ErrTransactionIDRequired    = NewError(http.StatusBadRequest, "transaction_id_required", "transaction ID is required")
+	ErrInvalidTransactionID     = NewError(http.StatusBadRequest, "invalid_transaction_id", "transaction ID is invalid")
+	ErrInvalidAmount            = NewError(http.StatusBadRequest, "invalid_amount", "invalid amount")
+	ErrNegativeAmount           = NewError(http.StatusBadRequest, "negative_amount", "transaction amount must be positive")
+	ErrInvalidCurrency          = NewError(http.StatusBadRequest, "invalid_currency", "invalid currency code")
+	ErrAmountPrecision          = NewError(http.StatusBadRequest, "amount_precision", "amount has more decimal places than the currency allows")
+	ErrClientAgentMismatch      = NewError(http.StatusBadRequest, "client_agent_mismatch", "client does not belong to this agent")
+
+	ErrInvalidTransactionStatus   = NewError(http.StatusBadRequest, "invalid_transaction_status", "invalid transaction status")
+	ErrInvalidStatusTransition    = NewError(http.StatusConflict, "invalid_status_transition", "transaction status transition is not allowed")
+	ErrDuplicateExternalReference = NewError(http.StatusConflict, "duplicate_external_reference", "a transaction with this external reference already exists")
+	ErrRefundAmountRequired       = NewError(http.StatusBadRequest, "refund_amount_required", "refund amount must be positive")
+	ErrRefundExceedsTransaction   = NewError(http.StatusConflict, "refund_exceeds_transaction", "refunds exceed the transaction amount")
+	ErrRefundCurrencyMismatch     = NewError(http.StatusBadRequest, "refund_currency_mismatch", "refund currency must match the transaction currency")
+	ErrTransactionNotRefundable   = NewError(http.StatusConflict, "transaction_not_refundable", "only completed or disputed transactions can be refunded")
+	ErrTransactionHasRefunds      = NewError(http.StatusConflict, "transaction_has_refunds", "the amount and currency of a refunded transaction cannot change")
 )
 
+// transactionStatusTransitions lists the statuses each status may move to
+// through UpdateTransactionStatus. REFUNDED is only reached by recording
+// refunds that cover the full amount.
+var transactionStatusTransitions = map[string][]string{
+	models.TransactionStatusPending:   {models.TransactionStatusCompleted, models.TransactionStatusDisputed},
+	models.TransactionStatusCompleted: {models.TransactionStatusDisputed},
+	models.TransactionStatusDisputed:  {models.TransactionStatusCompleted},
+	models.TransactionStatusRefunded:  {},
+}
+
+type TransactionTotalsFilter struct {
+	AgentID  uint
+	ClientID uint
+	From     time.Time
+	To       time.Time
+}
+
+// CurrencyTotal is the sum of transactions in a single currency. Amounts in
+// different currencies are never added together.
+type CurrencyTotal struct {
+	Currency      string `json:"currency"`
+	Count         int64  `json:"count"`
+	TotalMinor    int64  `json:"total_minor"`
+	RefundedMinor int64  `json:"refunded_minor"`
+	NetMinor      int64  `json:"net_minor"`
+	Total         string `json:"total"`
+	Refunded      string `json:"refunded"`
+	Net           string `json:"net"`
+}
+
 func (t *transactionServiceImpl) GetTransactionByID(ctx context.Context, id uint, userID uint) (*models.Transaction, error) {
 	var transaction models.Transaction
 	err := t.db.WithContext(ctx).
@@ -114,11 +168,14 @@ func (t *transactionServiceImpl) GetTransactionsByClientID(ctx context.Context,
 		return nil, ErrUnauthorized
 	}
 
+	// Every transaction of the client, whichever agent recorded it, so the
+	// history survives transfers.
 	var transactions []*models.Transaction
 	err = t.db.WithContext(ctx).
-		Preload("Agent").
+		Preload("Agent", withDeleted).
 		Preload("Client").
 		Where("client_id = ?", clientID).
+		Order("date asc").
 		Find(&transactions).
 		Error
 
@@ -139,16 +196,13 @@ func (t *transactionServiceImpl) GetTransactionsByAgentIDAndClientID(ctx context
 		return nil, ErrUnauthorized
 	}
 
-	client, err := t.clientService.GetClientByID(ctx, clientID, userID)
-
+	// The client may since have been transferred to another agent, so only
+	// ownership is checked, not the current assignment.
+	_, err = t.clientService.GetClientByID(ctx, clientID, userID)
 	if err != nil {
 		return nil, err
 	}
 
-	if client.AgentID != agentID {
-		return nil, errors.New("client does not belong to this agent")
-	}
-
 	var transactions []*models.Transaction
 	err = t.db.WithContext(ctx).
 		Preload("Agent").
@@ -165,8 +219,8 @@ func (t *transactionServiceImpl) GetTransactionsByAgentIDAndClientID(ctx context
 }
 
 func (t *transactionServiceImpl) CreateTransaction(ctx context.Context, transaction *models.Transaction, userID uint) (*models.Transaction, error) {
-	if transaction.Amount == 0 {
-		return nil, ErrAmountRequired
+	if err := validateTransactionAmount(transaction); err != nil {
+		return nil, err
 	}
 
 	agent, err := t.agentService.GetAgentByID(ctx, transaction.AgentID, userID)
@@ -184,16 +238,33 @@ func (t *transactionServiceImpl) CreateTransaction(ctx context.Context, transact
 	}
 
 	if client.AgentID != transaction.AgentID {
-		return nil, errors.New("client does not belong to this agent")
+		return nil, ErrClientAgentMismatch
 	}
 
 	if transaction.Date.IsZero() {
-		transaction.Date = time.Now()
+		transaction.Date = t.now()
 	}
 
-	err = t.db.WithContext(ctx).
-		Create(transaction).
-		Error
+	if transaction.Status == "" {
+		transaction.Status = models.TransactionStatusCompleted
+	}
+	if transaction.Status != models.TransactionStatusPending && transaction.Status != models.TransactionStatusCompleted && transaction.Status != models.TransactionStatusDisputed {
+		return nil, ErrInvalidTransactionStatus
+	}
+
+	if err := t.checkExternalReference(ctx, transaction.ExternalReference, 0, userID); err != nil {
+		return nil, err
+	}
+
+	err = t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(transaction).Error; err != nil {
+			return err
+		}
+		if err := recordEvent(tx, userID, EventTransactionCreated, transaction.ID, transaction); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, userID, models.AuditActionCreate, AuditEntityTransaction, transaction.ID, nil, transaction)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -216,24 +287,59 @@ func (t *transactionServiceImpl) UpdateTransaction(ctx context.Context, transact
 		return nil, ErrUnauthorized
 	}
 
-	updates := map[string]interface{}{
-		"amount": transaction.Amount,
-		"date":   transaction.Date,
+	if transaction.Currency == "" {
+		transaction.Currency = existingTransaction.Currency
 	}
-
-	err = t.db.WithContext(ctx).
-		Model(existingTransaction).
-		Updates(updates).
-		Error
-	if err != nil {
+	if err := validateTransactionAmount(transaction); err != nil {
 		return nil, err
 	}
 
-	err = t.db.WithContext(ctx).
-		Preload("Agent").
-		Preload("Client").
-		First(existingTransaction, existingTransaction.ID).
-		Error
+	updates := map[string]interface{}{
+		"amount_minor": transaction.AmountMinor,
+		"currency":     transaction.Currency,
+		"date":         transaction.Date,
+	}
+
+	if transaction.ExternalReference != "" && transaction.ExternalReference != existingTransaction.ExternalReference {
+		if err := t.checkExternalReference(ctx, transaction.ExternalReference, existingTransaction.ID, userID); err != nil {
+			return nil, err
+		}
+		updates["external_reference"] = transaction.ExternalReference
+	}
+
+	before := *existingTransaction
+	err = t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Refunds are checked against the amount and currency, so those are
+		// fixed once a refund exists. The check shares the transaction with
+		// the update so a refund recorded meanwhile cannot slip past.
+		if transaction.AmountMinor != existingTransaction.AmountMinor || transaction.Currency != existingTransaction.Currency {
+			if existingTransaction.Status == models.TransactionStatusRefunded {
+				return ErrTransactionHasRefunds
+			}
+			var refunds int64
+			err := tx.Model(&models.Refund{}).
+				Where("transaction_id = ?", existingTransaction.ID).
+				Count(&refunds).
+				Error
+			if err != nil {
+				return err
+			}
+			if refunds > 0 {
+				return ErrTransactionHasRefunds
+			}
+		}
+
+		if err := tx.Model(existingTransaction).Updates(updates).Error; err != nil {
+			return err
+		}
+		if err := tx.Preload("Agent").Preload("Client").First(existingTransaction, existingTransaction.ID).Error; err != nil {
+			return err
+		}
+		if err := recordEvent(tx, userID, EventTransactionUpdated, existingTransaction.ID, existingTransaction); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, userID, models.AuditActionUpdate, AuditEntityTransaction, existingTransaction.ID, &before, existingTransaction)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -256,12 +362,362 @@ func (t *transactionServiceImpl) DeleteTransaction(ctx context.Context, id uint,
 		return ErrUnauthorized
 	}
 
-	err = t.db.WithContext(ctx).
-		Delete(existingTransaction).
+	err = t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(existingTransaction).Error; err != nil {
+			return err
+		}
+		if err := recordEvent(tx, userID, EventTransactionDeleted, existingTransaction.ID, existingTransaction); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, userID, models.AuditActionDelete, AuditEntityTransaction, existingTransaction.ID, existingTransaction, nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (t *transactionServiceImpl) GetDeletedTransactions(ctx context.Context, userID uint) ([]*models.Transaction, error) {
+	var transactions []*models.Transaction
+	err := trashedForUser(t.db.WithContext(ctx), "transactions", userID).
+		Order("deleted_at desc").
+		Find(&transactions).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return transactions, nil
+}
+
+// RestoreTransaction undeletes a transaction. Its client must be live.
+func (t *transactionServiceImpl) RestoreTransaction(ctx context.Context, id uint, userID uint) (*models.Transaction, error) {
+	deletedTransaction, err := t.findDeletedTransaction(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	parentDeleted, err := clientIsDeleted(t.db.WithContext(ctx), deletedTransaction.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	if parentDeleted {
+		return nil, ErrParentDeleted
+	}
+
+	err = t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(deletedTransaction).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		deletedTransaction.DeletedAt = gorm.DeletedAt{}
+		if err := recordEvent(tx, userID, EventTransactionRestored, deletedTransaction.ID, deletedTransaction); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, userID, models.AuditActionRestore, AuditEntityTransaction, deletedTransaction.ID, nil, deletedTransaction)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return deletedTransaction, nil
+}
+
+// PurgeTransaction permanently removes a soft-deleted transaction.
+func (t *transactionServiceImpl) PurgeTransaction(ctx context.Context, id uint, userID uint) error {
+	deletedTransaction, err := t.findDeletedTransaction(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+
+	return t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("transaction_id = ?", deletedTransaction.ID).Delete(&models.Refund{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Delete(&models.Transaction{}, deletedTransaction.ID).Error; err != nil {
+			return err
+		}
+		if err := recordEvent(tx, userID, EventTransactionPurged, deletedTransaction.ID, deletedTransaction); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, userID, models.AuditActionPurge, AuditEntityTransaction, deletedTransaction.ID, deletedTransaction, nil)
+	})
+}
+
+func (t *transactionServiceImpl) findDeletedTransaction(ctx context.Context, id uint, userID uint) (*models.Transaction, error) {
+	var transaction models.Transaction
+	err := t.db.WithContext(ctx).
+		Unscoped().
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		First(&transaction).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTransactionNotFound
+		}
+		return nil, err
+	}
+
+	if err := agentOwnedBy(t.db.WithContext(ctx), transaction.AgentID, userID); err != nil {
+		return nil, err
+	}
+
+	return &transaction, nil
+}
+
+// GetTotals sums settled transactions and their refunds per currency. Pending
+// transactions are not counted.
+func (t *transactionServiceImpl) GetTotals(ctx context.Context, filter TransactionTotalsFilter, userID uint) ([]*CurrencyTotal, error) {
+	if filter.AgentID != 0 {
+		if _, err := t.agentService.GetAgentByID(ctx, filter.AgentID, userID); err != nil {
+			return nil, err
+		}
+	}
+	if filter.ClientID != 0 {
+		if _, err := t.clientService.GetClientByID(ctx, filter.ClientID, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	var totals []*CurrencyTotal
+	err := t.totalsScope(t.db.WithContext(ctx).Model(&models.Transaction{}), filter, userID).
+		Select("transactions.currency AS currency, SUM(transactions.amount_minor) AS total_minor, COUNT(*) AS count").
+		Group("transactions.currency").
+		Order("transactions.currency").
+		Scan(&totals).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	var refunds []struct {
+		Currency      string
+		RefundedMinor int64
+	}
+	refundsQuery := t.db.WithContext(ctx).
+		Model(&models.Refund{}).
+		Joins("JOIN transactions ON transactions.id = refunds.transaction_id AND transactions.deleted_at IS NULL")
+	err = t.totalsScope(refundsQuery, filter, userID).
+		Select("refunds.currency AS currency, SUM(refunds.amount_minor) AS refunded_minor").
+		Group("refunds.currency").
+		Scan(&refunds).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	refundedByCurrency := map[string]int64{}
+	for _, refund := range refunds {
+		refundedByCurrency[refund.Currency] = refund.RefundedMinor
+	}
+
+	for _, total := range totals {
+		total.RefundedMinor = refundedByCurrency[total.Currency]
+		total.NetMinor = total.TotalMinor - total.RefundedMinor
+		total.Total = money.Format(total.TotalMinor, total.Currency)
+		total.Refunded = money.Format(total.RefundedMinor, total.Currency)
+		total.Net = money.Format(total.NetMinor, total.Currency)
+	}
+
+	return totals, nil
+}
+
+// totalsScope restricts a query over "transactions" to the user's settled
+// transactions matching filter.
+func (t *transactionServiceImpl) totalsScope(query *gorm.DB, filter TransactionTotalsFilter, userID uint) *gorm.DB {
+	query = query.
+		Joins("JOIN agents ON agents.id = transactions.agent_id AND agents.deleted_at IS NULL").
+		Where("agents.user_id = ?", userID).
+		Where("transactions.status <> ?", models.TransactionStatusPending)
+
+	if filter.AgentID != 0 {
+		query = query.Where("transactions.agent_id = ?", filter.AgentID)
+	}
+	if filter.ClientID != 0 {
+		query = query.Where("transactions.client_id = ?", filter.ClientID)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("transactions.date >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("transactions.date < ?", filter.To)
+	}
+
+	return query
+}
+
+// UpdateTransactionStatus moves a transaction along its lifecycle.
+func (t *transactionServiceImpl) UpdateTransactionStatus(ctx context.Context, id uint, status string, userID uint) (*models.Transaction, error) {
+	existingTransaction, err := t.GetTransactionByID(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := transactionStatusTransitions[status]; !ok {
+		return nil, ErrInvalidTransactionStatus
+	}
+
+	if !statusTransitionAllowed(existingTransaction.Status, status) {
+		return nil, ErrInvalidStatusTransition
+	}
+
+	before := *existingTransaction
+	err = t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(existingTransaction).Update("status", status).Error; err != nil {
+			return err
+		}
+		if err := recordEvent(tx, userID, EventTransactionStatusChanged, existingTransaction.ID, existingTransaction); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, userID, models.AuditActionUpdate, AuditEntityTransaction, existingTransaction.ID, &before, existingTransaction)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return existingTransaction, nil
+}
+
+// CreateRefund records a full or partial refund against a transaction. Once
+// refunds cover the whole amount the transaction becomes REFUNDED.
+func (t *transactionServiceImpl) CreateRefund(ctx context.Context, refund *models.Refund, userID uint) (*models.Refund, error) {
+	if refund.AmountMinor <= 0 {
+		return nil, ErrRefundAmountRequired
+	}
+
+	original, err := t.GetTransactionByID(ctx, refund.TransactionID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if original.Status != models.TransactionStatusCompleted && original.Status != models.TransactionStatusDisputed {
+		return nil, ErrTransactionNotRefundable
+	}
+
+	if refund.Currency == "" {
+		refund.Currency = original.Currency
+	}
+	if refund.Currency != original.Currency {
+		return nil, ErrRefundCurrencyMismatch
+	}
+
+	if refund.Date.IsZero() {
+		refund.Date = t.now()
+	}
+
+	before := *original
+	err = t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var refundedMinor int64
+		err := tx.Model(&models.Refund{}).
+			Select("COALESCE(SUM(amount_minor), 0)").
+			Where("transaction_id = ?", original.ID).
+			Scan(&refundedMinor).
+			Error
+		if err != nil {
+			return err
+		}
+
+		if refundedMinor+refund.AmountMinor > original.AmountMinor {
+			return ErrRefundExceedsTransaction
+		}
+
+		if err := tx.Create(refund).Error; err != nil {
+			return err
+		}
+		if err := recordEvent(tx, userID, EventTransactionRefunded, original.ID, refund); err != nil {
+			return err
+		}
+		if err := recordAudit(ctx, tx, userID, models.AuditActionCreate, AuditEntityRefund, refund.ID, nil, refund); err != nil {
+			return err
+		}
+
+		if refundedMinor+refund.AmountMinor < original.AmountMinor {
+			return nil
+		}
+
+		if err := tx.Model(original).Update("status", models.TransactionStatusRefunded).Error; err != nil {
+			return err
+		}
+		if err := recordEvent(tx, userID, EventTransactionStatusChanged, original.ID, original); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, userID, models.AuditActionUpdate, AuditEntityTransaction, original.ID, &before, original)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return refund, nil
+}
+
+func (t *transactionServiceImpl) GetRefunds(ctx context.Context, transactionID uint, userID uint) ([]*models.Refund, error) {
+	if _, err := t.GetTransactionByID(ctx, transactionID, userID); err != nil {
+		return nil, err
+	}
+
+	var refunds []*models.Refund
+	err := t.db.WithContext(ctx).
+		Where("transaction_id = ?", transactionID).
+		Order("date asc").
+		Find(&refunds).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return refunds, nil
+}
+
+// checkExternalReference rejects a reference already used by another of the
+// user's transactions.
+func (t *transactionServiceImpl) checkExternalReference(ctx context.Context, reference string, excludeID uint, userID uint) error {
+	if reference == "" {
+		return nil
+	}
+
+	var count int64
+	err := t.db.WithContext(ctx).
+		Model(&models.Transaction{}).
+		Joins("JOIN agents ON agents.id = transactions.agent_id").
+		Where("agents.user_id = ? AND transactions.external_reference = ? AND transactions.id <> ?", userID, reference, excludeID).
+		Count(&count).
 		Error
 	if err != nil {
 		return err
 	}
 
+	if count > 0 {
+		return ErrDuplicateExternalReference
+	}
+
+	return nil
+}
+
+func statusTransitionAllowed(from, to string) bool {
+	for _, allowed := range transactionStatusTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+func validateTransactionAmount(transaction *models.Transaction) error {
+	if transaction.AmountMinor == 0 {
+		return ErrAmountRequired
+	}
+	if transaction.AmountMinor < 0 {
+		return ErrNegativeAmount
+	}
+
+	if transaction.Currency == "" {
+		transaction.Currency = models.DefaultCurrency
+	}
+	currency, err := money.NormalizeCurrency(transaction.Currency)
+	if err != nil {
+		return ErrInvalidCurrency
+	}
+	transaction.Currency = currency
+
 	return nil
 }
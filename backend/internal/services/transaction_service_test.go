@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateTransaction_AmountFixedOnceRefunded(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	user := &models.User{Username: "owner", Email: "owner@mail.com", Password: "x"}
+	require.NoError(t, NewUserService(db).CreateUser(ctx, user))
+
+	agentService := NewAgentService(db, time.Now)
+	clientService := NewClientService(db, agentService, time.Now)
+	transactionService := NewTransactionService(db, agentService, clientService, time.Now)
+
+	agent, err := agentService.CreateAgent(ctx, &models.Agent{Name: "a", Characteristics: "c"}, user.ID)
+	require.NoError(t, err)
+	client, err := clientService.CreateClient(ctx, &models.Client{Name: "c", AgentID: agent.ID}, agent.ID, user.ID)
+	require.NoError(t, err)
+	transaction, err := transactionService.CreateTransaction(ctx, &models.Transaction{AgentID: agent.ID, ClientID: client.ID, AmountMinor: 1000, Currency: "EUR"}, user.ID)
+	require.NoError(t, err)
+
+	date := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+	update := func(amountMinor int64, currency string) error {
+		_, err := transactionService.UpdateTransaction(ctx, &models.Transaction{
+			Model:       transaction.Model,
+			AmountMinor: amountMinor,
+			Currency:    currency,
+			Date:        date,
+		}, user.ID)
+		return err
+	}
+
+	// Without refunds the amount may change.
+	require.NoError(t, update(800, "EUR"))
+
+	_, err = transactionService.CreateRefund(ctx, &models.Refund{TransactionID: transaction.ID, AmountMinor: 500}, user.ID)
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, update(400, "EUR"), ErrTransactionHasRefunds, "the refund would exceed the amount")
+	assert.ErrorIs(t, update(800, "USD"), ErrTransactionHasRefunds, "the refund would be in another currency")
+	// Other fields can still be corrected.
+	require.NoError(t, update(800, ""))
+
+	_, err = transactionService.CreateRefund(ctx, &models.Refund{TransactionID: transaction.ID, AmountMinor: 300}, user.ID)
+	require.NoError(t, err)
+	refunded, err := transactionService.GetTransactionByID(ctx, transaction.ID, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.TransactionStatusRefunded, refunded.Status)
+	assert.Equal(t, date, refunded.Date.UTC())
+	assert.ErrorIs(t, update(2000, "EUR"), ErrTransactionHasRefunds)
+}
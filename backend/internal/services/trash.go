@@ -0,0 +1,267 @@
+package services
+
+import (
+	"backend/internal/models"
+	"errors"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrParentDeleted = NewError(http.StatusConflict, "parent_deleted", "cannot restore while the parent record is deleted")
+)
+
+// Soft deletes cascade from agents to clients and from clients to messages and
+// transactions. All rows removed together share the same deleted_at timestamp,
+// which is what restore uses to bring back exactly that set of rows and not
+// children that were deleted on their own earlier.
+//
+// An agent's tree is its current clients with their whole history. Messages
+// and transactions the agent handled for clients since transferred to another
+// agent belong to those clients and are left alone.
+
+// agentClientIDs selects the IDs of the agent's clients, deleted or not.
+func agentClientIDs(tx *gorm.DB, agentID uint) *gorm.DB {
+	return tx.Unscoped().Model(&models.Client{}).Select("id").Where("agent_id = ?", agentID)
+}
+
+func softDeleteAgentTree(tx *gorm.DB, agent *models.Agent, deletedAt time.Time) error {
+	for _, model := range []interface{}{&models.Message{}, &models.Transaction{}} {
+		err := tx.Model(model).
+			Where("client_id IN (?)", agentClientIDs(tx, agent.ID)).
+			Update("deleted_at", deletedAt).
+			Error
+		if err != nil {
+			return err
+		}
+	}
+
+	err := tx.Model(&models.Client{}).
+		Where("agent_id = ?", agent.ID).
+		Update("deleted_at", deletedAt).
+		Error
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Model(agent).Update("deleted_at", deletedAt).Error; err != nil {
+		return err
+	}
+	agent.DeletedAt = gorm.DeletedAt{Time: deletedAt, Valid: true}
+	return nil
+}
+
+func softDeleteClientTree(tx *gorm.DB, client *models.Client, deletedAt time.Time) error {
+	for _, model := range []interface{}{&models.Message{}, &models.Transaction{}} {
+		err := tx.Model(model).
+			Where("client_id = ?", client.ID).
+			Update("deleted_at", deletedAt).
+			Error
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Model(client).Update("deleted_at", deletedAt).Error; err != nil {
+		return err
+	}
+	client.DeletedAt = gorm.DeletedAt{Time: deletedAt, Valid: true}
+	return nil
+}
+
+func restoreAgentTree(tx *gorm.DB, agent *models.Agent) error {
+	deletedAt := agent.DeletedAt.Time
+	for _, model := range []interface{}{&models.Message{}, &models.Transaction{}} {
+		err := tx.Unscoped().
+			Model(model).
+			Where("client_id IN (?) AND deleted_at = ?", agentClientIDs(tx, agent.ID), deletedAt).
+			Update("deleted_at", nil).
+			Error
+		if err != nil {
+			return err
+		}
+	}
+
+	err := tx.Unscoped().
+		Model(&models.Client{}).
+		Where("agent_id = ? AND deleted_at = ?", agent.ID, deletedAt).
+		Update("deleted_at", nil).
+		Error
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Unscoped().Model(agent).Update("deleted_at", nil).Error; err != nil {
+		return err
+	}
+	agent.DeletedAt = gorm.DeletedAt{}
+	return nil
+}
+
+func restoreClientTree(tx *gorm.DB, client *models.Client) error {
+	deletedAt := client.DeletedAt.Time
+	for _, model := range []interface{}{&models.Message{}, &models.Transaction{}} {
+		err := tx.Unscoped().
+			Model(model).
+			Where("client_id = ? AND deleted_at = ?", client.ID, deletedAt).
+			Update("deleted_at", nil).
+			Error
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Unscoped().Model(client).Update("deleted_at", nil).Error; err != nil {
+		return err
+	}
+	client.DeletedAt = gorm.DeletedAt{}
+	return nil
+}
+
+// purgeAgentTree permanently removes an agent and everything that belongs to
+// it, whether or not the children are soft-deleted.
+func purgeAgentTree(tx *gorm.DB, agentID uint) error {
+	err := tx.Unscoped().
+		Where("transaction_id IN (?)", tx.Unscoped().Model(&models.Transaction{}).Select("id").Where("client_id IN (?)", agentClientIDs(tx, agentID))).
+		Delete(&models.Refund{}).
+		Error
+	if err != nil {
+		return err
+	}
+
+	if err := purgeClientProfiles(tx, agentClientIDs(tx, agentID)); err != nil {
+		return err
+	}
+
+	for _, model := range []interface{}{&models.Message{}, &models.Transaction{}} {
+		err := tx.Unscoped().
+			Where("client_id IN (?)", agentClientIDs(tx, agentID)).
+			Delete(model).
+			Error
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Unscoped().Where("agent_id = ?", agentID).Delete(&models.Client{}).Error; err != nil {
+		return err
+	}
+
+	return tx.Unscoped().Delete(&models.Agent{}, agentID).Error
+}
+
+func purgeClientTree(tx *gorm.DB, clientID uint) error {
+	err := tx.Unscoped().
+		Where("transaction_id IN (?)", tx.Unscoped().Model(&models.Transaction{}).Select("id").Where("client_id = ?", clientID)).
+		Delete(&models.Refund{}).
+		Error
+	if err != nil {
+		return err
+	}
+
+	if err := purgeClientProfiles(tx, []uint{clientID}); err != nil {
+		return err
+	}
+
+	for _, model := range []interface{}{&models.Message{}, &models.Transaction{}} {
+		err := tx.Unscoped().
+			Where("client_id = ?", clientID).
+			Delete(model).
+			Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Unscoped().Delete(&models.Client{}, clientID).Error
+}
+
+// purgeClientProfiles deletes the contacts, tags, custom field values, notes
+// and assignment history of the given clients; clientIDs is an ID slice or a
+// subquery.
+func purgeClientProfiles(tx *gorm.DB, clientIDs interface{}) error {
+	for _, model := range []interface{}{&models.ClientContact{}, &models.ClientTag{}, &models.ClientFieldValue{}, &models.ClientNote{}, &models.ClientAssignment{}} {
+		if err := tx.Where("client_id IN (?)", clientIDs).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findDeletedAgent loads a soft-deleted agent owned by userID.
+func findDeletedAgent(db *gorm.DB, id uint, userID uint) (*models.Agent, error) {
+	var agent models.Agent
+	err := db.Unscoped().
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		First(&agent).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAgentNotFound
+		}
+		return nil, err
+	}
+
+	if agent.UserID != userID {
+		return nil, ErrUnauthorized
+	}
+
+	return &agent, nil
+}
+
+// agentOwnedBy checks ownership of an agent regardless of its deleted state.
+func agentOwnedBy(db *gorm.DB, agentID uint, userID uint) error {
+	var count int64
+	err := db.Unscoped().
+		Model(&models.Agent{}).
+		Where("id = ? AND user_id = ?", agentID, userID).
+		Count(&count).
+		Error
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		return ErrUnauthorized
+	}
+
+	return nil
+}
+
+// agentIsDeleted reports whether an agent is currently soft-deleted.
+func agentIsDeleted(db *gorm.DB, agentID uint) (bool, error) {
+	var count int64
+	err := db.Unscoped().
+		Model(&models.Agent{}).
+		Where("id = ? AND deleted_at IS NOT NULL", agentID).
+		Count(&count).
+		Error
+	return count > 0, err
+}
+
+// clientIsDeleted reports whether a client is currently soft-deleted.
+func clientIsDeleted(db *gorm.DB, clientID uint) (bool, error) {
+	var count int64
+	err := db.Unscoped().
+		Model(&models.Client{}).
+		Where("id = ? AND deleted_at IS NOT NULL", clientID).
+		Count(&count).
+		Error
+	return count > 0, err
+}
+
+// trashedForUser scopes an unscoped query to soft-deleted rows whose agent
+// belongs to userID.
+func trashedForUser(db *gorm.DB, table string, userID uint) *gorm.DB {
+	return db.Unscoped().
+		Where(table+".deleted_at IS NOT NULL").
+		Where(table+".agent_id IN (?)", db.Unscoped().Model(&models.Agent{}).Select("id").Where("user_id = ?", userID))
+}
+
+// withDeleted is a preload condition that includes soft-deleted rows, so a
+// client's history still names agents that were deleted since.
+func withDeleted(db *gorm.DB) *gorm.DB {
+	return db.Unscoped()
+}
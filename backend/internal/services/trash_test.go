@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"backend/internal/config"
+	"backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrash_CascadingDeleteAndRestore(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	user := &models.User{Username: "owner", Email: "owner@mail.com", Password: "x"}
+	require.NoError(t, NewUserService(db).CreateUser(ctx, user))
+
+	agentService := NewAgentService(db, time.Now)
+	clientService := NewClientService(db, agentService, time.Now)
+	messageService := NewMessageService(db, agentService, clientService, time.Now)
+
+	agent, err := agentService.CreateAgent(ctx, &models.Agent{Name: "a", Characteristics: "c"}, user.ID)
+	require.NoError(t, err)
+	kept, err := clientService.CreateClient(ctx, &models.Client{Name: "kept", AgentID: agent.ID}, agent.ID, user.ID)
+	require.NoError(t, err)
+	removedEarlier, err := clientService.CreateClient(ctx, &models.Client{Name: "gone", AgentID: agent.ID}, agent.ID, user.ID)
+	require.NoError(t, err)
+	_, err = messageService.CreateMessage(ctx, &models.Message{AgentID: agent.ID, ClientID: kept.ID, Content: "hi", Type: models.MessageTypeAgentToClient}, user.ID)
+	require.NoError(t, err)
+
+	require.NoError(t, clientService.DeleteClient(ctx, removedEarlier.ID, user.ID))
+	require.NoError(t, agentService.DeleteAgent(ctx, agent.ID, user.ID))
+
+	_, err = clientService.RestoreClient(ctx, kept.ID, user.ID)
+	assert.ErrorIs(t, err, ErrParentDeleted)
+
+	_, err = agentService.RestoreAgent(ctx, agent.ID, user.ID)
+	require.NoError(t, err)
+
+	clients, err := clientService.GetClientsByAgentID(ctx, agent.ID, user.ID)
+	require.NoError(t, err)
+	require.Len(t, clients, 1)
+	assert.Equal(t, kept.ID, clients[0].ID)
+
+	messages, err := messageService.GetMessageByClientID(ctx, kept.ID, user.ID)
+	require.NoError(t, err)
+	assert.Len(t, messages, 1)
+
+	trashed, err := clientService.GetDeletedClients(ctx, user.ID)
+	require.NoError(t, err)
+	require.Len(t, trashed, 1)
+	assert.Equal(t, removedEarlier.ID, trashed[0].ID)
+}
+
+func TestRetentionJob_PurgesExpiredTrash(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	user := &models.User{Username: "owner", Email: "owner@mail.com", Password: "x"}
+	require.NoError(t, NewUserService(db).CreateUser(ctx, user))
+
+	agentService := NewAgentService(db, time.Now)
+	clientService := NewClientService(db, agentService, time.Now)
+
+	agent, err := agentService.CreateAgent(ctx, &models.Agent{Name: "a", Characteristics: "c"}, user.ID)
+	require.NoError(t, err)
+	_, err = clientService.CreateClient(ctx, &models.Client{Name: "c", AgentID: agent.ID}, agent.ID, user.ID)
+	require.NoError(t, err)
+	require.NoError(t, agentService.DeleteAgent(ctx, agent.ID, user.ID))
+
+	job := NewRetentionJob(db, &config.Config{}, time.Now)
+	require.NoError(t, job.PurgeExpired(ctx, time.Now().Add(-time.Hour)))
+
+	agents, err := agentService.GetDeletedAgents(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Len(t, agents, 1, "records inside the retention window are kept")
+
+	require.NoError(t, job.PurgeExpired(ctx, time.Now().Add(time.Hour)))
+
+	var remaining int64
+	require.NoError(t, db.Unscoped().Model(&models.Client{}).Count(&remaining).Error)
+	assert.Zero(t, remaining)
+	require.NoError(t, db.Unscoped().Model(&models.Agent{}).Count(&remaining).Error)
+	assert.Zero(t, remaining)
+}
@@ -6,6 +6,9 @@ import (
 	"context"
 	"errors"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"net/http"
+	"time"
 )
 
 type UserService interface {
@@ -13,6 +16,9 @@ type UserService interface {
 	GetUserByUsername(ctx context.Context, username string) (*models.User, error)
 	CreateUser(ctx context.Context, user *models.User) error
 	UpdateUser(ctx context.Context, user *models.User) error
+	UpdateLoginState(ctx context.Context, id uint, failedLogins int, lockedUntil *time.Time) error
+	IncrementFailedLogins(ctx context.Context, id uint) (int, error)
+	LockUntil(ctx context.Context, id uint, until time.Time) error
 	DeleteUser(ctx context.Context, id uint) error
 	ExistsByUsername(ctx context.Context, username string) (bool, error)
 	ExistsByEmail(ctx context.Context, email string) (bool, error)
@@ -60,21 +66,116 @@ func (u userServiceImpl) GetUserByUsername(ctx context.Context, username string)
 	return &user, nil
 }
 
+// userEventPayload is the outbox representation of a user; it never carries
+// the password hash.
+type userEventPayload struct {
+	ID       uint
+	Username string
+	Email    string
+	Admin    bool
+}
+
+func newUserEventPayload(user *models.User) userEventPayload {
+	return userEventPayload{
+		ID:       user.ID,
+		Username: user.Username,
+		Email:    user.Email,
+		Admin:    user.Admin,
+	}
+}
+
 func (u userServiceImpl) CreateUser(ctx context.Context, user *models.User) error {
-	return u.db.WithContext(ctx).Create(user).Error
+	return u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		payload := newUserEventPayload(user)
+		if err := recordEvent(tx, user.ID, EventUserCreated, user.ID, payload); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, user.ID, models.AuditActionCreate, AuditEntityUser, user.ID, nil, payload)
+	})
 }
 
 func (u userServiceImpl) UpdateUser(ctx context.Context, user *models.User) error {
-	return u.db.WithContext(ctx).Save(user).Error
+	return u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existingUser models.User
+		if err := tx.Where("id = ?", user.ID).First(&existingUser).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrUserNotFound
+			}
+			return err
+		}
+		if err := tx.Save(user).Error; err != nil {
+			return err
+		}
+		payload := newUserEventPayload(user)
+		if err := recordEvent(tx, user.ID, EventUserUpdated, user.ID, payload); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, actorFromContext(ctx, user.ID), models.AuditActionUpdate, AuditEntityUser, user.ID, newUserEventPayload(&existingUser), payload)
+	})
 }
 
-func (u userServiceImpl) DeleteUser(ctx context.Context, id uint) error {
+// UpdateLoginState stores the brute-force counters without touching the rest
+// of the user or emitting events; they change on every login attempt.
+func (u userServiceImpl) UpdateLoginState(ctx context.Context, id uint, failedLogins int, lockedUntil *time.Time) error {
+	return u.db.WithContext(ctx).
+		Model(&models.User{}).
+		Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{
+			"failed_logins": failedLogins,
+			"locked_until":  lockedUntil,
+		}).
+		Error
+}
+
+// IncrementFailedLogins counts a failed login in the database itself, so
+// concurrent attempts are all counted, and returns the new count.
+func (u userServiceImpl) IncrementFailedLogins(ctx context.Context, id uint) (int, error) {
+	var user models.User
+	result := u.db.WithContext(ctx).
+		Model(&user).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "failed_logins"}}}).
+		Where("id = ?", id).
+		UpdateColumn("failed_logins", gorm.Expr("failed_logins + 1"))
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return 0, ErrUserNotFound
+	}
+	return user.FailedLogins, nil
+}
+
+func (u userServiceImpl) LockUntil(ctx context.Context, id uint, until time.Time) error {
 	return u.db.WithContext(ctx).
+		Model(&models.User{}).
 		Where("id = ?", id).
-		Delete(&models.User{}).
+		UpdateColumn("locked_until", until).
 		Error
 }
 
+func (u userServiceImpl) DeleteUser(ctx context.Context, id uint) error {
+	return u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existingUser models.User
+		if err := tx.Where("id = ?", id).First(&existingUser).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrUserNotFound
+			}
+			return err
+		}
+		if err := tx.Delete(&existingUser).Error; err != nil {
+			return err
+		}
+		payload := newUserEventPayload(&existingUser)
+		if err := recordEvent(tx, id, EventUserDeleted, id, payload); err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, actorFromContext(ctx, id), models.AuditActionDelete, AuditEntityUser, id, payload, nil)
+	})
+}
+
 func (u userServiceImpl) ExistsByUsername(ctx context.Context, username string) (bool, error) {
 	var exists bool
 	err := u.db.WithContext(ctx).
@@ -106,6 +207,6 @@ func (u userServiceImpl) ExistsByEmail(ctx context.Context, email string) (bool,
 }
 
 var (
-	ErrUserNotFound = errors.New("user not found")
-	ErrEmailTaken   = errors.New("email already taken")
+	ErrUserNotFound = NewError(http.StatusNotFound, "user_not_found", "user not found")
+	ErrEmailTaken   = NewError(http.StatusConflict, "email_taken", "email already taken")
 )
@@ -0,0 +1,257 @@
+package services
+
+import (
+	"backend/internal/config"
+	"backend/internal/models"
+	"backend/internal/tracing"
+	"backend/pkg/database"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	WebhookSignatureHeader = "X-Siren-Signature"
+	WebhookTimestampHeader = "X-Siren-Timestamp"
+	WebhookEventHeader     = "X-Siren-Event"
+	WebhookDeliveryHeader  = "X-Siren-Delivery"
+
+	webhookBatchSize   = 100
+	webhookMaxBackoff  = 6 * time.Hour
+	webhookErrorLength = 1024
+)
+
+// WebhookDispatcher drains the outbox into per-subscription deliveries and
+// sends them, retrying failures with exponential backoff until they are
+// delivered or dead-lettered.
+type WebhookDispatcher struct {
+	db         *database.DB
+	httpClient *http.Client
+	cfg        *config.Config
+	now        func() time.Time
+}
+
+// NewWebhookDispatcher creates the dispatcher. now dates the deliveries,
+// their backoff and the signed timestamps.
+func NewWebhookDispatcher(db *database.DB, cfg *config.Config, now func() time.Time) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		db:         db,
+		httpClient: tracing.NewHTTPClientWithTransport(cfg.WebhookTimeout, newWebhookTransport(cfg.WebhookAllowPrivateNetworks)),
+		cfg:        cfg,
+		now:        now,
+	}
+}
+
+// Run polls until ctx is cancelled.
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.WebhookPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := d.Tick(ctx); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "webhook dispatch failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick performs a single fan-out and delivery pass.
+func (d *WebhookDispatcher) Tick(ctx context.Context) error {
+	if err := d.fanOut(ctx); err != nil {
+		return err
+	}
+	return d.deliverDue(ctx)
+}
+
+func (d *WebhookDispatcher) fanOut(ctx context.Context) error {
+	var events []*models.OutboxEvent
+	err := d.db.WithContext(ctx).
+		Where("dispatched_at IS NULL").
+		Order("id asc").
+		Limit(webhookBatchSize).
+		Find(&events).
+		Error
+	if err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			var subscriptions []*models.WebhookSubscription
+			err := tx.Where("user_id = ? AND active = ?", event.UserID, true).
+				Find(&subscriptions).
+				Error
+			if err != nil {
+				return err
+			}
+
+			now := d.now()
+			for _, subscription := range subscriptions {
+				if !subscriptionMatches(subscription.Events, event.Type) {
+					continue
+				}
+				delivery := &models.WebhookDelivery{
+					SubscriptionID: subscription.ID,
+					EventID:        event.ID,
+					Status:         models.WebhookDeliveryPending,
+					NextAttemptAt:  now,
+				}
+				if err := tx.Create(delivery).Error; err != nil {
+					return err
+				}
+			}
+
+			return tx.Model(event).Update("dispatched_at", now).Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *WebhookDispatcher) deliverDue(ctx context.Context) error {
+	var deliveries []*models.WebhookDelivery
+	err := d.db.WithContext(ctx).
+		Preload("Subscription").
+		Preload("Event").
+		Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, d.now()).
+		Order("next_attempt_at asc").
+		Limit(webhookBatchSize).
+		Find(&deliveries).
+		Error
+	if err != nil {
+		return err
+	}
+
+	for _, delivery := range deliveries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := d.attempt(ctx, delivery); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *WebhookDispatcher) attempt(ctx context.Context, delivery *models.WebhookDelivery) error {
+	statusCode, sendErr := d.send(ctx, delivery)
+
+	now := d.now()
+	attempts := delivery.Attempts + 1
+	updates := map[string]interface{}{
+		"attempts":        attempts,
+		"last_attempt_at": now,
+		"response_status": statusCode,
+	}
+
+	switch {
+	case sendErr == nil:
+		updates["status"] = models.WebhookDeliveryDelivered
+		updates["last_error"] = ""
+	case attempts >= d.cfg.WebhookMaxAttempts:
+		updates["status"] = models.WebhookDeliveryDead
+		updates["last_error"] = truncate(sendErr.Error(), webhookErrorLength)
+		slog.ErrorContext(ctx, "webhook delivery dead-lettered", "delivery_id", delivery.ID, "subscription_id", delivery.SubscriptionID, "attempts", attempts, "error", sendErr)
+	default:
+		updates["next_attempt_at"] = now.Add(webhookBackoff(d.cfg.WebhookBaseBackoff, attempts))
+		updates["last_error"] = truncate(sendErr.Error(), webhookErrorLength)
+		slog.WarnContext(ctx, "webhook delivery failed", "delivery_id", delivery.ID, "subscription_id", delivery.SubscriptionID, "attempts", attempts, "error", sendErr)
+	}
+
+	return d.db.WithContext(ctx).
+		Model(delivery).
+		Updates(updates).
+		Error
+}
+
+func (d *WebhookDispatcher) send(ctx context.Context, delivery *models.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Event.Payload)
+	timestamp := strconv.FormatInt(d.now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, delivery.Event.Type)
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, "sha256="+SignWebhookPayload(delivery.Subscription.Secret, timestamp, body))
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook endpoint responded with status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// SignWebhookPayload returns the hex HMAC-SHA256 of "<timestamp>.<body>".
+// Receivers recompute it with their subscription secret to verify a delivery.
+func SignWebhookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func webhookBackoff(base time.Duration, attempts int) time.Duration {
+	backoff := base
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= webhookMaxBackoff {
+			return webhookMaxBackoff
+		}
+	}
+	return backoff
+}
+
+// subscriptionMatches reports whether eventType is selected by a
+// comma-separated list of patterns such as "*", "agent.*" or "message.created".
+func subscriptionMatches(patterns string, eventType string) bool {
+	for _, pattern := range strings.Split(patterns, ",") {
+		pattern = strings.TrimSpace(pattern)
+		switch {
+		case pattern == "*" || pattern == eventType:
+			return true
+		case strings.HasSuffix(pattern, ".*") && strings.HasPrefix(eventType, strings.TrimSuffix(pattern, "*")):
+			return true
+		}
+	}
+	return false
+}
+
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max]
+}
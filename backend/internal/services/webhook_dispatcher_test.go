@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"backend/internal/config"
+	"backend/internal/models"
+	"backend/pkg/database"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDB(t *testing.T) *database.DB {
+	t.Helper()
+	return database.Connect(filepath.Join(t.TempDir(), "test.db"))
+}
+
+func TestWebhookDispatcher_DeliversSignedEvents(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	user := &models.User{Username: "owner", Email: "owner@mail.com", Password: "x"}
+	require.NoError(t, NewUserService(db).CreateUser(ctx, user))
+
+	received := make(chan *http.Request, 4)
+	bodies := make(chan []byte, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- r
+		bodies <- body
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{WebhookAllowPrivateNetworks: true, WebhookPollInterval: time.Second, WebhookMaxAttempts: 3, WebhookBaseBackoff: time.Second, WebhookTimeout: time.Second}
+	webhookService := NewWebhookService(db, cfg, time.Now)
+	subscription, err := webhookService.CreateSubscription(ctx, &models.WebhookSubscription{URL: server.URL, Events: "agent.*"}, user.ID)
+	require.NoError(t, err)
+
+	agentService := NewAgentService(db, time.Now)
+	_, err = agentService.CreateAgent(ctx, &models.Agent{Name: "a", Characteristics: "c"}, user.ID)
+	require.NoError(t, err)
+
+	dispatcher := NewWebhookDispatcher(db, cfg, time.Now)
+	require.NoError(t, dispatcher.Tick(ctx))
+
+	require.Len(t, received, 1)
+	req := <-received
+	body := <-bodies
+	assert.Equal(t, EventAgentCreated, req.Header.Get(WebhookEventHeader))
+	expected := "sha256=" + SignWebhookPayload(subscription.Secret, req.Header.Get(WebhookTimestampHeader), body)
+	assert.Equal(t, expected, req.Header.Get(WebhookSignatureHeader))
+
+	deliveries, err := webhookService.GetDeliveries(ctx, models.WebhookDeliveryDelivered, user.ID)
+	require.NoError(t, err)
+	assert.Len(t, deliveries, 1)
+}
+
+func TestWebhookDispatcher_DeadLettersAfterMaxAttempts(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	user := &models.User{Username: "owner", Email: "owner@mail.com", Password: "x"}
+	require.NoError(t, NewUserService(db).CreateUser(ctx, user))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{WebhookAllowPrivateNetworks: true, WebhookPollInterval: time.Second, WebhookMaxAttempts: 2, WebhookBaseBackoff: 0, WebhookTimeout: time.Second}
+	webhookService := NewWebhookService(db, cfg, time.Now)
+	_, err := webhookService.CreateSubscription(ctx, &models.WebhookSubscription{URL: server.URL, Events: "agent.created"}, user.ID)
+	require.NoError(t, err)
+
+	_, err = NewAgentService(db, time.Now).CreateAgent(ctx, &models.Agent{Name: "a", Characteristics: "c"}, user.ID)
+	require.NoError(t, err)
+
+	dispatcher := NewWebhookDispatcher(db, cfg, time.Now)
+	require.NoError(t, dispatcher.Tick(ctx))
+	require.NoError(t, dispatcher.Tick(ctx))
+
+	dead, err := webhookService.GetDeliveries(ctx, models.WebhookDeliveryDead, user.ID)
+	require.NoError(t, err)
+	require.Len(t, dead, 1)
+	assert.Equal(t, 2, dead[0].Attempts)
+	assert.Equal(t, http.StatusInternalServerError, dead[0].ResponseStatus)
+
+	retried, err := webhookService.RetryDelivery(ctx, dead[0].ID, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.WebhookDeliveryPending, retried.Status)
+
+	_, err = webhookService.RetryDelivery(ctx, dead[0].ID, user.ID)
+	assert.ErrorIs(t, err, ErrInvalidDeliveryStatus, "only dead deliveries are retried")
+}
+
+func TestWebhookService_RejectsInternalURLs(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	user := &models.User{Username: "owner", Email: "owner@mail.com", Password: "x"}
+	require.NoError(t, NewUserService(db).CreateUser(ctx, user))
+
+	webhookService := NewWebhookService(db, &config.Config{}, time.Now)
+	for _, url := range []string{
+		"http://localhost:8080/hook",
+		"http://127.0.0.1/hook",
+		"http://10.1.2.3/hook",
+		"http://192.168.0.10/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://0.0.0.0/hook",
+		"http://[::1]/hook",
+		"http://[fe80::1]/hook",
+	} {
+		_, err := webhookService.CreateSubscription(ctx, &models.WebhookSubscription{URL: url}, user.ID)
+		assert.ErrorIs(t, err, ErrWebhookURLNotAllowed, url)
+	}
+
+	_, err := webhookService.CreateSubscription(ctx, &models.WebhookSubscription{URL: "https://93.184.216.34/hook"}, user.ID)
+	assert.NoError(t, err)
+}
+
+func TestWebhookDispatcher_RefusesInternalAddressesWhenDialing(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	user := &models.User{Username: "owner", Email: "owner@mail.com", Password: "x"}
+	require.NoError(t, NewUserService(db).CreateUser(ctx, user))
+
+	received := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+	}))
+	defer server.Close()
+
+	// The subscription was accepted, but its host now resolves to the
+	// loopback address.
+	webhookService := NewWebhookService(db, &config.Config{WebhookAllowPrivateNetworks: true}, time.Now)
+	_, err := webhookService.CreateSubscription(ctx, &models.WebhookSubscription{URL: server.URL, Events: "agent.created"}, user.ID)
+	require.NoError(t, err)
+	_, err = NewAgentService(db, time.Now).CreateAgent(ctx, &models.Agent{Name: "a", Characteristics: "c"}, user.ID)
+	require.NoError(t, err)
+
+	cfg := &config.Config{WebhookPollInterval: time.Second, WebhookMaxAttempts: 3, WebhookBaseBackoff: time.Second, WebhookTimeout: time.Second}
+	require.NoError(t, NewWebhookDispatcher(db, cfg, time.Now).Tick(ctx))
+
+	assert.Empty(t, received)
+	failed, err := webhookService.GetDeliveries(ctx, models.WebhookDeliveryPending, user.ID)
+	require.NoError(t, err)
+	require.Len(t, failed, 1)
+	assert.Contains(t, failed[0].LastError, "not allowed")
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	assert.Equal(t, 30*time.Second, webhookBackoff(30*time.Second, 1))
+	assert.Equal(t, 60*time.Second, webhookBackoff(30*time.Second, 2))
+	assert.Equal(t, 120*time.Second, webhookBackoff(30*time.Second, 3))
+	assert.Equal(t, webhookMaxBackoff, webhookBackoff(30*time.Second, 50))
+}
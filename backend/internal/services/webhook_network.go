@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// blockedWebhookIP reports whether ip belongs to the host or its internal
+// networks, which webhooks must not reach.
+func blockedWebhookIP(ip net.IP) bool {
+	return ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsUnspecified()
+}
+
+// validateWebhookURL checks the URL and, unless private networks are
+// allowed, that none of the addresses its host resolves to is blocked. The
+// dispatcher checks again when it connects, as DNS answers may change.
+func validateWebhookURL(ctx context.Context, rawURL string, allowPrivate bool) error {
+	if rawURL == "" {
+		return ErrWebhookURLRequired
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return ErrInvalidWebhookURL
+	}
+	if allowPrivate {
+		return nil
+	}
+
+	addresses, err := net.DefaultResolver.LookupIPAddr(ctx, parsed.Hostname())
+	if err != nil || len(addresses) == 0 {
+		return ErrWebhookURLUnresolvable
+	}
+	for _, address := range addresses {
+		if blockedWebhookIP(address.IP) {
+			return ErrWebhookURLNotAllowed
+		}
+	}
+
+	return nil
+}
+
+// newWebhookTransport dials webhook endpoints directly, never through a
+// proxy, and refuses blocked addresses at connection time.
+func newWebhookTransport(allowPrivate bool) *http.Transport {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	if !allowPrivate {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || blockedWebhookIP(ip) {
+				return fmt.Errorf("webhook address %s is not allowed", host)
+			}
+			return nil
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+		return dialer.DialContext(ctx, network, address)
+	}
+	return transport
+}
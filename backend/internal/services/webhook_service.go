@@ -0,0 +1,266 @@
+package services
+
+import (
+	"backend/internal/config"
+	"backend/internal/models"
+	"backend/pkg/database"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type WebhookService interface {
+	GetSubscriptionByID(ctx context.Context, id uint, userID uint) (*models.WebhookSubscription, error)
+	GetAllSubscriptions(ctx context.Context, userID uint) ([]*models.WebhookSubscription, error)
+	CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription, userID uint) (*models.WebhookSubscription, error)
+	UpdateSubscription(ctx context.Context, subscription *models.WebhookSubscription, userID uint) (*models.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id uint, userID uint) error
+	GetDeliveries(ctx context.Context, status string, userID uint) ([]*models.WebhookDelivery, error)
+	RetryDelivery(ctx context.Context, id uint, userID uint) (*models.WebhookDelivery, error)
+}
+
+type webhookServiceImpl struct {
+	db  *database.DB
+	cfg *config.Config
+	now func() time.Time
+}
+
+func NewWebhookService(db *database.DB, cfg *config.Config, now func() time.Time) WebhookService {
+	return &webhookServiceImpl{db: db, cfg: cfg, now: now}
+}
+
+var (
+	ErrWebhookNotFound          = NewError(http.StatusNotFound, "webhook_not_found", "webhook subscription not found")
+	ErrWebhookIDRequired        = NewError(http.StatusBadRequest, "webhook_id_required", "webhook subscription ID is required")
+	ErrInvalidWebhookID         = NewError(http.StatusBadRequest, "invalid_webhook_id", "webhook subscription ID is invalid")
+	ErrWebhookURLRequired       = NewError(http.StatusBadRequest, "webhook_url_required", "webhook URL is required")
+	ErrInvalidWebhookURL        = NewError(http.StatusBadRequest, "invalid_webhook_url", "webhook URL is invalid")
+	ErrWebhookURLUnresolvable   = NewError(http.StatusBadRequest, "webhook_url_unresolvable", "webhook URL host cannot be resolved")
+	ErrWebhookURLNotAllowed     = NewError(http.StatusBadRequest, "webhook_url_not_allowed", "webhook URL must not point to a loopback, private or link-local address")
+	ErrWebhookDeliveryNotFound  = NewError(http.StatusNotFound, "webhook_delivery_not_found", "webhook delivery not found")
+	ErrInvalidWebhookDeliveryID = NewError(http.StatusBadRequest, "invalid_webhook_delivery_id", "webhook delivery ID is invalid")
+	ErrInvalidDeliveryStatus    = NewError(http.StatusBadRequest, "invalid_delivery_status", "invalid delivery status")
+)
+
+func (w *webhookServiceImpl) GetSubscriptionByID(ctx context.Context, id uint, userID uint) (*models.WebhookSubscription, error) {
+	var subscription models.WebhookSubscription
+	err := w.db.WithContext(ctx).
+		Where("id = ?", id).
+		First(&subscription).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, err
+	}
+
+	if subscription.UserID != userID {
+		return nil, ErrUnauthorized
+	}
+
+	return &subscription, nil
+}
+
+func (w *webhookServiceImpl) GetAllSubscriptions(ctx context.Context, userID uint) ([]*models.WebhookSubscription, error) {
+	var subscriptions []*models.WebhookSubscription
+	err := w.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Find(&subscriptions).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return subscriptions, nil
+}
+
+func (w *webhookServiceImpl) CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription, userID uint) (*models.WebhookSubscription, error) {
+	if err := validateWebhookURL(ctx, subscription.URL, w.cfg.WebhookAllowPrivateNetworks); err != nil {
+		return nil, err
+	}
+
+	if subscription.Secret == "" {
+		secret, err := generateWebhookSecret()
+		if err != nil {
+			return nil, err
+		}
+		subscription.Secret = secret
+	}
+
+	subscription.Events = normalizeEventList(subscription.Events)
+	subscription.UserID = userID
+	subscription.Active = true
+
+	err := w.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(subscription).Error; err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, userID, models.AuditActionCreate, AuditEntityWebhook, subscription.ID, nil, webhookAuditState(subscription))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return subscription, nil
+}
+
+func (w *webhookServiceImpl) UpdateSubscription(ctx context.Context, subscription *models.WebhookSubscription, userID uint) (*models.WebhookSubscription, error) {
+	existingSubscription, err := w.GetSubscriptionByID(ctx, subscription.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateWebhookURL(ctx, subscription.URL, w.cfg.WebhookAllowPrivateNetworks); err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{
+		"url":    subscription.URL,
+		"events": normalizeEventList(subscription.Events),
+		"active": subscription.Active,
+	}
+	if subscription.Secret != "" {
+		updates["secret"] = subscription.Secret
+	}
+
+	before := webhookAuditState(existingSubscription)
+	err = w.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(existingSubscription).Updates(updates).Error; err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, userID, models.AuditActionUpdate, AuditEntityWebhook, existingSubscription.ID, before, webhookAuditState(existingSubscription))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return existingSubscription, nil
+}
+
+func (w *webhookServiceImpl) DeleteSubscription(ctx context.Context, id uint, userID uint) error {
+	existingSubscription, err := w.GetSubscriptionByID(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+
+	return w.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(existingSubscription).Error; err != nil {
+			return err
+		}
+		return recordAudit(ctx, tx, userID, models.AuditActionDelete, AuditEntityWebhook, existingSubscription.ID, webhookAuditState(existingSubscription), nil)
+	})
+}
+
+func (w *webhookServiceImpl) GetDeliveries(ctx context.Context, status string, userID uint) ([]*models.WebhookDelivery, error) {
+	query := w.db.WithContext(ctx).
+		Preload("Event").
+		Joins("JOIN webhook_subscriptions ON webhook_subscriptions.id = webhook_deliveries.subscription_id").
+		Where("webhook_subscriptions.user_id = ?", userID)
+
+	if status != "" {
+		status = strings.ToUpper(status)
+		if status != models.WebhookDeliveryPending && status != models.WebhookDeliveryDelivered && status != models.WebhookDeliveryDead {
+			return nil, ErrInvalidDeliveryStatus
+		}
+		query = query.Where("webhook_deliveries.status = ?", status)
+	}
+
+	var deliveries []*models.WebhookDelivery
+	err := query.
+		Order("webhook_deliveries.id desc").
+		Find(&deliveries).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// RetryDelivery moves a dead-lettered delivery back into the pending queue
+// with a fresh attempt budget. Deliveries that are not dead are refused.
+func (w *webhookServiceImpl) RetryDelivery(ctx context.Context, id uint, userID uint) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := w.db.WithContext(ctx).
+		Preload("Subscription").
+		Where("id = ?", id).
+		First(&delivery).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookDeliveryNotFound
+		}
+		return nil, err
+	}
+
+	if delivery.Subscription.UserID != userID {
+		return nil, ErrUnauthorized
+	}
+	if delivery.Status != models.WebhookDeliveryDead {
+		return nil, ErrInvalidDeliveryStatus
+	}
+
+	result := w.db.WithContext(ctx).
+		Model(&delivery).
+		Where("status = ?", models.WebhookDeliveryDead).
+		Updates(map[string]interface{}{
+			"status":          models.WebhookDeliveryPending,
+			"attempts":        0,
+			"next_attempt_at": w.now(),
+			"last_error":      "",
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrInvalidDeliveryStatus
+	}
+
+	return &delivery, nil
+}
+
+// webhookAuditState is the audited view of a subscription; the signing secret
+// is never written to the audit log.
+func webhookAuditState(subscription *models.WebhookSubscription) map[string]interface{} {
+	return map[string]interface{}{
+		"ID":     subscription.ID,
+		"URL":    subscription.URL,
+		"Events": subscription.Events,
+		"Active": subscription.Active,
+	}
+}
+
+func normalizeEventList(events string) string {
+	var normalized []string
+	for _, event := range strings.Split(events, ",") {
+		event = strings.TrimSpace(event)
+		if event == "" {
+			continue
+		}
+		if event == "*" {
+			return "*"
+		}
+		normalized = append(normalized, event)
+	}
+
+	if len(normalized) == 0 {
+		return "*"
+	}
+
+	return strings.Join(normalized, ",")
+}
+
+func generateWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,58 @@
+package tracing
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"backend/internal/config"
+	"backend/internal/models"
+	"backend/pkg/database"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestInstrumentDB_QueriesAreChildSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	db := database.Connect(filepath.Join(t.TempDir(), "tracing.db"))
+	require.NoError(t, InstrumentDB(db.DB))
+
+	ctx, parent := Tracer().Start(context.Background(), "request")
+	var agents []models.Agent
+	require.NoError(t, db.WithContext(ctx).Where("user_id = ?", 1).Find(&agents).Error)
+	parent.End()
+
+	var query sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.Name() == "gorm.query" {
+			query = span
+		}
+	}
+	require.NotNil(t, query)
+	assert.Equal(t, parent.SpanContext().SpanID(), query.Parent().SpanID())
+
+	attributes := map[string]string{}
+	for _, attribute := range query.Attributes() {
+		attributes[string(attribute.Key)] = attribute.Value.Emit()
+	}
+	assert.Equal(t, "agents", attributes["db.sql.table"])
+	assert.Contains(t, attributes["db.statement"], "SELECT")
+}
+
+func TestSetup_RejectsUnknownExporter(t *testing.T) {
+	_, err := Setup(context.Background(), &config.Config{TracingExporter: "zipkin"})
+	assert.ErrorIs(t, err, ErrUnknownExporter)
+
+	shutdown, err := Setup(context.Background(), &config.Config{TracingExporter: ExporterNone})
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+}
@@ -0,0 +1,458 @@
+// Package userdata moves one user's agents, clients with their profiles,
+// messages and transactions between databases as a self-contained archive. It goes
+// through the services layer, so imports are validated, audited and emit
+// events like any API write.
+package userdata
+
+import (
+	"backend/internal/models"
+	"backend/internal/services"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ArchiveVersion is bumped whenever the archive layout changes in a way
+// older readers cannot handle. Version 2 added custom fields, client
+// profiles, agent statuses and archived clients; version 1 archives still
+// import, without them.
+const ArchiveVersion = 2
+
+// Archive holds a user's data without database IDs, so it can be imported
+// into any account.
+type Archive struct {
+	Version      int
+	ExportedAt   time.Time
+	Username     string
+	CustomFields []CustomField
+	Agents       []Agent
+}
+
+// CustomField is a field definition; client values refer to it by Key.
+type CustomField struct {
+	Key     string
+	Label   string
+	Type    string
+	Options []string
+}
+
+type Agent struct {
+	Name            string
+	Characteristics string
+	Status          string
+	Clients         []Client
+}
+
+// Client carries its profile and the messages and transactions exchanged
+// with it. On import they are attached to the client's agent. Fields maps
+// custom field keys to values in their canonical form.
+type Client struct {
+	Name         string
+	StartDate    time.Time
+	Score        float64
+	Archived     bool
+	Contacts     []Contact
+	Tags         []string
+	Fields       map[string]string
+	Notes        []Note
+	Messages     []Message
+	Transactions []Transaction
+}
+
+type Contact struct {
+	Kind    string
+	Value   string
+	Label   string
+	Primary bool
+}
+
+type Note struct {
+	CreatedAt time.Time
+	Body      string
+}
+
+type Message struct {
+	Date              time.Time
+	Content           string
+	Type              string
+	ExternalReference string
+}
+
+type Transaction struct {
+	AmountMinor       int64
+	Currency          string
+	Date              time.Time
+	Status            string
+	ExternalReference string
+	Refunds           []Refund
+}
+
+type Refund struct {
+	AmountMinor       int64
+	Currency          string
+	Reason            string
+	ExternalReference string
+	Date              time.Time
+}
+
+// Summary counts what an import created.
+type Summary struct {
+	CustomFields int
+	Agents       int
+	Clients      int
+	Contacts     int
+	Notes        int
+	Messages     int
+	Transactions int
+	Refunds      int
+}
+
+type Service struct {
+	agentService       services.AgentService
+	clientService      services.ClientService
+	profileService     services.ClientProfileService
+	customFieldService services.CustomFieldService
+	messageService     services.MessageService
+	transactionService services.TransactionService
+	now                func() time.Time
+}
+
+func NewService(
+	agentService services.AgentService,
+	clientService services.ClientService,
+	profileService services.ClientProfileService,
+	customFieldService services.CustomFieldService,
+	messageService services.MessageService,
+	transactionService services.TransactionService,
+	now func() time.Time,
+) *Service {
+	return &Service{
+		agentService:       agentService,
+		clientService:      clientService,
+		profileService:     profileService,
+		customFieldService: customFieldService,
+		messageService:     messageService,
+		transactionService: transactionService,
+		now:                now,
+	}
+}
+
+// Export collects everything the user owns that is not in the trash,
+// archived clients included.
+func (s *Service) Export(ctx context.Context, user *models.User) (*Archive, error) {
+	archive := &Archive{
+		Version:      ArchiveVersion,
+		ExportedAt:   s.now().UTC(),
+		Username:     user.Username,
+		CustomFields: []CustomField{},
+		Agents:       []Agent{},
+	}
+
+	fields, err := s.customFieldService.GetFields(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range fields {
+		archive.CustomFields = append(archive.CustomFields, CustomField{
+			Key:     field.Key,
+			Label:   field.Label,
+			Type:    field.Type,
+			Options: append([]string{}, field.Options...),
+		})
+	}
+
+	agents, err := s.agentService.GetAllAgents(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, agent := range agents {
+		exported := Agent{Name: agent.Name, Characteristics: agent.Characteristics, Status: agent.Status, Clients: []Client{}}
+
+		clients, err := s.clientService.GetClientsByAgentID(ctx, agent.ID, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, client := range clients {
+			exportedClient, err := s.exportClient(ctx, client, user.ID)
+			if err != nil {
+				return nil, err
+			}
+			exported.Clients = append(exported.Clients, exportedClient)
+		}
+
+		archive.Agents = append(archive.Agents, exported)
+	}
+
+	return archive, nil
+}
+
+func (s *Service) exportClient(ctx context.Context, client *models.Client, userID uint) (Client, error) {
+	exported := Client{
+		Name:         client.Name,
+		StartDate:    client.StartDate,
+		Score:        client.Score,
+		Archived:     client.ArchivedAt != nil,
+		Contacts:     []Contact{},
+		Fields:       map[string]string{},
+		Notes:        []Note{},
+		Messages:     []Message{},
+		Transactions: []Transaction{},
+	}
+
+	contacts, err := s.profileService.GetContacts(ctx, client.ID, userID)
+	if err != nil {
+		return Client{}, err
+	}
+	for _, contact := range contacts {
+		exported.Contacts = append(exported.Contacts, Contact{
+			Kind:    contact.Kind,
+			Value:   contact.Value,
+			Label:   contact.Label,
+			Primary: contact.Primary,
+		})
+	}
+
+	exported.Tags, err = s.profileService.GetTags(ctx, client.ID, userID)
+	if err != nil {
+		return Client{}, err
+	}
+
+	values, err := s.profileService.GetFieldValues(ctx, client.ID, userID)
+	if err != nil {
+		return Client{}, err
+	}
+	for _, value := range values {
+		exported.Fields[value.Field.Key] = value.Value
+	}
+
+	// Notes are listed newest first; the archive keeps them oldest first so
+	// an import creates them in their original order.
+	notes, err := s.profileService.GetNotes(ctx, client.ID, userID)
+	if err != nil {
+		return Client{}, err
+	}
+	for i := len(notes) - 1; i >= 0; i-- {
+		exported.Notes = append(exported.Notes, Note{CreatedAt: notes[i].CreatedAt, Body: notes[i].Body})
+	}
+
+	messages, err := s.messageService.GetMessageByClientID(ctx, client.ID, userID)
+	if err != nil {
+		return Client{}, err
+	}
+	for _, message := range messages {
+		exported.Messages = append(exported.Messages, Message{
+			Date:              message.Date,
+			Content:           message.Content,
+			Type:              message.Type,
+			ExternalReference: message.ExternalReference,
+		})
+	}
+
+	transactions, err := s.transactionService.GetTransactionsByClientID(ctx, client.ID, userID)
+	if err != nil {
+		return Client{}, err
+	}
+	for _, transaction := range transactions {
+		exportedTransaction := Transaction{
+			AmountMinor:       transaction.AmountMinor,
+			Currency:          transaction.Currency,
+			Date:              transaction.Date,
+			Status:            transaction.Status,
+			ExternalReference: transaction.ExternalReference,
+			Refunds:           []Refund{},
+		}
+
+		refunds, err := s.transactionService.GetRefunds(ctx, transaction.ID, userID)
+		if err != nil {
+			return Client{}, err
+		}
+		for _, refund := range refunds {
+			exportedTransaction.Refunds = append(exportedTransaction.Refunds, Refund{
+				AmountMinor:       refund.AmountMinor,
+				Currency:          refund.Currency,
+				Reason:            refund.Reason,
+				ExternalReference: refund.ExternalReference,
+				Date:              refund.Date,
+			})
+		}
+
+		exported.Transactions = append(exported.Transactions, exportedTransaction)
+	}
+
+	return exported, nil
+}
+
+// Import recreates the archive under userID. Every record is created in its
+// own service call, so a failed import leaves what was created before the
+// failure in place; the returned summary says how far it got. Custom fields
+// the user already has with the same key are reused as they are.
+func (s *Service) Import(ctx context.Context, userID uint, archive *Archive) (Summary, error) {
+	var summary Summary
+	if archive.Version < 1 || archive.Version > ArchiveVersion {
+		return summary, fmt.Errorf("unsupported archive version %d", archive.Version)
+	}
+
+	for _, field := range archive.CustomFields {
+		_, err := s.customFieldService.GetFieldByKey(ctx, field.Key, userID)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, services.ErrUnknownCustomField) {
+			return summary, fmt.Errorf("custom field %q: %w", field.Key, err)
+		}
+		_, err = s.customFieldService.CreateField(ctx, &models.CustomField{
+			Key:     field.Key,
+			Label:   field.Label,
+			Type:    field.Type,
+			Options: field.Options,
+		}, userID)
+		if err != nil {
+			return summary, fmt.Errorf("custom field %q: %w", field.Key, err)
+		}
+		summary.CustomFields++
+	}
+
+	for _, agent := range archive.Agents {
+		created, err := s.agentService.CreateAgent(ctx, &models.Agent{
+			Name:            agent.Name,
+			Characteristics: agent.Characteristics,
+		}, userID)
+		if err != nil {
+			return summary, fmt.Errorf("agent %q: %w", agent.Name, err)
+		}
+		summary.Agents++
+
+		for _, client := range agent.Clients {
+			if err := s.importClient(ctx, userID, created.ID, client, &summary); err != nil {
+				return summary, fmt.Errorf("agent %q, client %q: %w", agent.Name, client.Name, err)
+			}
+		}
+
+		// The status is set last, once the agent has all its clients.
+		if agent.Status != "" && agent.Status != created.Status {
+			if _, err := s.agentService.SetAgentStatus(ctx, created.ID, agent.Status, userID); err != nil {
+				return summary, fmt.Errorf("agent %q: %w", agent.Name, err)
+			}
+		}
+	}
+
+	return summary, nil
+}
+
+func (s *Service) importClient(ctx context.Context, userID, agentID uint, client Client, summary *Summary) error {
+	created, err := s.clientService.CreateClient(ctx, &models.Client{
+		AgentID:   agentID,
+		Name:      client.Name,
+		StartDate: client.StartDate,
+		Score:     client.Score,
+	}, agentID, userID)
+	if err != nil {
+		return err
+	}
+	summary.Clients++
+
+	for _, message := range client.Messages {
+		_, err := s.messageService.CreateMessage(ctx, &models.Message{
+			AgentID:           agentID,
+			ClientID:          created.ID,
+			Date:              message.Date,
+			Content:           message.Content,
+			Type:              message.Type,
+			ExternalReference: message.ExternalReference,
+		}, userID)
+		if err != nil {
+			return err
+		}
+		summary.Messages++
+	}
+
+	for _, transaction := range client.Transactions {
+		// Refunded is not a valid initial status; replaying the refunds
+		// below moves the transaction there again.
+		status := transaction.Status
+		if status == models.TransactionStatusRefunded {
+			status = models.TransactionStatusCompleted
+		}
+
+		createdTransaction, err := s.transactionService.CreateTransaction(ctx, &models.Transaction{
+			AgentID:           agentID,
+			ClientID:          created.ID,
+			AmountMinor:       transaction.AmountMinor,
+			Currency:          transaction.Currency,
+			Date:              transaction.Date,
+			Status:            status,
+			ExternalReference: transaction.ExternalReference,
+		}, userID)
+		if err != nil {
+			return err
+		}
+		summary.Transactions++
+
+		for _, refund := range transaction.Refunds {
+			_, err := s.transactionService.CreateRefund(ctx, &models.Refund{
+				TransactionID:     createdTransaction.ID,
+				AmountMinor:       refund.AmountMinor,
+				Currency:          refund.Currency,
+				Reason:            refund.Reason,
+				ExternalReference: refund.ExternalReference,
+				Date:              refund.Date,
+			}, userID)
+			if err != nil {
+				return err
+			}
+			summary.Refunds++
+		}
+	}
+
+	for _, contact := range client.Contacts {
+		_, err := s.profileService.CreateContact(ctx, &models.ClientContact{
+			ClientID: created.ID,
+			Kind:     contact.Kind,
+			Value:    contact.Value,
+			Label:    contact.Label,
+			Primary:  contact.Primary,
+		}, userID)
+		if err != nil {
+			return err
+		}
+		summary.Contacts++
+	}
+
+	if len(client.Tags) > 0 {
+		if _, err := s.profileService.AddTags(ctx, created.ID, client.Tags, userID); err != nil {
+			return err
+		}
+	}
+
+	if len(client.Fields) > 0 {
+		values := make(map[string]*string, len(client.Fields))
+		for key, value := range client.Fields {
+			values[key] = &value
+		}
+		if _, err := s.profileService.SetFieldValues(ctx, created.ID, values, userID); err != nil {
+			return err
+		}
+	}
+
+	for _, note := range client.Notes {
+		_, err := s.profileService.CreateNote(ctx, &models.ClientNote{
+			ClientID:  created.ID,
+			CreatedAt: note.CreatedAt,
+			Body:      note.Body,
+		}, userID)
+		if err != nil {
+			return err
+		}
+		summary.Notes++
+	}
+
+	if client.Archived {
+		if _, err := s.clientService.ArchiveClient(ctx, created.ID, userID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
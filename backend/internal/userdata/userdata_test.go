@@ -0,0 +1,129 @@
+package userdata
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/services"
+	"backend/pkg/database"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestService(t *testing.T) (*Service, services.UserService) {
+	t.Helper()
+	db := database.Connect(filepath.Join(t.TempDir(), "test.db"))
+	agentService := services.NewAgentService(db, time.Now)
+	clientService := services.NewClientService(db, agentService, time.Now)
+	customFieldService := services.NewCustomFieldService(db)
+	return NewService(
+		agentService,
+		clientService,
+		services.NewClientProfileService(db, clientService, customFieldService),
+		customFieldService,
+		services.NewMessageService(db, agentService, clientService, time.Now),
+		services.NewTransactionService(db, agentService, clientService, time.Now),
+		time.Now,
+	), services.NewUserService(db)
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	svc, users := newTestService(t)
+	ctx := context.Background()
+	date := time.Date(2026, time.January, 5, 12, 0, 0, 0, time.UTC)
+
+	source := &Archive{
+		Version:  ArchiveVersion,
+		Username: "alice",
+		CustomFields: []CustomField{
+			{Key: "tier", Label: "Tier", Type: models.CustomFieldTypeSelect, Options: []string{"gold", "silver"}},
+		},
+		Agents: []Agent{{
+			Name:            "Nova",
+			Characteristics: "warm",
+			Status:          models.AgentStatusPaused,
+			Clients: []Client{{
+				Name:      "Daniel",
+				StartDate: date,
+				Archived:  true,
+				Contacts:  []Contact{{Kind: models.ContactKindEmail, Value: "daniel@example.com", Primary: true}},
+				Tags:      []string{"vip"},
+				Fields:    map[string]string{"tier": "gold"},
+				Notes: []Note{
+					{CreatedAt: date, Body: "met at the fair"},
+					{CreatedAt: date.Add(time.Hour), Body: "prefers email"},
+				},
+				Messages: []Message{{Date: date, Content: "hi", Type: models.MessageTypeClientToAgent}},
+				Transactions: []Transaction{{
+					AmountMinor: 1500,
+					Currency:    "USD",
+					Date:        date,
+					Status:      models.TransactionStatusRefunded,
+					Refunds:     []Refund{{AmountMinor: 1500, Currency: "USD", Reason: "duplicate", Date: date}},
+				}},
+			}},
+		}},
+	}
+
+	alice := &models.User{Username: "alice", Email: "alice@example.com", Password: "x"}
+	bob := &models.User{Username: "bob", Email: "bob@example.com", Password: "x"}
+	require.NoError(t, users.CreateUser(ctx, alice))
+	require.NoError(t, users.CreateUser(ctx, bob))
+
+	summary, err := svc.Import(ctx, alice.ID, source)
+	require.NoError(t, err)
+	assert.Equal(t, Summary{CustomFields: 1, Agents: 1, Clients: 1, Contacts: 1, Notes: 2, Messages: 1, Transactions: 1, Refunds: 1}, summary)
+
+	exported, err := svc.Export(ctx, alice)
+	require.NoError(t, err)
+	_, err = svc.Import(ctx, bob.ID, exported)
+	require.NoError(t, err)
+
+	copied, err := svc.Export(ctx, bob)
+	require.NoError(t, err)
+	assert.Equal(t, "bob", copied.Username)
+	assert.Equal(t, exported.CustomFields, copied.CustomFields)
+	assert.Equal(t, exported.Agents, copied.Agents)
+	assert.Equal(t, source.CustomFields, copied.CustomFields)
+	assert.Equal(t, models.TransactionStatusRefunded, copied.Agents[0].Clients[0].Transactions[0].Status)
+
+	agent := copied.Agents[0]
+	assert.Equal(t, models.AgentStatusPaused, agent.Status)
+	client := agent.Clients[0]
+	assert.True(t, client.Archived)
+	assert.Equal(t, source.Agents[0].Clients[0].Contacts, client.Contacts)
+	assert.Equal(t, []string{"vip"}, client.Tags)
+	assert.Equal(t, map[string]string{"tier": "gold"}, client.Fields)
+	require.Len(t, client.Notes, 2)
+	assert.Equal(t, "met at the fair", client.Notes[0].Body)
+	assert.True(t, date.Equal(client.Notes[0].CreatedAt), "notes keep their dates")
+}
+
+func TestImportVersionOneArchive(t *testing.T) {
+	svc, users := newTestService(t)
+	ctx := context.Background()
+	alice := &models.User{Username: "alice", Email: "alice@example.com", Password: "x"}
+	require.NoError(t, users.CreateUser(ctx, alice))
+
+	summary, err := svc.Import(ctx, alice.ID, &Archive{
+		Version: 1,
+		Agents:  []Agent{{Name: "Nova", Characteristics: "warm", Clients: []Client{{Name: "Daniel"}}}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, Summary{Agents: 1, Clients: 1}, summary)
+
+	exported, err := svc.Export(ctx, alice)
+	require.NoError(t, err)
+	assert.Equal(t, models.AgentStatusActive, exported.Agents[0].Status)
+	assert.False(t, exported.Agents[0].Clients[0].Archived)
+}
+
+func TestImportRejectsUnknownVersion(t *testing.T) {
+	svc, _ := newTestService(t)
+	_, err := svc.Import(context.Background(), 1, &Archive{Version: ArchiveVersion + 1})
+	assert.Error(t, err)
+}
@@ -0,0 +1,7 @@
+// Package client is a typed Go client for the Siren-Net API, generated from
+// api/openapi.yaml. Regenerate it with `go generate ./pkg/client` after
+// changing the specification. The server URL passed to NewClient includes the
+// version prefix, e.g. http://localhost:8080/api/v1.
+package client
+
+//go:generate go run github.com/oapi-codegen/oapi-codegen/v2/cmd/oapi-codegen@v2.4.1 -config oapi-codegen.yaml ../../api/openapi.yaml
@@ -1,6 +1,8 @@
 package database
 
 import (
+	"time"
+
 	"backend/internal/models"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
@@ -10,6 +12,12 @@ type DB struct {
 	*gorm.DB
 }
 
+// WithClock returns a handle whose automatic timestamps, such as CreatedAt
+// and the deleted_at of soft deletes, come from now.
+func (db *DB) WithClock(now func() time.Time) *DB {
+	return &DB{DB: db.Session(&gorm.Session{NowFunc: now})}
+}
+
 func Connect(databaseURL string) *DB {
 
 	db, err := gorm.Open(sqlite.Open(databaseURL), &gorm.Config{})
@@ -17,10 +25,40 @@ func Connect(databaseURL string) *DB {
 		panic("Failed to connect to database")
 	}
 
-	err = db.AutoMigrate(&models.User{}, &models.Client{}, &models.Message{}, &models.Transaction{}, &models.Agent{})
+	err = db.AutoMigrate(
+		&models.User{},
+		&models.Client{},
+		&models.Message{},
+		&models.Transaction{},
+		&models.Refund{},
+		&models.Agent{},
+		&models.OutboxEvent{},
+		&models.WebhookSubscription{},
+		&models.WebhookDelivery{},
+		&models.AuditEntry{},
+		&models.DailyUsage{},
+		&models.ClientContact{},
+		&models.ClientTag{},
+		&models.CustomField{},
+		&models.ClientFieldValue{},
+		&models.ClientNote{},
+		&models.Segment{},
+		&models.BulkJob{},
+		&models.ClientAssignment{},
+		&models.ExportJob{},
+		&models.ClientErasure{},
+	)
 	if err != nil {
 		panic("Failed to migrate database")
 	}
 
+	if err := migrateTransactionAmounts(db); err != nil {
+		panic("Failed to migrate transaction amounts")
+	}
+
+	if err := migrateClientAssignments(db); err != nil {
+		panic("Failed to migrate client assignments")
+	}
+
 	return &DB{DB: db}
 }
@@ -0,0 +1,40 @@
+package database
+
+import (
+	"backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// migrateTransactionAmounts converts the legacy float "amount" column into
+// integer minor units and drops it. Legacy rows are assumed to be in the
+// default currency.
+func migrateTransactionAmounts(db *gorm.DB) error {
+	if !db.Migrator().HasColumn(&models.Transaction{}, "amount") {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(
+			"UPDATE transactions SET amount_minor = CAST(ROUND(amount * 100) AS INTEGER), currency = ? WHERE amount_minor = 0 AND amount <> 0",
+			models.DefaultCurrency,
+		).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Migrator().DropColumn(&models.Transaction{}, "amount")
+	})
+}
+
+// migrateClientAssignments gives clients created before assignments were
+// recorded their initial assignment to their current agent.
+func migrateClientAssignments(db *gorm.DB) error {
+	return db.Exec(`
+		INSERT INTO client_assignments (created_at, client_id, to_agent_id, actor_id, reason)
+		SELECT clients.created_at, clients.id, clients.agent_id, COALESCE(agents.user_id, 0), ''
+		FROM clients
+		LEFT JOIN agents ON agents.id = clients.agent_id
+		WHERE clients.id NOT IN (SELECT client_id FROM client_assignments)
+	`).Error
+}
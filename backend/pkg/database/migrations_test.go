@@ -0,0 +1,75 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// legacyTransaction is the schema of transactions before amounts were stored
+// in minor units.
+type legacyTransaction struct {
+	gorm.Model
+	AgentID  uint    `gorm:"not null"`
+	ClientID uint    `gorm:"not null"`
+	Amount   float64 `gorm:"not null;default:0"`
+	Date     time.Time
+}
+
+func (legacyTransaction) TableName() string {
+	return "transactions"
+}
+
+func TestMigrateTransactionAmounts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "legacy.db")
+
+	legacy, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, legacy.AutoMigrate(&legacyTransaction{}))
+	require.NoError(t, legacy.Create(&[]legacyTransaction{{AgentID: 1, ClientID: 1, Amount: 19.99}, {AgentID: 1, ClientID: 1, Amount: 0.1 + 0.2}}).Error)
+	sqlDB, _ := legacy.DB()
+	require.NoError(t, sqlDB.Close())
+
+	db := Connect(path)
+
+	var transactions []models.Transaction
+	require.NoError(t, db.Order("id").Find(&transactions).Error)
+	require.Len(t, transactions, 2)
+	assert.Equal(t, int64(1999), transactions[0].AmountMinor)
+	assert.Equal(t, int64(30), transactions[1].AmountMinor)
+	assert.Equal(t, models.DefaultCurrency, transactions[0].Currency)
+	assert.False(t, db.Migrator().HasColumn(&models.Transaction{}, "amount"))
+}
+
+func TestMigrateClientAssignments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "legacy.db")
+
+	legacy, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, legacy.AutoMigrate(&models.User{}, &models.Agent{}, &models.Client{}))
+	agent := &models.Agent{UserID: 7, Name: "a", Characteristics: "c"}
+	require.NoError(t, legacy.Create(agent).Error)
+	client := &models.Client{AgentID: agent.ID, Name: "c"}
+	require.NoError(t, legacy.Create(client).Error)
+	sqlDB, _ := legacy.DB()
+	require.NoError(t, sqlDB.Close())
+
+	db := Connect(path)
+	// A second start must not add the initial assignment again.
+	require.NoError(t, migrateClientAssignments(db.DB))
+
+	var assignments []models.ClientAssignment
+	require.NoError(t, db.Find(&assignments).Error)
+	require.Len(t, assignments, 1)
+	assert.Equal(t, client.ID, assignments[0].ClientID)
+	assert.Equal(t, agent.ID, assignments[0].ToAgentID)
+	assert.Nil(t, assignments[0].FromAgentID)
+	assert.Equal(t, uint(7), assignments[0].ActorID)
+}
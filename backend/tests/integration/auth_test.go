@@ -1 +1,71 @@
 package integration
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"backend/pkg/client"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustTime(t *testing.T, value string) time.Time {
+	t.Helper()
+	parsed, err := time.Parse(time.RFC3339, value)
+	require.NoError(t, err)
+	return parsed
+}
+
+func TestAuth(t *testing.T) {
+	h := newHarness(t)
+	alice := h.signUp("alice")
+
+	protected, err := alice.ProtectedWithResponse(h.ctx)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, protected.StatusCode())
+
+	anonymous := h.anonymous()
+
+	unauthenticated, err := anonymous.GetAllAgentsWithResponse(h.ctx)
+	require.NoError(t, err)
+	require.NotNil(t, unauthenticated.JSON401, string(unauthenticated.Body))
+	assert.Equal(t, "authentication_required", unauthenticated.JSON401.Code)
+
+	duplicate, err := anonymous.RegisterWithResponse(h.ctx, client.RegisterRequest{
+		Username:        "alice",
+		Email:           "other@example.com",
+		Password:        testPassword,
+		ConfirmPassword: testPassword,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, duplicate.JSON409, string(duplicate.Body))
+	assert.Equal(t, "username_taken", duplicate.JSON409.Code)
+
+	wrongPassword, err := anonymous.LoginWithResponse(h.ctx, client.LoginRequest{Username: "alice", Password: "wrong"})
+	require.NoError(t, err)
+	require.NotNil(t, wrongPassword.JSON401, string(wrongPassword.Body))
+	assert.Equal(t, "invalid_credentials", wrongPassword.JSON401.Code)
+}
+
+func TestAuthLockout(t *testing.T) {
+	t.Setenv("LOGIN_LOCKOUT_THRESHOLD", "2")
+	t.Setenv("LOGIN_LOCKOUT_BASE", "1m")
+	t.Setenv("RATE_LIMIT_AUTH_BURST", "100")
+	h := newHarness(t)
+	h.signUp("alice")
+	anonymous := h.anonymous()
+
+	for range 2 {
+		failed, err := anonymous.LoginWithResponse(h.ctx, client.LoginRequest{Username: "alice", Password: "wrong"})
+		require.NoError(t, err)
+		require.NotNil(t, failed.JSON401, string(failed.Body))
+	}
+
+	locked, err := anonymous.LoginWithResponse(h.ctx, client.LoginRequest{Username: "alice", Password: testPassword})
+	require.NoError(t, err)
+	require.NotNil(t, locked.JSON429, string(locked.Body))
+	assert.Equal(t, "account_locked", locked.JSON429.Code)
+	assert.Equal(t, "60", locked.HTTPResponse.Header.Get("Retry-After"))
+}
@@ -0,0 +1,90 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"backend/pkg/client"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCrossUserAccess checks that one user can neither read nor change
+// another user's records.
+// assertRefused checks for the error the services return when a record
+// belongs to someone else.
+func assertRefused(t *testing.T, status int, body []byte) {
+	t.Helper()
+	require.Equal(t, http.StatusUnauthorized, status, string(body))
+
+	var errorResponse client.ErrorResponse
+	require.NoError(t, json.Unmarshal(body, &errorResponse))
+	assert.Equal(t, "unauthorized", errorResponse.Code)
+}
+
+func TestCrossUserAccess(t *testing.T) {
+	h := newHarness(t)
+	alice := h.newAccount("alice")
+	bob := h.newAccount("bob")
+	aliceAgentID := fmt.Sprint(alice.agent.ID)
+	aliceClientID := fmt.Sprint(alice.client.ID)
+
+	transaction, err := alice.api.CreateTransactionWithResponse(h.ctx, client.CreateTransactionInput{
+		AgentId:  aliceAgentID,
+		ClientId: aliceClientID,
+		Amount:   "5.00",
+	})
+	require.NoError(t, err)
+	require.NotNil(t, transaction.JSON201, string(transaction.Body))
+
+	agent, err := bob.api.GetAgentByIDWithResponse(h.ctx, alice.agent.ID)
+	require.NoError(t, err)
+	assertRefused(t, agent.StatusCode(), agent.Body)
+
+	clientRecord, err := bob.api.GetClientByIDWithResponse(h.ctx, alice.client.ID)
+	require.NoError(t, err)
+	assertRefused(t, clientRecord.StatusCode(), clientRecord.Body)
+
+	fetched, err := bob.api.GetTransactionByIDWithResponse(h.ctx, transaction.JSON201.ID)
+	require.NoError(t, err)
+	assertRefused(t, fetched.StatusCode(), fetched.Body)
+
+	messages, err := bob.api.GetMessagesByClientIDWithResponse(h.ctx, alice.client.ID)
+	require.NoError(t, err)
+	assertRefused(t, messages.StatusCode(), messages.Body)
+
+	renamed, err := bob.api.UpdateAgentWithResponse(h.ctx, alice.agent.ID, client.AgentInput{Name: "Mine", Characteristics: "x"})
+	require.NoError(t, err)
+	assertRefused(t, renamed.StatusCode(), renamed.Body)
+
+	deleted, err := bob.api.DeleteClientWithResponse(h.ctx, alice.client.ID)
+	require.NoError(t, err)
+	assertRefused(t, deleted.StatusCode(), deleted.Body)
+
+	message, err := bob.api.CreateMessageWithResponse(h.ctx, client.CreateMessageInput{
+		AgentId:  aliceAgentID,
+		ClientId: aliceClientID,
+		Content:  "hi",
+		Type:     client.MessageTypeAGENTTOCLIENT,
+	})
+	require.NoError(t, err)
+	assertRefused(t, message.StatusCode(), message.Body)
+
+	// Bob's own agent with Alice's client is refused as well.
+	mixed, err := bob.api.CreateTransactionWithResponse(h.ctx, client.CreateTransactionInput{
+		AgentId:  fmt.Sprint(bob.agent.ID),
+		ClientId: aliceClientID,
+		Amount:   "5.00",
+	})
+	require.NoError(t, err)
+	assertRefused(t, mixed.StatusCode(), mixed.Body)
+
+	// Alice's data is untouched.
+	still, err := alice.api.GetClientByIDWithResponse(h.ctx, alice.client.ID)
+	require.NoError(t, err)
+	require.NotNil(t, still.JSON200, string(still.Body))
+	assert.Equal(t, "Daniel", still.JSON200.Name)
+}
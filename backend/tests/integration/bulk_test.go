@@ -0,0 +1,71 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"backend/pkg/client"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBulkActions(t *testing.T) {
+	h := newHarness(t)
+	alice := h.newAccount("alice")
+	bob := h.newAccount("bob")
+
+	clientIDs := []client.NumericID{fmt.Sprint(alice.client.ID), fmt.Sprint(bob.client.ID)}
+	archived, err := alice.api.BulkClientsWithResponse(h.ctx, client.BulkClientsInput{
+		Action:    client.BulkActionARCHIVE,
+		ClientIds: &clientIDs,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, archived.JSON200, string(archived.Body))
+	assert.Equal(t, client.BulkJobStatusCOMPLETED, archived.JSON200.Status)
+	assert.Equal(t, 1, archived.JSON200.Succeeded)
+	require.Len(t, *archived.JSON200.Results, 2)
+	assert.Equal(t, "unauthorized", *(*archived.JSON200.Results)[1].Code)
+
+	clients, err := alice.api.ListClientsWithResponse(h.ctx, &client.ListClientsParams{})
+	require.NoError(t, err)
+	require.NotNil(t, clients.JSON200, string(clients.Body))
+	assert.Empty(t, *clients.JSON200)
+
+	all := client.ListClientsParamsArchivedAll
+	clients, err = alice.api.ListClientsWithResponse(h.ctx, &client.ListClientsParams{Archived: &all})
+	require.NoError(t, err)
+	require.NotNil(t, clients.JSON200, string(clients.Body))
+	require.Len(t, *clients.JSON200, 1)
+	assert.NotNil(t, (*clients.JSON200)[0].ArchivedAt)
+
+	bobsClient, err := bob.api.GetClientByIDWithResponse(h.ctx, bob.client.ID)
+	require.NoError(t, err)
+	require.NotNil(t, bobsClient.JSON200, string(bobsClient.Body))
+	assert.Nil(t, bobsClient.JSON200.ArchivedAt)
+
+	paused := client.AgentStatusPAUSED
+	nameFilter := "nov"
+	agents, err := alice.api.BulkAgentsWithResponse(h.ctx, client.BulkAgentsInput{
+		Action: client.BulkActionSETSTATUS,
+		Filter: &client.BulkAgentFilter{Name: &nameFilter},
+		Status: paused,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, agents.JSON200, string(agents.Body))
+	assert.Equal(t, 1, agents.JSON200.Succeeded)
+
+	agent, err := alice.api.GetAgentByIDWithResponse(h.ctx, alice.agent.ID)
+	require.NoError(t, err)
+	require.NotNil(t, agent.JSON200, string(agent.Body))
+	assert.Equal(t, paused, agent.JSON200.Status)
+
+	rejected, err := alice.api.BulkClientsWithResponse(h.ctx, client.BulkClientsInput{Action: client.BulkActionTAG, ClientIds: &clientIDs})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, rejected.StatusCode(), string(rejected.Body))
+
+	job, err := bob.api.GetBulkJobByIDWithResponse(h.ctx, archived.JSON200.ID)
+	require.NoError(t, err)
+	assertRefused(t, job.StatusCode(), job.Body)
+}
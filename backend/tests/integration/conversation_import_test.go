@@ -0,0 +1,102 @@
+package integration
+
+import (
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+
+	"backend/pkg/client"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const telegramExport = `{
+	"id": 7,
+	"messages": [
+		{"id": 1, "type": "message", "date": "2024-01-12T14:32:00", "date_unixtime": "1705069920", "from": "Daniel", "text": "hello"},
+		{"id": 2, "type": "message", "date": "2024-01-12T14:33:00", "date_unixtime": "1705069980", "from": "Nova", "text": ["hi ", {"type": "bold", "text": "Daniel"}]},
+		{"id": 3, "type": "service", "date": "2024-01-12T14:34:00", "date_unixtime": "1705070040", "actor": "Daniel", "action": "pin_message"}
+	]
+}`
+
+// importForm builds the multipart body of a conversation import.
+func importForm(t *testing.T, filename, content string, fields map[string]string) (string, *bytes.Buffer) {
+	t.Helper()
+	body := &bytes.Buffer{}
+	form := multipart.NewWriter(body)
+	file, err := form.CreateFormFile("file", filename)
+	require.NoError(t, err)
+	_, err = file.Write([]byte(content))
+	require.NoError(t, err)
+	for name, value := range fields {
+		require.NoError(t, form.WriteField(name, value))
+	}
+	require.NoError(t, form.Close())
+	return form.FormDataContentType(), body
+}
+
+func TestConversationImport(t *testing.T) {
+	h := newHarness(t)
+	alice := h.newAccount("alice")
+	bob := h.newAccount("bob")
+
+	fields := map[string]string{"client_id": fmt.Sprint(alice.client.ID), "dry_run": "true"}
+	contentType, body := importForm(t, "result.json", telegramExport, fields)
+	preview, err := alice.api.ImportConversationWithBodyWithResponse(h.ctx, contentType, body)
+	require.NoError(t, err)
+	require.NotNil(t, preview.JSON200, string(preview.Body))
+	assert.Equal(t, client.ConversationImportFormatTelegram, preview.JSON200.Format)
+	assert.Equal(t, []string{"Daniel", "Nova"}, preview.JSON200.Participants)
+
+	fields = map[string]string{"client_id": fmt.Sprint(alice.client.ID), "agent_sender": "Nova"}
+	for _, expected := range []int{2, 0} {
+		contentType, body = importForm(t, "result.json", telegramExport, fields)
+		imported, err := alice.api.ImportConversationWithBodyWithResponse(h.ctx, contentType, body)
+		require.NoError(t, err)
+		require.NotNil(t, imported.JSON201, string(imported.Body))
+		assert.Equal(t, expected, imported.JSON201.Imported)
+		assert.Equal(t, 2-expected, imported.JSON201.Duplicates)
+		require.Len(t, imported.JSON201.Skipped, 1)
+		assert.Equal(t, 3, imported.JSON201.Skipped[0].Line)
+	}
+
+	messages, err := alice.api.GetMessagesByClientIDWithResponse(h.ctx, alice.client.ID)
+	require.NoError(t, err)
+	require.NotNil(t, messages.JSON200, string(messages.Body))
+	require.Len(t, *messages.JSON200, 2)
+	assert.Equal(t, client.MessageTypeAGENTTOCLIENT, (*messages.JSON200)[1].Type)
+	assert.Equal(t, "hi Daniel", (*messages.JSON200)[1].Content)
+	assert.Equal(t, "telegram:7:2", *(*messages.JSON200)[1].ExternalReference)
+
+	contentType, body = importForm(t, "chat.txt", "not a chat", fields)
+	invalid, err := alice.api.ImportConversationWithBodyWithResponse(h.ctx, contentType, body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, invalid.StatusCode(), string(invalid.Body))
+
+	contentType, body = importForm(t, "result.json", telegramExport, fields)
+	foreign, err := bob.api.ImportConversationWithBodyWithResponse(h.ctx, contentType, body)
+	require.NoError(t, err)
+	assertRefused(t, foreign.StatusCode(), foreign.Body)
+}
+
+func TestConversationImportRefusesLargeFiles(t *testing.T) {
+	t.Setenv("IMPORT_MAX_BYTES", "1024")
+	h := newHarness(t)
+	alice := h.newAccount("alice")
+
+	fields := map[string]string{"client_id": fmt.Sprint(alice.client.ID), "agent_sender": "Nova"}
+	contentType, body := importForm(t, "chat.txt", strings.Repeat("12/01/2024, 14:32 - Daniel: hello\n", 100), fields)
+	tooLarge, err := alice.api.ImportConversationWithBodyWithResponse(h.ctx, contentType, body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, tooLarge.StatusCode(), string(tooLarge.Body))
+
+	contentType, body = importForm(t, "chat.txt", "12/01/2024, 14:32 - Daniel: hello\n12/01/2024, 14:33 - Nova: hi\n", fields)
+	imported, err := alice.api.ImportConversationWithBodyWithResponse(h.ctx, contentType, body)
+	require.NoError(t, err)
+	require.NotNil(t, imported.JSON201, string(imported.Body))
+	assert.Equal(t, 2, imported.JSON201.Imported)
+}
@@ -0,0 +1,88 @@
+package integration
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"backend/pkg/client"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPersonalDataExportAndErasure(t *testing.T) {
+	h := newHarness(t)
+	alice := h.newAccount("alice")
+	bob := h.newAccount("bob")
+
+	message, err := alice.api.CreateMessageWithResponse(h.ctx, client.CreateMessageInput{
+		AgentId:  fmt.Sprint(alice.agent.ID),
+		ClientId: fmt.Sprint(alice.client.ID),
+		Content:  "I live at 3 rue Neuve",
+		Type:     client.MessageTypeCLIENTTOAGENT,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, message.JSON201, string(message.Body))
+
+	exported, err := alice.api.ExportClientPersonalDataWithResponse(h.ctx, alice.client.ID)
+	require.NoError(t, err)
+	require.NotNil(t, exported.JSON200, string(exported.Body))
+	assert.Equal(t, client.ExportScopePERSONALDATA, exported.JSON200.Scope)
+
+	download, err := alice.api.DownloadExportWithResponse(h.ctx, exported.JSON200.ID)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, download.StatusCode(), string(download.Body))
+	assert.Equal(t, "application/zip", download.HTTPResponse.Header.Get("Content-Type"))
+	archive, err := zip.NewReader(bytes.NewReader(download.Body), int64(len(download.Body)))
+	require.NoError(t, err)
+	assert.Len(t, archive.File, 5)
+
+	refused, err := bob.api.ExportClientPersonalDataWithResponse(h.ctx, alice.client.ID)
+	require.NoError(t, err)
+	assertRefused(t, refused.StatusCode(), refused.Body)
+
+	unconfirmed, err := alice.api.EraseClientWithResponse(h.ctx, alice.client.ID, client.EraseClientInput{})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, unconfirmed.StatusCode(), string(unconfirmed.Body))
+
+	stolen, err := bob.api.EraseClientWithResponse(h.ctx, alice.client.ID, client.EraseClientInput{Confirm: true})
+	require.NoError(t, err)
+	assertRefused(t, stolen.StatusCode(), stolen.Body)
+
+	reason := "data subject request"
+	erased, err := alice.api.EraseClientWithResponse(h.ctx, alice.client.ID, client.EraseClientInput{Confirm: true, Reason: &reason})
+	require.NoError(t, err)
+	require.NotNil(t, erased.JSON201, string(erased.Body))
+	assert.Equal(t, 1, erased.JSON201.Messages)
+	assert.Equal(t, reason, erased.JSON201.Reason)
+
+	erasedClient, err := alice.api.GetClientByIDWithResponse(h.ctx, alice.client.ID)
+	require.NoError(t, err)
+	require.NotNil(t, erasedClient.JSON200, string(erasedClient.Body))
+	assert.NotNil(t, erasedClient.JSON200.ErasedAt)
+	assert.NotEqual(t, "Daniel", erasedClient.JSON200.Name)
+
+	messages, err := alice.api.GetMessagesByClientIDWithResponse(h.ctx, alice.client.ID)
+	require.NoError(t, err)
+	require.NotNil(t, messages.JSON200, string(messages.Body))
+	require.Len(t, *messages.JSON200, 1)
+	assert.Empty(t, (*messages.JSON200)[0].Content)
+
+	// The archive made before the erasure is gone.
+	gone, err := alice.api.DownloadExportWithResponse(h.ctx, exported.JSON200.ID)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, gone.StatusCode(), string(gone.Body))
+
+	again, err := alice.api.EraseClientWithResponse(h.ctx, alice.client.ID, client.EraseClientInput{Confirm: true})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, again.StatusCode(), string(again.Body))
+
+	erasures, err := alice.api.GetErasuresWithResponse(h.ctx)
+	require.NoError(t, err)
+	require.NotNil(t, erasures.JSON200, string(erasures.Body))
+	require.Len(t, *erasures.JSON200, 1)
+	assert.Equal(t, alice.client.ID, (*erasures.JSON200)[0].ClientID)
+}
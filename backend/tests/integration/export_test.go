@@ -0,0 +1,64 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"testing"
+
+	"backend/pkg/client"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExports(t *testing.T) {
+	h := newHarness(t)
+	alice := h.newAccount("alice")
+	bob := h.newAccount("bob")
+
+	message, err := alice.api.CreateMessageWithResponse(h.ctx, client.CreateMessageInput{
+		AgentId:  fmt.Sprint(alice.agent.ID),
+		ClientId: fmt.Sprint(alice.client.ID),
+		Content:  "Hello, Daniel",
+		Type:     client.MessageTypeAGENTTOCLIENT,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, message.JSON201, string(message.Body))
+
+	clientID := fmt.Sprint(alice.client.ID)
+	created, err := alice.api.CreateExportWithResponse(h.ctx, client.CreateExportInput{
+		Format:   client.ExportFormatCsv,
+		ClientId: &clientID,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, created.JSON200, string(created.Body))
+	assert.Equal(t, client.ExportJobStatusCOMPLETED, created.JSON200.Status)
+	assert.Equal(t, client.ExportScopeCLIENT, created.JSON200.Scope)
+	assert.Equal(t, 1, created.JSON200.Records)
+
+	download, err := alice.api.DownloadExportWithResponse(h.ctx, created.JSON200.ID)
+	require.NoError(t, err)
+	require.Equal(t, 200, download.StatusCode(), string(download.Body))
+	assert.Contains(t, download.HTTPResponse.Header.Get("Content-Disposition"), created.JSON200.Filename)
+	rows, err := csv.NewReader(bytes.NewReader(download.Body)).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Contains(t, rows[1], "Hello, Daniel")
+
+	exports, err := alice.api.GetExportsWithResponse(h.ctx)
+	require.NoError(t, err)
+	require.NotNil(t, exports.JSON200, string(exports.Body))
+	assert.Len(t, *exports.JSON200, 1)
+
+	refused, err := bob.api.CreateExportWithResponse(h.ctx, client.CreateExportInput{
+		Format:   client.ExportFormatPdf,
+		ClientId: &clientID,
+	})
+	require.NoError(t, err)
+	assertRefused(t, refused.StatusCode(), refused.Body)
+
+	stolen, err := bob.api.DownloadExportWithResponse(h.ctx, created.JSON200.ID)
+	require.NoError(t, err)
+	assertRefused(t, stolen.StatusCode(), stolen.Body)
+}
@@ -0,0 +1,71 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"backend/pkg/client"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFullFlow(t *testing.T) {
+	h := newHarness(t)
+	alice := h.newAccount("alice")
+	agentID := fmt.Sprint(alice.agent.ID)
+	clientID := fmt.Sprint(alice.client.ID)
+
+	message, err := alice.api.CreateMessageWithResponse(h.ctx, client.CreateMessageInput{
+		AgentId:  agentID,
+		ClientId: clientID,
+		Content:  "Hey, how is your day going?",
+		Type:     client.MessageTypeCLIENTTOAGENT,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, message.JSON201, string(message.Body))
+
+	messages, err := alice.api.GetMessagesByClientIDWithResponse(h.ctx, alice.client.ID)
+	require.NoError(t, err)
+	require.NotNil(t, messages.JSON200, string(messages.Body))
+	assert.Len(t, *messages.JSON200, 1)
+
+	transaction, err := alice.api.CreateTransactionWithResponse(h.ctx, client.CreateTransactionInput{
+		AgentId:  agentID,
+		ClientId: clientID,
+		Amount:   "19.99",
+	})
+	require.NoError(t, err)
+	require.NotNil(t, transaction.JSON201, string(transaction.Body))
+	assert.Equal(t, int64(1999), transaction.JSON201.AmountMinor)
+
+	answer, err := alice.api.AskLLMWithResponse(h.ctx, client.LLMRequest{Prompt: "hello"})
+	require.NoError(t, err)
+	require.NotNil(t, answer.JSON200, string(answer.Body))
+	assert.Equal(t, "echo: hello", answer.JSON200.Response)
+	assert.EqualValues(t, 1, h.ollamaCalls.Load())
+
+	image, err := alice.api.GenerateImageWithResponse(h.ctx, client.SDRequest{Prompt: "a beach at sunset"})
+	require.NoError(t, err)
+	require.NotNil(t, image.JSON200, string(image.Body))
+	require.NotNil(t, image.JSON200.Images)
+	assert.Equal(t, []string{"aW1hZ2U="}, *image.JSON200.Images)
+	assert.EqualValues(t, 1, h.sdCalls.Load())
+}
+
+func TestAIEndpointsRequireLogin(t *testing.T) {
+	h := newHarness(t)
+	anonymous := h.anonymous()
+
+	answer, err := anonymous.AskLLMWithResponse(h.ctx, client.LLMRequest{Prompt: "hello"})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, answer.StatusCode())
+
+	image, err := anonymous.GenerateImageWithResponse(h.ctx, client.SDRequest{Prompt: "a beach"})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, image.StatusCode())
+
+	assert.Zero(t, h.ollamaCalls.Load())
+	assert.Zero(t, h.sdCalls.Load())
+}
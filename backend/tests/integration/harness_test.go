@@ -0,0 +1,141 @@
+// Package integration drives the full application router over HTTP with the
+// generated client. The database is an in-memory SQLite and Ollama and
+// Stable Diffusion are replaced by httptest servers.
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"backend/internal/app"
+	"backend/internal/config"
+	"backend/pkg/client"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+const testPassword = "password123"
+
+type harness struct {
+	t       *testing.T
+	ctx     context.Context
+	baseURL string
+
+	// Counters of calls that reached the fake AI servers.
+	ollamaCalls atomic.Int32
+	sdCalls     atomic.Int32
+}
+
+func newHarness(t *testing.T) *harness {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	h := &harness{t: t, ctx: context.Background()}
+
+	ollama := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ollamaCalls.Add(1)
+		var request struct {
+			Prompt string `json:"prompt"`
+		}
+		if r.URL.Path != "/api/generate" || json.NewDecoder(r.Body).Decode(&request) != nil {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"model":             "deepseek",
+			"response":          "echo: " + request.Prompt,
+			"total_duration":    1500,
+			"prompt_eval_count": 3,
+			"eval_count":        5,
+		})
+	}))
+	t.Cleanup(ollama.Close)
+
+	sd := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.sdCalls.Add(1)
+		if r.URL.Path != "/sdapi/v1/txt2img" {
+			http.Error(w, "unexpected request", http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"images": []string{"aW1hZ2U="}})
+	}))
+	t.Cleanup(sd.Close)
+
+	cfg := config.Load()
+	// A named shared-cache database stays private to this test but is
+	// visible to every connection in the pool.
+	cfg.DatabaseURL = fmt.Sprintf("file:%s?mode=memory&cache=shared", strings.ReplaceAll(t.Name(), "/", "_"))
+	cfg.JWTSecret = "integration-secret"
+	cfg.OllamaURL = ollama.URL
+	cfg.SDUrl = sd.URL
+
+	server := httptest.NewServer(app.New(app.WithConfig(cfg), app.WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))).Router)
+	t.Cleanup(server.Close)
+	h.baseURL = server.URL + "/api/v1"
+	return h
+}
+
+// anonymous returns a client without credentials.
+func (h *harness) anonymous() *client.ClientWithResponses {
+	h.t.Helper()
+	c, err := client.NewClientWithResponses(h.baseURL)
+	require.NoError(h.t, err)
+	return c
+}
+
+// signUp registers username and returns a client logged in as that user.
+func (h *harness) signUp(username string) *client.ClientWithResponses {
+	h.t.Helper()
+	anonymous := h.anonymous()
+
+	registered, err := anonymous.RegisterWithResponse(h.ctx, client.RegisterRequest{
+		Username:        username,
+		Email:           username + "@example.com",
+		Password:        testPassword,
+		ConfirmPassword: testPassword,
+	})
+	require.NoError(h.t, err)
+	require.NotNil(h.t, registered.JSON201, string(registered.Body))
+
+	login, err := anonymous.LoginWithResponse(h.ctx, client.LoginRequest{Username: username, Password: testPassword})
+	require.NoError(h.t, err)
+	require.NotNil(h.t, login.JSON200, string(login.Body))
+
+	c, err := client.NewClientWithResponses(h.baseURL, client.WithBearerToken(login.JSON200.Token))
+	require.NoError(h.t, err)
+	return c
+}
+
+// account is an agent with one client, the minimum most tests need.
+type account struct {
+	api    *client.ClientWithResponses
+	agent  client.Agent
+	client client.Client
+}
+
+func (h *harness) newAccount(username string) account {
+	h.t.Helper()
+	api := h.signUp(username)
+
+	agent, err := api.CreateAgentWithResponse(h.ctx, client.AgentInput{Name: "Nova", Characteristics: "warm"})
+	require.NoError(h.t, err)
+	require.NotNil(h.t, agent.JSON201, string(agent.Body))
+
+	created, err := api.CreateClientWithResponse(h.ctx, client.CreateClientInput{
+		AgentId:   fmt.Sprint(agent.JSON201.ID),
+		Name:      "Daniel",
+		StartDate: mustTime(h.t, "2026-01-05T00:00:00Z"),
+	})
+	require.NoError(h.t, err)
+	require.NotNil(h.t, created.JSON201, string(created.Body))
+
+	return account{api: api, agent: *agent.JSON201, client: *created.JSON201}
+}
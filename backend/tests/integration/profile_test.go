@@ -0,0 +1,61 @@
+package integration
+
+import (
+	"net/http"
+	"testing"
+
+	"backend/pkg/client"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientProfileFilters(t *testing.T) {
+	h := newHarness(t)
+	alice := h.newAccount("alice")
+
+	field, err := alice.api.CreateCustomFieldWithResponse(h.ctx, client.CreateCustomFieldInput{
+		Key:     "tier",
+		Label:   "Tier",
+		Type:    client.CustomFieldTypeSELECT,
+		Options: &[]string{"gold", "silver"},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, field.JSON201, string(field.Body))
+
+	tags, err := alice.api.AddClientTagsWithResponse(h.ctx, alice.client.ID, client.ClientTagsInput{Tags: []string{"VIP", "night-owl"}})
+	require.NoError(t, err)
+	require.NotNil(t, tags.JSON200, string(tags.Body))
+	assert.Equal(t, []string{"night-owl", "vip"}, *tags.JSON200)
+
+	gold := "gold"
+	values, err := alice.api.SetClientFieldValuesWithResponse(h.ctx, alice.client.ID, client.ClientFieldValuesInput{
+		Values: map[string]*string{"tier": &gold},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, values.JSON200, string(values.Body))
+
+	list := func(params client.ListClientsParams) []client.Client {
+		t.Helper()
+		clients, err := alice.api.ListClientsWithResponse(h.ctx, &params)
+		require.NoError(t, err)
+		require.NotNil(t, clients.JSON200, string(clients.Body))
+		return *clients.JSON200
+	}
+
+	assert.Len(t, list(client.ListClientsParams{Tag: &client.ClientTagQuery{"vip", "night-owl"}}), 1)
+	assert.Len(t, list(client.ListClientsParams{Field: &client.ClientFieldQuery{"tier": "gold"}}), 1)
+	assert.Empty(t, list(client.ListClientsParams{Field: &client.ClientFieldQuery{"tier": "silver"}}))
+	assert.Empty(t, list(client.ListClientsParams{Tag: &client.ClientTagQuery{"vip", "churned"}}))
+
+	unknown, err := alice.api.ListClientsWithResponse(h.ctx, &client.ListClientsParams{Field: &client.ClientFieldQuery{"nope": "x"}})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, unknown.StatusCode(), string(unknown.Body))
+
+	bob := h.newAccount("bob")
+	stolen, err := bob.api.GetClientTagsWithResponse(h.ctx, alice.client.ID)
+	require.NoError(t, err)
+	assertRefused(t, stolen.StatusCode(), stolen.Body)
+	nobody := client.ClientNameQuery("nobody")
+	assert.Empty(t, list(client.ListClientsParams{Name: &nobody}))
+}
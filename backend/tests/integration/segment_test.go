@@ -0,0 +1,54 @@
+package integration
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strings"
+	"testing"
+
+	"backend/pkg/client"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSegments(t *testing.T) {
+	h := newHarness(t)
+	alice := h.newAccount("alice")
+
+	_, err := alice.api.AddClientTagsWithResponse(h.ctx, alice.client.ID, client.ClientTagsInput{Tags: []string{"vip"}})
+	require.NoError(t, err)
+
+	vip := "vip"
+	segment, err := alice.api.CreateSegmentWithResponse(h.ctx, client.SegmentInput{
+		Name:       "VIPs",
+		Conditions: &[]client.SegmentConditionInput{{Field: client.SegmentFieldTAG, Op: client.SegmentOpHAS, Value: &vip}},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, segment.JSON201, string(segment.Body))
+
+	page, err := alice.api.GetSegmentClientsWithResponse(h.ctx, segment.JSON201.ID, &client.GetSegmentClientsParams{})
+	require.NoError(t, err)
+	require.NotNil(t, page.JSON200, string(page.Body))
+	assert.EqualValues(t, 1, page.JSON200.Total)
+
+	segmentID := client.SegmentIDQuery(segment.JSON201.ID)
+	clients, err := alice.api.ListClientsWithResponse(h.ctx, &client.ListClientsParams{SegmentId: &segmentID})
+	require.NoError(t, err)
+	require.NotNil(t, clients.JSON200, string(clients.Body))
+	assert.Len(t, *clients.JSON200, 1)
+
+	export, err := alice.api.ExportSegmentClientsWithResponse(h.ctx, segment.JSON201.ID)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, export.StatusCode(), string(export.Body))
+	rows, err := csv.NewReader(strings.NewReader(string(export.Body))).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, "Daniel", rows[1][1])
+	assert.Equal(t, "Nova", rows[1][3])
+
+	bob := h.newAccount("bob")
+	stolen, err := bob.api.ExportSegmentClientsWithResponse(h.ctx, segment.JSON201.ID)
+	require.NoError(t, err)
+	assertRefused(t, stolen.StatusCode(), stolen.Body)
+}
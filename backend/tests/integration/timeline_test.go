@@ -0,0 +1,48 @@
+package integration
+
+import (
+	"fmt"
+	"testing"
+
+	"backend/pkg/client"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientTimeline(t *testing.T) {
+	h := newHarness(t)
+	alice := h.newAccount("alice")
+
+	message, err := alice.api.CreateMessageWithResponse(h.ctx, client.CreateMessageInput{
+		AgentId:  fmt.Sprint(alice.agent.ID),
+		ClientId: fmt.Sprint(alice.client.ID),
+		Content:  "hello",
+		Type:     client.MessageTypeAGENTTOCLIENT,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, message.JSON201, string(message.Body))
+
+	limit := 1
+	first, err := alice.api.GetClientTimelineWithResponse(h.ctx, alice.client.ID, &client.GetClientTimelineParams{Limit: &limit})
+	require.NoError(t, err)
+	require.NotNil(t, first.JSON200, string(first.Body))
+	require.Len(t, first.JSON200.Entries, 1)
+	require.NotNil(t, first.JSON200.NextCursor)
+
+	second, err := alice.api.GetClientTimelineWithResponse(h.ctx, alice.client.ID, &client.GetClientTimelineParams{Cursor: first.JSON200.NextCursor})
+	require.NoError(t, err)
+	require.NotNil(t, second.JSON200, string(second.Body))
+	assert.Nil(t, second.JSON200.NextCursor)
+
+	kinds := map[client.TimelineKind]bool{}
+	for _, entry := range append(first.JSON200.Entries, second.JSON200.Entries...) {
+		kinds[entry.Kind] = true
+	}
+	assert.Equal(t, map[client.TimelineKind]bool{client.TimelineKindMESSAGE: true, client.TimelineKindCHANGE: true}, kinds)
+
+	bob := h.newAccount("bob")
+	stolen, err := bob.api.GetClientTimelineWithResponse(h.ctx, alice.client.ID, &client.GetClientTimelineParams{})
+	require.NoError(t, err)
+	assertRefused(t, stolen.StatusCode(), stolen.Body)
+}
@@ -0,0 +1,45 @@
+package integration
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"backend/pkg/client"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateTransactionKeepsCurrency(t *testing.T) {
+	h := newHarness(t)
+	alice := h.newAccount("alice")
+
+	currency := "JPY"
+	created, err := alice.api.CreateTransactionWithResponse(h.ctx, client.CreateTransactionInput{
+		AgentId:  fmt.Sprint(alice.agent.ID),
+		ClientId: fmt.Sprint(alice.client.ID),
+		Amount:   "1500",
+		Currency: &currency,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, created.JSON201, string(created.Body))
+
+	// Without a currency the amount is read in yen, not dollars.
+	updated, err := alice.api.UpdateTransactionWithResponse(h.ctx, created.JSON201.ID, client.UpdateTransactionInput{
+		Amount: "2000",
+		Date:   time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+	require.NotNil(t, updated.JSON200, string(updated.Body))
+	assert.Equal(t, "JPY", updated.JSON200.Currency)
+	assert.Equal(t, int64(2000), updated.JSON200.AmountMinor)
+
+	// Cents are not valid in yen.
+	rejected, err := alice.api.UpdateTransactionWithResponse(h.ctx, created.JSON201.ID, client.UpdateTransactionInput{
+		Amount: "20.50",
+		Date:   time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 400, rejected.StatusCode(), string(rejected.Body))
+}
@@ -0,0 +1,45 @@
+package integration
+
+import (
+	"fmt"
+	"testing"
+
+	"backend/pkg/client"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientTransfer(t *testing.T) {
+	h := newHarness(t)
+	alice := h.newAccount("alice")
+	bob := h.newAccount("bob")
+
+	second, err := alice.api.CreateAgentWithResponse(h.ctx, client.AgentInput{Name: "Vega", Characteristics: "calm"})
+	require.NoError(t, err)
+	require.NotNil(t, second.JSON201, string(second.Body))
+
+	stolen, err := bob.api.TransferClientWithResponse(h.ctx, alice.client.ID, client.TransferClientInput{AgentId: fmt.Sprint(bob.agent.ID)})
+	require.NoError(t, err)
+	assertRefused(t, stolen.StatusCode(), stolen.Body)
+	pushed, err := alice.api.TransferClientWithResponse(h.ctx, alice.client.ID, client.TransferClientInput{AgentId: fmt.Sprint(bob.agent.ID)})
+	require.NoError(t, err)
+	assertRefused(t, pushed.StatusCode(), pushed.Body)
+
+	reason := "workload"
+	moved, err := alice.api.TransferClientWithResponse(h.ctx, alice.client.ID, client.TransferClientInput{AgentId: fmt.Sprint(second.JSON201.ID), Reason: &reason})
+	require.NoError(t, err)
+	require.NotNil(t, moved.JSON200, string(moved.Body))
+	assert.Equal(t, second.JSON201.ID, moved.JSON200.AgentID)
+
+	assignments, err := alice.api.GetClientAssignmentsWithResponse(h.ctx, alice.client.ID)
+	require.NoError(t, err)
+	require.NotNil(t, assignments.JSON200, string(assignments.Body))
+	require.Len(t, *assignments.JSON200, 2)
+	assert.Equal(t, alice.agent.ID, *(*assignments.JSON200)[1].FromAgentID)
+	assert.Equal(t, "workload", (*assignments.JSON200)[1].Reason)
+
+	history, err := bob.api.GetClientAssignmentsWithResponse(h.ctx, alice.client.ID)
+	require.NoError(t, err)
+	assertRefused(t, history.StatusCode(), history.Body)
+}
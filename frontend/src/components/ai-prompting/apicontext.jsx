@@ -9,7 +9,7 @@ export function ApiProvider({ children }) {
   const getClients = async (agentId) => {
     if (!agentId) return [];
     try {
-      const response = await fetch(`/clients/agent/${agentId}`, {
+      const response = await fetch(`/api/v1/clients/agent/${agentId}`, {
         method: "GET",
         headers: {
           "Content-Type": "application/json",
@@ -25,7 +25,7 @@ export function ApiProvider({ children }) {
 
   const getAgents = async () => {
     try {
-      const response = await fetch("/agents", {
+      const response = await fetch("/api/v1/agents", {
         method: "GET",
         headers: {
           "Content-Type": "application/json",
@@ -43,7 +43,7 @@ export function ApiProvider({ children }) {
     if (!agentId || !clientId) return [];
     try {
       const response = await fetch(
-        `/messages/agent/${agentId}/client/${clientId}`,
+        `/api/v1/messages/agent/${agentId}/client/${clientId}`,
         {
           method: "GET",
           headers: {
@@ -63,9 +63,13 @@ export function ApiProvider({ children }) {
   const getDeepSeekResponse = async (promptText) => {
     try {
       const response = await fetch(
-        `/llm/ask`,
+        `/api/v1/llm/ask`,
         {
           method: "POST",
+          headers: {
+            "Content-Type": "application/json",
+            "Authorization": `Bearer ${token}`,
+          },
           body: JSON.stringify({
             prompt: promptText
           }),
@@ -82,9 +86,13 @@ export function ApiProvider({ children }) {
   const getStableDiffusionImage = async (promptText) => {
     try {
       const response = await fetch(
-        `/sd/generate`,
+        `/api/v1/sd/generate`,
         {
           method: "POST",
+          headers: {
+            "Content-Type": "application/json",
+            "Authorization": `Bearer ${token}`,
+          },
           body: JSON.stringify({
             prompt: promptText
           }),
@@ -102,7 +110,7 @@ export function ApiProvider({ children }) {
   
   try {
     const response = await fetch(
-      `/transactions/agent/${agentId}/client/${clientId}`,
+      `/api/v1/transactions/agent/${agentId}/client/${clientId}`,
       {
         method: "GET",
         headers: {
@@ -125,10 +133,31 @@ export function ApiProvider({ children }) {
 };
 
 
-  
+  // getAnalytics calls one of the /analytics endpoints, e.g. "clients", with
+  // optional agent_id, client_id, from and to query parameters.
+  const getAnalytics = async (report, params = {}) => {
+    try {
+      const query = new URLSearchParams(params).toString();
+      const response = await fetch(`/api/v1/analytics/${report}${query ? `?${query}` : ""}`, {
+        method: "GET",
+        headers: {
+          "Content-Type": "application/json",
+          "Authorization": `Bearer ${token}`,
+        },
+      });
+      if (!response.ok) {
+        console.error(`Analytics API error: ${response.status} ${response.statusText}`);
+        return [];
+      }
+      return await response.json();
+    } catch (error) {
+      console.error("Error fetching analytics:", error);
+      return [];
+    }
+  };
 
   return (
-    <ApiContext.Provider value={{ getClients, getAgents, getConversations, getTransactions,getDeepSeekResponse,getStableDiffusionImage }}>
+    <ApiContext.Provider value={{ getClients, getAgents, getConversations, getTransactions,getDeepSeekResponse,getStableDiffusionImage,getAnalytics }}>
       {children}
     </ApiContext.Provider>
   );
@@ -59,22 +59,17 @@ const [transactionData, setTransactionData] = useState([]);
 const [totalRevenue, setTotalRevenue] = useState(0);
 const [clientPerformanceData, setClientPerformanceData] = useState([]);
 const [timelineData, setTimelineData] = useState([]);
-const { getClients, getAgents, getConversations, getTransactions } = useApi();
+const { getConversations, getAnalytics } = useApi();
 
         async function fetchAgents() {
             try {
-                const agentsData = await getAgents();
-                const mappedAgents = await Promise.all(
-                    agentsData.map(async (agent) => {
-                        const agentClients = await getClients(agent.ID);
-                        return {
-                            id: agent.ID,
-                            name: agent.Name,
-                            status: agent.status || "active",
-                            clientCount: Array.isArray(agentClients) ? agentClients.length : 0,
-                        };
-                    })
-                );
+                const summaries = await getAnalytics("agents");
+                const mappedAgents = summaries.map((summary) => ({
+                    id: summary.agent_id,
+                    name: summary.name,
+                    status: "active",
+                    clientCount: summary.clients,
+                }));
                 setAgents(mappedAgents);
                 if (mappedAgents.length > 0 && !selectedAgent.id) {
                     setSelectedAgent(mappedAgents[0]);
@@ -89,105 +84,45 @@ const { getClients, getAgents, getConversations, getTransactions } = useApi();
 
         async function fetchDataForAgent(agentId) {
             try {
-            const clientData = await getClients(agentId);
-            console.log("Client Data fetched:", clientData);
-            var symmetry = [];
-            var timeGapsByDay = [];
-            var transactionArr = [];
-            var ClientPerformance = [];
-            let transactionX = 0; 
-            let totalRevenueLocal = 0;
-            let timeLineDataLocal = [];
-            let MaxScore = -1;
-            let bestClientData = null;
-
-                for(var client of clientData)
-                {
-                    var clientTransaction = 0;
-                    await getTransactions(agentId, client.ID).then((transactions) => 
-                    {
-                        clientTransaction = transactions.length;
-                        totalRevenueLocal += transactions.reduce((sum, transaction) => sum + (transaction.Amount || 0), 0);
-                    })
-
-                    if(client.Score > MaxScore) 
-                        {
-                            MaxScore = client.Score;
-                            bestClientData = client;
-                        };
-
-                    await getConversations(agentId, client.ID).then((conversationsClient) => {
-                        let agentToClient = 0;
-                        let clientToAgent = 0;
-
-                        const getDayAbbreviation = (dateObj) => {
-                        const days = ['Sun', 'Mon', 'Tue', 'Wed', 'Thu', 'Fri', 'Sat'];
-                        return days[dateObj.getDay()];
-                        };
-
-                        const sortedConvs = [...conversationsClient].sort(
-                          (a, b) => new Date(a.CreatedAt) - new Date(b.CreatedAt)
-                        );
-
-                        const gapsByDay = {};
-
-                        for (let i = 1; i < sortedConvs.length; i++) {
-                          const prev = new Date(sortedConvs[i - 1].CreatedAt);
-                          const curr = new Date(sortedConvs[i].CreatedAt);
-                          const dayOfWeek = getDayAbbreviation(curr);
-                          
-                          const gapMs = curr - prev;
-                          const gapHours = gapMs / (1000 * 60 * 60);
-                          if (!gapsByDay[dayOfWeek]) {
-                            gapsByDay[dayOfWeek] = [];
-                        }
-                        gapsByDay[dayOfWeek].push(gapHours);
-                        
-                        }
-
+                const dayNames = ['Sun', 'Mon', 'Tue', 'Wed', 'Thu', 'Fri', 'Sat'];
+                const [engagement, replyGaps, summaries] = await Promise.all([
+                    getAnalytics("clients", { agent_id: agentId }),
+                    getAnalytics("reply-gaps", { agent_id: agentId }),
+                    getAnalytics("agents", { agent_id: agentId }),
+                ]);
+
+                const symmetry = engagement.map((client) => ({ client: client.name, ratio: client.symmetry_ratio }));
+
+                const transactionArr = engagement.map((client, index) => ({
+                    client: client.name,
+                    ratio: client.transactions_per_message.toFixed(3),
+                    amount: client.transactions,
+                    x: index,
+                    y: client.transactions_per_message.toFixed(3),
+                }));
 
-                        conversationsClient.forEach(conv => {
-                            if (conv.Type === "AGENT_TO_CLIENT") agentToClient++;
-                            else if (conv.Type === "CLIENT_TO_AGENT") clientToAgent++;
-                        });
-                        let ratio = clientToAgent === 0 ? 0 : agentToClient / clientToAgent;
-                        symmetry.push({ client: client.Name, ratio});
+                const ClientPerformance = engagement.map((client) => ({
+                    id: client.client_id,
+                    name: client.name,
+                    score: client.score,
+                    symmetryRatio: client.symmetry_ratio,
+                    avgMsgGap: (client.median_reply_seconds / 3600).toFixed(2),
+                    txRatio: client.transactions_per_message.toFixed(3),
+                    status: client.transactions > 1 ? "excellent" : "needs attention",
+                }));
 
-                        transactionArr.push({
-                        client: client.Name,
-                        ratio: (clientTransaction / conversationsClient.length).toFixed(3), 
-                        amount: clientTransaction,
-                        x: transactionX++,
-                        y: (clientTransaction / conversationsClient.length).toFixed(3) 
-                        });
+                const averagedByDay = (replyGaps.by_weekday || []).map((gap) => ({
+                    day: dayNames[gap.weekday],
+                    avgGap: gap.median_seconds / 3600,
+                }));
 
-                        ClientPerformance.push({
-                            id: client.ID,
-                            name: client.Name,
-                            score: client.Score, 
-                            symmetryRatio: ratio,
-                            avgMsgGap: (Object.values(gapsByDay)
-                            .flat()
-                            .reduce((sum, gap) => sum + gap, 0) / 
-                            Object.values(gapsByDay).flat().length).toFixed(2) || 0,
-                            txRatio: (clientTransaction / conversationsClient.length).toFixed(3),
-                            status: clientTransaction > 1 ? "excellent" : clientTransaction > 2 ? "not important" : "needs attention",
-                        });
+                const revenue = summaries.length > 0 ? summaries[0].revenue : [];
+                const totalRevenueLocal = revenue.reduce((sum, total) => sum + (parseFloat(total.net) || 0), 0);
 
-                       for (const day in gapsByDay) {
-                        const dayGaps = gapsByDay[day];
-                        const avgGap = dayGaps.reduce((a, b) => a + b, 0) / dayGaps.length;
-                        
-                        timeGapsByDay.push({
-                            day: day,
-                            avgGap: avgGap.toFixed(3)
-                        });
-                        }
-                    });
-                }
+                // Clients are ordered by score, so the first one is the best.
                 var formattedTimelineData = [];
-                if (bestClientData) {
-                const bestClientConvs = await getConversations(agentId, bestClientData.ID);
+                if (engagement.length > 0) {
+                const bestClientConvs = await getConversations(agentId, engagement[0].client_id);
                 formattedTimelineData = bestClientConvs.map((conv) => {
                     const time = new Date(conv.CreatedAt).toLocaleTimeString([], { hour: '2-digit', minute: '2-digit' });
                     return {
@@ -199,23 +134,10 @@ const { getClients, getAgents, getConversations, getTransactions } = useApi();
                 });
                 }
 
-
-                const groupedByDay = {};
-                timeGapsByDay.forEach(({ day, avgGap }) => {
-                  if (!groupedByDay[day]) groupedByDay[day] = [];
-                  groupedByDay[day].push(Number(avgGap));
-                });
-
-                const averagedByDay = Object.entries(groupedByDay).map(([day, gaps]) => ({
-                  day,
-                  avgGap: gaps.reduce((a, b) => a + b, 0) / gaps.length,
-                }));
-                console.log("performance data:", ClientPerformance);
                 setClientPerformanceData(ClientPerformance);
                 setTransactionData(transactionArr);
                 setMessageGapData(averagedByDay);
                 setSymmetryData(symmetry);
-                console.log("timeLineDataLocal:", timeLineDataLocal);
                 setTotalRevenue(totalRevenueLocal);
                 setTimelineData(formattedTimelineData);
             
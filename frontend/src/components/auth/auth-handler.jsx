@@ -27,7 +27,7 @@ export default function AuthProvider({children})
 
     const register = async (formData) => {
         try {
-          const response = await fetch("/auth/register", {
+          const response = await fetch("/api/v1/auth/register", {
             method: "POST",
             headers: {
               "Content-Type": "application/json",
@@ -51,7 +51,7 @@ export default function AuthProvider({children})
 
     const login = async (formData) => {
       try {
-        const response = await fetch("auth/login", {
+        const response = await fetch("/api/v1/auth/login", {
           method: "POST",
           headers: {
             "Content-Type": "application/json",
@@ -60,7 +60,7 @@ export default function Dashboard()
       }
     
       try {
-        const response = await fetch("protected/", {
+        const response = await fetch("/api/v1/protected", {
           method: "GET",
           headers: {
             "Content-Type": "application/json",
@@ -14,43 +14,18 @@ export default defineConfig({
   },
   server: {
     proxy: {
-      "/auth": {
-        target: "http://localhost:8080", 
-        changeOrigin: true,
-        secure: false,
-      },
-      "/protected": {
-        target: "http://localhost:8080", 
-        changeOrigin: true,
-        secure: false,
-      },
-       "/client": {
-        target: "http://localhost:8080", 
-        changeOrigin: true,
-        secure: false,
-      },
-      "/agents": {
-        target: "http://localhost:8080", 
-        changeOrigin: true,
-        secure: false,
-      },
-      "/messages": {
-        target: "http://localhost:8080", 
-        changeOrigin: true,
-        secure: false,
-      },
-      "/transactions": {
-        target: "http://localhost:8080", 
+      "/api/v1/llm": {
+        target: `http://${link}:8080`, 
         changeOrigin: true,
         secure: false,
       },
-      "/llm": {
+      "/api/v1/sd": {
         target: `http://${link}:8080`, 
         changeOrigin: true,
         secure: false,
       },
-      "/sd": {
-        target: `http://${link}:8080`, 
+      "/api": {
+        target: "http://localhost:8080", 
         changeOrigin: true,
         secure: false,
       },